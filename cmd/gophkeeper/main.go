@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
 	"github.com/wurt83ow/gophkeeper-client/pkg/client"
+	"github.com/wurt83ow/gophkeeper-client/pkg/clipboard"
 	"github.com/wurt83ow/gophkeeper-client/pkg/config"
 	"github.com/wurt83ow/gophkeeper-client/pkg/encription"
 	"github.com/wurt83ow/gophkeeper-client/pkg/gksync"
@@ -20,6 +22,10 @@ var (
 )
 
 func main() {
+	// The process clearing the clipboard after get --copy is this binary run again
+	if clipboard.RunClearer() {
+		return
+	}
 
 	// Initialize encryption as a nil pointer
 	var enc *encription.Enc
@@ -32,8 +38,13 @@ func main() {
 	// Initialize configuration options
 	option := config.NewConfig(enc)
 
-	// Initialize database keeper
-	keeper := bdkeeper.NewKeeper(nil)
+	// Open the local database, migrations are applied by the client before commands run
+	keeper, err := bdkeeper.OpenUnmigrated("./data.db")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open the local database: %s\n", err)
+		os.Exit(1)
+	}
+	defer keeper.Close()
 
 	// Initialize synchronization manager
 	sm := syncinfo.NewSyncManager(option.SysInfoPath)
@@ -58,14 +69,17 @@ func main() {
 
 	// Perform initial data synchronization
 	if err != nil {
-		fmt.Printf("Error synchronizing data: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error synchronizing data: %s\n", err)
 	}
 
 	// Start periodic data synchronization with the server
 
 	// Initialize and start the client
 	gk := client.NewClient(ctx, service, enc, option, userID, token, sessionStart, sm.GetTimeWithoutTimeZone)
-	defer gk.Close()
-	gk.Start(version, buildTime)
-
+	err = gk.Start(version, buildTime)
+	gk.Close()
+	if err != nil {
+		keeper.Close()
+		os.Exit(1)
+	}
 }
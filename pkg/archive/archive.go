@@ -0,0 +1,195 @@
+// Package archive reads and writes GophKeeper export archives: portable JSON documents
+// holding the entries of a vault with their folders, tags, TOTP authenticators and files.
+//
+// Archives are encrypted with a password, independently of the key of the installation
+// they were exported from, so they can be imported into any other one. The entries are
+// encrypted with AES-256-GCM under a key derived from the password with Argon2id; the
+// key derivation parameters are stored in the clear with the ciphertext. Plaintext
+// archives hold the entries as they are.
+package archive
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Format identifies GophKeeper archives.
+const Format = "gophkeeper-export"
+
+// Version is the version of the archive format written.
+const Version = 1
+
+// Errors returned by Read.
+var (
+	ErrNotArchive = errors.New("not a GophKeeper export")
+	ErrDecrypt    = errors.New("wrong password or damaged export")
+)
+
+// Archive is the content of an export.
+type Archive struct {
+	Exported time.Time `json:"exported"`
+	Entries  []Entry   `json:"entries"`
+}
+
+// Entry is an exported record.
+type Entry struct {
+	Type   string            `json:"type"` // Type is the table of the record type.
+	Folder string            `json:"folder,omitempty"`
+	Tags   []string          `json:"tags,omitempty"`
+	Fields map[string]string `json:"fields"`
+	// TOTP holds the fields of the TOTP authenticator linked to the record, if any.
+	TOTP map[string]string `json:"totp,omitempty"`
+	// File is the content of a binary data record.
+	File        *File  `json:"file,omitempty"`
+	Attachments []File `json:"attachments,omitempty"`
+}
+
+// File is an exported file.
+type File struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// document is the JSON document of an archive. Encrypted archives hold the encrypted
+// JSON of the Archive in Data instead of its fields.
+type document struct {
+	Format     string      `json:"format"`
+	Version    int         `json:"version"`
+	Encryption *encryption `json:"encryption,omitempty"`
+	Data       []byte      `json:"data,omitempty"`
+	*Archive
+}
+
+// encryption holds the parameters of an encrypted archive.
+type encryption struct {
+	Cipher  string `json:"cipher"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // Memory is in KiB.
+	Threads uint8  `json:"threads"`
+	Nonce   []byte `json:"nonce"`
+}
+
+const (
+	cipherAESGCM = "aes-256-gcm"
+	kdfArgon2id  = "argon2id"
+
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024 // KiB
+	kdfThreads = 4
+)
+
+// Write writes a plaintext archive.
+func Write(w io.Writer, a *Archive) error {
+	return writeDocument(w, &document{Format: Format, Version: Version, Archive: a})
+}
+
+// WriteEncrypted writes an archive encrypted with the password.
+func WriteEncrypted(w io.Writer, a *Archive, password string) error {
+	if password == "" {
+		return errors.New("the password must not be empty")
+	}
+	plain, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	enc := &encryption{
+		Cipher: cipherAESGCM, KDF: kdfArgon2id, Salt: randomBytes(16),
+		Time: kdfTime, Memory: kdfMemory, Threads: kdfThreads,
+	}
+	aead, err := enc.aead(password)
+	if err != nil {
+		return err
+	}
+	enc.Nonce = randomBytes(aead.NonceSize())
+	data := aead.Seal(nil, enc.Nonce, plain, additionalData())
+	return writeDocument(w, &document{Format: Format, Version: Version, Encryption: enc, Data: data})
+}
+
+func writeDocument(w io.Writer, doc *document) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(doc)
+}
+
+// Is reports whether data looks like an archive, encrypted or not.
+func Is(data []byte) bool {
+	var doc struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &doc) == nil && doc.Format == Format
+}
+
+// Read reads an archive. password is only called for encrypted archives.
+func Read(data []byte, password func() (string, error)) (*Archive, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Format != Format {
+		return nil, ErrNotArchive
+	}
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported export format version %d", doc.Version)
+	}
+	if doc.Encryption == nil {
+		if doc.Archive == nil {
+			return &Archive{}, nil
+		}
+		return doc.Archive, nil
+	}
+
+	enc := doc.Encryption
+	if enc.Cipher != cipherAESGCM || enc.KDF != kdfArgon2id || enc.Time == 0 || enc.Threads == 0 || enc.Memory > 1024*1024 {
+		return nil, fmt.Errorf("unsupported export encryption %s with %s", enc.Cipher, enc.KDF)
+	}
+	pw, err := password()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := enc.aead(pw)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc.Nonce) != aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plain, err := aead.Open(nil, enc.Nonce, doc.Data, additionalData())
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	a := &Archive{}
+	if err := json.Unmarshal(plain, a); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+	return a, nil
+}
+
+// aead derives the key of the archive from the password.
+func (e *encryption) aead(password string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(password), e.Salt, e.Time, e.Memory, e.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the ciphertext to the format and its version.
+func additionalData() []byte {
+	return []byte(fmt.Sprintf("%s/%d", Format, Version))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testArchive() *Archive {
+	return &Archive{
+		Exported: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Entries: []Entry{
+			{Type: "UserCredentials", Folder: "Work", Tags: []string{"mail"}, Fields: map[string]string{"meta_info": "Mail", "password": "pw"},
+				Attachments: []File{{Name: "codes.txt", Data: []byte("111 222")}}},
+			{Type: "FilesData", Fields: map[string]string{"meta_info": "Passport"}, File: &File{Name: "passport.pdf", Data: []byte("%PDF")}},
+		},
+	}
+}
+
+func password(pw string) func() (string, error) {
+	return func() (string, error) { return pw, nil }
+}
+
+func TestWriteEncrypted(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteEncrypted(&buf, testArchive(), "secret"))
+	assert.True(t, Is(buf.Bytes()))
+	assert.NotContains(t, buf.String(), "Mail")
+
+	a, err := Read(buf.Bytes(), password("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, testArchive(), a)
+
+	_, err = Read(buf.Bytes(), password("wrong"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	damaged := bytes.Replace(buf.Bytes(), []byte(`"data": "`), []byte(`"data": "AAAA`), 1)
+	_, err = Read(damaged, password("secret"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, testArchive()))
+	assert.Contains(t, buf.String(), `"meta_info": "Mail"`)
+
+	a, err := Read(buf.Bytes(), func() (string, error) {
+		t.Fatal("no password is needed for a plaintext archive")
+		return "", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testArchive(), a)
+}
+
+func TestRead_NotArchive(t *testing.T) {
+	_, err := Read([]byte(`{"encrypted": false, "items": []}`), password(""))
+	assert.ErrorIs(t, err, ErrNotArchive)
+	assert.False(t, Is([]byte("a,b\n")))
+}
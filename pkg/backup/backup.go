@@ -0,0 +1,481 @@
+// Package backup writes and reads encrypted vault backups.
+//
+// A backup is a single file: a header followed by a tar archive encrypted with
+// AES-256-GCM in chunks. The key is derived from a passphrase with Argon2id.
+// Every chunk is authenticated together with the header and its position, and the
+// last chunk is marked, so a modified, reordered or truncated backup is rejected.
+// The archive ends with a manifest listing the files with their SHA-256 checksums.
+package backup
+
+import (
+	"archive/tar"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ManifestName is the name of the manifest in the archive.
+const ManifestName = "manifest.json"
+
+// FormatVersion is the version of the backup format written by Create.
+const FormatVersion = 1
+
+// Errors returned when a backup cannot be read.
+var (
+	ErrNotBackup = errors.New("not a GophKeeper backup")
+	ErrDecrypt   = errors.New("wrong passphrase or damaged backup")
+	ErrTruncated = errors.New("the backup is truncated")
+	ErrManifest  = errors.New("the backup does not match its manifest")
+)
+
+// Manifest describes the content of a backup.
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Files   []File    `json:"files"`
+	Missing []string  `json:"missing,omitempty"` // Missing lists the files that should have been backed up but did not exist.
+}
+
+// File is a file in a backup.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Entry is a file to put into a backup.
+type Entry struct {
+	Name string // Name is the slash-separated name of the file in the backup.
+	Path string // Path is the file on disk.
+}
+
+const (
+	magic     = "GKBACKUP"
+	saltSize  = 16
+	chunkSize = 64 * 1024
+
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024 // KiB
+	kdfThreads = 4
+)
+
+// header is the unencrypted start of a backup.
+type header struct {
+	Version uint8
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	Salt    [saltSize]byte
+}
+
+const headerSize = len(magic) + 1 + 4 + 4 + 1 + saltSize
+
+func (h *header) marshal() []byte {
+	b := make([]byte, 0, headerSize)
+	b = append(b, magic...)
+	b = append(b, h.Version)
+	b = binary.BigEndian.AppendUint32(b, h.Time)
+	b = binary.BigEndian.AppendUint32(b, h.Memory)
+	b = append(b, h.Threads)
+	return append(b, h.Salt[:]...)
+}
+
+func parseHeader(b []byte) (*header, error) {
+	if len(b) != headerSize || string(b[:len(magic)]) != magic {
+		return nil, ErrNotBackup
+	}
+	b = b[len(magic):]
+	h := &header{
+		Version: b[0],
+		Time:    binary.BigEndian.Uint32(b[1:5]),
+		Memory:  binary.BigEndian.Uint32(b[5:9]),
+		Threads: b[9],
+	}
+	copy(h.Salt[:], b[10:])
+	if h.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", h.Version)
+	}
+	if h.Time == 0 || h.Threads == 0 || h.Memory > 1024*1024 {
+		return nil, ErrNotBackup
+	}
+	return h, nil
+}
+
+// aead derives the key of the backup from the passphrase.
+func (h *header) aead(passphrase string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), h.Salt[:], h.Time, h.Memory, h.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Create writes an encrypted backup of the entries to w. missing lists files that
+// should have been backed up but do not exist; they are recorded in the manifest.
+func Create(w io.Writer, passphrase string, entries []Entry, missing []string, now time.Time) (*Manifest, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase must not be empty")
+	}
+	h := &header{Version: FormatVersion, Time: kdfTime, Memory: kdfMemory, Threads: kdfThreads}
+	if _, err := io.ReadFull(rand.Reader, h.Salt[:]); err != nil {
+		return nil, err
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	hb := h.marshal()
+	if _, err := w.Write(hb); err != nil {
+		return nil, err
+	}
+
+	ew := &encWriter{w: w, aead: aead, header: hb}
+	tw := tar.NewWriter(ew)
+	manifest := &Manifest{Version: FormatVersion, Created: now.UTC(), Missing: missing}
+	for _, e := range entries {
+		f, err := addFile(tw, e, now)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, f)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = tw.WriteHeader(&tar.Header{Name: ManifestName, Mode: 0o600, Size: int64(len(data)), ModTime: now})
+	if err == nil {
+		_, err = tw.Write(data)
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = ew.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// addFile writes a file to the archive and returns its manifest record.
+func addFile(tw *tar.Writer, e Entry, now time.Time) (File, error) {
+	in, err := os.Open(e.Path)
+	if err != nil {
+		return File{}, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return File{}, err
+	}
+
+	err = tw.WriteHeader(&tar.Header{Name: e.Name, Mode: 0o600, Size: info.Size(), ModTime: now})
+	if err != nil {
+		return File{}, err
+	}
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tw, hash), in)
+	if err != nil {
+		return File{}, err
+	}
+	if n != info.Size() {
+		return File{}, fmt.Errorf("%s changed while it was backed up", e.Path)
+	}
+	return File{Name: e.Name, Size: n, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// Extract decrypts a backup into dir and checks every file against the manifest.
+// dir must exist. On error the files already written are left in dir.
+func Extract(r io.Reader, passphrase string, dir string) (*Manifest, error) {
+	hb := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, hb); err != nil {
+		return nil, ErrNotBackup
+	}
+	h, err := parseHeader(hb)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	dr := &decReader{r: r, aead: aead, header: hb}
+	tr := tar.NewReader(dr)
+	extracted := make(map[string]File)
+	var manifest *Manifest
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if manifest != nil {
+			return nil, fmt.Errorf("%w: %s follows the manifest", ErrManifest, hdr.Name)
+		}
+		if hdr.Name == ManifestName {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrManifest, err)
+			}
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg || !localName(hdr.Name) {
+			return nil, fmt.Errorf("%w: unexpected entry %s", ErrManifest, hdr.Name)
+		}
+		if _, ok := extracted[hdr.Name]; ok {
+			return nil, fmt.Errorf("%w: %s is stored twice", ErrManifest, hdr.Name)
+		}
+		f, err := extractFile(tr, hdr.Name, dir)
+		if err != nil {
+			return nil, err
+		}
+		extracted[f.Name] = f
+	}
+	// Read up to the last chunk, so that a truncated backup is detected even if
+	// the archive itself is complete.
+	if _, err := io.Copy(io.Discard, dr); err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: the manifest is missing", ErrManifest)
+	}
+	if err := manifest.check(extracted); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// extractFile writes a file of the archive into dir.
+func extractFile(r io.Reader, name string, dir string) (File, error) {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return File{}, err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return File{}, err
+	}
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, hash), r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return File{}, err
+	}
+	return File{Name: name, Size: n, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// check compares the extracted files with the manifest.
+func (m *Manifest) check(extracted map[string]File) error {
+	var problems []string
+	listed := make(map[string]bool, len(m.Files))
+	for _, f := range m.Files {
+		listed[f.Name] = true
+		got, ok := extracted[f.Name]
+		switch {
+		case !ok:
+			problems = append(problems, f.Name+" is missing")
+		case got.Size != f.Size || got.SHA256 != f.SHA256:
+			problems = append(problems, f.Name+" has a wrong checksum")
+		}
+	}
+	for name := range extracted {
+		if !listed[name] {
+			problems = append(problems, name+" is not listed")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrManifest, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// localName reports whether an archive name stays inside the extraction directory.
+func localName(name string) bool {
+	return name != "" && name == path.Clean(name) && filepath.IsLocal(filepath.FromSlash(name))
+}
+
+// FileName returns the name of a backup made at the given time.
+func FileName(now time.Time) string {
+	return "gophkeeper-" + now.UTC().Format("20060102-150405") + ".gkbackup"
+}
+
+// Rotate deletes all but the newest keep backups in dir named by FileName and
+// returns the paths of the deleted backups.
+func Rotate(dir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && isBackupName(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	// The names sort chronologically.
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	var deleted []string
+	for i := keep; i < len(names); i++ {
+		p := filepath.Join(dir, names[i])
+		if err := os.Remove(p); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, p)
+	}
+	return deleted, nil
+}
+
+func isBackupName(name string) bool {
+	stamp, ok := strings.CutPrefix(name, "gophkeeper-")
+	if !ok {
+		return false
+	}
+	stamp, ok = strings.CutSuffix(stamp, ".gkbackup")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse("20060102-150405", stamp)
+	return err == nil
+}
+
+// encWriter encrypts the data written to it in chunks. Each chunk is written as
+// a flag marking the last chunk, the length of the sealed chunk and the sealed chunk.
+type encWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint64
+}
+
+func (e *encWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		free := chunkSize - len(e.buf)
+		if free > len(p) {
+			free = len(p)
+		}
+		e.buf = append(e.buf, p[:free]...)
+		p = p[free:]
+		if len(e.buf) == chunkSize {
+			if err := e.flush(false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close writes the last chunk, which may be empty.
+func (e *encWriter) Close() error {
+	return e.flush(true)
+}
+
+func (e *encWriter) flush(last bool) error {
+	flag := byte(0)
+	if last {
+		flag = 1
+	}
+	sealed := e.aead.Seal(nil, chunkNonce(e.aead, e.counter), e.buf, chunkAD(e.header, flag))
+	e.counter++
+	e.buf = e.buf[:0]
+
+	frame := make([]byte, 5, 5+len(sealed))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(sealed)))
+	_, err := e.w.Write(append(frame, sealed...))
+	return err
+}
+
+// decReader decrypts the chunks written by encWriter.
+type decReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint64
+	done    bool
+}
+
+func (d *decReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decReader) next() error {
+	frame := make([]byte, 5)
+	if _, err := io.ReadFull(d.r, frame); err != nil {
+		return ErrTruncated
+	}
+	size := binary.BigEndian.Uint32(frame[1:])
+	if frame[0] > 1 || size > chunkSize+uint32(d.aead.Overhead()) {
+		return ErrDecrypt
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return ErrTruncated
+	}
+	plain, err := d.aead.Open(nil, chunkNonce(d.aead, d.counter), sealed, chunkAD(d.header, frame[0]))
+	if err != nil {
+		return ErrDecrypt
+	}
+	d.counter++
+	d.buf = plain
+	if frame[0] == 1 {
+		d.done = true
+		var extra [1]byte
+		if n, _ := d.r.Read(extra[:]); n > 0 {
+			return fmt.Errorf("%w: data after the end of the backup", ErrDecrypt)
+		}
+	}
+	return nil
+}
+
+// chunkNonce returns the nonce of the n-th chunk. The key is unique to the backup,
+// so a counter is enough.
+func chunkNonce(aead cipher.AEAD, n uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], n)
+	return nonce
+}
+
+// chunkAD returns the additional data of a chunk: the header and the last chunk flag.
+func chunkAD(header []byte, flag byte) []byte {
+	return append(append([]byte(nil), header...), flag)
+}
@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// create writes the given files to a directory and returns a backup of them.
+func create(t *testing.T, files map[string][]byte) []byte {
+	dir := t.TempDir()
+	var entries []Entry
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.Base(name))
+		require.NoError(t, os.WriteFile(p, data, 0o600))
+		entries = append(entries, Entry{Name: name, Path: p})
+	}
+	var buf bytes.Buffer
+	_, err := Create(&buf, "passphrase", entries, []string{"files/gone"}, time.Now())
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestCreateExtract(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 3*chunkSize/10+7)
+	data := create(t, map[string][]byte{"data.db": []byte("database"), "files/blob": large})
+	assert.NotContains(t, string(data), "database")
+
+	dir := t.TempDir()
+	manifest, err := Extract(bytes.NewReader(data), "passphrase", dir)
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 2)
+	assert.Equal(t, []string{"files/gone"}, manifest.Missing)
+
+	got, err := os.ReadFile(filepath.Join(dir, "data.db"))
+	require.NoError(t, err)
+	assert.Equal(t, "database", string(got))
+	got, err = os.ReadFile(filepath.Join(dir, "files", "blob"))
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+}
+
+func TestExtract_Rejected(t *testing.T) {
+	data := create(t, map[string][]byte{"data.db": bytes.Repeat([]byte("x"), 2*chunkSize)})
+
+	_, err := Extract(bytes.NewReader(data), "wrong", t.TempDir())
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = Extract(bytes.NewReader([]byte("not a backup at all, just some text")), "passphrase", t.TempDir())
+	assert.ErrorIs(t, err, ErrNotBackup)
+
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)/2] ^= 1
+	_, err = Extract(bytes.NewReader(tampered), "passphrase", t.TempDir())
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// Dropping the last chunk leaves a valid prefix that must still be rejected.
+	lastChunk := (len(data) - headerSize) % (5 + chunkSize + 16)
+	_, err = Extract(bytes.NewReader(data[:len(data)-lastChunk]), "passphrase", t.TempDir())
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	_, err = Extract(bytes.NewReader(append(append([]byte(nil), data...), 0)), "passphrase", t.TempDir())
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestExtract_UnsafeName(t *testing.T) {
+	data := create(t, map[string][]byte{"../escape": []byte("x")})
+	_, err := Extract(bytes.NewReader(data), "passphrase", t.TempDir())
+	assert.ErrorIs(t, err, ErrManifest)
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, FileName(start.Add(time.Duration(i)*time.Hour))), nil, 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600))
+
+	deleted, err := Rotate(dir, 2)
+	require.NoError(t, err)
+	assert.Len(t, deleted, 3)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"gophkeeper-20261019-130000.gkbackup", "gophkeeper-20261019-140000.gkbackup", "notes.txt"}, names)
+}
@@ -29,6 +29,13 @@ Data Structures:
 
 	SyncQueue - Represents a synchronization queue entry with pending operations.
 
+	Schema - The whitelist of tables and columns, derived from the migrations. Table and
+	column names are never taken from callers without being checked against it; unknown
+	ones are rejected with an UnknownTableError or UnknownColumnError.
+
+	Repository - Typed access to the records of a single table, see NewRepository. Its
+	Rows methods give the same access with maps, restricted to the columns of the model.
+
 Example Usage:
 
 	keeper := bdkeeper.NewKeeper()
@@ -62,12 +69,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/fs"
-	"log"
-	"strings"
+	"sort"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3" // SQLite driver
 	"github.com/wurt83ow/gophkeeper-client/pkg/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -77,78 +81,118 @@ import (
 //go:embed migrations/*.sql
 var embeddedMigrations embed.FS
 
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // Keeper represents a database keeper with methods to interact with the database.
 type Keeper struct {
-	db *sql.DB
+	db     *sql.DB
+	q      querier // q is db, or the transaction of a keeper returned by WithTx
+	schema *Schema // schema is the whitelist of tables and columns
+	cache  *cache  // cache holds the prepared statements, shared with the transactions
 }
 
-// NewKeeper creates a new instance of Keeper and initializes the database.
+// NewKeeper creates a new instance of Keeper using db.
+// If db is nil, ./data.db is opened and migrated with Open, and NewKeeper panics
+// if that fails; use Open to handle the error instead.
 func NewKeeper(db *sql.DB) *Keeper {
 	if db == nil {
-		var err error
-		db, err = sql.Open("sqlite3", "./data.db")
+		k, err := Open("./data.db")
 		if err != nil {
 			panic(err)
 		}
+		return k
+	}
 
-		// Create the migrations table if it doesn't exist
-		_, err = db.Exec("CREATE TABLE IF NOT EXISTS migrations (name TEXT PRIMARY KEY)")
-		if err != nil {
-			panic(err)
-		}
+	k := &Keeper{
+		db:     db,
+		q:      db,
+		schema: defaultSchema,
+		cache:  newCache(db),
+	}
 
-		// Apply migrations
-		files, err := fs.ReadDir(embeddedMigrations, "migrations")
-		if err != nil {
-			panic(err)
-		}
+	return k
+}
 
-		for _, file := range files {
-			if !file.IsDir() {
-				// Check if the migration has already been applied
-				var name string
-				err = db.QueryRow("SELECT name FROM migrations WHERE name = ?", file.Name()).Scan(&name)
-				if err == sql.ErrNoRows {
-					// The migration has not been applied yet
-					f, err := embeddedMigrations.Open("migrations/" + file.Name())
-					if err != nil {
-						panic(err)
-					}
-					defer f.Close()
-
-					bytes, err := io.ReadAll(f)
-					if err != nil {
-						panic(err)
-					}
-
-					upAndDown := strings.Split(string(bytes), "-- +goose Down")
-					upStatements := strings.Split(upAndDown[0], ";")
-
-					// Run the "up" statements.
-					for _, stmt := range upStatements {
-						if _, err := db.Exec(stmt); err != nil {
-							log.Fatalf("Failed to execute migration %s: %v", file.Name(), err)
-						}
-					}
-
-					// Record the migration as having been applied
-					_, err = db.Exec("INSERT INTO migrations (name) VALUES (?)", file.Name())
-					if err != nil {
-						panic(err)
-					}
-				} else if err != nil {
-					// An error occurred checking if the migration has been applied
-					panic(err)
-				}
-			}
-		}
+// Connection settings of the databases opened with Open.
+const (
+	// busyTimeout is how long a connection waits for a lock held by another one.
+	busyTimeout = 5 * time.Second
+	// maxOpenConns bounds the connections. In WAL mode readers do not block the
+	// writer, but there is only one writer at a time, so more connections do not help.
+	maxOpenConns = 4
+)
+
+// Open opens the SQLite database at path and applies all pending migrations.
+func Open(path string) (*Keeper, error) {
+	k, err := OpenUnmigrated(path)
+	if err != nil {
+		return nil, err
 	}
+	if _, err := k.MigrateUp(context.Background()); err != nil {
+		k.Close()
+		return nil, fmt.Errorf("failed to migrate the database: %w", err)
+	}
+	return k, nil
+}
 
-	k := &Keeper{
-		db: db,
+// OpenUnmigrated opens the SQLite database at path without applying migrations, for
+// them to be inspected or applied with MigrationStatus and MigrateUp.
+//
+// The database runs in WAL mode, so that the background synchronization can write
+// while entries are read, and connections wait up to busyTimeout for locks.
+// Transactions take the write lock when they begin, which avoids lock upgrade
+// failures between concurrent transactions.
+func OpenUnmigrated(path string) (*Keeper, error) {
+	db, err := sql.Open(DriverName, dsn(path))
+	if err != nil {
+		return nil, err
 	}
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxOpenConns)
+	return NewKeeper(db), nil
+}
 
-	return k
+// Close closes the database.
+func (k *Keeper) Close() error {
+	k.cache.reset()
+	return k.db.Close()
+}
+
+// WithTx runs fn with a keeper whose operations all belong to a single transaction.
+// The transaction is committed if fn returns nil and rolled back otherwise, so a data
+// change and its synchronization queue entry are either both stored or both discarded.
+// Calling WithTx on a keeper that is already bound to a transaction reuses it.
+func (k *Keeper) WithTx(ctx context.Context, fn func(tx Storage) error) (err error) {
+	if _, ok := k.q.(*sql.Tx); ok {
+		return fn(k)
+	}
+
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(&Keeper{db: k.db, q: tx, schema: k.schema, cache: k.cache}); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 // UserExists checks if a user exists in the database.
@@ -157,7 +201,7 @@ func (k *Keeper) UserExists(ctx context.Context, username string) (bool, error)
 	query := `SELECT COUNT(*) FROM Users WHERE username = ?;`
 
 	// Execute the query
-	row := k.db.QueryRowContext(ctx, query, username)
+	row := k.queryRow(ctx, query, username)
 
 	// Get the result
 	var count int
@@ -176,7 +220,39 @@ func (k *Keeper) AddUser(ctx context.Context, username string, hashedPassword st
 	query := `INSERT INTO Users (username, password) VALUES (?, ?);`
 
 	// Execute the query
-	_, err := k.db.ExecContext(ctx, query, username, hashedPassword)
+	_, err := k.exec(ctx, query, username, hashedPassword)
+	return err
+}
+
+// UpdatePassword replaces the hashed password of a user in the database.
+func (k *Keeper) UpdatePassword(ctx context.Context, username string, hashedPassword string) error {
+	// Query to update the hashed password of a user
+	query := `UPDATE Users SET password = ? WHERE username = ?;`
+
+	// Execute the query
+	res, err := k.exec(ctx, query, hashedPassword, username)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the user exists
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("No records found")
+	}
+	return nil
+}
+
+// DeleteUser removes a user from the database.
+func (k *Keeper) DeleteUser(ctx context.Context, username string) error {
+	// Query to delete the user from the database
+	query := `DELETE FROM Users WHERE username = ?;`
+
+	// Execute the query
+	_, err := k.exec(ctx, query, username)
 	return err
 }
 
@@ -186,7 +262,7 @@ func (k *Keeper) IsEmpty(ctx context.Context) (bool, error) {
 	query := `SELECT count(*) FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%';`
 
 	// Execute the query
-	row := k.db.QueryRowContext(ctx, query)
+	row := k.queryRow(ctx, query)
 
 	// Get the result
 	var count int
@@ -211,7 +287,7 @@ func (k *Keeper) CreateSyncEntry(ctx context.Context, operation string, table st
 	status := "Pending"
 
 	// Add entry to SyncQueue table
-	_, err = k.db.ExecContext(ctx, "INSERT INTO SyncQueue (operation, table_name, user_id, entry_id, data, status) VALUES (?, ?, ?, ?, ?, ?)",
+	_, err = k.exec(ctx, "INSERT INTO SyncQueue (operation, table_name, user_id, entry_id, data, status) VALUES (?, ?, ?, ?, ?, ?)",
 		operation, table, user_id, entry_id, dataJson, status)
 	return err
 }
@@ -222,7 +298,7 @@ func (k *Keeper) GetPassword(ctx context.Context, username string) (string, erro
 	query := `SELECT password FROM Users WHERE username = ?;`
 
 	// Execute the query
-	row := k.db.QueryRowContext(ctx, query, username)
+	row := k.queryRow(ctx, query, username)
 
 	// Get the result
 	var password string
@@ -248,7 +324,7 @@ func (k *Keeper) GetUserID(ctx context.Context, username string) (int, error) {
 	query := `SELECT id FROM Users WHERE username = ?;`
 
 	// Execute the query
-	row := k.db.QueryRowContext(ctx, query, username)
+	row := k.queryRow(ctx, query, username)
 
 	// Get the result
 	var id int
@@ -261,55 +337,95 @@ func (k *Keeper) GetUserID(ctx context.Context, username string) (int, error) {
 	return id, nil
 }
 
-// AddData adds data to the specified database table.
-// Keys and values from the provided data map will be inserted into the specified table, along with the given user_id and entry_id.
-func (k *Keeper) AddData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error {
-	// Create lists of keys and values considering user_id and entry_id
-	keys := make([]string, 0, len(data)+2)        // +2 for user_id and entry_id
-	values := make([]interface{}, 0, len(data)+2) // +2 for user_id and entry_id
-
-	// Add user_id and entry_id to the beginning of keys and values lists
-	keys = append(keys, "user_id", "id")
-	values = append(values, user_id, entry_id)
+// Schema returns the whitelist of tables and columns the keeper may access.
+func (k *Keeper) Schema() *Schema {
+	return k.schema
+}
 
-	// Add other keys and values from the provided data map
-	for key, value := range data {
-		if key == "deleted" {
+// dataColumns returns the columns of data to write, sorted, checked against the table.
+// The id and user_id columns are set by the keeper, and "deleted" is a server-side marker.
+func dataColumns(t *Table, data map[string]string) ([]string, error) {
+	columns := make([]string, 0, len(data))
+	for key := range data {
+		if key == "id" || key == "user_id" || key == "deleted" {
 			continue
 		}
-		keys = append(keys, key)
-		values = append(values, value)
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+	if err := t.Check(columns...); err != nil {
+		return nil, err
 	}
+	return columns, nil
+}
 
-	// Prepare the SQL query and execute it
-	stmt, err := k.db.Prepare(fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", table, strings.Join(keys, ","), strings.Repeat("?,", len(keys)-1)+"?"))
+// AddData adds data to the specified database table.
+// Keys and values from the provided data map will be inserted into the specified table, along with the given user_id and entry_id.
+// Unknown tables and columns are rejected with an UnknownTableError or UnknownColumnError.
+func (k *Keeper) AddData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error {
+	t, err := k.schema.Table(table)
+	if err != nil {
+		return err
+	}
+	columns, err := dataColumns(t, data)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.ExecContext(ctx, values...)
 
+	// user_id and entry_id go first, followed by the provided data
+	values := make([]interface{}, 0, len(columns)+3)
+	values = append(values, user_id, entry_id)
+	for _, column := range columns {
+		values = append(values, data[column])
+	}
+	columns = append([]string{"user_id", "id"}, columns...)
+	if data["created_at"] == "" {
+		// Set the creation time if the table in the database has the column
+		existing, err := k.cache.tableColumns(ctx, k.q, t.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get columns: %w", err)
+		}
+		for _, col := range existing {
+			if col == "created_at" && t.HasColumn(col) {
+				columns = append(columns, col)
+				values = append(values, createdAt())
+			}
+		}
+	}
+
+	_, err = k.exec(ctx, t.insertQuery(columns), values...)
 	return err
 }
 
+// createdAt returns the creation time of a new entry, in the format of SQLite's CURRENT_TIMESTAMP.
+func createdAt() string {
+	return time.Now().UTC().Format(time.DateTime)
+}
+
 // UpdateData updates data in the specified database table.
 // Values from the provided data map will be used to update the record with the specified user_id and entry_id in the specified table.
+// Unknown tables and columns are rejected with an UnknownTableError or UnknownColumnError.
 func (k *Keeper) UpdateData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error {
-	// Create lists of keys and values to update data
-	keys := make([]string, 0, len(data))
-	values := make([]interface{}, 0, len(data))
-	for key, value := range data {
-		keys = append(keys, key+" = ?")
-		values = append(values, value)
+	t, err := k.schema.Table(table)
+	if err != nil {
+		return err
 	}
-	// Append user_id and entry_id to the end of the lists
-	values = append(values, user_id, entry_id)
-
-	// Prepare the SQL query and execute it
-	stmt, err := k.db.Prepare(fmt.Sprintf("UPDATE %s SET %s WHERE user_id = ? AND id = ?", table, strings.Join(keys, ",")))
+	columns, err := dataColumns(t, data)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.ExecContext(ctx, values...)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(columns)+2)
+	for _, column := range columns {
+		values = append(values, data[column])
+	}
+	// Append user_id and entry_id to the end of the lists
+	values = append(values, user_id, entry_id)
+
+	_, err = k.exec(ctx, t.updateQuery(columns), values...)
 	return err
 }
 
@@ -326,14 +442,17 @@ func (k *Keeper) DeleteData(ctx context.Context, table string, user_id int, entr
 		return errors.New("id must be specified")
 	}
 
-	// Prepare the query to check the existence of the record
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE user_id = ? AND id = ?", table)
-	args := []interface{}{user_id, entry_id}
+	t, err := k.schema.Table(table)
+	if err != nil {
+		return err
+	}
 
-	// Execute the query
-	row := k.db.QueryRowContext(ctx, query, args...)
+	// Check the existence of the record
+	where := "user_id = ? AND id = ?"
+	args := []interface{}{user_id, entry_id}
+	row := k.queryRow(ctx, t.countQuery(where), args...)
 	var count int
-	err := row.Scan(&count)
+	err = row.Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -346,42 +465,33 @@ func (k *Keeper) DeleteData(ctx context.Context, table string, user_id int, entr
 	}
 
 	// Delete the record
-	query = strings.Replace(query, "SELECT COUNT(*)", "DELETE", 1)
-	_, err = k.db.ExecContext(ctx, query, args...)
+	_, err = k.exec(ctx, t.deleteQuery(where), args...)
 	return err
 }
 
 // GetData retrieves data for a specific entry from the specified table in the database.
 // It returns a map containing column names as keys and corresponding values for the entry.
 func (k *Keeper) GetData(ctx context.Context, table string, user_id int, entry_id string) (map[string]string, error) {
-	// Get all columns of the table
-	columns, err := k.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	t, err := k.schema.Table(table)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %w", err)
+		return nil, err
 	}
 
-	var cols []string
-	for columns.Next() {
-		var col struct {
-			Cid        int
-			Name       string
-			Type       string
-			NotNull    bool
-			Dflt_value *string
-			Pk         int
-		}
-		err := columns.Scan(&col.Cid, &col.Name, &col.Type, &col.NotNull, &col.Dflt_value, &col.Pk)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan column: %w", err)
-		}
+	// Get the columns present in the database, limited to the whitelisted ones
+	columns, err := k.cache.tableColumns(ctx, k.q, t.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	cols := make([]string, 0, len(columns))
+	for _, col := range columns {
 		// Exclude unnecessary columns
-		if col.Name != "id" && col.Name != "deleted" && col.Name != "user_id" && col.Name != "updated_at" {
-			cols = append(cols, col.Name)
+		if col != "id" && col != "deleted" && col != "user_id" && col != "updated_at" && col != "created_at" && t.HasColumn(col) {
+			cols = append(cols, col)
 		}
 	}
 
 	// Query the specific entry
-	row := k.db.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE id = ?", strings.Join(cols, ","), table), entry_id)
+	row := k.queryRow(ctx, t.selectQuery(cols, "id = ?"), entry_id)
 	values := make([]interface{}, len(cols))
 	for i := range values {
 		var value string
@@ -400,9 +510,17 @@ func (k *Keeper) GetData(ctx context.Context, table string, user_id int, entry_i
 
 // GetAllData retrieves all data from the specified table in the database for the given user_id.
 // It returns a slice of maps, with each map containing column names as keys and corresponding values for each row.
+// Unknown tables and columns are rejected with an UnknownTableError or UnknownColumnError.
 func (k *Keeper) GetAllData(ctx context.Context, table string, user_id int, columns ...string) ([]map[string]string, error) {
-	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = ?", strings.Join(columns, ","), table)
-	rows, err := k.db.QueryContext(ctx, query, user_id)
+	t, err := k.schema.Table(table)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Check(columns...); err != nil {
+		return nil, err
+	}
+
+	rows, err := k.query(ctx, t.selectQuery(columns, "user_id = ?"), user_id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -437,11 +555,11 @@ func (k *Keeper) GetAllData(ctx context.Context, table string, user_id int, colu
 
 // ClearData deletes all records associated with the specified user_id from the specified table in the database.
 func (k *Keeper) ClearData(ctx context.Context, table string, userID int) error {
-	stmt, err := k.db.Prepare(fmt.Sprintf("DELETE FROM %s WHERE user_id = ?", table))
+	t, err := k.schema.Table(table)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.ExecContext(ctx, userID)
+	_, err = k.exec(ctx, t.deleteQuery("user_id = ?"), userID)
 	return err
 }
 
@@ -449,7 +567,7 @@ func (k *Keeper) ClearData(ctx context.Context, table string, userID int) error
 func (k *Keeper) GetSyncEntriesByStatus(ctx context.Context, status string) ([]models.SyncQueue, error) {
 	var entries []models.SyncQueue
 	query := "SELECT * FROM SyncQueue WHERE status = ?"
-	rows, err := k.db.QueryContext(ctx, query, status)
+	rows, err := k.query(ctx, query, status)
 	if err != nil {
 		return nil, err
 	}
@@ -471,8 +589,14 @@ func (k *Keeper) GetSyncEntriesByStatus(ctx context.Context, status string) ([]m
 	return entries, nil
 }
 
+// ClearSyncEntries deletes all synchronization queue entries of the specified user.
+func (k *Keeper) ClearSyncEntries(ctx context.Context, userID int) error {
+	_, err := k.exec(ctx, "DELETE FROM SyncQueue WHERE user_id = ?", userID)
+	return err
+}
+
 // UpdateSyncEntryStatus updates the status of an entry in the sync table.
 func (k *Keeper) UpdateSyncEntryStatus(ctx context.Context, id int, status string) error {
-	_, err := k.db.ExecContext(ctx, "UPDATE SyncQueue SET status = ? WHERE id = ?", status, id)
+	_, err := k.exec(ctx, "UPDATE SyncQueue SET status = ? WHERE id = ?", status, id)
 	return err
 }
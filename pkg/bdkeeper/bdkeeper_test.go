@@ -3,6 +3,7 @@ package bdkeeper_test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"testing"
 	"time"
@@ -14,7 +15,7 @@ import (
 
 func setup(t *testing.T) (*sql.DB, func()) {
 	t.Helper()
-	db, err := sql.Open("sqlite3", ":memory:")
+	db, err := sql.Open(bdkeeper.DriverName, ":memory:")
 	if err != nil {
 		t.Fatalf("failed to connect to database: %v", err)
 	}
@@ -497,3 +498,114 @@ func TestNewKeeperWithoutMockDB(t *testing.T) {
 	}
 
 }
+
+func TestUpdatePassword(t *testing.T) {
+	db, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	keeper := bdkeeper.NewKeeper(db)
+
+	addUser(db, "testuser", "oldhash")
+
+	err := keeper.UpdatePassword(ctx, "testuser", "newhash")
+	assert.NoError(t, err)
+
+	password, err := keeper.GetPassword(ctx, "testuser")
+	assert.NoError(t, err)
+	assert.Equal(t, "newhash", password)
+
+	// Updating an unknown user must fail
+	err = keeper.UpdatePassword(ctx, "unknown", "newhash")
+	assert.Error(t, err)
+}
+
+func TestDeleteUser(t *testing.T) {
+	db, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	keeper := bdkeeper.NewKeeper(db)
+
+	addUser(db, "testuser", "hash")
+
+	err := keeper.DeleteUser(ctx, "testuser")
+	assert.NoError(t, err)
+
+	exists, err := keeper.UserExists(ctx, "testuser")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestClearSyncEntries(t *testing.T) {
+	db, cleanup := setup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	keeper := bdkeeper.NewKeeper(db)
+
+	_, err := db.Exec(`INSERT INTO SyncQueue (operation, table_name, user_id, entry_id, data, status) VALUES
+		('Create', 'UserCredentials', 1, 'entry1', '{}', 'Pending'),
+		('Create', 'UserCredentials', 2, 'entry2', '{}', 'Pending')`)
+	assert.NoError(t, err)
+
+	err = keeper.ClearSyncEntries(ctx, 1)
+	assert.NoError(t, err)
+
+	entries, err := keeper.GetSyncEntriesByStatus(ctx, "Pending")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].UserID)
+}
+
+func TestWithTx(t *testing.T) {
+	db, cleanup := setup(t)
+	defer cleanup()
+	keeper := bdkeeper.NewKeeper(db)
+	ctx := context.Background()
+	data := map[string]string{"login": "l", "password": "p", "meta_info": "m"}
+
+	count := func(query string) int {
+		var n int
+		if err := db.QueryRow(query).Scan(&n); err != nil {
+			t.Fatalf("failed to count rows: %v", err)
+		}
+		return n
+	}
+
+	// Data and sync entry are committed together
+	err := keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		if err := tx.AddData(ctx, "UserCredentials", 1, "e1", data); err != nil {
+			return err
+		}
+		return tx.CreateSyncEntry(ctx, "Create", "UserCredentials", 1, "e1", data)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM UserCredentials"))
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM SyncQueue"))
+
+	// A failing sync entry rolls back the data change
+	err = keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		if err := tx.AddData(ctx, "UserCredentials", 1, "e2", data); err != nil {
+			return err
+		}
+		// The operation is rejected by the CHECK constraint
+		return tx.CreateSyncEntry(ctx, "Merge", "UserCredentials", 1, "e2", data)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM UserCredentials"))
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM SyncQueue"))
+
+	// Nested calls share the outer transaction
+	err = keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		if err := tx.DeleteData(ctx, "UserCredentials", 1, "e1"); err != nil {
+			return err
+		}
+		return tx.WithTx(ctx, func(inner bdkeeper.Storage) error {
+			assert.Same(t, tx, inner)
+			return errors.New("abort")
+		})
+	})
+	assert.EqualError(t, err, "abort")
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM UserCredentials"))
+}
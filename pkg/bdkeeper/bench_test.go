@@ -0,0 +1,165 @@
+package bdkeeper_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+)
+
+// vaultSize is the number of entries of the benchmark vault.
+const vaultSize = 50000
+
+func TestOpen_ConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.db")
+	keeper, err := bdkeeper.Open(path)
+	require.NoError(t, err)
+	defer keeper.Close()
+
+	// Concurrent transactions wait for each other instead of failing with
+	// "database is locked".
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+				id := fmt.Sprint(i)
+				if err := tx.AddData(ctx, "TextData", 1, id, map[string]string{"data": id, "meta_info": id}); err != nil {
+					return err
+				}
+				_, err := tx.GetData(ctx, "TextData", 1, id)
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	rows, err := keeper.GetAllData(ctx, "TextData", 1, "id")
+	require.NoError(t, err)
+	assert.Len(t, rows, 20)
+	assert.FileExists(t, path+"-wal")
+}
+
+// benchVault returns a keeper on a vault with vaultSize credentials of user 1,
+// and a few of user 2.
+func benchVault(b *testing.B) *bdkeeper.Keeper {
+	keeper, err := bdkeeper.Open(filepath.Join(b.TempDir(), "data.db"))
+	require.NoError(b, err)
+	b.Cleanup(func() { keeper.Close() })
+
+	ctx := context.Background()
+	err = keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		for i := 0; i < vaultSize; i++ {
+			data := map[string]string{
+				"login":     fmt.Sprintf("user%d@example.com", i),
+				"password":  "c2VjcmV0LXZhbHVlLWVuY3J5cHRlZC13aXRoLWFlcw==",
+				"meta_info": fmt.Sprintf("Entry %d", i),
+			}
+			if err := tx.AddData(ctx, "UserCredentials", 1+i/(vaultSize-10), fmt.Sprintf("entry-%d", i), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(b, err)
+	b.ResetTimer()
+	return keeper
+}
+
+func BenchmarkKeeper_GetData(b *testing.B) {
+	keeper := benchVault(b)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		if _, err := keeper.GetData(ctx, "UserCredentials", 1, fmt.Sprintf("entry-%d", i%vaultSize)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeeper_GetAllData(b *testing.B) {
+	keeper := benchVault(b)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		rows, err := keeper.GetAllData(ctx, "UserCredentials", 1, "id", "login", "meta_info")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(rows) != vaultSize-10 {
+			b.Fatalf("got %d rows", len(rows))
+		}
+	}
+}
+
+func BenchmarkKeeper_GetAllDataSmallUser(b *testing.B) {
+	keeper := benchVault(b)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		if _, err := keeper.GetAllData(ctx, "UserCredentials", 2, "id", "login", "meta_info"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeeper_AddData(b *testing.B) {
+	keeper := benchVault(b)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		data := map[string]string{"login": "new", "password": "secret", "meta_info": "New entry"}
+		if err := keeper.AddData(ctx, "UserCredentials", 1, fmt.Sprintf("new-%d", i), data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeeper_UpdateData(b *testing.B) {
+	keeper := benchVault(b)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		data := map[string]string{"login": fmt.Sprint(i)}
+		if err := keeper.UpdateData(ctx, "UserCredentials", 1, fmt.Sprintf("entry-%d", i%vaultSize), data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeeper_ClearData(b *testing.B) {
+	keeper := benchVault(b)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		if err := keeper.ClearData(ctx, "UserCredentials", 3); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeeper_GetSyncEntriesByStatus(b *testing.B) {
+	keeper := benchVault(b)
+	ctx := context.Background()
+	err := keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		for i := 0; i < vaultSize; i++ {
+			if err := tx.CreateSyncEntry(ctx, "Create", "UserCredentials", 1, fmt.Sprintf("entry-%d", i), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := keeper.GetSyncEntriesByStatus(ctx, "Error"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
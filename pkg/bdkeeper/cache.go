@@ -0,0 +1,140 @@
+package bdkeeper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// maxCachedStmts bounds the statement cache. Queries depend on the columns written
+// or read, so the number of distinct queries is small but not fixed. Queries beyond
+// the bound run unprepared: evicting statements could close one that is in use.
+const maxCachedStmts = 256
+
+// cache keeps the prepared statements of a database by query, and the columns of
+// its tables. It is shared by a keeper and the keepers bound to its transactions,
+// and is reset when the schema changes.
+type cache struct {
+	mu      sync.Mutex
+	db      *sql.DB
+	stmts   map[string]*sql.Stmt
+	columns map[string][]string
+}
+
+func newCache(db *sql.DB) *cache {
+	return &cache{db: db, stmts: make(map[string]*sql.Stmt), columns: make(map[string][]string)}
+}
+
+// stmt returns the prepared statement for query, preparing it on first use unless
+// cachedOnly is set. It returns nil if the statement is not cached and is not prepared.
+func (c *cache) stmt(ctx context.Context, query string, cachedOnly bool) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.stmts[query]; ok {
+		return s, nil
+	}
+	if cachedOnly || len(c.stmts) >= maxCachedStmts {
+		return nil, nil
+	}
+	s, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = s
+	return s, nil
+}
+
+// tableColumns returns the columns of a table in the database, reading them once.
+func (c *cache) tableColumns(ctx context.Context, q querier, table string) ([]string, error) {
+	c.mu.Lock()
+	columns, ok := c.columns[table]
+	c.mu.Unlock()
+	if ok {
+		return columns, nil
+	}
+
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", quote(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var col struct {
+			Cid        int
+			Name       string
+			Type       string
+			NotNull    bool
+			Dflt_value *string
+			Pk         int
+		}
+		if err := rows.Scan(&col.Cid, &col.Name, &col.Type, &col.NotNull, &col.Dflt_value, &col.Pk); err != nil {
+			return nil, err
+		}
+		columns = append(columns, col.Name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.columns[table] = columns
+	c.mu.Unlock()
+	return columns, nil
+}
+
+// reset forgets the statements and columns, after the schema changed.
+func (c *cache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeStmts()
+	c.columns = make(map[string][]string)
+}
+
+func (c *cache) closeStmts() {
+	for query, s := range c.stmts {
+		s.Close()
+		delete(c.stmts, query)
+	}
+}
+
+// stmt returns the cached statement for query, bound to the transaction of the keeper
+// if any. It returns nil if the statement cannot be cached.
+func (k *Keeper) stmt(ctx context.Context, query string) *sql.Stmt {
+	// Preparing takes a connection from the pool. Within a transaction the pool may be
+	// held by transactions waiting for this one, so only cached statements are used.
+	tx, inTx := k.q.(*sql.Tx)
+	s, err := k.cache.stmt(ctx, query, inTx)
+	if s == nil || err != nil {
+		// The query runs unprepared and reports the error itself.
+		return nil
+	}
+	if inTx {
+		return tx.StmtContext(ctx, s)
+	}
+	return s
+}
+
+// exec executes a statement through the statement cache.
+func (k *Keeper) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if s := k.stmt(ctx, query); s != nil {
+		return s.ExecContext(ctx, args...)
+	}
+	return k.q.ExecContext(ctx, query, args...)
+}
+
+// query runs a query through the statement cache.
+func (k *Keeper) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if s := k.stmt(ctx, query); s != nil {
+		return s.QueryContext(ctx, args...)
+	}
+	return k.q.QueryContext(ctx, query, args...)
+}
+
+// queryRow runs a query returning a single row through the statement cache.
+func (k *Keeper) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if s := k.stmt(ctx, query); s != nil {
+		return s.QueryRowContext(ctx, args...)
+	}
+	return k.q.QueryRowContext(ctx, query, args...)
+}
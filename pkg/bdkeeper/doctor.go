@@ -0,0 +1,98 @@
+package bdkeeper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the problems it reports,
+// nil if the database is intact.
+func (k *Keeper) IntegrityCheck(ctx context.Context) ([]string, error) {
+	rows, err := k.q.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return nil, err
+		}
+		problems = append(problems, problem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(problems) == 1 && problems[0] == "ok" {
+		return nil, nil
+	}
+	return problems, nil
+}
+
+// GetUsers returns the names of all local users by id.
+func (k *Keeper) GetUsers(ctx context.Context) (map[int]string, error) {
+	rows, err := k.q.QueryContext(ctx, "SELECT id, username FROM Users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		users[id] = username
+	}
+	return users, rows.Err()
+}
+
+// OwnerIDs returns the ids of the users owning rows in any table other than Users, sorted.
+func (k *Keeper) OwnerIDs(ctx context.Context) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, name := range k.schema.Tables() {
+		t, _ := k.schema.Table(name)
+		if name == "Users" || !t.HasColumn("user_id") {
+			continue
+		}
+		query := fmt.Sprintf("SELECT DISTINCT user_id FROM %s WHERE user_id IS NOT NULL", quote(t.Name))
+		rows, err := k.q.QueryContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			seen[id] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sortedIDs(seen), nil
+}
+
+// DeleteSyncEntry deletes an entry of the sync table.
+func (k *Keeper) DeleteSyncEntry(ctx context.Context, id int) error {
+	_, err := k.exec(ctx, "DELETE FROM SyncQueue WHERE id = ?", id)
+	return err
+}
+
+func sortedIDs(set map[int]bool) []int {
+	ids := make([]int, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,19 @@
+//go:build cgo
+
+package bdkeeper
+
+import (
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// DriverName is the database/sql driver used to open SQLite databases. Builds with
+// cgo use mattn/go-sqlite3, builds with CGO_ENABLED=0 use the pure-Go modernc.org/sqlite.
+const DriverName = "sqlite3"
+
+// dsn returns the data source name opening the database at path with the connection settings.
+func dsn(path string) string {
+	return fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=%d&_txlock=immediate",
+		path, busyTimeout.Milliseconds())
+}
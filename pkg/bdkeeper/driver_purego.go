@@ -0,0 +1,19 @@
+//go:build !cgo
+
+package bdkeeper
+
+import (
+	"fmt"
+
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver
+)
+
+// DriverName is the database/sql driver used to open SQLite databases. Builds with
+// cgo use mattn/go-sqlite3, builds with CGO_ENABLED=0 use the pure-Go modernc.org/sqlite.
+const DriverName = "sqlite"
+
+// dsn returns the data source name opening the database at path with the connection settings.
+func dsn(path string) string {
+	return fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(%d)&_txlock=immediate",
+		path, busyTimeout.Milliseconds())
+}
@@ -0,0 +1,102 @@
+package bdkeeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+)
+
+// ErrVersionNotFound is returned when a version is not in the history of an entry.
+var ErrVersionNotFound = errors.New("version not found")
+
+// AddVersion stores data as the next version in the history of an entry.
+func (k *Keeper) AddVersion(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) (int, error) {
+	if _, err := k.schema.Table(table); err != nil {
+		return 0, err
+	}
+	dataJson, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+
+	var last sql.NullInt64
+	err = k.queryRow(ctx, "SELECT MAX(version) FROM EntryHistory WHERE table_name = ? AND entry_id = ?",
+		table, entry_id).Scan(&last)
+	if err != nil {
+		return 0, err
+	}
+	version := int(last.Int64) + 1
+
+	_, err = k.exec(ctx, "INSERT INTO EntryHistory (user_id, table_name, entry_id, version, data, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		user_id, table, entry_id, version, string(dataJson), time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// GetVersions returns the history of an entry, oldest version first.
+func (k *Keeper) GetVersions(ctx context.Context, table string, user_id int, entry_id string) ([]models.EntryVersion, error) {
+	rows, err := k.query(ctx, "SELECT version, created_at, data FROM EntryHistory WHERE table_name = ? AND user_id = ? AND entry_id = ? ORDER BY version",
+		table, user_id, entry_id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []models.EntryVersion
+	for rows.Next() {
+		var v models.EntryVersion
+		var dataJson string
+		if err := rows.Scan(&v.Version, &v.CreatedAt, &dataJson); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(dataJson), &v.Data); err != nil {
+			return nil, fmt.Errorf("invalid history data of version %d: %w", v.Version, err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion returns a single version of an entry or ErrVersionNotFound.
+func (k *Keeper) GetVersion(ctx context.Context, table string, user_id int, entry_id string, version int) (models.EntryVersion, error) {
+	versions, err := k.GetVersions(ctx, table, user_id, entry_id)
+	if err != nil {
+		return models.EntryVersion{}, err
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return models.EntryVersion{}, ErrVersionNotFound
+}
+
+// PruneVersions deletes all but the newest keep versions of an entry. A keep of 0 keeps all versions.
+func (k *Keeper) PruneVersions(ctx context.Context, table string, user_id int, entry_id string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	_, err := k.exec(ctx, `DELETE FROM EntryHistory WHERE table_name = ? AND user_id = ? AND entry_id = ? AND version NOT IN (
+		SELECT version FROM EntryHistory WHERE table_name = ? AND user_id = ? AND entry_id = ? ORDER BY version DESC LIMIT ?)`,
+		table, user_id, entry_id, table, user_id, entry_id, keep)
+	return err
+}
+
+// DeleteVersions deletes the history of an entry.
+func (k *Keeper) DeleteVersions(ctx context.Context, table string, user_id int, entry_id string) error {
+	_, err := k.exec(ctx, "DELETE FROM EntryHistory WHERE table_name = ? AND user_id = ? AND entry_id = ?", table, user_id, entry_id)
+	return err
+}
+
+// ClearVersions deletes the history of all entries of the specified user.
+func (k *Keeper) ClearVersions(ctx context.Context, userID int) error {
+	_, err := k.exec(ctx, "DELETE FROM EntryHistory WHERE user_id = ?", userID)
+	return err
+}
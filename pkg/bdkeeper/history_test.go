@@ -0,0 +1,59 @@
+package bdkeeper_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+)
+
+func TestVersions(t *testing.T) {
+	ctx := context.Background()
+	keeper, err := bdkeeper.Open(filepath.Join(t.TempDir(), "data.db"))
+	require.NoError(t, err)
+	defer keeper.Close()
+
+	for _, login := range []string{"alice", "bob", "carol"} {
+		_, err := keeper.AddVersion(ctx, "UserCredentials", 1, "entry", map[string]string{"login": login})
+		require.NoError(t, err)
+	}
+	_, err = keeper.AddVersion(ctx, "UserCredentials", 1, "other", map[string]string{"login": "dave"})
+	require.NoError(t, err)
+
+	versions, err := keeper.GetVersions(ctx, "UserCredentials", 1, "entry")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, "alice", versions[0].Data["login"])
+	assert.False(t, versions[0].CreatedAt.IsZero())
+
+	v, err := keeper.GetVersion(ctx, "UserCredentials", 1, "entry", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", v.Data["login"])
+	_, err = keeper.GetVersion(ctx, "UserCredentials", 1, "entry", 7)
+	assert.ErrorIs(t, err, bdkeeper.ErrVersionNotFound)
+
+	// Pruning keeps the newest versions and numbering continues after them
+	require.NoError(t, keeper.PruneVersions(ctx, "UserCredentials", 1, "entry", 2))
+	versions, err = keeper.GetVersions(ctx, "UserCredentials", 1, "entry")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+	next, err := keeper.AddVersion(ctx, "UserCredentials", 1, "entry", map[string]string{"login": "erin"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, next)
+
+	require.NoError(t, keeper.DeleteVersions(ctx, "UserCredentials", 1, "entry"))
+	versions, err = keeper.GetVersions(ctx, "UserCredentials", 1, "entry")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+	versions, err = keeper.GetVersions(ctx, "UserCredentials", 1, "other")
+	require.NoError(t, err)
+	assert.Len(t, versions, 1)
+
+	_, err = keeper.AddVersion(ctx, "Unknown", 1, "entry", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,572 @@
+package bdkeeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+)
+
+// MemoryStorage is a Storage keeping all data in memory. It behaves like a Keeper on
+// a fully migrated database, including the schema checks, and is meant for tests.
+// It is safe for concurrent use; transactions are serialized.
+type MemoryStorage struct {
+	mu     *sync.Mutex
+	state  *memState
+	schema *Schema
+	inTx   bool // inTx is set on the storage passed to the function of WithTx
+}
+
+// memState is the content of a MemoryStorage.
+type memState struct {
+	users      []memUser
+	lastUserID int
+	tables     map[string][]map[string]string // tables holds the rows of every table in insertion order
+	syncQueue  []models.SyncQueue
+	lastSyncID int
+	versions   []memVersion
+}
+
+type memUser struct {
+	id       int
+	username string
+	password string
+}
+
+type memVersion struct {
+	userID  int
+	table   string
+	entryID string
+	version models.EntryVersion
+}
+
+// NewMemoryStorage returns an empty in-memory storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		mu:     &sync.Mutex{},
+		state:  &memState{tables: make(map[string][]map[string]string)},
+		schema: defaultSchema,
+	}
+}
+
+// lock locks the storage unless it is bound to a transaction, which holds the lock already.
+func (m *MemoryStorage) lock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+// clone returns a deep copy of the state.
+func (st *memState) clone() *memState {
+	c := *st
+	c.users = append([]memUser(nil), st.users...)
+	c.tables = make(map[string][]map[string]string, len(st.tables))
+	for table, rows := range st.tables {
+		c.tables[table] = make([]map[string]string, len(rows))
+		for i, row := range rows {
+			c.tables[table][i] = copyMap(row)
+		}
+	}
+	c.syncQueue = append([]models.SyncQueue(nil), st.syncQueue...)
+	c.versions = make([]memVersion, len(st.versions))
+	for i, v := range st.versions {
+		c.versions[i] = v
+		c.versions[i].version.Data = copyMap(v.version.Data)
+	}
+	return &c
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// WithTx runs fn with a storage whose changes are discarded if fn returns an error or panics.
+func (m *MemoryStorage) WithTx(ctx context.Context, fn func(tx Storage) error) (err error) {
+	if m.inTx {
+		return fn(m)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.state.clone()
+	defer func() {
+		if p := recover(); p != nil {
+			*m.state = *snapshot
+			panic(p)
+		}
+		if err != nil {
+			*m.state = *snapshot
+		}
+	}()
+	return fn(&MemoryStorage{mu: m.mu, state: m.state, schema: m.schema, inTx: true})
+}
+
+// Close does nothing; the data is kept until the storage is garbage collected.
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+// UserExists checks if a user exists.
+func (m *MemoryStorage) UserExists(ctx context.Context, username string) (bool, error) {
+	defer m.lock()()
+	return m.state.user(username) != nil, nil
+}
+
+// AddUser adds a new user.
+func (m *MemoryStorage) AddUser(ctx context.Context, username string, hashedPassword string) error {
+	defer m.lock()()
+	if m.state.user(username) != nil {
+		return errors.New("UNIQUE constraint failed: Users.username")
+	}
+	m.state.lastUserID++
+	m.state.users = append(m.state.users, memUser{id: m.state.lastUserID, username: username, password: hashedPassword})
+	return nil
+}
+
+// UpdatePassword replaces the hashed password of a user.
+func (m *MemoryStorage) UpdatePassword(ctx context.Context, username string, hashedPassword string) error {
+	defer m.lock()()
+	u := m.state.user(username)
+	if u == nil {
+		return errors.New("No records found")
+	}
+	u.password = hashedPassword
+	return nil
+}
+
+// DeleteUser removes a user.
+func (m *MemoryStorage) DeleteUser(ctx context.Context, username string) error {
+	defer m.lock()()
+	for i, u := range m.state.users {
+		if u.username == username {
+			m.state.users = append(m.state.users[:i], m.state.users[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// GetPassword retrieves the hashed password of a user.
+func (m *MemoryStorage) GetPassword(ctx context.Context, username string) (string, error) {
+	defer m.lock()()
+	u := m.state.user(username)
+	if u == nil {
+		return "", sql.ErrNoRows
+	}
+	return u.password, nil
+}
+
+// GetUserID retrieves the user ID of a user.
+func (m *MemoryStorage) GetUserID(ctx context.Context, username string) (int, error) {
+	defer m.lock()()
+	u := m.state.user(username)
+	if u == nil {
+		return 0, sql.ErrNoRows
+	}
+	return u.id, nil
+}
+
+func (st *memState) user(username string) *memUser {
+	for i := range st.users {
+		if st.users[i].username == username {
+			return &st.users[i]
+		}
+	}
+	return nil
+}
+
+// AddData adds an entry to a table. Columns without a value are empty, as they are
+// in the migrated database, except updated_at and created_at, which default to the current time.
+func (m *MemoryStorage) AddData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error {
+	t, err := m.schema.Table(table)
+	if err != nil {
+		return err
+	}
+	columns, err := dataColumns(t, data)
+	if err != nil {
+		return err
+	}
+
+	defer m.lock()()
+	for _, row := range m.state.tables[t.Name] {
+		if row["id"] == entry_id {
+			return fmt.Errorf("UNIQUE constraint failed: %s.id", t.Name)
+		}
+	}
+	row := make(map[string]string, len(t.Columns))
+	for _, column := range t.Columns {
+		row[column] = ""
+	}
+	if t.HasColumn("updated_at") {
+		row["updated_at"] = time.Now().UTC().Format(time.DateTime)
+	}
+	for _, column := range columns {
+		row[column] = data[column]
+	}
+	if t.HasColumn("created_at") && row["created_at"] == "" {
+		row["created_at"] = createdAt()
+	}
+	row["id"] = entry_id
+	row["user_id"] = strconv.Itoa(user_id)
+	m.state.tables[t.Name] = append(m.state.tables[t.Name], row)
+	return nil
+}
+
+// UpdateData updates the given columns of an entry of the user. Updating a missing entry does nothing.
+func (m *MemoryStorage) UpdateData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error {
+	t, err := m.schema.Table(table)
+	if err != nil {
+		return err
+	}
+	columns, err := dataColumns(t, data)
+	if err != nil {
+		return err
+	}
+
+	defer m.lock()()
+	if row := m.state.row(t.Name, user_id, entry_id); row != nil {
+		for _, column := range columns {
+			row[column] = data[column]
+		}
+	}
+	return nil
+}
+
+// DeleteData deletes an entry of the user.
+func (m *MemoryStorage) DeleteData(ctx context.Context, table string, user_id int, entry_id string) error {
+	if user_id == 0 || table == "" {
+		return errors.New("user_id and table must be specified")
+	}
+	if entry_id == "" {
+		return errors.New("id must be specified")
+	}
+	t, err := m.schema.Table(table)
+	if err != nil {
+		return err
+	}
+
+	defer m.lock()()
+	rows := m.state.tables[t.Name]
+	for i, row := range rows {
+		if row["id"] == entry_id && row["user_id"] == strconv.Itoa(user_id) {
+			m.state.tables[t.Name] = append(rows[:i], rows[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("No records found")
+}
+
+// GetData returns the data columns of an entry. A missing entry is reported with sql.ErrNoRows.
+func (m *MemoryStorage) GetData(ctx context.Context, table string, user_id int, entry_id string) (map[string]string, error) {
+	t, err := m.schema.Table(table)
+	if err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+	for _, row := range m.state.tables[t.Name] {
+		if row["id"] != entry_id {
+			continue
+		}
+		data := make(map[string]string)
+		for _, column := range t.Columns {
+			if column != "id" && column != "deleted" && column != "user_id" && column != "updated_at" && column != "created_at" {
+				data[column] = row[column]
+			}
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("failed to scan row: %w", sql.ErrNoRows)
+}
+
+// GetAllData returns the given columns of all entries of the user.
+func (m *MemoryStorage) GetAllData(ctx context.Context, table string, user_id int, columns ...string) ([]map[string]string, error) {
+	t, err := m.schema.Table(table)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Check(columns...); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+	var data []map[string]string
+	for _, row := range m.state.tables[t.Name] {
+		if row["user_id"] != strconv.Itoa(user_id) {
+			continue
+		}
+		values := make(map[string]string, len(columns))
+		for _, column := range columns {
+			values[column] = row[column]
+		}
+		data = append(data, values)
+	}
+	return data, nil
+}
+
+// ClearData deletes all entries of the user from a table.
+func (m *MemoryStorage) ClearData(ctx context.Context, table string, userID int) error {
+	t, err := m.schema.Table(table)
+	if err != nil {
+		return err
+	}
+
+	defer m.lock()()
+	var kept []map[string]string
+	for _, row := range m.state.tables[t.Name] {
+		if row["user_id"] != strconv.Itoa(userID) {
+			kept = append(kept, row)
+		}
+	}
+	m.state.tables[t.Name] = kept
+	return nil
+}
+
+func (st *memState) row(table string, user_id int, entry_id string) map[string]string {
+	for _, row := range st.tables[table] {
+		if row["id"] == entry_id && row["user_id"] == strconv.Itoa(user_id) {
+			return row
+		}
+	}
+	return nil
+}
+
+// CreateSyncEntry creates a pending synchronization queue entry.
+func (m *MemoryStorage) CreateSyncEntry(ctx context.Context, operation string, table string, user_id int, entry_id string, data map[string]string) error {
+	dataJson, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	defer m.lock()()
+	m.state.lastSyncID++
+	m.state.syncQueue = append(m.state.syncQueue, models.SyncQueue{
+		ID:        m.state.lastSyncID,
+		TableName: table,
+		UserID:    user_id,
+		EntryID:   entry_id,
+		Operation: operation,
+		Data:      string(dataJson),
+		Status:    "Pending",
+	})
+	return nil
+}
+
+// GetSyncEntriesByStatus returns all synchronization queue entries with the given status.
+func (m *MemoryStorage) GetSyncEntriesByStatus(ctx context.Context, status string) ([]models.SyncQueue, error) {
+	defer m.lock()()
+	var entries []models.SyncQueue
+	for _, entry := range m.state.syncQueue {
+		if entry.Status == status {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// UpdateSyncEntryStatus updates the status of a synchronization queue entry.
+func (m *MemoryStorage) UpdateSyncEntryStatus(ctx context.Context, id int, status string) error {
+	defer m.lock()()
+	for i := range m.state.syncQueue {
+		if m.state.syncQueue[i].ID == id {
+			m.state.syncQueue[i].Status = status
+		}
+	}
+	return nil
+}
+
+// ClearSyncEntries deletes all synchronization queue entries of the user.
+func (m *MemoryStorage) ClearSyncEntries(ctx context.Context, userID int) error {
+	defer m.lock()()
+	var kept []models.SyncQueue
+	for _, entry := range m.state.syncQueue {
+		if entry.UserID != userID {
+			kept = append(kept, entry)
+		}
+	}
+	m.state.syncQueue = kept
+	return nil
+}
+
+// AddVersion stores data as the next version in the history of an entry.
+func (m *MemoryStorage) AddVersion(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) (int, error) {
+	if _, err := m.schema.Table(table); err != nil {
+		return 0, err
+	}
+
+	defer m.lock()()
+	last := 0
+	for _, v := range m.state.versions {
+		if v.table == table && v.entryID == entry_id && v.version.Version > last {
+			last = v.version.Version
+		}
+	}
+	m.state.versions = append(m.state.versions, memVersion{
+		userID:  user_id,
+		table:   table,
+		entryID: entry_id,
+		version: models.EntryVersion{Version: last + 1, CreatedAt: time.Now().UTC(), Data: copyMap(data)},
+	})
+	return last + 1, nil
+}
+
+// GetVersions returns the history of an entry, oldest version first.
+func (m *MemoryStorage) GetVersions(ctx context.Context, table string, user_id int, entry_id string) ([]models.EntryVersion, error) {
+	defer m.lock()()
+	return m.state.entryVersions(table, user_id, entry_id), nil
+}
+
+// GetVersion returns a single version of an entry or ErrVersionNotFound.
+func (m *MemoryStorage) GetVersion(ctx context.Context, table string, user_id int, entry_id string, version int) (models.EntryVersion, error) {
+	defer m.lock()()
+	for _, v := range m.state.entryVersions(table, user_id, entry_id) {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return models.EntryVersion{}, ErrVersionNotFound
+}
+
+// PruneVersions deletes all but the newest keep versions of an entry. A keep of 0 keeps all versions.
+func (m *MemoryStorage) PruneVersions(ctx context.Context, table string, user_id int, entry_id string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	defer m.lock()()
+	versions := m.state.entryVersions(table, user_id, entry_id)
+	if len(versions) <= keep {
+		return nil
+	}
+	oldest := versions[len(versions)-keep].Version
+	m.state.deleteVersions(func(v memVersion) bool {
+		return v.table == table && v.userID == user_id && v.entryID == entry_id && v.version.Version < oldest
+	})
+	return nil
+}
+
+// DeleteVersions deletes the history of an entry.
+func (m *MemoryStorage) DeleteVersions(ctx context.Context, table string, user_id int, entry_id string) error {
+	defer m.lock()()
+	m.state.deleteVersions(func(v memVersion) bool {
+		return v.table == table && v.userID == user_id && v.entryID == entry_id
+	})
+	return nil
+}
+
+// ClearVersions deletes the history of all entries of the user.
+func (m *MemoryStorage) ClearVersions(ctx context.Context, userID int) error {
+	defer m.lock()()
+	m.state.deleteVersions(func(v memVersion) bool {
+		return v.userID == userID
+	})
+	return nil
+}
+
+func (st *memState) entryVersions(table string, user_id int, entry_id string) []models.EntryVersion {
+	var versions []models.EntryVersion
+	for _, v := range st.versions {
+		if v.table == table && v.userID == user_id && v.entryID == entry_id {
+			version := v.version
+			version.Data = copyMap(v.version.Data)
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions
+}
+
+func (st *memState) deleteVersions(match func(memVersion) bool) {
+	var kept []memVersion
+	for _, v := range st.versions {
+		if !match(v) {
+			kept = append(kept, v)
+		}
+	}
+	st.versions = kept
+}
+
+// MigrationStatus returns no migrations: the memory storage always has the current schema.
+func (m *MemoryStorage) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	return nil, nil
+}
+
+// MigrateUp does nothing: the memory storage always has the current schema.
+func (m *MemoryStorage) MigrateUp(ctx context.Context) ([]*Migration, error) {
+	return nil, nil
+}
+
+// MigrateDown returns ErrNoMigration: the memory storage has no migrations to roll back.
+func (m *MemoryStorage) MigrateDown(ctx context.Context, steps int) ([]*Migration, error) {
+	return nil, ErrNoMigration
+}
+
+// Schema returns the schema of the storage.
+func (m *MemoryStorage) Schema() *Schema {
+	return m.schema
+}
+
+// IntegrityCheck finds no problems: the memory storage cannot be corrupted on disk.
+func (m *MemoryStorage) IntegrityCheck(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// GetUsers returns the names of all users by id.
+func (m *MemoryStorage) GetUsers(ctx context.Context) (map[int]string, error) {
+	defer m.lock()()
+	users := make(map[int]string, len(m.state.users))
+	for _, u := range m.state.users {
+		users[u.id] = u.username
+	}
+	return users, nil
+}
+
+// OwnerIDs returns the ids of the users owning any entry, sync queue entry or version, sorted.
+func (m *MemoryStorage) OwnerIDs(ctx context.Context) ([]int, error) {
+	defer m.lock()()
+	seen := make(map[int]bool)
+	for _, rows := range m.state.tables {
+		for _, row := range rows {
+			if id, err := strconv.Atoi(row["user_id"]); err == nil {
+				seen[id] = true
+			}
+		}
+	}
+	for _, entry := range m.state.syncQueue {
+		seen[entry.UserID] = true
+	}
+	for _, v := range m.state.versions {
+		seen[v.userID] = true
+	}
+	return sortedIDs(seen), nil
+}
+
+// DeleteSyncEntry deletes a synchronization queue entry.
+func (m *MemoryStorage) DeleteSyncEntry(ctx context.Context, id int) error {
+	defer m.lock()()
+	for i, entry := range m.state.syncQueue {
+		if entry.ID == id {
+			m.state.syncQueue = append(m.state.syncQueue[:i], m.state.syncQueue[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// Snapshot is not supported: the memory storage has no database to copy.
+func (m *MemoryStorage) Snapshot(ctx context.Context, path string) error {
+	return errors.New("the memory storage cannot be written to a database file")
+}
@@ -0,0 +1,424 @@
+package bdkeeper
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MigrationFunc is a Go-coded migration step. It runs in the transaction of its migration.
+type MigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+// Migration is a single versioned migration, either SQL from a file in the migrations
+// directory or Go code registered with RegisterMigration.
+type Migration struct {
+	Version  int64  // Version orders the migrations; it is the numeric prefix of SQL files.
+	Name     string // Name is the file name, or the name given to RegisterMigration.
+	Checksum string // Checksum detects edits of migrations that were already applied.
+
+	UpSQL, DownSQL   string        // UpSQL and DownSQL are the sections of an SQL migration.
+	UpFunc, DownFunc MigrationFunc // UpFunc and DownFunc are the steps of a Go migration.
+}
+
+// Reversible reports whether the migration can be rolled back.
+func (m *Migration) Reversible() bool {
+	return strings.TrimSpace(m.DownSQL) != "" || m.DownFunc != nil
+}
+
+// MigrationStatus describes the state of a migration in the database.
+type MigrationStatus struct {
+	Migration *Migration // Migration is nil for applied migrations unknown to this build.
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool // Modified is set when the migration changed after it was applied.
+}
+
+// ChecksumMismatchError is returned when an applied migration was modified afterwards.
+type ChecksumMismatchError struct {
+	Version int64
+	Name    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("migration %d (%s) was modified after it was applied", e.Version, e.Name)
+}
+
+// ErrNoMigration is returned by Down when there is no applied migration to roll back.
+var ErrNoMigration = errors.New("no applied migration to roll back")
+
+// goMigrations holds the migrations registered with RegisterMigration.
+var goMigrations = struct {
+	sync.Mutex
+	list []*Migration
+}{}
+
+// RegisterMigration registers a Go-coded migration, typically from an init function.
+// Go migrations are meant for data changes such as re-encryption; schema changes belong
+// in SQL files, from which the table and column whitelist is derived.
+//
+// The code of a Go migration cannot be checksummed like an SQL file, so the revision
+// stands for it: it must be increased whenever up or down changes after a release, for
+// databases that applied an earlier revision to be reported as modified.
+func RegisterMigration(version int64, name string, revision int, up, down MigrationFunc) {
+	goMigrations.Lock()
+	defer goMigrations.Unlock()
+	goMigrations.list = append(goMigrations.list, NewGoMigration(version, name, revision, up, down))
+}
+
+// NewGoMigration returns a Go-coded migration, whose checksum is derived from its name
+// and revision. See RegisterMigration.
+func NewGoMigration(version int64, name string, revision int, up, down MigrationFunc) *Migration {
+	return &Migration{
+		Version:  version,
+		Name:     name,
+		Checksum: checksum(fmt.Sprintf("go:%s:%d", name, revision)),
+		UpFunc:   up,
+		DownFunc: down,
+	}
+}
+
+// Migrator applies and rolls back migrations, recording them in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration // migrations are sorted by version
+}
+
+// NewMigrator returns a migrator for the SQL migrations in dir and the given Go migrations.
+func NewMigrator(db *sql.DB, fsys fs.FS, dir string, extra ...*Migration) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations = append(migrations, extra...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s",
+				migrations[i].Version, migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrator returns the migrator for the embedded SQL migrations and the registered Go migrations.
+func (k *Keeper) Migrator() (*Migrator, error) {
+	goMigrations.Lock()
+	extra := append([]*Migration(nil), goMigrations.list...)
+	goMigrations.Unlock()
+	return NewMigrator(k.db, embeddedMigrations, "migrations", extra...)
+}
+
+// MigrationStatus returns the state of every migration of the database.
+func (k *Keeper) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	m, err := k.Migrator()
+	if err != nil {
+		return nil, err
+	}
+	return m.Status(ctx)
+}
+
+// MigrateUp applies all pending migrations of the database.
+func (k *Keeper) MigrateUp(ctx context.Context) ([]*Migration, error) {
+	m, err := k.Migrator()
+	if err != nil {
+		return nil, err
+	}
+	defer k.cache.reset()
+	return m.Up(ctx)
+}
+
+// MigrateDown rolls back the given number of the most recent migrations of the database.
+func (k *Keeper) MigrateDown(ctx context.Context, steps int) ([]*Migration, error) {
+	m, err := k.Migrator()
+	if err != nil {
+		return nil, err
+	}
+	defer k.cache.reset()
+	return m.Down(ctx, steps)
+}
+
+// Migrations returns all known migrations sorted by version.
+func (m *Migrator) Migrations() []*Migration {
+	return append([]*Migration(nil), m.migrations...)
+}
+
+// appliedMigration is a row of the schema_migrations table.
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// init creates the schema_migrations table. Databases created by the previous runner,
+// which only recorded file names in the migrations table, are carried over.
+func (m *Migrator) init(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var legacy int
+	err = m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'migrations'").Scan(&legacy)
+	if err != nil || legacy == 0 {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, "SELECT name FROM migrations")
+	if err != nil {
+		return err
+	}
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, mig := range m.migrations {
+		if applied[mig.Name] {
+			if err := record(ctx, tx, mig); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DROP TABLE migrations"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied returns the applied migrations by version.
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedMigration, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+// Status returns the state of all known and applied migrations, sorted by version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []MigrationStatus
+	for _, mig := range m.migrations {
+		st := MigrationStatus{Migration: mig, Version: mig.Version, Name: mig.Name}
+		if a, ok := applied[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = a.appliedAt
+			st.Modified = a.checksum != mig.Checksum
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, st)
+	}
+	// Migrations applied by a newer build
+	for version, a := range applied {
+		statuses = append(statuses, MigrationStatus{Version: version, Name: a.name, Applied: true, AppliedAt: a.appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Up applies all pending migrations in version order, each in its own transaction,
+// and returns the applied ones. It refuses to run if an applied migration was modified.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range statuses {
+		if st.Modified {
+			return nil, &ChecksumMismatchError{Version: st.Version, Name: st.Name}
+		}
+	}
+
+	var done []*Migration
+	for _, st := range statuses {
+		if st.Applied {
+			continue
+		}
+		if err := m.run(ctx, st.Migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, st.Migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the given number of most recently applied migrations, newest first,
+// and returns the rolled back ones.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		st := statuses[i]
+		if !st.Applied {
+			continue
+		}
+		switch {
+		case st.Migration == nil:
+			return done, fmt.Errorf("migration %d (%s) is unknown to this version of GophKeeper", st.Version, st.Name)
+		case st.Modified:
+			return done, &ChecksumMismatchError{Version: st.Version, Name: st.Name}
+		case !st.Migration.Reversible():
+			return done, fmt.Errorf("migration %d (%s) cannot be rolled back", st.Version, st.Name)
+		}
+		if err := m.run(ctx, st.Migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, st.Migration)
+	}
+	if len(done) == 0 {
+		return nil, ErrNoMigration
+	}
+	return done, nil
+}
+
+// run applies or rolls back a single migration in a transaction.
+func (m *Migrator) run(ctx context.Context, mig *Migration, up bool) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	direction, sqlText, fn := "up", mig.UpSQL, mig.UpFunc
+	if !up {
+		direction, sqlText, fn = "down", mig.DownSQL, mig.DownFunc
+	}
+	if strings.TrimSpace(sqlText) != "" {
+		if _, err := tx.ExecContext(ctx, sqlText); err != nil {
+			return fmt.Errorf("migration %d (%s) %s failed: %w", mig.Version, mig.Name, direction, err)
+		}
+	}
+	if fn != nil {
+		if err := fn(ctx, tx); err != nil {
+			return fmt.Errorf("migration %d (%s) %s failed: %w", mig.Version, mig.Name, direction, err)
+		}
+	}
+
+	if up {
+		err = record(ctx, tx, mig)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", mig.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// record marks a migration as applied.
+func record(ctx context.Context, tx *sql.Tx, mig *Migration) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		mig.Version, mig.Name, mig.Checksum, time.Now().UTC())
+	return err
+}
+
+// loadMigrations reads the SQL migrations of a directory. File names must start with
+// a numeric version followed by an underscore, for example 20240118102916_users.sql.
+func loadMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []*Migration
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(file.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: file name must start with a version number", file.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		up, down := splitSections(string(data))
+		migrations = append(migrations, &Migration{
+			Version:  version,
+			Name:     file.Name(),
+			Checksum: checksum(string(data)),
+			UpSQL:    up,
+			DownSQL:  down,
+		})
+	}
+	return migrations, nil
+}
+
+// splitSections splits an SQL migration into its "-- +goose Up" and "-- +goose Down" sections.
+func splitSections(text string) (up, down string) {
+	var b [2]strings.Builder
+	section := 0
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section = 0
+			continue
+		case "-- +goose Down":
+			section = 1
+			continue
+		}
+		b[section].WriteString(line)
+		b[section].WriteByte('\n')
+	}
+	return b[0].String(), b[1].String()
+}
+
+// checksum returns the hex-encoded SHA-256 of s.
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,229 @@
+package bdkeeper_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+)
+
+func TestOpen_MigratesUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	keeper, err := bdkeeper.Open(filepath.Join(t.TempDir(), "data.db"))
+	require.NoError(t, err)
+	defer keeper.Close()
+
+	m, err := keeper.Migrator()
+	require.NoError(t, err)
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	for _, st := range statuses {
+		assert.True(t, st.Applied, st.Name)
+		assert.False(t, st.Modified, st.Name)
+	}
+
+	// Every migration can be rolled back and applied again
+	down, err := m.Down(ctx, len(statuses))
+	require.NoError(t, err)
+	assert.Len(t, down, len(statuses))
+	_, err = m.Down(ctx, 1)
+	assert.ErrorIs(t, err, bdkeeper.ErrNoMigration)
+
+	up, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, up, len(statuses))
+}
+
+func TestOpenUnmigrated_LeavesMigrationsPending(t *testing.T) {
+	ctx := context.Background()
+	keeper, err := bdkeeper.OpenUnmigrated(filepath.Join(t.TempDir(), "data.db"))
+	require.NoError(t, err)
+	defer keeper.Close()
+
+	statuses, err := keeper.MigrationStatus(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	for _, st := range statuses {
+		assert.False(t, st.Applied, st.Name)
+	}
+
+	applied, err := keeper.MigrateUp(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(statuses))
+}
+
+func openMemory(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open(bdkeeper.DriverName, ":memory:")
+	require.NoError(t, err)
+	// A single connection keeps the in-memory database alive across transactions
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrator_DetectsModifiedMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := openMemory(t)
+	fsys := fstest.MapFS{
+		"m/1_items.sql": {Data: []byte("-- +goose Up\nCREATE TABLE Items (id TEXT);\n-- +goose Down\nDROP TABLE Items;\n")},
+	}
+
+	m, err := bdkeeper.NewMigrator(db, fsys, "m")
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	fsys["m/1_items.sql"] = &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE TABLE Items (id TEXT, name TEXT);\n")}
+	fsys["m/2_more.sql"] = &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE TABLE More (id TEXT);\n")}
+	m, err = bdkeeper.NewMigrator(db, fsys, "m")
+	require.NoError(t, err)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Modified)
+	assert.False(t, statuses[1].Applied)
+
+	_, err = m.Up(ctx)
+	var mismatch *bdkeeper.ChecksumMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, int64(1), mismatch.Version)
+}
+
+func TestMigrator_RollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openMemory(t)
+	fsys := fstest.MapFS{
+		"m/1_items.sql": {Data: []byte("-- +goose Up\nCREATE TABLE Items (id TEXT);\nINSERT INTO Missing VALUES (1);\n")},
+	}
+
+	m, err := bdkeeper.NewMigrator(db, fsys, "m")
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.Error(t, err)
+
+	// Neither the table nor the migration record survived
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'Items'").Scan(&count))
+	assert.Zero(t, count)
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Applied)
+}
+
+func TestMigrator_GoMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := openMemory(t)
+	fsys := fstest.MapFS{
+		"m/1_items.sql": {Data: []byte("-- +goose Up\nCREATE TABLE Items (name TEXT);\nINSERT INTO Items VALUES ('a');\n")},
+	}
+	upper := &bdkeeper.Migration{
+		Version: 2,
+		Name:    "upper_case_names",
+		UpFunc: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE Items SET name = upper(name)")
+			return err
+		},
+		DownFunc: func(ctx context.Context, tx *sql.Tx) error {
+			return errors.New("irreversible")
+		},
+	}
+
+	m, err := bdkeeper.NewMigrator(db, fsys, "m", upper)
+	require.NoError(t, err)
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM Items").Scan(&name))
+	assert.Equal(t, "A", name)
+
+	// A failing down step keeps the migration applied
+	_, err = m.Down(ctx, 1)
+	assert.ErrorContains(t, err, "irreversible")
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[1].Applied)
+}
+
+func TestMigrator_ReversibleGoMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openMemory(t)
+	fsys := fstest.MapFS{
+		"m/1_items.sql": {Data: []byte("-- +goose Up\nCREATE TABLE Items (name TEXT);\nINSERT INTO Items VALUES ('a');\n")},
+	}
+	upper := func(revision int) *bdkeeper.Migration {
+		return bdkeeper.NewGoMigration(2, "upper_case_names", revision,
+			func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, "UPDATE Items SET name = upper(name)")
+				return err
+			},
+			func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, "UPDATE Items SET name = lower(name)")
+				return err
+			})
+	}
+	name := func() string {
+		var name string
+		require.NoError(t, db.QueryRow("SELECT name FROM Items").Scan(&name))
+		return name
+	}
+
+	m, err := bdkeeper.NewMigrator(db, fsys, "m", upper(1))
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "A", name())
+
+	down, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, down, 1)
+	assert.Equal(t, int64(2), down[0].Version)
+	assert.Equal(t, "a", name())
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, "A", name())
+
+	// A new revision of the code is reported as a modification of the applied one
+	m, err = bdkeeper.NewMigrator(db, fsys, "m", upper(2))
+	require.NoError(t, err)
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[1].Modified)
+	_, err = m.Up(ctx)
+	var mismatch *bdkeeper.ChecksumMismatchError
+	assert.ErrorAs(t, err, &mismatch)
+}
+
+func TestMigrator_ImportsLegacyRunner(t *testing.T) {
+	ctx := context.Background()
+	db := openMemory(t)
+	_, err := db.Exec(`CREATE TABLE migrations (name TEXT PRIMARY KEY);
+		INSERT INTO migrations VALUES ('1_items.sql');
+		CREATE TABLE Items (id TEXT);`)
+	require.NoError(t, err)
+	fsys := fstest.MapFS{
+		"m/1_items.sql": {Data: []byte("-- +goose Up\nCREATE TABLE Items (id TEXT);\n")},
+	}
+
+	m, err := bdkeeper.NewMigrator(db, fsys, "m")
+	require.NoError(t, err)
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'migrations'").Scan(&count))
+	assert.Zero(t, count)
+}
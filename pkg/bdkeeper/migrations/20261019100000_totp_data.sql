@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS TOTPData (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER,
+    secret TEXT NOT NULL,
+    issuer TEXT,
+    account_name TEXT,
+    algorithm TEXT NOT NULL,
+    digits TEXT NOT NULL,
+    period TEXT NOT NULL,
+    meta_info TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES Users(id)
+);
+
+-- +goose Down
+DROP TABLE TOTPData;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- totp_id links a login/password entry to a TOTPData entry
+ALTER TABLE UserCredentials ADD COLUMN totp_id TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE UserCredentials DROP COLUMN totp_id;
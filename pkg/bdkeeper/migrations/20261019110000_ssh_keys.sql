@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS SSHKeys (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER,
+    private_key TEXT NOT NULL,
+    key_type TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    comment TEXT,
+    confirm TEXT NOT NULL,
+    meta_info TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES Users(id)
+);
+
+-- +goose Down
+DROP TABLE SSHKeys;
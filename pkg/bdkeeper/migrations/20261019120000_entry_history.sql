@@ -0,0 +1,16 @@
+-- +goose Up
+-- EntryHistory keeps the previous versions of updated entries, with their values still encrypted
+CREATE TABLE IF NOT EXISTS EntryHistory (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER,
+    table_name TEXT NOT NULL,
+    entry_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    data TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE(table_name, entry_id, version),
+    FOREIGN KEY(user_id) REFERENCES Users(id)
+);
+
+-- +goose Down
+DROP TABLE EntryHistory;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- Folders hold entries of any type, meta_info is the folder name
+CREATE TABLE IF NOT EXISTS Folders (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER,
+    meta_info TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES Users(id)
+);
+
+-- Tags are shared by entries of any type, meta_info is the tag name
+CREATE TABLE IF NOT EXISTS Tags (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER,
+    meta_info TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES Users(id)
+);
+
+-- EntryTags links a tag to an entry of the table table_name
+CREATE TABLE IF NOT EXISTS EntryTags (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER,
+    tag_id TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    entry_id TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES Users(id)
+);
+
+-- folder_id links an entry to a Folders entry
+ALTER TABLE UserCredentials ADD COLUMN folder_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE TextData ADD COLUMN folder_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE FilesData ADD COLUMN folder_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE CreditCardData ADD COLUMN folder_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE TOTPData ADD COLUMN folder_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE SSHKeys ADD COLUMN folder_id TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE SSHKeys DROP COLUMN folder_id;
+ALTER TABLE TOTPData DROP COLUMN folder_id;
+ALTER TABLE CreditCardData DROP COLUMN folder_id;
+ALTER TABLE FilesData DROP COLUMN folder_id;
+ALTER TABLE TextData DROP COLUMN folder_id;
+ALTER TABLE UserCredentials DROP COLUMN folder_id;
+DROP TABLE EntryTags;
+DROP TABLE Tags;
+DROP TABLE Folders;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- custom_fields holds a JSON list of user defined fields, attachments a JSON list of FilesData ids
+ALTER TABLE UserCredentials ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '';
+ALTER TABLE UserCredentials ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
+ALTER TABLE TextData ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '';
+ALTER TABLE TextData ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
+ALTER TABLE FilesData ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '';
+ALTER TABLE FilesData ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
+ALTER TABLE CreditCardData ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '';
+ALTER TABLE CreditCardData ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
+ALTER TABLE TOTPData ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '';
+ALTER TABLE TOTPData ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
+ALTER TABLE SSHKeys ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '';
+ALTER TABLE SSHKeys ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE SSHKeys DROP COLUMN attachments;
+ALTER TABLE SSHKeys DROP COLUMN custom_fields;
+ALTER TABLE TOTPData DROP COLUMN attachments;
+ALTER TABLE TOTPData DROP COLUMN custom_fields;
+ALTER TABLE CreditCardData DROP COLUMN attachments;
+ALTER TABLE CreditCardData DROP COLUMN custom_fields;
+ALTER TABLE FilesData DROP COLUMN attachments;
+ALTER TABLE FilesData DROP COLUMN custom_fields;
+ALTER TABLE TextData DROP COLUMN attachments;
+ALTER TABLE TextData DROP COLUMN custom_fields;
+ALTER TABLE UserCredentials DROP COLUMN attachments;
+ALTER TABLE UserCredentials DROP COLUMN custom_fields;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Entries are always read per user; updated_at orders them for synchronization
+CREATE INDEX IF NOT EXISTS idx_UserCredentials_user_updated ON UserCredentials(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_CreditCardData_user_updated ON CreditCardData(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_TextData_user_updated ON TextData(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_FilesData_user_updated ON FilesData(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_TOTPData_user_updated ON TOTPData(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_SSHKeys_user_updated ON SSHKeys(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_Folders_user_updated ON Folders(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_Tags_user_updated ON Tags(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_EntryTags_user_updated ON EntryTags(user_id, updated_at);
+
+-- The sync queue is polled by status, the history is read per entry
+CREATE INDEX IF NOT EXISTS idx_SyncQueue_status ON SyncQueue(status);
+CREATE INDEX IF NOT EXISTS idx_EntryHistory_user_entry ON EntryHistory(user_id, table_name, entry_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_UserCredentials_user_updated;
+DROP INDEX IF EXISTS idx_CreditCardData_user_updated;
+DROP INDEX IF EXISTS idx_TextData_user_updated;
+DROP INDEX IF EXISTS idx_FilesData_user_updated;
+DROP INDEX IF EXISTS idx_TOTPData_user_updated;
+DROP INDEX IF EXISTS idx_SSHKeys_user_updated;
+DROP INDEX IF EXISTS idx_Folders_user_updated;
+DROP INDEX IF EXISTS idx_Tags_user_updated;
+DROP INDEX IF EXISTS idx_EntryTags_user_updated;
+DROP INDEX IF EXISTS idx_SyncQueue_status;
+DROP INDEX IF EXISTS idx_EntryHistory_user_entry;
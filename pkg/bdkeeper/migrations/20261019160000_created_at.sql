@@ -0,0 +1,23 @@
+-- +goose Up
+-- created_at is stored in plain text, as it is set locally and never synchronized.
+-- Existing entries get their update time when it is not encrypted, and stay empty otherwise.
+ALTER TABLE UserCredentials ADD COLUMN created_at DATETIME NOT NULL DEFAULT '';
+ALTER TABLE CreditCardData ADD COLUMN created_at DATETIME NOT NULL DEFAULT '';
+ALTER TABLE TextData ADD COLUMN created_at DATETIME NOT NULL DEFAULT '';
+ALTER TABLE FilesData ADD COLUMN created_at DATETIME NOT NULL DEFAULT '';
+ALTER TABLE TOTPData ADD COLUMN created_at DATETIME NOT NULL DEFAULT '';
+ALTER TABLE SSHKeys ADD COLUMN created_at DATETIME NOT NULL DEFAULT '';
+UPDATE UserCredentials SET created_at = updated_at WHERE updated_at GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*';
+UPDATE CreditCardData SET created_at = updated_at WHERE updated_at GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*';
+UPDATE TextData SET created_at = updated_at WHERE updated_at GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*';
+UPDATE FilesData SET created_at = updated_at WHERE updated_at GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*';
+UPDATE TOTPData SET created_at = updated_at WHERE updated_at GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*';
+UPDATE SSHKeys SET created_at = updated_at WHERE updated_at GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*';
+
+-- +goose Down
+ALTER TABLE SSHKeys DROP COLUMN created_at;
+ALTER TABLE TOTPData DROP COLUMN created_at;
+ALTER TABLE FilesData DROP COLUMN created_at;
+ALTER TABLE TextData DROP COLUMN created_at;
+ALTER TABLE CreditCardData DROP COLUMN created_at;
+ALTER TABLE UserCredentials DROP COLUMN created_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- expires_at holds the last day an entry is valid, password_changed_at when the password
+-- was last changed. Both are encrypted like the other fields of the entries.
+ALTER TABLE UserCredentials ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE CreditCardData ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE TextData ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE FilesData ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE TOTPData ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE SSHKeys ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE UserCredentials ADD COLUMN password_changed_at TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE UserCredentials DROP COLUMN password_changed_at;
+ALTER TABLE SSHKeys DROP COLUMN expires_at;
+ALTER TABLE TOTPData DROP COLUMN expires_at;
+ALTER TABLE FilesData DROP COLUMN expires_at;
+ALTER TABLE TextData DROP COLUMN expires_at;
+ALTER TABLE CreditCardData DROP COLUMN expires_at;
+ALTER TABLE UserCredentials DROP COLUMN expires_at;
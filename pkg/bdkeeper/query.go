@@ -0,0 +1,53 @@
+package bdkeeper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The query builder only ever receives table and column names that were checked
+// against the schema; identifiers are quoted nevertheless.
+
+// quote quotes an SQL identifier.
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteAll quotes a list of SQL identifiers.
+func quoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return quoted
+}
+
+// insertQuery returns an INSERT statement for the given columns.
+func (t *Table) insertQuery(columns []string) string {
+	return fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)",
+		quote(t.Name), strings.Join(quoteAll(columns), ","), strings.TrimSuffix(strings.Repeat("?,", len(columns)), ","))
+}
+
+// updateQuery returns an UPDATE statement setting the given columns of a single user's entry.
+func (t *Table) updateQuery(columns []string) string {
+	set := quoteAll(columns)
+	for i := range set {
+		set[i] += " = ?"
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE user_id = ? AND id = ?", quote(t.Name), strings.Join(set, ","))
+}
+
+// selectQuery returns a SELECT statement for the given columns and condition.
+func (t *Table) selectQuery(columns []string, where string) string {
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(quoteAll(columns), ","), quote(t.Name), where)
+}
+
+// countQuery returns a statement counting the rows matching the condition.
+func (t *Table) countQuery(where string) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", quote(t.Name), where)
+}
+
+// deleteQuery returns a DELETE statement for the rows matching the condition.
+func (t *Table) deleteQuery(where string) string {
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", quote(t.Name), where)
+}
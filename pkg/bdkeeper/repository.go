@@ -0,0 +1,236 @@
+package bdkeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Model is a record model stored in a table of the local database.
+// Its string fields tagged with `db:"column"` map to the columns of the table,
+// including those of embedded structs.
+type Model interface {
+	TableName() string
+}
+
+// Rows gives access to the records of a table as maps of column values, for callers
+// handling records of any type. Every Repository provides it, TableRows gives it for
+// tables without a model.
+type Rows interface {
+	AddRow(ctx context.Context, userID int, id string, data map[string]string) error
+	UpdateRow(ctx context.Context, userID int, id string, data map[string]string) error
+	GetRow(ctx context.Context, userID int, id string) (map[string]string, error)
+	ListRows(ctx context.Context, userID int, columns ...string) ([]map[string]string, error)
+	Delete(ctx context.Context, userID int, id string) error
+}
+
+// Repository gives typed access to the records of a single table. Only the columns
+// of the model can be written or read, even through its Rows methods.
+// Values are stored as they are given, encryption is up to the caller.
+type Repository[T Model] struct {
+	storage Storage
+	table   *Table
+	columns []string // columns lists the tagged columns of T in field order
+	fields  [][]int  // fields lists the index paths of the tagged fields of T
+}
+
+// NewRepository returns the repository of the model T in the storage, which may be
+// a transaction. It fails if the table or any of the tagged columns of T is not
+// declared by the migrations.
+func NewRepository[T Model](s Storage) (*Repository[T], error) {
+	var model T
+	t, err := s.Schema().Table(model.TableName())
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Repository[T]{storage: s, table: t}
+	typ := reflect.TypeOf(model)
+	for _, f := range reflect.VisibleFields(typ) {
+		column := f.Tag.Get("db")
+		if column == "" {
+			continue
+		}
+		if f.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("field %s of %s must be a string", f.Name, typ.Name())
+		}
+		r.columns = append(r.columns, column)
+		r.fields = append(r.fields, f.Index)
+	}
+	if err := t.Check(r.columns...); err != nil {
+		return nil, err
+	}
+	if !t.HasColumn("id") || len(r.columns) == 0 || r.columns[0] != "id" {
+		return nil, fmt.Errorf("the first tagged field of %s must be the id column", typ.Name())
+	}
+	return r, nil
+}
+
+// Columns returns the columns of the model, the id first.
+func (r *Repository[T]) Columns() []string {
+	return append([]string(nil), r.columns...)
+}
+
+// Add stores a new record. The record must have an id.
+func (r *Repository[T]) Add(ctx context.Context, userID int, record T) error {
+	data := r.toMap(record)
+	if data["id"] == "" {
+		return errors.New("id must be specified")
+	}
+	return r.storage.AddData(ctx, r.table.Name, userID, data["id"], data)
+}
+
+// Update replaces the stored values of a record. Timestamps left empty keep their
+// stored values.
+func (r *Repository[T]) Update(ctx context.Context, userID int, record T) error {
+	data := r.toMap(record)
+	return r.storage.UpdateData(ctx, r.table.Name, userID, data["id"], data)
+}
+
+// Get returns the record with the given id.
+func (r *Repository[T]) Get(ctx context.Context, userID int, id string) (T, error) {
+	data, err := r.GetRow(ctx, userID, id)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	data["id"] = id
+	return r.fromMap(data), nil
+}
+
+// List returns all records of the user.
+func (r *Repository[T]) List(ctx context.Context, userID int) ([]T, error) {
+	rows, err := r.storage.GetAllData(ctx, r.table.Name, userID, r.columns...)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]T, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, r.fromMap(row))
+	}
+	return records, nil
+}
+
+// Delete removes the record with the given id.
+func (r *Repository[T]) Delete(ctx context.Context, userID int, id string) error {
+	return r.storage.DeleteData(ctx, r.table.Name, userID, id)
+}
+
+// AddRow stores a new record given as a map of column values.
+func (r *Repository[T]) AddRow(ctx context.Context, userID int, id string, data map[string]string) error {
+	if err := r.check(data); err != nil {
+		return err
+	}
+	return r.storage.AddData(ctx, r.table.Name, userID, id, data)
+}
+
+// UpdateRow updates the columns of a record given in data.
+func (r *Repository[T]) UpdateRow(ctx context.Context, userID int, id string, data map[string]string) error {
+	if err := r.check(data); err != nil {
+		return err
+	}
+	return r.storage.UpdateData(ctx, r.table.Name, userID, id, data)
+}
+
+// GetRow returns the values of a record, without its id and timestamps.
+func (r *Repository[T]) GetRow(ctx context.Context, userID int, id string) (map[string]string, error) {
+	data, err := r.storage.GetData(ctx, r.table.Name, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	for column := range data {
+		if !r.hasColumn(column) {
+			delete(data, column)
+		}
+	}
+	return data, nil
+}
+
+// ListRows returns the given columns of all records of the user.
+func (r *Repository[T]) ListRows(ctx context.Context, userID int, columns ...string) ([]map[string]string, error) {
+	for _, column := range columns {
+		if !r.hasColumn(column) {
+			return nil, &UnknownColumnError{Table: r.table.Name, Column: column}
+		}
+	}
+	return r.storage.GetAllData(ctx, r.table.Name, userID, columns...)
+}
+
+// check returns an UnknownColumnError for the first key of data that is not a column
+// of the model. The owner and the deletion mark of rows from the server are let through.
+func (r *Repository[T]) check(data map[string]string) error {
+	for key := range data {
+		if key != "user_id" && key != "deleted" && !r.hasColumn(key) {
+			return &UnknownColumnError{Table: r.table.Name, Column: key}
+		}
+	}
+	return nil
+}
+
+// hasColumn reports whether the model has the column.
+func (r *Repository[T]) hasColumn(column string) bool {
+	for _, c := range r.columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// toMap converts a record to a column map. Empty timestamps are left out, the storage
+// maintains them.
+func (r *Repository[T]) toMap(record T) map[string]string {
+	v := reflect.ValueOf(record)
+	data := make(map[string]string, len(r.columns))
+	for i, column := range r.columns {
+		value := v.FieldByIndex(r.fields[i]).String()
+		if value == "" && (column == "created_at" || column == "updated_at") {
+			continue
+		}
+		data[column] = value
+	}
+	return data
+}
+
+// fromMap converts a column map to a record. Missing columns are left empty.
+func (r *Repository[T]) fromMap(data map[string]string) T {
+	var record T
+	v := reflect.ValueOf(&record).Elem()
+	for i, column := range r.columns {
+		v.FieldByIndex(r.fields[i]).SetString(data[column])
+	}
+	return record
+}
+
+// tableRows gives access to the records of a table without a model.
+type tableRows struct {
+	storage Storage
+	table   string
+}
+
+// TableRows returns the access to the records of a table of the storage, checked
+// against the schema only.
+func TableRows(s Storage, table string) Rows {
+	return tableRows{storage: s, table: table}
+}
+
+func (t tableRows) AddRow(ctx context.Context, userID int, id string, data map[string]string) error {
+	return t.storage.AddData(ctx, t.table, userID, id, data)
+}
+
+func (t tableRows) UpdateRow(ctx context.Context, userID int, id string, data map[string]string) error {
+	return t.storage.UpdateData(ctx, t.table, userID, id, data)
+}
+
+func (t tableRows) GetRow(ctx context.Context, userID int, id string) (map[string]string, error) {
+	return t.storage.GetData(ctx, t.table, userID, id)
+}
+
+func (t tableRows) ListRows(ctx context.Context, userID int, columns ...string) ([]map[string]string, error) {
+	return t.storage.GetAllData(ctx, t.table, userID, columns...)
+}
+
+func (t tableRows) Delete(ctx context.Context, userID int, id string) error {
+	return t.storage.DeleteData(ctx, t.table, userID, id)
+}
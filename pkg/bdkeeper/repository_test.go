@@ -0,0 +1,124 @@
+package bdkeeper_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+)
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			repo, err := bdkeeper.NewRepository[models.TextData](s)
+			require.NoError(t, err)
+
+			note := models.TextData{ID: "n1", MetaInfo: "note", Data: "hello", Entry: models.Entry{FolderID: "f1"}}
+			require.NoError(t, repo.Add(ctx, 1, note))
+			assert.Error(t, repo.Add(ctx, 1, models.TextData{Data: "no id"}))
+
+			got, err := repo.Get(ctx, 1, "n1")
+			require.NoError(t, err)
+			assert.Equal(t, note, got)
+
+			note.Data = "updated"
+			require.NoError(t, repo.Update(ctx, 1, note))
+
+			list, err := repo.List(ctx, 1)
+			require.NoError(t, err)
+			require.Len(t, list, 1)
+			assert.Equal(t, "updated", list[0].Data)
+			assert.Equal(t, "f1", list[0].FolderID)
+			assert.NotEmpty(t, list[0].CreatedAt, "the creation time is kept by updates")
+
+			require.NoError(t, repo.Delete(ctx, 1, "n1"))
+			list, err = repo.List(ctx, 1)
+			require.NoError(t, err)
+			assert.Empty(t, list)
+		})
+	}
+}
+
+func TestRepository_Rows(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			repo, err := bdkeeper.NewRepository[models.Credentials](s)
+			require.NoError(t, err)
+			var rows bdkeeper.Rows = repo
+
+			require.NoError(t, rows.AddRow(ctx, 1, "a", map[string]string{"meta_info": "mail", "login": "alice", "password": "old"}))
+			require.NoError(t, rows.UpdateRow(ctx, 1, "a", map[string]string{"password": "p"}))
+			data, err := rows.GetRow(ctx, 1, "a")
+			require.NoError(t, err)
+			assert.Equal(t, "alice", data["login"])
+			assert.Equal(t, "p", data["password"])
+
+			// Columns of other tables are rejected, although SQL would not know better
+			var columnErr *bdkeeper.UnknownColumnError
+			require.ErrorAs(t, rows.AddRow(ctx, 1, "b", map[string]string{"card_number": "4111"}), &columnErr)
+			assert.Equal(t, "card_number", columnErr.Column)
+			_, err = rows.ListRows(ctx, 1, "id", "cvv")
+			assert.ErrorAs(t, err, &columnErr)
+
+			list, err := rows.ListRows(ctx, 1, "id", "login")
+			require.NoError(t, err)
+			assert.Equal(t, []map[string]string{{"id": "a", "login": "alice"}}, list)
+		})
+	}
+}
+
+// All record models must match the tables declared by the migrations, and cover all
+// their columns but the owner.
+func TestRepository_Models(t *testing.T) {
+	s := bdkeeper.NewMemoryStorage()
+	check := func(table string, columns []string, err error) {
+		t.Helper()
+		require.NoError(t, err, table)
+		declared, err := s.Schema().Table(table)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, columns, without(declared.Columns, "user_id"), table)
+	}
+
+	credentials, err := bdkeeper.NewRepository[models.Credentials](s)
+	check(models.Credentials{}.TableName(), credentials.Columns(), err)
+	text, err := bdkeeper.NewRepository[models.TextData](s)
+	check(models.TextData{}.TableName(), text.Columns(), err)
+	files, err := bdkeeper.NewRepository[models.FileData](s)
+	check(models.FileData{}.TableName(), files.Columns(), err)
+	cards, err := bdkeeper.NewRepository[models.CreditCard](s)
+	check(models.CreditCard{}.TableName(), cards.Columns(), err)
+	totp, err := bdkeeper.NewRepository[models.TOTP](s)
+	check(models.TOTP{}.TableName(), totp.Columns(), err)
+	ssh, err := bdkeeper.NewRepository[models.SSHKey](s)
+	check(models.SSHKey{}.TableName(), ssh.Columns(), err)
+}
+
+func without(columns []string, column string) []string {
+	var out []string
+	for _, c := range columns {
+		if c != column {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+type badModel struct {
+	ID    string `db:"id"`
+	Login string `db:"login"`
+	Role  string `db:"role"`
+}
+
+func (badModel) TableName() string { return "UserCredentials" }
+
+func TestRepository_UnknownColumn(t *testing.T) {
+	_, err := bdkeeper.NewRepository[badModel](bdkeeper.NewMemoryStorage())
+	var columnErr *bdkeeper.UnknownColumnError
+	require.ErrorAs(t, err, &columnErr)
+	assert.Equal(t, "role", columnErr.Column)
+}
@@ -0,0 +1,217 @@
+package bdkeeper
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// UnknownTableError is returned when a table is not declared by the migrations.
+type UnknownTableError struct {
+	Table string
+}
+
+func (e *UnknownTableError) Error() string {
+	return fmt.Sprintf("unknown table %q", e.Table)
+}
+
+// UnknownColumnError is returned when a column is not declared by the migrations.
+type UnknownColumnError struct {
+	Table  string
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("unknown column %q in table %q", e.Column, e.Table)
+}
+
+// Table describes a table of the local database as declared by the migrations.
+type Table struct {
+	Name    string   // Name is the table name.
+	Columns []string // Columns lists the columns in declaration order.
+}
+
+// HasColumn reports whether the table has the given column.
+func (t *Table) HasColumn(name string) bool {
+	for _, c := range t.Columns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an UnknownColumnError for the first column the table does not have.
+func (t *Table) Check(columns ...string) error {
+	for _, c := range columns {
+		if !t.HasColumn(c) {
+			return &UnknownColumnError{Table: t.Name, Column: c}
+		}
+	}
+	return nil
+}
+
+// Schema is the whitelist of tables and columns the keeper may access.
+// It is derived from the migrations, so it always matches the database they create.
+type Schema struct {
+	tables map[string]*Table
+}
+
+// Table returns the table with the given name or an UnknownTableError.
+func (s *Schema) Table(name string) (*Table, error) {
+	t, ok := s.tables[name]
+	if !ok {
+		return nil, &UnknownTableError{Table: name}
+	}
+	return t, nil
+}
+
+// Tables returns the names of all tables, sorted.
+func (s *Schema) Tables() []string {
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	createTableRe = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)\s*\((.*)\)$`)
+	addColumnRe   = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\w+)\s+ADD\s+(?:COLUMN\s+)?(\w+)`)
+	dropColumnRe  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\w+)\s+DROP\s+(?:COLUMN\s+)?(\w+)`)
+	renameTableRe = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\w+)\s+RENAME\s+TO\s+(\w+)`)
+	dropTableRe   = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(\w+)`)
+)
+
+// ParseSchema builds the schema by replaying the "up" sections of the migrations
+// in dir, in file name order.
+func ParseSchema(fsys fs.FS, dir string) (*Schema, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{tables: make(map[string]*Table)}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sql") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		up, _ := splitSections(string(data))
+		for _, stmt := range strings.Split(stripComments(up), ";") {
+			if err := s.apply(strings.TrimSpace(stmt)); err != nil {
+				return nil, fmt.Errorf("%s: %w", file.Name(), err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// apply updates the schema with a single statement. Statements that do not change
+// the set of tables or columns are ignored.
+func (s *Schema) apply(stmt string) error {
+	if m := createTableRe.FindStringSubmatch(stmt); m != nil {
+		t := &Table{Name: m[1]}
+		for _, def := range splitDefinitions(m[2]) {
+			name := strings.Fields(def)[0]
+			switch strings.ToUpper(name) {
+			case "FOREIGN", "PRIMARY", "UNIQUE", "CHECK", "CONSTRAINT":
+				continue
+			}
+			t.Columns = append(t.Columns, strings.Trim(name, "\"`[]"))
+		}
+		s.tables[t.Name] = t
+		return nil
+	}
+	if m := addColumnRe.FindStringSubmatch(stmt); m != nil {
+		t, err := s.Table(m[1])
+		if err != nil {
+			return err
+		}
+		t.Columns = append(t.Columns, m[2])
+		return nil
+	}
+	if m := dropColumnRe.FindStringSubmatch(stmt); m != nil {
+		t, err := s.Table(m[1])
+		if err != nil {
+			return err
+		}
+		for i, c := range t.Columns {
+			if c == m[2] {
+				t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+				return nil
+			}
+		}
+		return &UnknownColumnError{Table: m[1], Column: m[2]}
+	}
+	if m := renameTableRe.FindStringSubmatch(stmt); m != nil {
+		t, err := s.Table(m[1])
+		if err != nil {
+			return err
+		}
+		delete(s.tables, t.Name)
+		t.Name = m[2]
+		s.tables[t.Name] = t
+		return nil
+	}
+	if m := dropTableRe.FindStringSubmatch(stmt); m != nil {
+		delete(s.tables, m[1])
+	}
+	return nil
+}
+
+// stripComments removes "--" comments from SQL.
+func stripComments(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		if idx := strings.Index(line, "--"); idx >= 0 {
+			lines[i] = line[:idx]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitDefinitions splits the body of a CREATE TABLE statement on top-level commas.
+func splitDefinitions(body string) []string {
+	var defs []string
+	depth, start := 0, 0
+	for i, r := range body {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, body[start:i])
+				start = i + 1
+			}
+		}
+	}
+	defs = append(defs, body[start:])
+
+	result := defs[:0]
+	for _, def := range defs {
+		if def = strings.TrimSpace(def); def != "" {
+			result = append(result, def)
+		}
+	}
+	return result
+}
+
+// defaultSchema is the schema declared by the embedded migrations.
+var defaultSchema = mustParseSchema()
+
+func mustParseSchema() *Schema {
+	s, err := ParseSchema(embeddedMigrations, "migrations")
+	if err != nil {
+		panic(fmt.Sprintf("bdkeeper: invalid migrations: %v", err))
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package bdkeeper_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+)
+
+func TestParseSchema(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/001_items.sql": {Data: []byte(`-- +goose Up
+CREATE TABLE IF NOT EXISTS Items (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER, -- owner
+    name TEXT NOT NULL,
+    price NUMERIC(10, 2),
+    FOREIGN KEY(user_id) REFERENCES Users(id)
+);
+CREATE TABLE Old (id TEXT);
+
+-- +goose Down
+DROP TABLE Items;`)},
+		"migrations/002_alter.sql": {Data: []byte(`-- +goose Up
+ALTER TABLE Items ADD COLUMN note TEXT DEFAULT '';
+ALTER TABLE Items DROP COLUMN price;
+DROP TABLE Old;
+CREATE INDEX items_name ON Items(name);`)},
+	}
+
+	schema, err := bdkeeper.ParseSchema(fsys, "migrations")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Items"}, schema.Tables())
+
+	items, err := schema.Table("Items")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "user_id", "name", "note"}, items.Columns)
+
+	_, err = schema.Table("Old")
+	var tableErr *bdkeeper.UnknownTableError
+	assert.ErrorAs(t, err, &tableErr)
+}
+
+func TestSchema_Migrations(t *testing.T) {
+	db, cleanup := setup(t)
+	defer cleanup()
+	keeper := bdkeeper.NewKeeper(db)
+
+	creds, err := keeper.Schema().Table("UserCredentials")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "user_id", "login", "password", "meta_info", "updated_at", "totp_id", "folder_id", "custom_fields", "attachments", "created_at", "expires_at", "password_changed_at"}, creds.Columns)
+}
+
+func TestKeeper_RejectsUnknownTablesAndColumns(t *testing.T) {
+	db, cleanup := setup(t)
+	defer cleanup()
+	keeper := bdkeeper.NewKeeper(db)
+	ctx := context.Background()
+
+	var tableErr *bdkeeper.UnknownTableError
+	err := keeper.AddData(ctx, "Users; DROP TABLE Users", 1, "id", map[string]string{"login": "x"})
+	require.ErrorAs(t, err, &tableErr)
+	assert.Equal(t, "Users; DROP TABLE Users", tableErr.Table)
+
+	_, err = keeper.GetAllData(ctx, "sqlite_master", 1, "name")
+	assert.ErrorAs(t, err, &tableErr)
+	assert.ErrorAs(t, keeper.ClearData(ctx, "Nope", 1), &tableErr)
+
+	var columnErr *bdkeeper.UnknownColumnError
+	err = keeper.AddData(ctx, "UserCredentials", 1, "id", map[string]string{
+		"login":                      "x",
+		"password":                   "y",
+		"meta_info) VALUES(1,2); --": "z",
+	})
+	require.ErrorAs(t, err, &columnErr)
+	assert.Equal(t, "UserCredentials", columnErr.Table)
+
+	err = keeper.UpdateData(ctx, "UserCredentials", 1, "id", map[string]string{"role": "admin"})
+	assert.ErrorAs(t, err, &columnErr)
+
+	_, err = keeper.GetAllData(ctx, "UserCredentials", 1, "id", "(SELECT password FROM Users)")
+	assert.ErrorAs(t, err, &columnErr)
+
+	// Nothing was written
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM UserCredentials").Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestAddData_IgnoresKeeperColumns(t *testing.T) {
+	db, cleanup := setup(t)
+	defer cleanup()
+	keeper := bdkeeper.NewKeeper(db)
+	ctx := context.Background()
+
+	// Rows coming from the server carry their id, owner and deletion marker
+	err := keeper.AddData(ctx, "UserCredentials", 1, "entry", map[string]string{
+		"id": "other", "user_id": "2", "deleted": "false",
+		"login": "l", "password": "p", "meta_info": "m",
+	})
+	require.NoError(t, err)
+
+	var id string
+	var userID int
+	require.NoError(t, db.QueryRow("SELECT id, user_id FROM UserCredentials").Scan(&id, &userID))
+	assert.Equal(t, "entry", id)
+	assert.Equal(t, 1, userID)
+}
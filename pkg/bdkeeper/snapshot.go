@@ -0,0 +1,17 @@
+package bdkeeper
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// Snapshot writes a consistent copy of the database to path, which must not exist.
+// It is safe to use while the database is in use.
+func (k *Keeper) Snapshot(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return errors.New("the snapshot file already exists")
+	}
+	_, err := k.q.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
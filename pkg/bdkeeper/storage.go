@@ -0,0 +1,64 @@
+package bdkeeper
+
+import (
+	"context"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+)
+
+// Storage is the local vault storage used by the services. It is implemented by
+// Keeper, on SQLite, and by MemoryStorage, which keeps everything in memory for tests.
+//
+// Table and column names are checked against the schema derived from the migrations
+// by all implementations.
+type Storage interface {
+	// WithTx runs fn with a storage whose operations all belong to a single transaction,
+	// committed if fn returns nil and rolled back otherwise. Nested calls reuse it.
+	WithTx(ctx context.Context, fn func(tx Storage) error) error
+	Close() error
+	Schema() *Schema
+	// Snapshot writes a copy of the storage as an SQLite database to path.
+	Snapshot(ctx context.Context, path string) error
+
+	UserExists(ctx context.Context, username string) (bool, error)
+	AddUser(ctx context.Context, username string, hashedPassword string) error
+	UpdatePassword(ctx context.Context, username string, hashedPassword string) error
+	DeleteUser(ctx context.Context, username string) error
+	GetPassword(ctx context.Context, username string) (string, error)
+	GetUserID(ctx context.Context, username string) (int, error)
+	GetUsers(ctx context.Context) (map[int]string, error)
+
+	AddData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error
+	UpdateData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error
+	DeleteData(ctx context.Context, table string, user_id int, entry_id string) error
+	GetData(ctx context.Context, table string, user_id int, entry_id string) (map[string]string, error)
+	GetAllData(ctx context.Context, table string, user_id int, columns ...string) ([]map[string]string, error)
+	ClearData(ctx context.Context, table string, userID int) error
+
+	CreateSyncEntry(ctx context.Context, operation string, table string, user_id int, entry_id string, data map[string]string) error
+	GetSyncEntriesByStatus(ctx context.Context, status string) ([]models.SyncQueue, error)
+	UpdateSyncEntryStatus(ctx context.Context, id int, status string) error
+	DeleteSyncEntry(ctx context.Context, id int) error
+	ClearSyncEntries(ctx context.Context, userID int) error
+
+	AddVersion(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) (int, error)
+	GetVersions(ctx context.Context, table string, user_id int, entry_id string) ([]models.EntryVersion, error)
+	GetVersion(ctx context.Context, table string, user_id int, entry_id string, version int) (models.EntryVersion, error)
+	PruneVersions(ctx context.Context, table string, user_id int, entry_id string, keep int) error
+	DeleteVersions(ctx context.Context, table string, user_id int, entry_id string) error
+	ClearVersions(ctx context.Context, userID int) error
+
+	// IntegrityCheck returns the problems of the storage itself, nil if it is intact.
+	IntegrityCheck(ctx context.Context) ([]string, error)
+	// OwnerIDs returns the ids of the users owning any stored row, sorted.
+	OwnerIDs(ctx context.Context) ([]int, error)
+
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+	MigrateUp(ctx context.Context) ([]*Migration, error)
+	MigrateDown(ctx context.Context, steps int) ([]*Migration, error)
+}
+
+var (
+	_ Storage = (*Keeper)(nil)
+	_ Storage = (*MemoryStorage)(nil)
+)
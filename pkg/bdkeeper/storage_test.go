@@ -0,0 +1,211 @@
+package bdkeeper_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+)
+
+// storages returns every Storage implementation, empty and with the current schema.
+func storages(t *testing.T) map[string]bdkeeper.Storage {
+	keeper, err := bdkeeper.Open(filepath.Join(t.TempDir(), "data.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { keeper.Close() })
+
+	return map[string]bdkeeper.Storage{
+		"sqlite": keeper,
+		"memory": bdkeeper.NewMemoryStorage(),
+	}
+}
+
+func TestStorage_Users(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, s.AddUser(ctx, "alice", "hash"))
+			assert.Error(t, s.AddUser(ctx, "alice", "other"))
+
+			exists, err := s.UserExists(ctx, "alice")
+			require.NoError(t, err)
+			assert.True(t, exists)
+
+			require.NoError(t, s.UpdatePassword(ctx, "alice", "new"))
+			password, err := s.GetPassword(ctx, "alice")
+			require.NoError(t, err)
+			assert.Equal(t, "new", password)
+			assert.Error(t, s.UpdatePassword(ctx, "bob", "new"))
+
+			id, err := s.GetUserID(ctx, "alice")
+			require.NoError(t, err)
+			assert.NotZero(t, id)
+
+			require.NoError(t, s.DeleteUser(ctx, "alice"))
+			_, err = s.GetUserID(ctx, "alice")
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		})
+	}
+}
+
+func TestStorage_Data(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			err := s.AddData(ctx, "UserCredentials", 1, "a", map[string]string{"login": "alice", "password": "p", "meta_info": "mail"})
+			require.NoError(t, err)
+			require.NoError(t, s.AddData(ctx, "UserCredentials", 1, "b", map[string]string{"login": "bob", "password": "p"}))
+			require.NoError(t, s.AddData(ctx, "UserCredentials", 2, "c", map[string]string{"login": "carol", "password": "p"}))
+			assert.Error(t, s.AddData(ctx, "UserCredentials", 1, "a", map[string]string{"login": "x", "password": "p"}))
+
+			var columnErr *bdkeeper.UnknownColumnError
+			assert.ErrorAs(t, s.AddData(ctx, "UserCredentials", 1, "d", map[string]string{"nope": "x"}), &columnErr)
+			var tableErr *bdkeeper.UnknownTableError
+			_, err = s.GetAllData(ctx, "Nope", 1, "id")
+			assert.ErrorAs(t, err, &tableErr)
+
+			require.NoError(t, s.UpdateData(ctx, "UserCredentials", 1, "a", map[string]string{"login": "alice2"}))
+			data, err := s.GetData(ctx, "UserCredentials", 1, "a")
+			require.NoError(t, err)
+			assert.Equal(t, "alice2", data["login"])
+			assert.Equal(t, "mail", data["meta_info"])
+			assert.Equal(t, "", data["totp_id"])
+			assert.NotContains(t, data, "id")
+			assert.NotContains(t, data, "user_id")
+			assert.NotContains(t, data, "created_at")
+
+			all, err := s.GetAllData(ctx, "UserCredentials", 1, "id", "login")
+			require.NoError(t, err)
+			assert.Equal(t, []map[string]string{{"id": "a", "login": "alice2"}, {"id": "b", "login": "bob"}}, all)
+			created, err := s.GetAllData(ctx, "UserCredentials", 1, "created_at")
+			require.NoError(t, err)
+			assert.NotEmpty(t, created[0]["created_at"])
+
+			require.NoError(t, s.DeleteData(ctx, "UserCredentials", 1, "b"))
+			assert.Error(t, s.DeleteData(ctx, "UserCredentials", 1, "b"))
+			_, err = s.GetData(ctx, "UserCredentials", 1, "b")
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+
+			require.NoError(t, s.ClearData(ctx, "UserCredentials", 1))
+			all, err = s.GetAllData(ctx, "UserCredentials", 1, "id")
+			require.NoError(t, err)
+			assert.Empty(t, all)
+			all, err = s.GetAllData(ctx, "UserCredentials", 2, "id")
+			require.NoError(t, err)
+			assert.Len(t, all, 1)
+		})
+	}
+}
+
+func TestStorage_WithTx(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			failure := errors.New("failure")
+			err := s.WithTx(ctx, func(tx bdkeeper.Storage) error {
+				if err := tx.AddData(ctx, "TextData", 1, "a", map[string]string{"data": "x"}); err != nil {
+					return err
+				}
+				if err := tx.CreateSyncEntry(ctx, "Create", "TextData", 1, "a", map[string]string{"data": "x"}); err != nil {
+					return err
+				}
+				return failure
+			})
+			assert.ErrorIs(t, err, failure)
+			all, err := s.GetAllData(ctx, "TextData", 1, "id")
+			require.NoError(t, err)
+			assert.Empty(t, all)
+			entries, err := s.GetSyncEntriesByStatus(ctx, "Pending")
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+
+			err = s.WithTx(ctx, func(tx bdkeeper.Storage) error {
+				if err := tx.AddData(ctx, "TextData", 1, "a", map[string]string{"data": "x"}); err != nil {
+					return err
+				}
+				return tx.WithTx(ctx, func(inner bdkeeper.Storage) error {
+					return inner.CreateSyncEntry(ctx, "Create", "TextData", 1, "a", map[string]string{"data": "x"})
+				})
+			})
+			require.NoError(t, err)
+			entries, err = s.GetSyncEntriesByStatus(ctx, "Pending")
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, "TextData", entries[0].TableName)
+			assert.Equal(t, `{"data":"x"}`, entries[0].Data)
+
+			require.NoError(t, s.UpdateSyncEntryStatus(ctx, entries[0].ID, "Done"))
+			entries, err = s.GetSyncEntriesByStatus(ctx, "Done")
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+			require.NoError(t, s.ClearSyncEntries(ctx, 1))
+			entries, err = s.GetSyncEntriesByStatus(ctx, "Done")
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestStorage_Versions(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, login := range []string{"a", "b", "c"} {
+				_, err := s.AddVersion(ctx, "UserCredentials", 1, "entry", map[string]string{"login": login})
+				require.NoError(t, err)
+			}
+			require.NoError(t, s.PruneVersions(ctx, "UserCredentials", 1, "entry", 2))
+			versions, err := s.GetVersions(ctx, "UserCredentials", 1, "entry")
+			require.NoError(t, err)
+			require.Len(t, versions, 2)
+			assert.Equal(t, 2, versions[0].Version)
+			assert.Equal(t, "c", versions[1].Data["login"])
+
+			_, err = s.GetVersion(ctx, "UserCredentials", 1, "entry", 1)
+			assert.ErrorIs(t, err, bdkeeper.ErrVersionNotFound)
+
+			require.NoError(t, s.ClearVersions(ctx, 1))
+			versions, err = s.GetVersions(ctx, "UserCredentials", 1, "entry")
+			require.NoError(t, err)
+			assert.Empty(t, versions)
+		})
+	}
+}
+
+func TestStorage_Doctor(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			problems, err := s.IntegrityCheck(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, problems)
+
+			require.NoError(t, s.AddUser(ctx, "alice", "hash"))
+			id, err := s.GetUserID(ctx, "alice")
+			require.NoError(t, err)
+			users, err := s.GetUsers(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[int]string{id: "alice"}, users)
+
+			require.NoError(t, s.AddData(ctx, "TextData", 3, "a", map[string]string{"data": "x"}))
+			require.NoError(t, s.CreateSyncEntry(ctx, "Create", "TextData", 5, "a", map[string]string{"data": "x"}))
+			_, err = s.AddVersion(ctx, "TextData", 4, "a", map[string]string{"data": "x"})
+			require.NoError(t, err)
+			owners, err := s.OwnerIDs(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []int{3, 4, 5}, owners)
+
+			entries, err := s.GetSyncEntriesByStatus(ctx, "Pending")
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			require.NoError(t, s.DeleteSyncEntry(ctx, entries[0].ID))
+			entries, err = s.GetSyncEntriesByStatus(ctx, "Pending")
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/backup"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// backupCommand returns the command group for encrypted backups of the vault.
+func (c *Client) backupCommand() *cobra.Command {
+	backupCmd := &cobra.Command{
+		Use:         "backup",
+		Short:       "Create, verify and restore encrypted backups of the vault",
+		Annotations: structuredOutput,
+	}
+	backupCmd.AddCommand(&cobra.Command{
+		Use:   "create <file>",
+		Short: "Write an encrypted backup of the database and all files",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.createBackup(args[0])
+		},
+	})
+	backupCmd.AddCommand(&cobra.Command{
+		Use:   "verify <file>",
+		Short: "Check that a backup can be decrypted and is complete",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.verifyBackup(args[0])
+		},
+	})
+	backupCmd.AddCommand(&cobra.Command{
+		Use:   "restore <file> <dir>",
+		Short: "Restore a backup into a new profile directory",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.restoreBackup(args[0], args[1])
+		},
+	})
+	return backupCmd
+}
+
+// backupPassphrase asks for the passphrase of a backup, twice for a new one.
+func (c *Client) backupPassphrase(repeat bool) (string, error) {
+	if !c.tty {
+		return "", fmt.Errorf("%w, the backup passphrase is asked for", errTerminalRequired)
+	}
+	passphrase := c.readPassword("Enter the backup passphrase: ")
+	if !repeat {
+		return passphrase, nil
+	}
+	if passphrase == "" {
+		return "", errors.New("the passphrase must not be empty")
+	}
+	if c.readPassword("Repeat the backup passphrase: ") != passphrase {
+		return "", errors.New("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// createBackup asks for a passphrase and writes a backup to path.
+func (c *Client) createBackup(path string) error {
+	passphrase, err := c.backupPassphrase(true)
+	if err != nil {
+		return err
+	}
+	manifest, err := c.service.CreateBackup(c.ctx, path, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to create the backup: %w", err)
+	}
+	return c.render(backupDocument(path, manifest), func() {
+		fmt.Printf("Backup written to %s: %d file(s).\n", path, len(manifest.Files))
+		printMissing(manifest)
+	})
+}
+
+// verifyBackup checks a backup and prints its content.
+func (c *Client) verifyBackup(path string) error {
+	passphrase, err := c.backupPassphrase(false)
+	if err != nil {
+		return err
+	}
+	manifest, err := c.service.VerifyBackup(c.ctx, path, passphrase)
+	if err != nil {
+		return fmt.Errorf("the backup is not valid: %w", err)
+	}
+	return c.render(backupDocument(path, manifest), func() {
+		fmt.Printf("The backup made on %s is valid.\n", manifest.Created.Local().Format("2006-01-02 15:04:05"))
+		for _, f := range manifest.Files {
+			fmt.Printf("%-70s %10d bytes\n", f.Name, f.Size)
+		}
+		printMissing(manifest)
+	})
+}
+
+// restoreBackup restores a backup into a new profile directory.
+func (c *Client) restoreBackup(path, dir string) error {
+	passphrase, err := c.backupPassphrase(false)
+	if err != nil {
+		return err
+	}
+	manifest, err := c.service.RestoreBackup(c.ctx, path, passphrase, dir)
+	if err != nil {
+		return fmt.Errorf("failed to restore the backup: %w", err)
+	}
+	doc := restoreOutput{backupOutput: backupDocument(path, manifest), Dir: dir,
+		FileStoragePath: filepath.Join(dir, services.BackupFilesDir)}
+	return c.render(doc, func() {
+		fmt.Printf("Restored %d file(s) into %s.\n", len(manifest.Files), dir)
+		printMissing(manifest)
+		fmt.Printf("To use the restored vault, start GophKeeper in %s with -fileStoragePath %s.\n",
+			dir, doc.FileStoragePath)
+	})
+}
+
+// backupDocument returns the content of a backup in the json and yaml formats.
+func backupDocument(path string, manifest *backup.Manifest) backupOutput {
+	doc := backupOutput{Path: path, CreatedAt: manifest.Created.UTC().Format(time.RFC3339),
+		Files: []backupFile{}, Missing: nonNil(manifest.Missing)}
+	for _, f := range manifest.Files {
+		doc.Files = append(doc.Files, backupFile{Name: f.Name, Size: f.Size})
+	}
+	return doc
+}
+
+// printMissing warns about the files that were missing when the backup was made.
+func printMissing(manifest *backup.Manifest) {
+	if len(manifest.Missing) == 0 {
+		return
+	}
+	fmt.Printf("%d file(s) were missing locally and are not in the backup:\n", len(manifest.Missing))
+	for _, name := range manifest.Missing {
+		fmt.Println("-", name)
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -18,51 +18,77 @@ import (
 	"github.com/wurt83ow/gophkeeper-client/pkg/appcontext"
 	"github.com/wurt83ow/gophkeeper-client/pkg/config"
 	"github.com/wurt83ow/gophkeeper-client/pkg/encription"
+	"github.com/wurt83ow/gophkeeper-client/pkg/gksync"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
 	"github.com/wurt83ow/gophkeeper-client/pkg/services"
 )
 
-// ActionFunc represents a function that performs an action.
-type ActionFunc func()
-
 // Client represents a GophKeeper client.
 type Client struct {
-	rl                     *readline.Instance // Readline instance for user input
-	service                *services.Service  // Service for backend operations
-	enc                    *encription.Enc    // Encryption utility
-	opt                    *config.Options    // Options for client configuration
-	ctx                    context.Context    // Context for client operations
-	userID                 int                // User ID of the current user
-	token                  string             // Authentication token for the current session
-	sessionStart           time.Time          // Start time of the current session
+	rl                     *readline.Instance   // Readline instance for user input
+	prompts                *switchWriter        // Output of the readline instance, the standard error for json and yaml
+	service                *services.Service    // Service for backend operations
+	enc                    *encription.Enc      // Encryption utility
+	opt                    *config.Options      // Options for client configuration
+	ctx                    context.Context      // Context for client operations
+	userID                 int                  // User ID of the current user
+	token                  string               // Authentication token for the current session
+	sessionStart           time.Time            // Start time of the current session
+	filter                 services.EntryFilter // Folder and tag filter of the listed entries
+	order                  services.ListOptions // Sort order and page size of ls
+	tty                    bool                 // Whether the standard input is a terminal to prompt on
+	stdinRead              bool                 // Whether a value piped to the standard input was read
+	output                 string               // Output format: table, json or yaml
+	migrated               bool                 // Whether the local database is known to be up to date
 	getTimeWithoutTimeZone func() time.Time
 }
 
 // NewClient initializes a new GophKeeper client.
 func NewClient(ctx context.Context, service *services.Service, enc *encription.Enc,
 	opt *config.Options, userID int, token string, sessionStart time.Time, getTimeWithoutTimeZone func() time.Time) *Client {
-	rl, err := readline.New("> ")
+	// Prompts never read the standard input when it is piped, it is kept for the secrets
+	// read by the scripting commands
+	tty := readline.IsTerminal(int(os.Stdin.Fd()))
+	prompts := &switchWriter{w: os.Stdout}
+	cfg := &readline.Config{Prompt: "> ", Stdout: prompts}
+	if !tty {
+		cfg.Stdin = io.NopCloser(strings.NewReader(""))
+	}
+	rl, err := readline.NewEx(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	return &Client{rl: rl, ctx: ctx, service: service, enc: enc,
+	return &Client{rl: rl, prompts: prompts, ctx: ctx, service: service, enc: enc, tty: tty,
 		opt: opt, userID: userID, token: token, sessionStart: sessionStart, getTimeWithoutTimeZone: getTimeWithoutTimeZone}
 }
 
-// Start starts the GophKeeper client.
-func (c *Client) Start(version, buildTime string) {
+// switchWriter writes to w, which can be changed once the readline instance writing to it
+// is created.
+type switchWriter struct {
+	w io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+// Start starts the GophKeeper client. It returns the error of a failed command.
+func (c *Client) Start(version, buildTime string) error {
 
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
 		defer ticker.Stop()
+		failing := false // Only the first of consecutive failures is reported
 		for {
 			select {
 			case <-ticker.C:
 				c.service.SyncAllWithServer(c.ctx)
 				err := c.service.SyncAllData(c.ctx, c.userID, true)
-				if err != nil {
-					fmt.Printf("Error synchronizing data: %s\n", err)
+				if err != nil && !failing {
+					fmt.Fprintf(os.Stderr, "Error synchronizing data: %s\n", err)
 				}
+				failing = err != nil
 
 			case <-c.ctx.Done():
 				return
@@ -70,12 +96,45 @@ func (c *Client) Start(version, buildTime string) {
 		}
 
 	}()
+
+	// Make scheduled backups while the client is running
+	if c.opt.BackupInterval > 0 {
+		if c.opt.BackupPassphrase == "" {
+			fmt.Fprintln(os.Stderr, "Scheduled backups are disabled: BACKUP_PASSPHRASE is not set.")
+		} else {
+			go c.service.RunScheduledBackups(c.ctx)
+		}
+	}
+
 	// Root command for Cobra CLI
 	rootCmd := &cobra.Command{
 		Use:           "gophkeeper",
 		Short:         "GophKeeper is a secure password manager",
 		SilenceErrors: true, // Prevent Cobra from printing errors
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The arguments are valid by now, later errors are not about usage
+			cmd.SilenceUsage = true
+			if err := c.checkOutput(cmd); err != nil {
+				return err
+			}
+			// The migrate commands inspect and change the migrations themselves
+			if !isMigrateCommand(cmd) {
+				if err := c.applyMigrations(); err != nil {
+					return err
+				}
+			}
+			// Prompts are written to the standard error, the documents to the standard output
+			if c.structured() {
+				c.prompts.w = os.Stderr
+			}
+			// Warn about expiring entries, unless they are being listed or the output is for scripts
+			if cmd.Name() != "expiring" && !c.structured() {
+				c.expiryBanner()
+			}
+			return nil
+		},
 	}
+	rootCmd.PersistentFlags().StringVarP(&c.output, "output", "o", outputTable, "output format: table, json or yaml")
 
 	// Map of command names to their corresponding functions
 	commands := map[string]func(){
@@ -83,11 +142,7 @@ func (c *Client) Start(version, buildTime string) {
 		"login":    c.login,
 		"logout":   c.Logout,
 
-		"add":  c.addData,
 		"edit": c.editData,
-		"ls":   c.list,
-		"rm":   c.DeleteData,
-		"get":  c.getData,
 	}
 
 	// Set JWT token in the context
@@ -95,57 +150,203 @@ func (c *Client) Start(version, buildTime string) {
 
 	// If session has expired, prompt the user to log in again
 	if time.Since(c.sessionStart) > c.opt.SessionDuration {
-		fmt.Println("Your session has expired. Please log in again.")
+		fmt.Fprintln(os.Stderr, "Your session has expired. Please log in again.")
 		// c.ClearSession()
 	}
 
 	// Add commands to the root command
 	for use, runFunc := range commands {
 		localRunFunc := runFunc // Create a local variable
-		command := &cobra.Command{
+		rootCmd.AddCommand(&cobra.Command{
 			Use:   use,
 			Short: use,
 			Run: func(cmd *cobra.Command, args []string) {
 				localRunFunc() // Use the local variable
 			},
-		}
-		// Add aliases for commands 'ls' and 'rm'
-		if use == "ls" {
-			command.Aliases = []string{"list"}
-		} else if use == "rm" {
-			command.Aliases = []string{"remove"}
-		}
-		rootCmd.AddCommand(command)
+		})
+	}
+
+	// Entry commands, prompting for what is not given on the command line
+	rootCmd.AddCommand(c.lsCommand())
+	rootCmd.AddCommand(c.getCommand())
+	rootCmd.AddCommand(c.addCommand())
+	rootCmd.AddCommand(c.rmCommand())
+
+	// Account management commands
+	accountCmd := &cobra.Command{
+		Use:   "account",
+		Short: "Manage the GophKeeper account",
+	}
+	accountCommands := map[string]func(){
+		"passwd": c.changePassword,
+		"delete": c.deleteAccount,
+	}
+	for use, runFunc := range accountCommands {
+		localRunFunc := runFunc // Create a local variable
+		accountCmd.AddCommand(&cobra.Command{
+			Use:   use,
+			Short: use,
+			Run: func(cmd *cobra.Command, args []string) {
+				localRunFunc() // Use the local variable
+			},
+		})
 	}
+	accountCmd.AddCommand(&cobra.Command{
+		Use:   "sessions",
+		Short: "List the sessions of the account and revoke them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.sessions()
+		},
+		Annotations: structuredOutput,
+	})
+	rootCmd.AddCommand(accountCmd)
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "otp [entry]",
+		Short: "Print the current TOTP code of an entry",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.otp(args)
+		},
+		Annotations: structuredOutput,
+	})
 
-	// Execute the root command
+	var searchSecrets bool
+	searchCmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search entries of all types by title, tags and other fields",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.search(strings.Join(args, " "), searchSecrets)
+		},
+		Annotations: structuredOutput,
+	}
+	searchCmd.Flags().BoolVar(&searchSecrets, "secrets", false, "also match passwords and other sensitive fields")
+	rootCmd.AddCommand(searchCmd)
+
+	rootCmd.AddCommand(c.tagCommand())
+	rootCmd.AddCommand(c.folderCommand())
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "history <entry>",
+		Short: "List the previous versions of an entry",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.history(args[0])
+		},
+		Annotations: structuredOutput,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "diff <entry> [version]",
+		Short: "Show the fields changed by an update of an entry",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.diff(args[0], args[1:])
+		},
+		Annotations: structuredOutput,
+	})
+	var rollbackYes bool
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback <entry> <version>",
+		Short: "Restore a previous version of an entry",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.rollback(args[0], args[1], rollbackYes)
+		},
+		Annotations: structuredOutput,
+	}
+	rollbackCmd.Flags().BoolVar(&rollbackYes, "yes", false, "restore without asking for confirmation")
+	rootCmd.AddCommand(rollbackCmd)
+
+	var agentSocket string
+	var agentConfirm bool
+	agentCmd := &cobra.Command{
+		Use:   "ssh-agent",
+		Short: "Serve the stored SSH keys over the ssh-agent protocol",
+		Run: func(cmd *cobra.Command, args []string) {
+			c.sshAgent(agentSocket, agentConfirm)
+		},
+	}
+	agentCmd.Flags().StringVar(&agentSocket, "socket", "", "path of the agent socket")
+	agentCmd.Flags().BoolVar(&agentConfirm, "confirm", false, "ask for confirmation before each use of any key")
+	rootCmd.AddCommand(agentCmd)
+
+	rootCmd.AddCommand(c.dbCommand())
+	rootCmd.AddCommand(c.doctorCommand())
+	rootCmd.AddCommand(c.backupCommand())
+	rootCmd.AddCommand(c.expiringCommand())
+	rootCmd.AddCommand(c.importCommand())
+	rootCmd.AddCommand(c.exportCommand())
+
+	// Execute the root command; the options of the configuration are left out of its arguments
+	args := c.opt.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	rootCmd.SetArgs(args)
 	err := rootCmd.Execute()
 	if err != nil {
 		if strings.Contains(err.Error(), "unknown command") {
+			// Flags are not parsed for unknown commands, the output format is needed
+			rootCmd.FParseErrWhitelist.UnknownFlags = true
+			_ = rootCmd.ParseFlags(args)
+		}
+		if strings.Contains(err.Error(), "unknown command") && !c.structured() {
 			// Вывод информации о версии и времени сборки
 			fmt.Printf("Version: %s\nBuild Time: %s\n", version, buildTime)
 			fmt.Println("Command not found. Here is the list of available commands:")
 			for cmd := range commands {
 				fmt.Println("-", cmd)
 			}
-		} else {
-			fmt.Println("Error:", err)
+			fmt.Println("- ls [type] [--folder name] [--tag name] [--sort title|updated|created] [--limit n] [--reverse]")
+			fmt.Println("- get [type entry] [--field name] [--save path|-] [--copy [--reveal]]")
+			fmt.Println("- add [type] [--title t] [--set name=value] [--from-file path] [--folder f] [--tag t]")
+			fmt.Println("- rm [type entry] [--yes]")
+			for cmd := range accountCommands {
+				fmt.Println("- account", cmd)
+			}
+			fmt.Println("- account sessions")
+			fmt.Println("- search <query> [--secrets]")
+			fmt.Println("- tag add|remove <entry> <tag>, tag rename <old> <new>, tag ls")
+			fmt.Println("- folder add|remove <name> [--yes], folder rename <old> <new>, folder ls")
+			fmt.Println("- otp [entry]")
+			fmt.Println("- history <entry>")
+			fmt.Println("- diff <entry> [version]")
+			fmt.Println("- rollback <entry> <version> [--yes]")
+			fmt.Println("- ssh-agent [--socket path] [--confirm]")
+			fmt.Println("- db migrate status|up|down [--steps n] [--yes]")
+			fmt.Println("- doctor [--fix] [--json]")
+			fmt.Println("- backup create <file>, backup verify <file>, backup restore <file> <dir>")
+			fmt.Println("- expiring [--within 30d]")
+			fmt.Println("- import <file> [--format name] [--key secret.asc] [--dry-run] [--keep-duplicates] [--yes]")
+			fmt.Println("- export <file> [--format json|kdbx|pass|csv] [--unsafe-plaintext] [--type t] [--folder f] [--tag t] [--cipher aes256|chacha20] [--recipient public.asc]")
+			fmt.Println("Commands accept --output json|yaml|table, except the interactive register, login, logout, edit, account and ssh-agent.")
+			return nil
 		}
+		c.renderError(err)
 	}
+	return err
 }
 
 // Close closes the GophKeeper client.
 func (c *Client) Close() {
+	// The sync queue may not exist before the migrations are applied
+	if !c.migrated {
+		c.rl.Close()
+		return
+	}
 	// Получаем записи с статусом "Progress"
 	entries, err := c.service.GetSyncEntriesByStatus(context.Background(), "Progress")
 	if err != nil {
 		// Обработка ошибки
-		fmt.Println("Error retrieving sync entries:", err)
+		fmt.Fprintln(os.Stderr, "Error retrieving sync entries:", err)
+		c.rl.Close()
 		return
 	}
 
 	// Если есть записи со статусом "Progress", запрашиваем у пользователя продолжение или прерывание синхронизации
-	if len(entries) > 0 {
+	if len(entries) > 0 && c.tty && !c.structured() {
 		fmt.Println("There are pending sync entries. Do you want to continue syncing data before closing? (yes/no)")
 
 		// Читаем ответ пользователя
@@ -160,22 +361,15 @@ func (c *Client) Close() {
 	c.rl.Close()
 }
 
-// chooseAction prompts the user to choose an action and executes the corresponding function.
-func (c *Client) chooseAction(func1, func2, func3, func4 ActionFunc) {
+// chooseType prints the menu of record types and returns the type chosen by the user.
+func (c *Client) chooseType() (*records.Type, bool) {
 	printMenu()
 	line, _ := c.rl.Readline()
-	switch strings.TrimSpace(line) {
-	case "1":
-		func1()
-	case "2":
-		func2()
-	case "3":
-		func3()
-	case "4":
-		func4()
-	default:
+	t, ok := records.ByChoice(line)
+	if !ok {
 		fmt.Println("Invalid choice")
 	}
+	return t, ok
 }
 
 // getData prompts the user to choose the type of data to retrieve.
@@ -184,31 +378,24 @@ func (c *Client) getData() {
 		fmt.Println("Please log in or register.")
 		return
 	}
-	c.chooseAction(c.getLoginPassword, c.getTextData, c.getBinaryData, c.getBankCardData)
+	t, ok := c.chooseType()
+	if !ok {
+		return
+	}
+	if t.Binary {
+		c.getBinaryDataAndSave(t)
+		return
+	}
+	c.getDataAndPrint(t.Table, func(data map[string]string) {
+		c.printData(t, data)
+	})
 }
 
 // printMenu prints the menu for data type selection.
 func printMenu() {
 	fmt.Println("Choose data type:")
-	fmt.Println("1. Login/Password")
-	fmt.Println("2. Text data")
-	fmt.Println("3. Binary data")
-	fmt.Println("4. Bank card data")
-}
-
-// getTableNameByChoice returns the table name based on the user's choice.
-func getTableNameByChoice(choice string) (string, bool) {
-	switch choice {
-	case "1":
-		return "UserCredentials", true
-	case "2":
-		return "TextData", true
-	case "3":
-		return "FilesData", true
-	case "4":
-		return "CreditCardData", true
-	default:
-		return "", false
+	for i, t := range records.All() {
+		fmt.Printf("%d. %s\n", i+1, t.Name)
 	}
 }
 
@@ -218,7 +405,18 @@ func (c *Client) addData() {
 		fmt.Println("Please log in or register.")
 		return
 	}
-	c.chooseAction(c.addLoginPassword, c.addTextData, c.addBinaryData, c.addBankCardData)
+	t, ok := c.chooseType()
+	if !ok {
+		return
+	}
+	switch {
+	case t.Binary:
+		c.addBinaryData(t)
+	case t.Table == records.SSHKeysTable:
+		c.addSSHKey(t)
+	default:
+		c.addRecord(t)
+	}
 }
 
 // editData prompts the user to choose the type of data to edit.
@@ -227,7 +425,11 @@ func (c *Client) editData() {
 		fmt.Println("Please log in or register.")
 		return
 	}
-	c.chooseAction(c.editLoginPassword, c.editTextData, c.editBinaryData, c.editBankCardData)
+	t, ok := c.chooseType()
+	if !ok {
+		return
+	}
+	c.editRecord(t)
 }
 
 // register implements the registration function.
@@ -320,34 +522,233 @@ func (c *Client) ClearSession() {
 	os.Remove(c.opt.SessionPath)
 }
 
-// list displays a list of entries of a specified data type for the current user.
-func (c *Client) list() {
+// readPassword prompts the user for a password without echoing it.
+func (c *Client) readPassword(prompt string) string {
+	c.rl.SetPrompt(prompt)
+	c.rl.Config.EnableMask = true
+	password, _ := c.rl.Readline()
+	c.rl.Config.EnableMask = false
+	return password
+}
+
+// changePassword changes the login password of the current user on the server and in the local database.
+func (c *Client) changePassword() {
 	if c.userID == 0 {
 		fmt.Println("Please log in or register.")
 		return
 	}
-	printMenu()
+	c.rl.SetPrompt("Enter username: ")
+	username, _ := c.rl.Readline()
+	oldPassword := c.readPassword("Enter current password: ")
+	newPassword := c.readPassword("Enter new password: ")
+	if newPassword == "" {
+		fmt.Println("Password must not be empty.")
+		return
+	}
+	if c.readPassword("Repeat new password: ") != newPassword {
+		fmt.Println("Passwords do not match.")
+		return
+	}
+
+	err := c.service.ChangePassword(c.ctx, c.userID, username, oldPassword, newPassword)
+	if err != nil {
+		fmt.Printf("Failed to change password: %s\n", err)
+		return
+	}
+	fmt.Println("Password changed successfully!")
+}
+
+// deleteAccount removes the account of the current user from the server and wipes the local vault.
+func (c *Client) deleteAccount() {
+	if c.userID == 0 {
+		fmt.Println("Please log in or register.")
+		return
+	}
+	fmt.Println("This will permanently delete your account, all synchronized data and all local files.")
+	c.rl.SetPrompt("Enter username: ")
+	username, _ := c.rl.Readline()
+	password := c.readPassword("Enter password: ")
+
+	fmt.Println("Type 'yes' to confirm or 'no' to cancel.")
 	line, _ := c.rl.Readline()
-	tableName, valid := getTableNameByChoice(strings.TrimSpace(line))
-	if !valid {
-		fmt.Println("Invalid choice")
+	if strings.ToLower(strings.TrimSpace(line)) != "yes" {
+		fmt.Println("Deletion canceled.")
 		return
 	}
-	data, _ := c.service.GetAllData(c.ctx, tableName, c.userID, "id", "meta_info")
-	if len(data) == 0 {
-		fmt.Println("No entries found in the table:", tableName)
+
+	err := c.service.DeleteAccount(c.ctx, c.userID, username, password)
+	if err != nil {
+		fmt.Printf("Failed to delete account: %s\n", err)
 		return
 	}
-	c.printAllData(data)
+	c.ClearSession()
+	fmt.Println("Account deleted.")
+}
+
+// sessions lists the active server sessions of the current user and lets the user revoke them.
+// In the json and yaml formats, or without a terminal, the sessions are only listed.
+func (c *Client) sessions() error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	for {
+		sessions, err := c.service.GetSessions(c.ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get sessions: %w", err)
+		}
+		if c.structured() {
+			return c.render(sessionsDocument(sessions), nil)
+		}
+		if len(sessions) == 0 {
+			fmt.Println("No active sessions found.")
+			return nil
+		}
+		for i, session := range sessions {
+			fmt.Printf("#%d: %s\n", i+1, formatSession(session))
+		}
+		if !c.tty {
+			return nil
+		}
+
+		c.rl.SetPrompt("Enter the number of the session to revoke, 'all' to sign out everywhere else, or press Enter to exit: ")
+		line, _ := c.rl.Readline()
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			return nil
+		case strings.ToLower(line) == "all":
+			err = c.service.RevokeOtherSessions(c.ctx)
+			if err != nil {
+				return fmt.Errorf("failed to revoke sessions: %w", err)
+			}
+			fmt.Println("Signed out of all other sessions.")
+		default:
+			num, err := strconv.Atoi(line)
+			if err != nil || num < 1 || num > len(sessions) || sessions[num-1].SessionID == nil {
+				fmt.Println("Invalid input. Please enter a valid number.")
+				continue
+			}
+			err = c.service.RevokeSession(c.ctx, *sessions[num-1].SessionID)
+			if err != nil {
+				return fmt.Errorf("failed to revoke session: %w", err)
+			}
+			if sessions[num-1].Current != nil && *sessions[num-1].Current {
+				c.ClearSession()
+				fmt.Println("Current session revoked. Please log in again.")
+				return nil
+			}
+			fmt.Println("Session revoked.")
+		}
+	}
+}
+
+// sessionsDocument returns server sessions in the json and yaml formats.
+func sessionsDocument(sessions []gksync.Session) sessionsOutput {
+	doc := sessionsOutput{Sessions: []sessionItem{}}
+	for _, session := range sessions {
+		var item sessionItem
+		if session.SessionID != nil {
+			item.ID = *session.SessionID
+		}
+		if session.Device != nil {
+			item.Device = *session.Device
+		}
+		if session.CreatedAt != nil {
+			item.SignedInAt = session.CreatedAt.Format(time.RFC3339)
+		}
+		if session.LastSeenAt != nil {
+			item.LastSeenAt = session.LastSeenAt.Format(time.RFC3339)
+		}
+		item.Current = session.Current != nil && *session.Current
+		doc.Sessions = append(doc.Sessions, item)
+	}
+	return doc
+}
+
+// formatSession formats a server session for output.
+func formatSession(session gksync.Session) string {
+	var formatted strings.Builder
+	if session.Device != nil && *session.Device != "" {
+		formatted.WriteString(*session.Device)
+	} else {
+		formatted.WriteString("unknown device")
+	}
+	if session.CreatedAt != nil {
+		formatted.WriteString(", signed in " + session.CreatedAt.Local().Format(time.DateTime))
+	}
+	if session.LastSeenAt != nil {
+		formatted.WriteString(", last seen " + session.LastSeenAt.Local().Format(time.DateTime))
+	}
+	if session.Current != nil && *session.Current {
+		formatted.WriteString(" (current)")
+	}
+	return formatted.String()
+}
+
+// list displays the entries of a specified data type for the current user page by page,
+// in the order given by the flags of the command.
+func (c *Client) list() {
+	if c.userID == 0 {
+		fmt.Println("Please log in or register.")
+		return
+	}
+	t, ok := c.chooseType()
+	if !ok {
+		return
+	}
+	opt := c.order
+	opt.Filter = c.filter
+	shown := 0
+	for {
+		page, err := c.service.List(c.ctx, t.Table, c.userID, opt)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if page.Total == 0 {
+			c.printNoEntries(t.Table)
+			return
+		}
+		for i, entry := range page.Entries {
+			fmt.Printf("#%d: %s%s\n", shown+i+1, entry["meta_info"], formatListTime(entry, opt.Sort))
+		}
+		shown += len(page.Entries)
+		if page.Next == "" {
+			return
+		}
+
+		c.rl.SetPrompt(fmt.Sprintf("Shown %d of %d entries. Show the next page? (yes/no): ", shown, page.Total))
+		choice, _ := c.rl.Readline()
+		if strings.ToLower(choice) != "yes" && strings.ToLower(choice) != "y" {
+			return
+		}
+		opt.Cursor = page.Next
+	}
+}
+
+// formatListTime returns the time an entry is sorted by, to be printed after its title.
+func formatListTime(entry map[string]string, order string) string {
+	var label, value string
+	switch order {
+	case services.SortUpdated:
+		label, value = "updated", entry["updated_at"]
+	case services.SortCreated:
+		label, value = "created", entry["created_at"]
+	default:
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf(" (%s %s)", label, t.Format("2006-01-02 15:04"))
 }
 
 // getDataAndPrint retrieves and prints data entries of a specified data type for the current user.
 func (c *Client) getDataAndPrint(tableName string, printFunc func(data map[string]string)) {
 	for {
-		data, _ := c.service.GetAllData(c.ctx, tableName, c.userID, "id", "meta_info")
-
+		data := c.listEntries(tableName)
 		if len(data) == 0 {
-			fmt.Println("No entries found in the table:", tableName)
 			return
 		}
 		c.printAllData(data)
@@ -366,6 +767,7 @@ func (c *Client) getDataAndPrint(tableName string, printFunc func(data map[strin
 			fmt.Printf("Failed to get data: %s\n", err)
 		} else {
 			printFunc(newdata)
+			c.printTags(tableName, row["id"])
 			fmt.Println("Data retrieved successfully!")
 		}
 
@@ -377,6 +779,29 @@ func (c *Client) getDataAndPrint(tableName string, printFunc func(data map[strin
 	}
 }
 
+// listEntries returns the id and title of the entries of a table matching the filter
+// of the current command. It prints a message if there are none.
+func (c *Client) listEntries(tableName string) []map[string]string {
+	data, err := c.service.ListEntries(c.ctx, tableName, c.userID, c.filter)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	if len(data) == 0 {
+		c.printNoEntries(tableName)
+	}
+	return data
+}
+
+// printNoEntries tells that a table has no entries matching the filter of the current command.
+func (c *Client) printNoEntries(tableName string) {
+	if c.filter != (services.EntryFilter{}) {
+		fmt.Println("No entries matching the filter found in the table:", tableName)
+	} else {
+		fmt.Println("No entries found in the table:", tableName)
+	}
+}
+
 func getStringFromSlice(data []map[string]string, index int) (map[string]string, error) {
 	if index < 0 || index >= len(data) {
 		return nil, errors.New("index out of range")
@@ -385,10 +810,10 @@ func getStringFromSlice(data []map[string]string, index int) (map[string]string,
 }
 
 // getBinaryDataAndSave retrieves binary data entries of a specified data type for the current user and saves them as files.
-func (c *Client) getBinaryDataAndSave(tableName string) {
-	data, _ := c.service.GetAllData(c.ctx, tableName, c.userID, "id", "meta_info")
+func (c *Client) getBinaryDataAndSave(t *records.Type) {
+	tableName := t.Table
+	data := c.listEntries(tableName)
 	if len(data) == 0 {
-		fmt.Println("No entries found in the table:", tableName)
 		return
 	}
 
@@ -403,7 +828,8 @@ func (c *Client) getBinaryDataAndSave(tableName string) {
 	if err != nil {
 		fmt.Printf("Failed to get data: %s\n", err)
 	} else {
-		c.printData(newdata)
+		c.printData(t, newdata)
+		c.printTags(tableName, row["id"])
 		fmt.Println("Data retrieved successfully!")
 
 		// Prompt to save the file
@@ -418,22 +844,7 @@ func (c *Client) getBinaryDataAndSave(tableName string) {
 		c.rl.SetPrompt("Enter the file name to save the file: ")
 		outputFileName, _ := c.rl.Readline()
 
-		// If the file name has no extension, add it from the database record
-		if filepath.Ext(outputFileName) == "" && newdata["extension"] != "" {
-			outputFileName += "." + newdata["extension"]
-		}
-
-		// Get the file path from the data
-		fileName := newdata["path"]
-		inputPath := filepath.Join(c.opt.FileStoragePath, fileName)
-
-		// Check if the file exists, if not, retrieve it from the server
-		if _, err := os.Stat(inputPath); os.IsNotExist(err) {
-			c.service.RetrieveFile(c.ctx, c.userID, fileName, inputPath)
-		}
-		// Decrypt the file
-		err = c.enc.DecryptFile(inputPath, outputFileName)
-		if err != nil {
+		if err := c.saveFile(newdata, outputFileName); err != nil {
 			fmt.Println("Failed to decrypt file:", err)
 			return
 		}
@@ -447,42 +858,75 @@ func (c *Client) getBinaryDataAndSave(tableName string) {
 			break
 		}
 
-		c.getBinaryDataAndSave("FilesData")
+		c.getBinaryDataAndSave(t)
 	}
 }
 
-// printAllData prints all entries in a given data set.
-func (c *Client) printAllData(data []map[string]string) {
-	for i, entry := range data {
-		fmt.Printf("#%d: %s\n", i+1, entry["meta_info"])
+// saveFile decrypts the file of a binary data entry to outputFileName, or writes it to the
+// standard output if outputFileName is "-". The file is retrieved from the server if it is
+// not stored locally, and the extension of the entry is added to names without one.
+func (c *Client) saveFile(data map[string]string, outputFileName string) error {
+	ext := strings.TrimPrefix(data["extension"], ".")
+	if outputFileName != "-" && filepath.Ext(outputFileName) == "" && ext != "" {
+		outputFileName += "." + ext
 	}
-}
 
-// printData prints the given data to the standard output.
-func (c *Client) printData(data map[string]string) {
-	for key, value := range data {
-		fmt.Printf("%s: %s\n", key, value)
+	// Check if the file exists, if not, retrieve it from the server
+	fileName := data["path"]
+	inputPath := filepath.Join(c.opt.FileStoragePath, fileName)
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		c.service.RetrieveFile(c.ctx, c.userID, fileName, inputPath)
+	}
+	if outputFileName != "-" {
+		return c.enc.DecryptFile(inputPath, outputFileName)
 	}
-}
-
-// getLoginPassword fetches and prints user login credentials.
-func (c *Client) getLoginPassword() {
-	c.getDataAndPrint("UserCredentials", c.printData)
-}
 
-// getTextData fetches and prints text data.
-func (c *Client) getTextData() {
-	c.getDataAndPrint("TextData", c.printData)
+	tmp, err := os.CreateTemp("", "gophkeeper-get-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := c.enc.DecryptFile(inputPath, tmp.Name()); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(content)
+	return err
 }
 
-// getBinaryData fetches binary data.
-func (c *Client) getBinaryData() {
-	c.getBinaryDataAndSave("FilesData")
+// printAllData prints all entries in a given data set.
+func (c *Client) printAllData(data []map[string]string) {
+	for i, entry := range data {
+		fmt.Printf("#%d: %s\n", i+1, entry["meta_info"])
+	}
 }
 
-// getBankCardData fetches and prints bank card data.
-func (c *Client) getBankCardData() {
-	c.getDataAndPrint("CreditCardData", c.printData)
+// printData prints the given record to the standard output in the field order of its type.
+// Linked records and attachments are shown by title, custom fields by name, and the current
+// code is printed for types generating codes.
+func (c *Client) printData(t *records.Type, data map[string]string) {
+	for _, key := range t.Keys(data) {
+		switch key {
+		case records.CustomFieldsField:
+			printCustomFields(data[key])
+			continue
+		case records.AttachmentsField:
+			c.printAttachments(data[key])
+			continue
+		}
+		if f, ok := t.Field(key); ok && f.Ref != "" {
+			c.printRef(f, data[key])
+			continue
+		}
+		fmt.Printf("%s: %s\n", key, data[key])
+	}
+	if t.Code != nil {
+		printCode(t, data)
+	}
 }
 
 // addDataRepeatedly adds data repeatedly until the user decides to stop.
@@ -506,41 +950,54 @@ func (c *Client) addDataRepeatedly(dataType string, dataFunc func() map[string]s
 	}
 }
 
-// addLoginPassword prompts the user to add login credentials repeatedly.
-func (c *Client) addLoginPassword() {
-	c.addDataRepeatedly("UserCredentials", func() map[string]string {
-		c.rl.SetPrompt("Choose a title (meta-information): ")
-		title, _ := c.rl.Readline()
-		c.rl.SetPrompt("Enter login: ")
-		login, _ := c.rl.Readline()
-		c.rl.SetPrompt("Enter password: ")
-		c.rl.Config.EnableMask = true
-		password, _ := c.rl.Readline()
-		c.rl.Config.EnableMask = false
-		return map[string]string{
-			"login":     login,
-			"password":  password,
-			"meta_info": title,
+// addRecord prompts the user to add records of the given type repeatedly.
+func (c *Client) addRecord(t *records.Type) {
+	c.addDataRepeatedly(t.Table, func() map[string]string {
+		if data := c.importRecord(t); data != nil {
+			return data
 		}
+		data := make(map[string]string)
+		for _, f := range t.InputFields() {
+			if f.Ref != "" {
+				data[f.Name] = c.readRef(f, "")
+				continue
+			}
+			data[f.Name] = c.readField(f, f.AddPrompt(), "")
+		}
+		return data
 	}, func(data map[string]string) {
-		fmt.Printf("Login: %s", data["login"])
+		fmt.Println(t.Summary(data))
 	})
 }
 
-// addTextData prompts the user to add text data repeatedly.
-func (c *Client) addTextData() {
-	c.addDataRepeatedly("TextData", func() map[string]string {
-		c.rl.SetPrompt("Choose a title (meta-information): ")
-		title, _ := c.rl.Readline()
-		c.rl.SetPrompt("Enter text data: ")
-		text, _ := c.rl.Readline()
-		return map[string]string{
-			"data":      text,
-			"meta_info": title,
+// clearInput is the input clearing a value when editing.
+const clearInput = "-"
+
+// readField prompts the user for a field value until it passes validation.
+// When editing, an empty input keeps the old value and clearInput clears it.
+func (c *Client) readField(f records.Field, prompt string, old string) string {
+	for {
+		c.rl.SetPrompt(prompt)
+		c.rl.Config.EnableMask = f.Sensitive
+		value, err := c.rl.Readline()
+		c.rl.Config.EnableMask = false
+		if err != nil {
+			return old
 		}
-	}, func(data map[string]string) {
-		fmt.Printf("Title: %s, Text: %s\n", data["meta_info"], data["data"])
-	})
+		if value == "" && old != "" {
+			return old
+		}
+		if value == clearInput && old != "" {
+			value = ""
+		} else if value == "" {
+			value = f.Default
+		}
+		if err := f.Check(value); err != nil {
+			fmt.Printf("%s!\n", err)
+			continue
+		}
+		return value
+	}
 }
 
 // addBinaryData adds binary data.
@@ -548,7 +1005,7 @@ func (c *Client) addTextData() {
 // Then, it checks if the file exists and if its size is within the allowed limit.
 // If the file is valid, it encrypts the file, retrieves its extension, and adds metadata to the file service.
 // Finally, it sends the encrypted file to the server in a separate goroutine.
-func (c *Client) addBinaryData() {
+func (c *Client) addBinaryData(t *records.Type) {
 	c.rl.SetPrompt("Choose a title (meta-information): ")
 	title, _ := c.rl.Readline()
 	c.rl.SetPrompt("Specify the file path: ")
@@ -577,13 +1034,16 @@ func (c *Client) addBinaryData() {
 	// Get the file extension
 	extension := filepath.Ext(inputPath)
 
+	folder, _ := t.Field(records.FolderField)
+
 	// Add file metadata to the service
 	fileData := map[string]string{
 		"path":      fmt.Sprintf("%x", hash), // Save the hash instead of the file path
 		"meta_info": title,
 		"extension": extension, // Save the file extension
+		"folder_id": c.readRef(folder, ""),
 	}
-	err = c.service.AddData(c.ctx, "FilesData", c.userID, fileData)
+	err = c.service.AddData(c.ctx, t.Table, c.userID, fileData)
 	if err != nil {
 		fmt.Printf("Failed to add data: %s\n", err)
 		return
@@ -602,68 +1062,18 @@ func (c *Client) addBinaryData() {
 			break
 		}
 
-		c.addBinaryData()
+		c.addBinaryData(t)
 	}
 }
 
-// addBankCardData prompts the user to add bank card data repeatedly.
-func (c *Client) addBankCardData() {
-	c.addDataRepeatedly("CreditCardData", func() map[string]string {
-		digitsOnly, _ := regexp.Compile(`^\d+$`)
-		dateFormat, _ := regexp.Compile(`^\d{2}/\d{2}$`)
-		c.rl.SetPrompt("Choose a title (meta-information): ")
-		title, _ := c.rl.Readline()
-		var cardNumber, expiryDate, cvv string
-		for {
-			c.rl.SetPrompt("Enter card number: ")
-			cardNumber, _ = c.rl.Readline()
-			if !digitsOnly.MatchString(cardNumber) {
-				fmt.Println("Card number can only contain digits!")
-			} else {
-				break
-			}
-		}
-		for {
-			c.rl.SetPrompt("Enter expiry date (MM/YY): ")
-			expiryDate, _ = c.rl.Readline()
-			if !dateFormat.MatchString(expiryDate) {
-				fmt.Println("Expiry date must be in the format MM/YY!")
-			} else {
-				break
-			}
-		}
-		for {
-			c.rl.SetPrompt("Enter CVV: ")
-			c.rl.Config.EnableMask = true
-			cvv, _ = c.rl.Readline()
-			c.rl.Config.EnableMask = false
-			if !digitsOnly.MatchString(cvv) {
-				fmt.Println("CVV can only contain digits!")
-			} else {
-				break
-			}
-		}
-		return map[string]string{
-			"card_number":     cardNumber,
-			"expiration_date": expiryDate,
-			"cvv":             cvv,
-			"meta_info":       title,
-		}
-	}, func(data map[string]string) {
-		fmt.Printf("Title: %s, Card Number: %s, Expiry Date: %s\n", data["meta_info"], data["card_number"], data["expiration_date"])
-	})
-}
-
 // editAllData allows the user to edit data in a specified table.
 // It retrieves existing data from the table, prompts the user to select a row to edit,
 // then prompts the user to enter new data, and updates the existing data in the table.
 // It continues editing data until the user decides to stop.
 func (c *Client) editAllData(tableName string, getDataFunc func(oldData map[string]string) map[string]string) {
 	for {
-		data, _ := c.service.GetAllData(c.ctx, tableName, c.userID, "id", "meta_info")
-
+		data := c.listEntries(tableName)
 		if len(data) == 0 {
-			fmt.Println("No entries found in the table:", tableName)
 			return
 		}
 
@@ -696,109 +1106,28 @@ func (c *Client) editAllData(tableName string, getDataFunc func(oldData map[stri
 	}
 }
 
-// editLoginPassword allows the user to edit login credentials.
-// It retrieves existing login credentials, prompts the user to enter new data,
-// and updates the existing login credentials in the "UserCredentials" table.
-func (c *Client) editLoginPassword() {
-	c.editAllData("UserCredentials", func(oldData map[string]string) map[string]string {
-		c.rl.SetPrompt(fmt.Sprintf("Choose a new title (meta-information) [%s]: ", oldData["meta_info"]))
-		title, _ := c.rl.Readline()
-		if title == "" {
-			title = oldData["meta_info"]
-		}
-		c.rl.SetPrompt(fmt.Sprintf("Enter new login [%s]: ", oldData["login"]))
-		login, _ := c.rl.Readline()
-		if login == "" {
-			login = oldData["login"]
-		}
-		c.rl.SetPrompt("Enter new password: ")
-		c.rl.Config.EnableMask = true
-		password, _ := c.rl.Readline()
-		c.rl.Config.EnableMask = false
-		if password == "" {
-			password = oldData["password"]
-		}
-		return map[string]string{
-			"login":      login,
-			"password":   password,
-			"meta_info":  title,
-			"updated_at": c.getTimeWithoutTimeZone().Format(time.RFC3339),
-		}
-	})
-}
-
-// editTextData allows the user to edit text data.
-// It retrieves existing text data, prompts the user to enter new data,
-// and updates the existing text data in the "TextData" table.
-func (c *Client) editTextData() {
-	c.editAllData("TextData", func(oldData map[string]string) map[string]string {
-		c.rl.SetPrompt(fmt.Sprintf("Choose a new title (meta-information) [%s]: ", oldData["meta_info"]))
-		title, _ := c.rl.Readline()
-		if title == "" {
-			title = oldData["meta_info"]
-		}
-		c.rl.SetPrompt(fmt.Sprintf("Enter new text data [%s]: ", oldData["data"]))
-		text, _ := c.rl.Readline()
-		if text == "" {
-			text = oldData["data"]
-		}
-		return map[string]string{
-			"data":       text,
-			"meta_info":  title,
-			"updated_at": c.getTimeWithoutTimeZone().Format(time.RFC3339),
-		}
-	})
-}
-
-// editBinaryData allows the user to edit binary data.
-// It retrieves existing binary data, prompts the user to enter new data,
-// and updates the existing binary data in the "FilesData" table.
-func (c *Client) editBinaryData() {
-	c.editAllData("FilesData", func(oldData map[string]string) map[string]string {
-		c.rl.SetPrompt(fmt.Sprintf("Choose a new title (meta-information) [%s]: ", oldData["meta_info"]))
-		title, _ := c.rl.Readline()
-		if title == "" {
-			title = oldData["meta_info"]
+// editRecord allows the user to edit records of the given type.
+// It prompts for every field the user may change; an empty input keeps the old value
+// and '-' clears it.
+func (c *Client) editRecord(t *records.Type) {
+	c.editAllData(t.Table, func(oldData map[string]string) map[string]string {
+		fmt.Printf("Press Enter to keep a value, enter '%s' to clear it.\n", clearInput)
+		data := make(map[string]string)
+		for _, f := range t.InputFields() {
+			if f.Ref != "" {
+				data[f.Name] = c.readRef(f, oldData[f.Name])
+				continue
+			}
+			data[f.Name] = c.readField(f, f.EditPrompt(oldData[f.Name]), oldData[f.Name])
 		}
-		return map[string]string{
-			"meta_info":  title,
-			"updated_at": c.getTimeWithoutTimeZone().Format(time.RFC3339),
+		if _, ok := t.Field(records.CustomFieldsField); ok {
+			data[records.CustomFieldsField] = c.editCustomFields(oldData[records.CustomFieldsField])
 		}
-	})
-}
-
-// editBankCardData allows the user to edit bank card data.
-// It retrieves existing bank card data, prompts the user to enter new data,
-// and updates the existing bank card data in the "CreditCardData" table.
-func (c *Client) editBankCardData() {
-	c.editAllData("CreditCardData", func(oldData map[string]string) map[string]string {
-		c.rl.SetPrompt(fmt.Sprintf("Choose a new title (meta-information) [%s]: ", oldData["meta_info"]))
-		title, _ := c.rl.Readline()
-		if title == "" {
-			title = oldData["meta_info"]
-		}
-		c.rl.SetPrompt(fmt.Sprintf("Enter new card number [%s]: ", oldData["card_number"]))
-		cardNumber, _ := c.rl.Readline()
-		if cardNumber == "" {
-			cardNumber = oldData["card_number"]
-		}
-		c.rl.SetPrompt(fmt.Sprintf("Enter new expiry date (MM/YY) [%s]: ", oldData["expiration_date"]))
-		expiryDate, _ := c.rl.Readline()
-		if expiryDate == "" {
-			expiryDate = oldData["expiration_date"]
-		}
-		c.rl.SetPrompt(fmt.Sprintf("Enter new CVV [%s]: ", oldData["cvv"]))
-		cvv, _ := c.rl.Readline()
-		if cvv == "" {
-			cvv = oldData["cvv"]
-		}
-		return map[string]string{
-			"card_number":     cardNumber,
-			"expiration_date": expiryDate,
-			"cvv":             cvv,
-			"meta_info":       title,
-			"updated_at":      c.getTimeWithoutTimeZone().Format(time.RFC3339),
+		if _, ok := t.Field(records.AttachmentsField); ok {
+			data[records.AttachmentsField] = c.editAttachments(oldData[records.AttachmentsField])
 		}
+		data["updated_at"] = c.getTimeWithoutTimeZone().Format(time.RFC3339)
+		return data
 	})
 }
 
@@ -810,18 +1139,15 @@ func (c *Client) DeleteData() {
 		fmt.Println("Please log in or register.")
 		return
 	}
-	printMenu()
-	line, _ := c.rl.Readline()
-	tableName, valid := getTableNameByChoice(strings.TrimSpace(line))
-	if !valid {
-		fmt.Println("Invalid choice")
+	t, ok := c.chooseType()
+	if !ok {
 		return
 	}
+	tableName := t.Table
 
 	for {
-		data, _ := c.service.GetAllData(c.ctx, tableName, c.userID, "id", "meta_info")
+		data := c.listEntries(tableName)
 		if len(data) == 0 {
-			fmt.Println("No entries found in the table:", tableName)
 			return
 		}
 		c.printAllData(data)
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/wurt83ow/gophkeeper-client/pkg/clipboard"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// copyField copies a field of an entry to the clipboard, the current code or the first
+// sensitive field if none is given, and schedules the clipboard to be cleared. With
+// reveal, the other fields of the entry are printed as well.
+func (c *Client) copyField(t *records.Type, id string, data map[string]string, field string, reveal bool) error {
+	if field == "" {
+		field = secretField(t, data)
+		if field == "" {
+			return fmt.Errorf("%s entries have no secret to copy, give one with --field", t.Key)
+		}
+	}
+	value, err := c.fieldValue(t, id, data, field)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("the field %s is empty", field)
+	}
+
+	cb, err := c.clipboard()
+	if err != nil {
+		return err
+	}
+	if err := cb.Copy(value); err != nil {
+		return fmt.Errorf("failed to copy to the clipboard: %w", err)
+	}
+	doc := copyOutput{ID: id, Field: field, Clipboard: cb.Name()}
+	// The terminal cannot tell whether something else was copied since, so the OSC 52
+	// clipboard is only cleared if the user accepts to lose it
+	_, osc52 := cb.(*clipboard.OSC52)
+	keepUnreadable := osc52 && !c.opt.ClipboardForceClear
+	if c.opt.ClipboardTimeout > 0 && !keepUnreadable {
+		if err := clipboard.ScheduleClear(cb, value, c.opt.ClipboardTimeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: the clipboard will not be cleared: %s\n", err)
+		} else {
+			doc.ClearAfterSeconds = int(c.opt.ClipboardTimeout.Seconds())
+		}
+	}
+	switch {
+	case c.opt.ClipboardTimeout > 0 && keepUnreadable:
+		fmt.Fprintf(os.Stderr, "Copied %s to the clipboard (%s), not cleared automatically as it cannot be read back; "+
+			"set -clipboardForceClear to clear it whatever it holds then.\n", field, cb.Name())
+	case doc.ClearAfterSeconds == 0:
+		fmt.Fprintf(os.Stderr, "Copied %s to the clipboard (%s), not cleared automatically.\n", field, cb.Name())
+	case osc52:
+		fmt.Fprintf(os.Stderr, "Copied %s to the clipboard (%s), cleared in %s whatever it holds then.\n", field, cb.Name(), c.opt.ClipboardTimeout)
+	default:
+		fmt.Fprintf(os.Stderr, "Copied %s to the clipboard (%s), cleared in %s unless changed.\n", field, cb.Name(), c.opt.ClipboardTimeout)
+	}
+
+	if !reveal {
+		return c.render(doc, func() {})
+	}
+	t, data, err = withoutField(t, data, field)
+	if err != nil {
+		return err
+	}
+	entry, err := c.entryDocument(t, id, data)
+	if err != nil {
+		return err
+	}
+	doc.Entry = &entry
+	return c.render(doc, func() {
+		fmt.Printf("id: %s\n", id)
+		c.printData(t, data)
+		c.printTags(t.Table, id)
+	})
+}
+
+// secretField returns the field copied when none is given: the current code of types
+// generating codes, else the first sensitive field with a value.
+func secretField(t *records.Type, data map[string]string) string {
+	if t.Code != nil {
+		return "code"
+	}
+	for _, f := range t.Fields {
+		if f.Sensitive && data[f.Name] != "" {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// withoutField returns a type and data leaving out a field given by name or label, for the
+// copied field not to be printed. The code is left out with a copy of the type.
+func withoutField(t *records.Type, data map[string]string, name string) (*records.Type, map[string]string, error) {
+	if strings.EqualFold(name, "code") && t.Code != nil {
+		withoutCode := *t
+		withoutCode.Code = nil
+		return &withoutCode, data, nil
+	}
+	rest := make(map[string]string, len(data))
+	for k, v := range data {
+		rest[k] = v
+	}
+	for _, f := range t.Fields {
+		if strings.EqualFold(name, f.Name) || strings.EqualFold(name, f.Label) {
+			delete(rest, f.Name)
+			return t, rest, nil
+		}
+	}
+	custom, err := records.ParseCustomFields(data[records.CustomFieldsField])
+	if err != nil {
+		return nil, nil, err
+	}
+	var kept []records.CustomField
+	for _, f := range custom {
+		if !strings.EqualFold(name, f.Name) {
+			kept = append(kept, f)
+		}
+	}
+	rest[records.CustomFieldsField] = records.FormatCustomFields(kept)
+	return t, rest, nil
+}
+
+// clipboard returns the clipboard of the display, or of the terminal through OSC 52 when
+// no clipboard tool can be used and the standard error is a terminal.
+func (c *Client) clipboard() (clipboard.Clipboard, error) {
+	tool, err := clipboard.Detect(os.Getenv, exec.LookPath)
+	if err == nil {
+		return tool, nil
+	}
+	if !errors.Is(err, clipboard.ErrUnavailable) || !readline.IsTerminal(int(os.Stderr.Fd())) {
+		return nil, err
+	}
+	return clipboard.NewOSC52(os.Stderr), nil
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// printCustomFields prints the custom fields of a record in their order.
+func printCustomFields(value string) {
+	fields, err := records.ParseCustomFields(value)
+	if err != nil {
+		fmt.Printf("custom fields: %s\n", err)
+		return
+	}
+	for _, f := range fields {
+		fmt.Printf("%s: %s\n", f.Name, f.Value)
+	}
+}
+
+// printAttachments prints the titles of the files attached to a record.
+func (c *Client) printAttachments(value string) {
+	ids, err := records.ParseAttachments(value)
+	if err != nil {
+		fmt.Printf("attachments: %s\n", err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	fmt.Printf("attachments: %s\n", strings.Join(c.attachmentTitles(ids), ", "))
+}
+
+// attachmentTitles returns the titles of the attached files. Files deleted since are marked.
+func (c *Client) attachmentTitles(ids []string) []string {
+	titles := make([]string, 0, len(ids))
+	for _, id := range ids {
+		data, err := c.service.GetData(c.ctx, records.FilesTable, c.userID, id)
+		if err != nil {
+			titles = append(titles, "(deleted file)")
+			continue
+		}
+		titles = append(titles, data[records.TitleField])
+	}
+	return titles
+}
+
+// editCustomFields lets the user add, change, reorder and delete the custom fields
+// of a record, and returns their new stored value.
+func (c *Client) editCustomFields(value string) string {
+	c.rl.SetPrompt("Do you want to edit the custom fields? (yes/no): ")
+	choice, _ := c.rl.Readline()
+	if strings.ToLower(choice) != "yes" && strings.ToLower(choice) != "y" {
+		return value
+	}
+	fields, err := records.ParseCustomFields(value)
+	if err != nil {
+		fmt.Println(err)
+		return value
+	}
+
+	for {
+		for i, f := range fields {
+			shown := f.Value
+			if f.Type == records.CustomHidden {
+				shown = "********"
+			}
+			fmt.Printf("#%d: %s (%s): %s\n", i+1, f.Name, f.Type, shown)
+		}
+		c.rl.SetPrompt("Enter 'a' to add a field, 'e N' to edit, 'd N' to delete, 'u N' to move up, or press Enter to finish: ")
+		line, err := c.rl.Readline()
+		if err != nil || strings.TrimSpace(line) == "" {
+			return records.FormatCustomFields(fields)
+		}
+
+		action, num, err := listAction(line, len(fields))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		switch action {
+		case "a":
+			fields = append(fields, c.readCustomField(records.CustomField{}))
+		case "e":
+			fields[num-1] = c.readCustomField(fields[num-1])
+		case "d":
+			fields = append(fields[:num-1], fields[num:]...)
+		case "u":
+			if num > 1 {
+				fields[num-2], fields[num-1] = fields[num-1], fields[num-2]
+			}
+		default:
+			fmt.Println("Invalid input.")
+		}
+	}
+}
+
+// readCustomField prompts for the name, type and value of a custom field until they are valid.
+// When editing, an empty input keeps the old name, type or value.
+func (c *Client) readCustomField(old records.CustomField) records.CustomField {
+	for {
+		f := old
+		c.rl.SetPrompt(withDefault("Enter field name", old.Name))
+		if name, _ := c.rl.Readline(); strings.TrimSpace(name) != "" {
+			f.Name = strings.TrimSpace(name)
+		}
+
+		if f.Type == "" {
+			f.Type = records.CustomText
+		}
+		c.rl.SetPrompt(withDefault("Choose field type ("+strings.Join(records.CustomTypes, ", ")+")", f.Type))
+		if typ, _ := c.rl.Readline(); strings.TrimSpace(typ) != "" {
+			f.Type = strings.ToLower(strings.TrimSpace(typ))
+		}
+
+		label := "Enter value"
+		if f.Type == records.CustomDate {
+			label = "Enter date (YYYY-MM-DD)"
+		}
+		// Hidden values are never shown as defaults
+		shown := old.Value
+		if f.Type == records.CustomHidden || old.Type == records.CustomHidden {
+			shown = ""
+		}
+		c.rl.SetPrompt(withDefault(label, shown))
+		c.rl.Config.EnableMask = f.Type == records.CustomHidden
+		value, _ := c.rl.Readline()
+		c.rl.Config.EnableMask = false
+		if value != "" {
+			f.Value = value
+		}
+
+		if err := f.Check(); err != nil {
+			fmt.Printf("Custom field %s!\n", err)
+			continue
+		}
+		return f
+	}
+}
+
+// editAttachments lets the user attach stored files to a record and detach them,
+// and returns the new stored value.
+func (c *Client) editAttachments(value string) string {
+	ids, err := records.ParseAttachments(value)
+	if err != nil {
+		fmt.Println(err)
+		return value
+	}
+	files, _ := c.service.GetAllData(c.ctx, records.FilesTable, c.userID, "id", records.TitleField)
+	if len(files) == 0 && len(ids) == 0 {
+		return value
+	}
+	c.rl.SetPrompt("Do you want to edit the attachments? (yes/no): ")
+	choice, _ := c.rl.Readline()
+	if strings.ToLower(choice) != "yes" && strings.ToLower(choice) != "y" {
+		return value
+	}
+
+	for {
+		for i, title := range c.attachmentTitles(ids) {
+			fmt.Printf("#%d: %s\n", i+1, title)
+		}
+		c.rl.SetPrompt("Enter 'a' to attach a file, 'd N' to detach one, or press Enter to finish: ")
+		line, err := c.rl.Readline()
+		if err != nil || strings.TrimSpace(line) == "" {
+			return records.FormatAttachments(ids)
+		}
+
+		action, num, err := listAction(line, len(ids))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		switch action {
+		case "a":
+			if len(files) == 0 {
+				fmt.Println("No files found. Add the file as binary data first.")
+				continue
+			}
+			c.printAllData(files)
+			row, err := getRowFromUserInput(files, c.rl)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			if !contains(ids, row["id"]) {
+				ids = append(ids, row["id"])
+			}
+		case "d":
+			ids = append(ids[:num-1], ids[num:]...)
+		default:
+			fmt.Println("Invalid input.")
+		}
+	}
+}
+
+// listAction parses a list editing command such as "a" or "d 2". Commands other
+// than "a" need the number of an item of the list.
+func listAction(line string, n int) (string, int, error) {
+	parts := strings.Fields(strings.ToLower(line))
+	if parts[0] == "a" {
+		return "a", 0, nil
+	}
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid input, enter the command followed by the number of the item")
+	}
+	num, err := strconv.Atoi(parts[1])
+	if err != nil || num < 1 || num > n {
+		return "", 0, fmt.Errorf("invalid input, enter a number from 1 to %d", n)
+	}
+	return parts[0], num, nil
+}
+
+// withDefault returns a prompt showing the value used when nothing is entered.
+func withDefault(prompt, value string) string {
+	if value == "" {
+		return prompt + ": "
+	}
+	return fmt.Sprintf("%s [%s]: ", prompt, value)
+}
+
+// contains reports whether the list contains the value.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+)
+
+// dbCommand returns the command group for maintenance of the local database.
+func (c *Client) dbCommand() *cobra.Command {
+	dbCmd := &cobra.Command{
+		Use:         "db",
+		Short:       "Maintain the local database",
+		Annotations: structuredOutput,
+	}
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Show, apply or roll back database migrations",
+	}
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the state of the migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.migrateStatus()
+		},
+	})
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.migrateUp()
+		},
+	})
+
+	var steps int
+	var yes bool
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back the most recent migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.migrateDown(steps, yes)
+		},
+	}
+	downCmd.Flags().IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	downCmd.Flags().BoolVar(&yes, "yes", false, "roll back without asking for confirmation")
+	migrateCmd.AddCommand(downCmd)
+
+	dbCmd.AddCommand(migrateCmd)
+	return dbCmd
+}
+
+// migrateStatus prints the state of every migration.
+func (c *Client) migrateStatus() error {
+	statuses, err := c.service.MigrationStatus(c.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the migration status: %w", err)
+	}
+	doc := migrationsOutput{Migrations: []migrationItem{}}
+	for _, st := range statuses {
+		item := migrationItem{Version: st.Version, Name: migrationLabel(st.Version, st.Name), Applied: st.Applied,
+			Modified: st.Modified, Unknown: st.Applied && st.Migration == nil}
+		if st.Applied {
+			item.AppliedAt = st.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		doc.Migrations = append(doc.Migrations, item)
+	}
+	return c.render(doc, func() {
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			switch {
+			case st.Modified:
+				state += " (modified after it was applied)"
+			case st.Applied && st.Migration == nil:
+				state += " (unknown to this version)"
+			}
+			fmt.Printf("%-50s %s\n", migrationLabel(st.Version, st.Name), state)
+		}
+	})
+}
+
+// migrateUp applies the pending migrations.
+func (c *Client) migrateUp() error {
+	applied, err := c.service.MigrateUp(c.ctx)
+	if !c.structured() {
+		for _, m := range applied {
+			fmt.Println("Applied", migrationLabel(m.Version, m.Name))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	doc := migrateUpOutput{Applied: migrationLabels(applied)}
+	return c.render(doc, func() {
+		if len(applied) == 0 {
+			fmt.Println("The database is up to date.")
+		}
+	})
+}
+
+// migrateDown rolls back migrations, after confirmation unless yes is set. Without a
+// terminal to confirm on, yes is required.
+func (c *Client) migrateDown(steps int, yes bool) error {
+	if steps < 1 {
+		return errors.New("the number of steps must be positive")
+	}
+	if !yes {
+		if !c.tty {
+			return fmt.Errorf("%w, use --yes to roll back without confirmation", errTerminalRequired)
+		}
+		c.rl.SetPrompt(fmt.Sprintf("Rolling back %d migration(s) may delete stored data. Type 'yes' to confirm or 'no' to cancel: ", steps))
+		line, _ := c.rl.Readline()
+		if strings.ToLower(strings.TrimSpace(line)) != "yes" {
+			if c.structured() {
+				return errors.New("rollback canceled")
+			}
+			fmt.Println("Rollback canceled.")
+			return nil
+		}
+	}
+
+	rolledBack, err := c.service.MigrateDown(c.ctx, steps)
+	if !c.structured() {
+		for _, m := range rolledBack {
+			fmt.Println("Rolled back", migrationLabel(m.Version, m.Name))
+		}
+	}
+	if errors.Is(err, bdkeeper.ErrNoMigration) {
+		return errors.New("there are no applied migrations")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	return c.render(migrateDownOutput{RolledBack: migrationLabels(rolledBack)}, func() {
+		fmt.Println("Pending migrations are applied again before the next command, unless AUTO_MIGRATE is false.")
+	})
+}
+
+// isMigrateCommand reports whether cmd is one of the db migrate commands, which run
+// before pending migrations are applied.
+func isMigrateCommand(cmd *cobra.Command) bool {
+	parent := cmd.Parent()
+	return parent != nil && parent.Name() == "migrate" && parent.Parent() != nil && parent.Parent().Name() == "db"
+}
+
+// applyMigrations applies the pending migrations of the local database before a command
+// runs. When automatic migrations are disabled, pending ones are reported instead.
+func (c *Client) applyMigrations() error {
+	if c.opt.AutoMigrate {
+		if _, err := c.service.MigrateUp(c.ctx); err != nil {
+			return fmt.Errorf("failed to migrate the local database, see gophkeeper db migrate status: %w", err)
+		}
+		c.migrated = true
+		return nil
+	}
+	statuses, err := c.service.MigrationStatus(c.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the migration status: %w", err)
+	}
+	for _, st := range statuses {
+		if !st.Applied || st.Modified {
+			return errors.New("the local database is not up to date, see gophkeeper db migrate status")
+		}
+	}
+	c.migrated = true
+	return nil
+}
+
+// migrationLabels returns the labels of migrations, never nil.
+func migrationLabels(migrations []*bdkeeper.Migration) []string {
+	labels := []string{}
+	for _, m := range migrations {
+		labels = append(labels, migrationLabel(m.Version, m.Name))
+	}
+	return labels
+}
+
+// migrationLabel names a migration. SQL migrations are named after their file, which
+// starts with the version; Go migrations get the version prepended.
+func migrationLabel(version int64, name string) string {
+	if v := strconv.FormatInt(version, 10); !strings.HasPrefix(name, v) {
+		return v + "_" + name
+	}
+	return name
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// doctorReport is the json and yaml output of the doctor command.
+type doctorReport struct {
+	OK       bool               `json:"ok"` // OK is set if no problem is left unfixed.
+	Findings []services.Finding `json:"findings"`
+}
+
+// doctorCommand returns the command checking the integrity of the local vault.
+func (c *Client) doctorCommand() *cobra.Command {
+	var fix, asJSON bool
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the local vault for problems and repair them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if asJSON {
+				c.output = outputJSON
+			}
+			return c.doctor(fix)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().BoolVar(&fix, "fix", false, "apply the automatic fixes")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the findings as JSON, like --output json")
+	return cmd
+}
+
+// doctor runs the checks and prints the findings with their fixes.
+func (c *Client) doctor(fix bool) error {
+	findings, err := c.service.Doctor(c.ctx, c.userID, fix)
+	if err != nil {
+		return fmt.Errorf("failed to check the vault: %w", err)
+	}
+
+	if c.structured() {
+		report := doctorReport{OK: true, Findings: nonNil(findings)}
+		for _, f := range findings {
+			if !f.Fixed {
+				report.OK = false
+			}
+		}
+		return c.render(report, nil)
+	}
+
+	if len(findings) == 0 {
+		fmt.Println("No problems found.")
+		return nil
+	}
+	auto := 0
+	for i, f := range findings {
+		fmt.Printf("#%d [%s] %s\n", i+1, f.Check, f.Problem)
+		switch {
+		case f.Fixed:
+			fmt.Printf("    Fixed: %s\n", f.Fix)
+		case f.Error != "":
+			fmt.Printf("    Fix failed: %s (%s)\n", f.Fix, f.Error)
+		case f.Auto:
+			fmt.Printf("    Automatic fix: %s\n", f.Fix)
+			auto++
+		default:
+			fmt.Printf("    Suggested fix: %s\n", f.Fix)
+		}
+	}
+	fmt.Printf("%d problem(s) found.\n", len(findings))
+	if auto > 0 {
+		fmt.Println("Run 'doctor --fix' to apply the automatic fixes.")
+	}
+	return nil
+}
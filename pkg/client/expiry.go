@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/config"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// expiringCommand returns the command listing expired and expiring entries.
+func (c *Client) expiringCommand() *cobra.Command {
+	var within string
+	cmd := &cobra.Command{
+		Use:   "expiring",
+		Short: "List expired entries, entries expiring soon and passwords due for a change",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.expiring(within)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().StringVar(&within, "within", "", "how far ahead to look, such as 30d (default -expiryWarning)")
+	return cmd
+}
+
+// expiring prints the entries expiring within the given duration.
+func (c *Client) expiring(within string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	period := c.opt.ExpiryWarning
+	if within != "" {
+		var err error
+		if period, err = config.ParseDuration(within); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	found, err := c.service.Expiring(c.ctx, c.userID, period, now)
+	if err != nil {
+		return fmt.Errorf("failed to check expiry dates: %w", err)
+	}
+	doc := expiringOutput{Entries: []expiringItem{}}
+	for _, entry := range found {
+		doc.Entries = append(doc.Entries, expiringItem{ID: entry.ID, Type: typeKey(entry.Table), Title: entry.Title,
+			Reason: entry.Reason, At: entry.At.Format(time.RFC3339), Expired: entry.Expired(now)})
+	}
+	return c.render(doc, func() {
+		if len(found) == 0 {
+			fmt.Printf("Nothing expires within %s.\n", formatDays(period))
+			return
+		}
+		for _, entry := range found {
+			typeName := entry.Table
+			if t, ok := records.ByTable(entry.Table); ok {
+				typeName = t.Name
+			}
+			fmt.Printf("%-40s %-16s %s\n", formatExpiry(entry, now), typeName, entry.Title)
+		}
+	})
+}
+
+// expiryBanner warns about expired entries, entries expiring within the configured time
+// and passwords due for a change.
+func (c *Client) expiryBanner() {
+	if c.userID == 0 {
+		return
+	}
+	now := time.Now()
+	found, err := c.service.Expiring(c.ctx, c.userID, c.opt.ExpiryWarning, now)
+	if err != nil || len(found) == 0 {
+		return
+	}
+	expired := 0
+	for _, entry := range found {
+		if entry.Expired(now) {
+			expired++
+		}
+	}
+	var parts []string
+	if expired > 0 {
+		parts = append(parts, fmt.Sprintf("%d expired", expired))
+	}
+	if soon := len(found) - expired; soon > 0 {
+		parts = append(parts, fmt.Sprintf("%d expiring within %s", soon, formatDays(c.opt.ExpiryWarning)))
+	}
+	// The banner goes to the standard error, not to mix with the output of the command
+	fmt.Fprintf(os.Stderr, "Expiry warning: %s. Run 'gophkeeper expiring' for details.\n", strings.Join(parts, ", "))
+}
+
+// formatExpiry describes when an entry expires relative to now.
+func formatExpiry(entry services.ExpiringEntry, now time.Time) string {
+	date := entry.At.Local().Format(time.DateOnly)
+	if entry.Reason == services.ExpiryEntry {
+		// Entries expire at the end of their last day
+		date = entry.At.Local().AddDate(0, 0, -1).Format(time.DateOnly)
+	}
+	switch {
+	case entry.Reason == services.ExpiryPassword && entry.Expired(now):
+		return "password change due since " + date
+	case entry.Reason == services.ExpiryPassword:
+		return fmt.Sprintf("password change due in %s", formatDays(entry.At.Sub(now)))
+	case entry.Expired(now):
+		return "expired on " + date
+	default:
+		return fmt.Sprintf("expires on %s (%s)", date, formatDays(entry.At.Sub(now)))
+	}
+}
+
+// formatDays formats a duration in whole days, rounded up.
+func formatDays(d time.Duration) string {
+	days := int((d + config.Day - 1) / config.Day)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
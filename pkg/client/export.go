@@ -0,0 +1,230 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/exporter"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/kdbx"
+	"github.com/wurt83ow/gophkeeper-client/pkg/passstore"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// exportOptions are the flags of the export command.
+type exportOptions struct {
+	format string
+	cipher string
+	// unsafePlaintext confirms that the export may be written unencrypted.
+	unsafePlaintext bool
+	types           []string
+	filter          services.EntryFilter
+	// recipients are the files of the OpenPGP public keys a pass store is encrypted to.
+	recipients []string
+}
+
+// exportCommand returns the command exporting the vault to a file other applications can read.
+func (c *Client) exportCommand() *cobra.Command {
+	var opt exportOptions
+	cmd := &cobra.Command{
+		Use:   "export <file>",
+		Short: "Export the vault to an encrypted archive, a KeePass database, a pass store or a plaintext file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.exportFile(args[0], opt)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().StringVar(&opt.format, "format", "", "format of the file: "+strings.Join(exporter.Formats, ", ")+
+		" (default guessed from the file name, json otherwise)")
+	cmd.Flags().StringVar(&opt.cipher, "cipher", kdbx.AES256, "cipher of a KeePass database: aes256 or chacha20")
+	cmd.Flags().StringArrayVar(&opt.recipients, "recipient", nil, "file of the OpenPGP public key of a recipient of a pass store, may be repeated")
+	cmd.Flags().BoolVar(&opt.unsafePlaintext, "unsafe-plaintext", false, "write the secrets unencrypted, as required by csv")
+	cmd.Flags().StringSliceVar(&opt.types, "type", nil, "only entries of the types: "+strings.Join(records.TypeKeys(), ", "))
+	cmd.Flags().StringVar(&opt.filter.Folder, "folder", "", "only entries in the folder")
+	cmd.Flags().StringVar(&opt.filter.Tag, "tag", "", "only entries with the tag")
+	return cmd
+}
+
+// exportFile writes the entries of the vault selected by the options to path, encrypted
+// with a new password unless a plaintext export is confirmed.
+func (c *Client) exportFile(path string, opt exportOptions) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	format := opt.format
+	if format == "" {
+		format = exportFormat(path)
+	}
+	switch format {
+	case exporter.KDBX:
+		if opt.unsafePlaintext {
+			return errors.New("KeePass databases are always encrypted, --unsafe-plaintext cannot be used")
+		}
+		if opt.cipher != kdbx.AES256 && opt.cipher != kdbx.ChaCha20 {
+			return fmt.Errorf("unknown cipher %s, use aes256 or chacha20", opt.cipher)
+		}
+	case exporter.JSON:
+	case exporter.Pass:
+		if opt.unsafePlaintext {
+			return errors.New("password stores are always encrypted, --unsafe-plaintext cannot be used")
+		}
+		if len(opt.recipients) == 0 {
+			return errors.New("give the public keys the password store is encrypted to with --recipient")
+		}
+	case exporter.CSV:
+		if !opt.unsafePlaintext {
+			return errors.New("CSV exports are not encrypted, use --unsafe-plaintext to confirm")
+		}
+	default:
+		return fmt.Errorf("unknown export format %s, use %s", format, strings.Join(exporter.Formats, ", "))
+	}
+	var tables []string
+	for _, key := range opt.types {
+		t, ok := records.ByKey(strings.TrimSpace(key))
+		if !ok {
+			return fmt.Errorf("unknown type %s, use %s", key, strings.Join(records.TypeKeys(), ", "))
+		}
+		tables = append(tables, t.Table)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	recs, err := c.service.ExportRecords(c.ctx, c.userID, tables, opt.filter)
+	if errors.Is(err, services.ErrFolderNotFound) || errors.Is(err, services.ErrTagNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the vault: %w", err)
+	}
+	if len(recs) == 0 {
+		return errors.New("no entries to export")
+	}
+	if format == exporter.Pass {
+		return c.exportPassStore(path, recs, opt.recipients)
+	}
+
+	var write func(w io.Writer) error
+	if opt.unsafePlaintext {
+		fmt.Fprintln(os.Stderr, "Warning: the export is not encrypted, anyone who can read the file can read your secrets.")
+		now := time.Now()
+		write = func(w io.Writer) error {
+			if format == exporter.CSV {
+				return exporter.WriteCSV(w, recs, now)
+			}
+			return exporter.WritePlainJSON(w, recs, now)
+		}
+	} else {
+		if !c.tty {
+			return fmt.Errorf("%w, the password of the export is asked for", errTerminalRequired)
+		}
+		password := c.readPassword("Enter a password for the export: ")
+		if password == "" {
+			return errors.New("the password must not be empty")
+		}
+		if c.readPassword("Repeat the password: ") != password {
+			return errors.New("the passwords do not match")
+		}
+		write = func(w io.Writer) error {
+			if format == exporter.KDBX {
+				kopt := kdbx.DefaultOptions
+				kopt.Cipher = opt.cipher
+				return exporter.WriteKDBX(w, recs, password, kopt)
+			}
+			return exporter.WriteJSON(w, recs, password, time.Now())
+		}
+	}
+
+	// The file is created only once the export is written. Temporary files are only
+	// readable by the user.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gophkeeper-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create the file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	doc := exportOutput{Path: path, Format: format, Exported: len(recs), Counts: importCounts(recs), Skipped: []skippedItem{}}
+	return c.render(doc, func() {
+		fmt.Printf("Exported %d entries to %s.\n", len(recs), path)
+		printImportCounts(os.Stdout, recs)
+	})
+}
+
+// exportFormat guesses the format of an export from the extension of its file.
+func exportFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".kdbx":
+		return exporter.KDBX
+	case ".zip":
+		return exporter.CSV
+	}
+	return exporter.JSON
+}
+
+// exportPassStore writes records to a new pass store in dir, encrypted to the public keys
+// of the recipient files.
+func (c *Client) exportPassStore(dir string, recs []importer.Record, recipientFiles []string) error {
+	var recipients openpgp.EntityList
+	for _, name := range recipientFiles {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read the key: %w", err)
+		}
+		keys, err := passstore.ReadKeys(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		recipients = append(recipients, keys...)
+	}
+
+	// The store is moved in place only once all entries are written
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".gophkeeper-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create the store: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	skipped, err := exporter.WritePass(filepath.Join(tmp, "store"), recs, recipients)
+	if err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	if err := os.Rename(filepath.Join(tmp, "store"), dir); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	var exported []importer.Record
+	for _, rec := range recs {
+		if t, ok := records.ByTable(rec.Table); ok && !t.Binary {
+			exported = append(exported, rec)
+		}
+	}
+	doc := exportOutput{Path: dir, Format: exporter.Pass, Exported: len(exported), Counts: importCounts(exported), Skipped: []skippedItem{}}
+	for _, s := range skipped {
+		doc.Skipped = append(doc.Skipped, skippedItem{Source: s.Source, Title: s.Title, Reason: s.Reason})
+	}
+	return c.render(doc, func() {
+		fmt.Printf("Exported %d entries to the pass store %s.\n", len(exported), dir)
+		printImportCounts(os.Stdout, exported)
+		if len(skipped) > 0 {
+			fmt.Println("Not exported:")
+			for _, s := range skipped {
+				fmt.Printf("- %s: %s\n", s.Title, s.Reason)
+			}
+		}
+	})
+}
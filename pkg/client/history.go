@@ -0,0 +1,227 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// history lists the previous versions of an entry with the time they were replaced.
+func (c *Client) history(ref string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	t, id, versions, current, err := c.loadHistory(ref)
+	if err != nil {
+		return err
+	}
+	doc := historyOutput{ID: id, Type: t.Key, Versions: []versionItem{}}
+	for _, v := range versions {
+		doc.Versions = append(doc.Versions, versionItem{Version: v.Version, ReplacedAt: v.CreatedAt.Format(time.RFC3339), Summary: t.Summary(v.Data)})
+	}
+	return c.render(doc, func() {
+		if len(versions) == 0 {
+			fmt.Println("The entry has no previous versions.")
+			return
+		}
+		for _, v := range versions {
+			fmt.Printf("Version %d, replaced %s: %s\n", v.Version, v.CreatedAt.Local().Format("2006-01-02 15:04:05"), t.Summary(v.Data))
+		}
+		fmt.Printf("Current: %s\n", t.Summary(current))
+		fmt.Printf("Use 'diff %s <version>' to see what changed and 'rollback %s <version>' to restore a version.\n", id, id)
+	})
+}
+
+// diff shows which fields changed between a version of an entry and the version that
+// replaced it. Without a version, the latest change is shown. Sensitive values are masked.
+func (c *Client) diff(ref string, args []string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	t, id, versions, current, err := c.loadHistory(ref)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return errors.New("the entry has no previous versions")
+	}
+
+	i := len(versions) - 1
+	if len(args) > 0 {
+		if i, err = versionIndex(versions, args[0]); err != nil {
+			return err
+		}
+	}
+	doc := diffOutput{ID: id, FromVersion: versions[i].Version}
+	next, nextName := current, "current"
+	if i+1 < len(versions) {
+		next, nextName = versions[i+1].Data, fmt.Sprintf("version %d", versions[i+1].Version)
+		doc.ToVersion = &versions[i+1].Version
+	}
+
+	doc.Changes = nonNil(diffData(t, versions[i].Data, next))
+	return c.render(doc, func() {
+		fmt.Printf("Changes from version %d to %s:\n", versions[i].Version, nextName)
+		if len(doc.Changes) == 0 {
+			fmt.Println("No changes.")
+		}
+		for _, change := range doc.Changes {
+			fmt.Println(change)
+		}
+	})
+}
+
+// rollback restores a previous version of an entry, after confirmation unless yes is set.
+// Without a terminal to confirm on, yes is required.
+func (c *Client) rollback(ref, version string, yes bool) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	t, id, versions, current, err := c.loadHistory(ref)
+	if err != nil {
+		return err
+	}
+	i, err := versionIndex(versions, version)
+	if err != nil {
+		return err
+	}
+
+	doc := rollbackOutput{ID: id, Type: t.Key, Version: versions[i].Version, Changes: nonNil(diffData(t, current, versions[i].Data))}
+	if len(doc.Changes) == 0 {
+		return c.render(doc, func() {
+			fmt.Printf("Version %d is identical to the current values.\n", doc.Version)
+		})
+	}
+	if !yes {
+		if !c.tty {
+			return fmt.Errorf("%w, use --yes to restore without confirmation", errTerminalRequired)
+		}
+		fmt.Fprintf(c.rl.Stdout(), "Restoring version %d changes:\n%s\n", doc.Version, strings.Join(doc.Changes, "\n"))
+		c.rl.SetPrompt("Type 'yes' to confirm or 'no' to cancel: ")
+		line, _ := c.rl.Readline()
+		if strings.ToLower(strings.TrimSpace(line)) != "yes" {
+			if c.structured() {
+				return errors.New("rollback canceled")
+			}
+			fmt.Println("Rollback canceled.")
+			return nil
+		}
+	}
+
+	err = c.service.Rollback(c.ctx, t.Table, c.userID, id, doc.Version)
+	if err != nil {
+		return fmt.Errorf("failed to restore version: %w", err)
+	}
+	doc.Restored = true
+	return c.render(doc, func() {
+		fmt.Printf("Version %d restored. The replaced values are kept in the history.\n", doc.Version)
+	})
+}
+
+// loadHistory finds an entry and returns its type, id, previous versions and current values.
+func (c *Client) loadHistory(ref string) (*records.Type, string, []models.EntryVersion, map[string]string, error) {
+	t, id, err := c.findAnyEntry(ref)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	versions, err := c.service.GetHistory(c.ctx, t.Table, c.userID, id)
+	if err != nil {
+		return nil, "", nil, nil, fmt.Errorf("failed to get history: %w", err)
+	}
+	current, err := c.service.GetData(c.ctx, t.Table, c.userID, id)
+	if err != nil {
+		return nil, "", nil, nil, fmt.Errorf("failed to get data: %w", err)
+	}
+	return t, id, versions, current, nil
+}
+
+// versionIndex returns the index of the version given by the user.
+func versionIndex(versions []models.EntryVersion, arg string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", arg)
+	}
+	for i, v := range versions {
+		if v.Version == n {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %d", bdkeeper.ErrVersionNotFound, n)
+}
+
+// diffData describes the fields that differ between two versions of a record.
+// Values of sensitive fields and hidden custom fields are never shown, nor are
+// the ids of linked records.
+func diffData(t *records.Type, old, new map[string]string) []string {
+	keys := t.Keys(old)
+	for _, key := range t.Keys(new) {
+		if _, ok := old[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var changes []string
+	for _, key := range keys {
+		if key == "updated_at" || old[key] == new[key] {
+			continue
+		}
+		if key == records.CustomFieldsField {
+			changes = append(changes, diffCustomFields(old[key], new[key])...)
+			continue
+		}
+		label := key
+		f, ok := t.Field(key)
+		if ok {
+			label = f.Label
+		}
+		if ok && (f.Sensitive || f.Ref != "" || key == records.AttachmentsField) {
+			changes = append(changes, fmt.Sprintf("%s: changed", label))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", label, old[key], new[key]))
+	}
+	return changes
+}
+
+// diffCustomFields describes the custom fields added, removed or changed between two versions.
+func diffCustomFields(oldValue, newValue string) []string {
+	oldFields, err1 := records.ParseCustomFields(oldValue)
+	newFields, err2 := records.ParseCustomFields(newValue)
+	if err1 != nil || err2 != nil {
+		return []string{"custom fields: changed"}
+	}
+
+	byName := make(map[string]records.CustomField, len(oldFields))
+	for _, f := range oldFields {
+		byName[f.Name] = f
+	}
+	var changes []string
+	for _, f := range newFields {
+		o, ok := byName[f.Name]
+		delete(byName, f.Name)
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("custom field %s: added", f.Name))
+		case o == f:
+		case o.Type == records.CustomHidden || f.Type == records.CustomHidden:
+			changes = append(changes, fmt.Sprintf("custom field %s: changed", f.Name))
+		default:
+			changes = append(changes, fmt.Sprintf("custom field %s: %q -> %q", f.Name, o.Value, f.Value))
+		}
+	}
+	for _, f := range oldFields {
+		if _, ok := byName[f.Name]; ok {
+			changes = append(changes, fmt.Sprintf("custom field %s: removed", f.Name))
+		}
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "custom fields: reordered")
+	}
+	return changes
+}
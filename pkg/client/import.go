@@ -0,0 +1,195 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/passstore"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// importCommand returns the command importing the export of another password manager.
+func (c *Client) importCommand() *cobra.Command {
+	var format, keyFile string
+	var dryRun, keepDuplicates, yes bool
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import the export of Bitwarden, 1Password, LastPass, Chrome, Firefox, KeePass or pass",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.importFile(args[0], format, keyFile, dryRun, keepDuplicates, yes)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().StringVar(&format, "format", "", "format of the file: "+strings.Join(importer.Formats, ", ")+" (default guessed from the file)")
+	cmd.Flags().StringVar(&keyFile, "key", "", "file of the OpenPGP secret key decrypting a pass store")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show what would be imported")
+	cmd.Flags().BoolVar(&keepDuplicates, "keep-duplicates", false, "also import entries already in the vault")
+	cmd.Flags().BoolVar(&yes, "yes", false, "import without asking for confirmation")
+	return cmd
+}
+
+// importFile reads an export, shows what it contains and imports it, after confirmation
+// unless yes is set. Directories are read as pass stores.
+func (c *Client) importFile(path, format, keyFile string, dryRun, keepDuplicates, yes bool) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	var result *importer.Result
+	if info, err := os.Stat(path); (err == nil && info.IsDir()) || format == importer.Pass {
+		format = importer.Pass
+		result, err = c.readPassStore(path, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the pass store: %w", err)
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read the file: %w", err)
+		}
+		if format == "" {
+			if format, err = importer.Detect(path, data); err != nil {
+				return err
+			}
+		}
+		result, err = importer.Parse(format, data, importer.Options{
+			Password: func() (string, error) {
+				return c.readPassword("Enter the password of the export: "), nil
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read the %s export: %w", format, err)
+		}
+	}
+	plan, err := c.service.PlanImport(c.ctx, c.userID, result)
+	if err != nil {
+		return fmt.Errorf("failed to check the export: %w", err)
+	}
+
+	recs := plan.Records
+	if keepDuplicates {
+		recs = append(recs, plan.Duplicates...)
+	}
+	doc := importOutput{Format: format, DryRun: dryRun, Counts: importCounts(recs),
+		Duplicates: []importItem{}, Skipped: []skippedItem{}}
+	for _, rec := range plan.Duplicates {
+		doc.Duplicates = append(doc.Duplicates, importItem{Source: rec.Source, Title: rec.Title(), Type: typeKey(rec.Table)})
+	}
+	for _, s := range plan.Skipped {
+		doc.Skipped = append(doc.Skipped, skippedItem{Source: s.Source, Title: s.Title, Reason: s.Reason})
+	}
+
+	// The content is shown before the confirmation, and in the table format
+	confirm := !dryRun && !yes && len(recs) > 0
+	if confirm && !c.tty {
+		return fmt.Errorf("%w, use --yes to import without confirmation or --dry-run", errTerminalRequired)
+	}
+	if confirm || !c.structured() {
+		printImportPlan(c.rl.Stdout(), format, plan, recs, keepDuplicates)
+	}
+	if dryRun || len(recs) == 0 {
+		return c.render(doc, func() {})
+	}
+
+	if confirm {
+		c.rl.SetPrompt(fmt.Sprintf("Import %d entries? (yes/no): ", len(recs)))
+		choice, _ := c.rl.Readline()
+		if strings.ToLower(choice) != "yes" && strings.ToLower(choice) != "y" {
+			if c.structured() {
+				return errors.New("nothing was imported")
+			}
+			fmt.Println("Nothing was imported.")
+			return nil
+		}
+	}
+	n, err := c.service.Import(c.ctx, c.userID, recs)
+	if err != nil {
+		return fmt.Errorf("failed to import, nothing was imported: %w", err)
+	}
+	doc.Imported = n
+	return c.render(doc, func() {
+		fmt.Printf("Imported %d entries.\n", n)
+	})
+}
+
+// printImportPlan prints the content of an export: the entries to import by type, the
+// duplicates and the items that cannot be imported.
+func printImportPlan(w io.Writer, format string, plan *services.ImportPlan, recs []importer.Record, keepDuplicates bool) {
+	fmt.Fprintf(w, "Read a %s export: %d new entries, %d duplicates, %d skipped.\n",
+		format, len(plan.Records), len(plan.Duplicates), len(plan.Skipped))
+	printImportCounts(w, recs)
+	if len(plan.Duplicates) > 0 {
+		if keepDuplicates {
+			fmt.Fprintln(w, "Duplicates of entries in the vault, imported as well:")
+		} else {
+			fmt.Fprintln(w, "Duplicates of entries in the vault, not imported (use --keep-duplicates to import them):")
+		}
+		for _, rec := range plan.Duplicates {
+			fmt.Fprintf(w, "- %s: %s (%s)\n", rec.Source, rec.Title(), typeName(rec.Table))
+		}
+	}
+	if len(plan.Skipped) > 0 {
+		fmt.Fprintln(w, "Skipped:")
+		for _, s := range plan.Skipped {
+			fmt.Fprintf(w, "- %s: %s: %s\n", s.Source, s.Title, s.Reason)
+		}
+	}
+}
+
+// readPassStore reads a pass store with the secret key of keyFile, asking for its
+// passphrase if it is protected.
+func (c *Client) readPassStore(dir, keyFile string) (*importer.Result, error) {
+	if keyFile == "" {
+		return nil, errors.New("give the file of the OpenPGP secret key with --key, exported by gpg --export-secret-keys")
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := passstore.ReadKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return importer.ParsePass(dir, importer.Options{
+		Keys: keys,
+		Password: func() (string, error) {
+			return c.readPassword("Enter the passphrase of the secret key: "), nil
+		},
+	})
+}
+
+// printImportCounts prints the number of records of each type to import.
+func printImportCounts(w io.Writer, recs []importer.Record) {
+	counts := make(map[string]int)
+	for _, rec := range recs {
+		counts[rec.Table]++
+	}
+	for _, table := range records.Tables() {
+		if counts[table] > 0 {
+			fmt.Fprintf(w, "  %-20s %d\n", typeName(table), counts[table])
+		}
+	}
+}
+
+// importCounts returns the number of records of each type, by type key.
+func importCounts(recs []importer.Record) map[string]int {
+	counts := make(map[string]int)
+	for _, rec := range recs {
+		counts[typeKey(rec.Table)]++
+	}
+	return counts
+}
+
+// typeName returns the name of the record type of a table.
+func typeName(table string) string {
+	if t, ok := records.ByTable(table); ok {
+		return t.Name
+	}
+	return table
+}
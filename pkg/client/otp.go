@@ -0,0 +1,246 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// errEntryNotFound is returned when no entry matches the title or id given by the user.
+var errEntryNotFound = errors.New("entry not found")
+
+// otp prints the current code of a TOTP entry and the seconds it remains valid.
+// The entry is given by its title or id, or by the title of a login/password entry
+// linked to it. Without an argument the user chooses the entry from a list.
+func (c *Client) otp(args []string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	t, _ := records.ByTable(records.TOTPTable)
+
+	var data map[string]string
+	var err error
+	if len(args) == 0 {
+		if err := c.canPrompt("give the title or id of the entry"); err != nil {
+			return err
+		}
+		data, err = c.chooseEntry(t.Table)
+	} else {
+		data, err = c.findTOTP(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	code, remaining, err := t.Code(data, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+	doc := otpOutput{Title: data[records.TitleField], codeOutput: codeOutput{Code: code, RemainingSeconds: int(remaining.Seconds())}}
+	return c.render(doc, func() {
+		fmt.Printf("%s (%d seconds remaining)\n", doc.Code, doc.RemainingSeconds)
+	})
+}
+
+// findTOTP returns the TOTP entry with the given title or id. If there is none,
+// it looks for a login/password entry with that title and follows its link.
+func (c *Client) findTOTP(ref string) (map[string]string, error) {
+	data, err := c.findEntry(records.TOTPTable, ref)
+	if !errors.Is(err, errEntryNotFound) {
+		return data, err
+	}
+	creds, err := c.findEntry(records.CredentialsTable, ref)
+	if err != nil {
+		return nil, err
+	}
+	if creds["totp_id"] == "" {
+		return nil, fmt.Errorf("entry %q has no linked TOTP authenticator", ref)
+	}
+	return c.service.GetData(c.ctx, records.TOTPTable, c.userID, creds["totp_id"])
+}
+
+// findEntry returns the entry of the table whose id or title matches ref.
+func (c *Client) findEntry(table, ref string) (map[string]string, error) {
+	id, err := c.findEntryID(table, ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.service.GetData(c.ctx, table, c.userID, id)
+}
+
+// findEntryID returns the id of the entry of the table whose id or title matches ref.
+func (c *Client) findEntryID(table, ref string) (string, error) {
+	matches, err := c.matchEntries(table, ref)
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", errEntryNotFound, ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%d entries are titled %q, use the entry id instead", len(matches), ref)
+	}
+}
+
+// matchEntries returns the ids of the entries of the table whose id or title matches ref.
+// An id match is exact and takes precedence; titles are compared case-insensitively.
+func (c *Client) matchEntries(table, ref string) ([]string, error) {
+	entries, err := c.service.GetAllData(c.ctx, table, c.userID, "id", "meta_info")
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, entry := range entries {
+		if entry["id"] == ref {
+			return []string{entry["id"]}, nil
+		}
+		if strings.EqualFold(entry[records.TitleField], ref) {
+			matches = append(matches, entry["id"])
+		}
+	}
+	return matches, nil
+}
+
+// findAnyEntry returns the record type and id of the entry of any type whose id or title matches ref.
+func (c *Client) findAnyEntry(ref string) (*records.Type, string, error) {
+	var (
+		found     *records.Type
+		foundID   string
+		foundMany bool
+	)
+	for _, t := range records.All() {
+		matches, err := c.matchEntries(t.Table, ref)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if found != nil || len(matches) > 1 {
+			foundMany = true
+		}
+		found, foundID = t, matches[0]
+	}
+	switch {
+	case found == nil:
+		return nil, "", fmt.Errorf("%w: %s", errEntryNotFound, ref)
+	case foundMany:
+		return nil, "", fmt.Errorf("several entries are titled %q, use the entry id instead", ref)
+	}
+	return found, foundID, nil
+}
+
+// chooseEntry lists the entries of the table and returns the one chosen by the user.
+func (c *Client) chooseEntry(table string) (map[string]string, error) {
+	entries, err := c.service.GetAllData(c.ctx, table, c.userID, "id", "meta_info")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no entries found in the table: %s", table)
+	}
+	c.printAllData(entries)
+	row, err := getRowFromUserInput(entries, c.rl)
+	if err != nil {
+		return nil, err
+	}
+	return c.service.GetData(c.ctx, table, c.userID, row["id"])
+}
+
+// importRecord offers to create a record from a single line, such as an otpauth:// URI,
+// for types supporting it. It returns nil if the user chooses to enter the fields manually.
+func (c *Client) importRecord(t *records.Type) map[string]string {
+	if t.Import == nil {
+		return nil
+	}
+	for {
+		c.rl.SetPrompt(t.ImportPrompt)
+		line, err := c.rl.Readline()
+		if err != nil || strings.TrimSpace(line) == "" {
+			return nil
+		}
+		data, err := t.Import(line)
+		if err != nil {
+			fmt.Printf("Failed to import: %s!\n", err)
+			continue
+		}
+		return data
+	}
+}
+
+// readRef lets the user link the record to an entry of the table referenced by the field.
+// An empty input keeps the current link and "-" removes it.
+func (c *Client) readRef(f records.Field, old string) string {
+	entries, err := c.service.GetAllData(c.ctx, f.Ref, c.userID, "id", "meta_info")
+	if err != nil || len(entries) == 0 {
+		return old
+	}
+
+	fmt.Printf("Link a %s? Available entries:\n", f.Label)
+	c.printAllData(entries)
+	prompt := "Enter the number of the entry to link (press Enter to skip): "
+	if old != "" {
+		prompt = "Enter the number of the entry to link (press Enter to keep the current link, '-' to unlink): "
+	}
+	for {
+		c.rl.SetPrompt(prompt)
+		line, err := c.rl.Readline()
+		if err != nil {
+			return old
+		}
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			return old
+		case clearInput:
+			return ""
+		}
+		num, err := strconv.Atoi(line)
+		if err != nil || num < 1 || num > len(entries) {
+			fmt.Println("Invalid input. Please enter a valid number.")
+			continue
+		}
+		return entries[num-1]["id"]
+	}
+}
+
+// printRef prints the title of a linked record, and its current code if its type generates codes.
+func (c *Client) printRef(f records.Field, id string) {
+	if id == "" {
+		return
+	}
+	data, err := c.service.GetData(c.ctx, f.Ref, c.userID, id)
+	if err != nil {
+		fmt.Printf("%s: linked entry not found\n", f.Label)
+		return
+	}
+	fmt.Printf("%s: %s\n", f.Label, data[records.TitleField])
+	if t, ok := records.ByTable(f.Ref); ok && t.Code != nil {
+		printCode(t, data)
+	}
+}
+
+// printCode prints the current code of a record.
+func printCode(t *records.Type, data map[string]string) {
+	code, err := formatCode(t, data)
+	if err != nil {
+		fmt.Printf("code: %s\n", err)
+		return
+	}
+	fmt.Printf("code: %s\n", code)
+}
+
+// formatCode returns the current code of a record together with the seconds it remains valid.
+func formatCode(t *records.Type, data map[string]string) (string, error) {
+	code, remaining, err := t.Code(data, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%d seconds remaining)", code, int(remaining.Seconds())), nil
+}
@@ -0,0 +1,438 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the --output flag. The json and yaml formats print the results of a command
+// as a single document with the schema documented in the README; the table format is
+// meant for people.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// outputAnnotation marks the commands printing their results in the json and yaml
+// formats. The other commands prompt or report progress, and refuse these formats.
+const outputAnnotation = "output"
+
+// structuredOutput is the annotation of the commands supporting the json and yaml formats.
+var structuredOutput = map[string]string{outputAnnotation: "structured"}
+
+// Codes of the structured errors.
+const (
+	codeError       = "error"
+	codeNotLoggedIn = "not_logged_in"
+	codeNotFound    = "not_found"
+	codeTerminal    = "terminal_required"
+	codeUnsupported = "unsupported_output"
+)
+
+// Errors with their own code in the json and yaml formats.
+var (
+	errTerminalRequired  = errors.New("the standard input is not a terminal")
+	errUnsupportedOutput = errors.New("the output format is not supported")
+)
+
+// errorOutput is the document printed for a failed command in the json and yaml formats.
+type errorOutput struct {
+	Error struct {
+		Code    string `json:"code"`    // Code is one of the codes above, for scripts to test.
+		Message string `json:"message"` // Message describes the error for people.
+	} `json:"error"`
+}
+
+// entryOutput is an entry printed by get.
+type entryOutput struct {
+	ID     string   `json:"id"`
+	Type   string   `json:"type"`
+	Title  string   `json:"title"`
+	Folder string   `json:"folder"`
+	Tags   []string `json:"tags"`
+	// Fields holds the other fields of the record type by name, links to other entries
+	// by id.
+	Fields       map[string]string     `json:"fields"`
+	CustomFields []records.CustomField `json:"custom_fields"`
+	Attachments  []string              `json:"attachments"` // Attachments are the ids of the attached files.
+	Code         *codeOutput           `json:"code,omitempty"`
+}
+
+// codeOutput is the current code of a TOTP entry.
+type codeOutput struct {
+	Code             string `json:"code"`
+	RemainingSeconds int    `json:"remaining_seconds"`
+}
+
+// listOutput is the document printed by ls.
+type listOutput struct {
+	Entries []listItem `json:"entries"`
+}
+
+// listItem is an entry listed by ls. Times are in RFC 3339 format, empty if unknown.
+type listItem struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	UpdatedAt string `json:"updated_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+// fieldOutput is the document printed by get --field.
+type fieldOutput struct {
+	ID    string `json:"id"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// copyOutput is the document printed by get --copy. ClearAfterSeconds is 0 when the
+// clipboard is not cleared; Entry holds the other fields with --reveal.
+type copyOutput struct {
+	ID                string       `json:"id"`
+	Field             string       `json:"field"`
+	Clipboard         string       `json:"clipboard"`
+	ClearAfterSeconds int          `json:"clear_after_seconds"`
+	Entry             *entryOutput `json:"entry,omitempty"`
+}
+
+// addOutput is the document printed by add.
+type addOutput struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+// deleteOutput is the document printed by rm.
+type deleteOutput struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Deleted bool   `json:"deleted"`
+}
+
+// entryTagsOutput is the document printed by tag add|remove, with the tags of the entry.
+type entryTagsOutput struct {
+	ID   string   `json:"id"`
+	Type string   `json:"type"`
+	Tags []string `json:"tags"`
+}
+
+// searchOutput is the document printed by search.
+type searchOutput struct {
+	Results []searchItem `json:"results"`
+}
+
+// searchItem is an entry found by search, with the names of the matching fields.
+type searchItem struct {
+	ID      string   `json:"id"`
+	Type    string   `json:"type"`
+	Title   string   `json:"title"`
+	Matched []string `json:"matched"`
+}
+
+// expiringOutput is the document printed by expiring.
+type expiringOutput struct {
+	Entries []expiringItem `json:"entries"`
+}
+
+// expiringItem is an entry found by expiring. Reason is "expires" for entries expiring
+// themselves and "password" for passwords due for a change; At is in RFC 3339 format.
+type expiringItem struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Reason  string `json:"reason"`
+	At      string `json:"at"`
+	Expired bool   `json:"expired"`
+}
+
+// otpOutput is the document printed by otp.
+type otpOutput struct {
+	Title string `json:"title"`
+	codeOutput
+}
+
+// tagsOutput is the document printed by tag ls.
+type tagsOutput struct {
+	Tags []nameCount `json:"tags"`
+}
+
+// foldersOutput is the document printed by folder ls.
+type foldersOutput struct {
+	Folders []nameCount `json:"folders"`
+}
+
+// nameCount is a tag or folder with the number of entries having it.
+type nameCount struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// nameOutput is the document printed by tag rename and folder add|rename.
+type nameOutput struct {
+	Name string `json:"name"`
+}
+
+// folderDeleteOutput is the document printed by folder remove, with the number of entries
+// moved out of the folder.
+type folderDeleteOutput struct {
+	Name         string `json:"name"`
+	Deleted      bool   `json:"deleted"`
+	MovedEntries int    `json:"moved_entries"`
+}
+
+// historyOutput is the document printed by history.
+type historyOutput struct {
+	ID       string        `json:"id"`
+	Type     string        `json:"type"`
+	Versions []versionItem `json:"versions"`
+}
+
+// versionItem is a previous version of an entry. Summary leaves out sensitive fields.
+type versionItem struct {
+	Version    int    `json:"version"`
+	ReplacedAt string `json:"replaced_at"`
+	Summary    string `json:"summary"`
+}
+
+// diffOutput is the document printed by diff. ToVersion is null for the current version.
+type diffOutput struct {
+	ID          string   `json:"id"`
+	FromVersion int      `json:"from_version"`
+	ToVersion   *int     `json:"to_version"`
+	Changes     []string `json:"changes"`
+}
+
+// rollbackOutput is the document printed by rollback. Restored is false when the version
+// is identical to the current values.
+type rollbackOutput struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	Version  int      `json:"version"`
+	Restored bool     `json:"restored"`
+	Changes  []string `json:"changes"`
+}
+
+// migrationsOutput is the document printed by db migrate status.
+type migrationsOutput struct {
+	Migrations []migrationItem `json:"migrations"`
+}
+
+// migrationItem is the state of a migration. Unknown is set for applied migrations
+// unknown to this version.
+type migrationItem struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"applied_at"`
+	Modified  bool   `json:"modified"`
+	Unknown   bool   `json:"unknown"`
+}
+
+// migrateUpOutput is the document printed by db migrate up.
+type migrateUpOutput struct {
+	Applied []string `json:"applied"`
+}
+
+// migrateDownOutput is the document printed by db migrate down.
+type migrateDownOutput struct {
+	RolledBack []string `json:"rolled_back"`
+}
+
+// backupOutput is the document printed by backup create and backup verify. Missing lists
+// the files that were missing locally when the backup was made.
+type backupOutput struct {
+	Path      string       `json:"path"`
+	CreatedAt string       `json:"created_at"`
+	Files     []backupFile `json:"files"`
+	Missing   []string     `json:"missing"`
+}
+
+// backupFile is a file in a backup.
+type backupFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// restoreOutput is the document printed by backup restore, with the directory of the
+// restored profile and the file storage path to start GophKeeper with.
+type restoreOutput struct {
+	backupOutput
+	Dir             string `json:"dir"`
+	FileStoragePath string `json:"file_storage_path"`
+}
+
+// importOutput is the document printed by import. Counts are the numbers of entries
+// imported, or to import with --dry-run, by type.
+type importOutput struct {
+	Format     string         `json:"format"`
+	DryRun     bool           `json:"dry_run"`
+	Counts     map[string]int `json:"counts"`
+	Duplicates []importItem   `json:"duplicates"`
+	Skipped    []skippedItem  `json:"skipped"`
+	Imported   int            `json:"imported"`
+}
+
+// importItem is an entry of an export duplicating an entry of the vault.
+type importItem struct {
+	Source string `json:"source"`
+	Title  string `json:"title"`
+	Type   string `json:"type"`
+}
+
+// skippedItem is an entry that cannot be imported or exported.
+type skippedItem struct {
+	Source string `json:"source"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// exportOutput is the document printed by export, with the numbers of entries exported
+// by type.
+type exportOutput struct {
+	Path     string         `json:"path"`
+	Format   string         `json:"format"`
+	Exported int            `json:"exported"`
+	Counts   map[string]int `json:"counts"`
+	Skipped  []skippedItem  `json:"skipped"`
+}
+
+// sessionsOutput is the document printed by account sessions.
+type sessionsOutput struct {
+	Sessions []sessionItem `json:"sessions"`
+}
+
+// sessionItem is a session of the account on the server. Times are in RFC 3339 format,
+// empty if unknown.
+type sessionItem struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	SignedInAt string `json:"signed_in_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	Current    bool   `json:"current"`
+}
+
+// checkOutput validates the --output flag for a command.
+func (c *Client) checkOutput(cmd *cobra.Command) error {
+	switch c.output {
+	case outputTable:
+		return nil
+	case outputJSON, outputYAML:
+	default:
+		return fmt.Errorf("invalid output format %s, use table, json or yaml", c.output)
+	}
+	for p := cmd; p != nil; p = p.Parent() {
+		if p.Annotations[outputAnnotation] != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s prints no %s", errUnsupportedOutput, cmd.CommandPath(), c.output)
+}
+
+// structured reports whether the results are printed in the json or yaml format.
+func (c *Client) structured() bool {
+	return c.output == outputJSON || c.output == outputYAML
+}
+
+// render prints v in the json or yaml format, or calls table for the table format.
+func (c *Client) render(v any, table func()) error {
+	if !c.structured() {
+		table()
+		return nil
+	}
+	return writeDocument(os.Stdout, c.output, v)
+}
+
+// renderError prints a failed command in the output format: to the standard output as a
+// document in the json and yaml formats, to the standard error otherwise.
+func (c *Client) renderError(err error) {
+	if !c.structured() {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+	var doc errorOutput
+	doc.Error.Code = errorCode(err)
+	doc.Error.Message = err.Error()
+	if err := writeDocument(os.Stdout, c.output, doc); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+}
+
+// errorCode returns the code of a structured error.
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, errNotLoggedIn):
+		return codeNotLoggedIn
+	case errors.Is(err, errEntryNotFound), errors.Is(err, services.ErrFolderNotFound),
+		errors.Is(err, services.ErrTagNotFound), errors.Is(err, bdkeeper.ErrVersionNotFound):
+		return codeNotFound
+	case errors.Is(err, errTerminalRequired):
+		return codeTerminal
+	case errors.Is(err, errUnsupportedOutput):
+		return codeUnsupported
+	}
+	return codeError
+}
+
+// writeDocument writes v as an indented JSON document, or as a YAML document with the
+// keys in the same order.
+func writeDocument(w io.Writer, format string, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if format == outputJSON {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	// JSON is YAML in flow style; clearing the styles gives the usual block style
+	var node yaml.Node
+	if err := yaml.Unmarshal(buf.Bytes(), &node); err != nil {
+		return err
+	}
+	clearStyle(&node)
+	ye := yaml.NewEncoder(w)
+	ye.SetIndent(2)
+	if err := ye.Encode(&node); err != nil {
+		return err
+	}
+	return ye.Close()
+}
+
+// clearStyle resets the style of a YAML node and its children.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// nonNil returns an empty slice for nil, for lists to be printed as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// typeKey returns the key of the record type of a table, or the table if it is unknown.
+func typeKey(table string) string {
+	if t, ok := records.ByTable(table); ok {
+		return t.Key
+	}
+	return table
+}
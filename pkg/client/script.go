@@ -0,0 +1,639 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// errNotLoggedIn is returned by the commands needing a logged in user.
+var errNotLoggedIn = errors.New("please log in or register")
+
+// lsCommand returns the command listing entries. Given a type, such as "ls logins", it
+// prints the id and title of all entries without prompting. Otherwise the user chooses
+// the type from a menu and pages through the entries.
+func (c *Client) lsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "ls [type]",
+		Aliases: []string{"list"},
+		Short:   "List the entries of a type: " + strings.Join(records.TypeKeys(), ", "),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return c.prompting(c.list, "give the type of the entries, such as 'ls logins'")
+			}
+			t, err := recordType(args[0])
+			if err != nil {
+				return err
+			}
+			return c.listAll(t)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().StringVar(&c.order.Sort, "sort", services.SortTitle, "sort by title, updated or created")
+	cmd.Flags().IntVar(&c.order.Limit, "limit", 20, "number of entries per page when paging, 0 for all")
+	cmd.Flags().BoolVar(&c.order.Reverse, "reverse", false, "list in reverse order")
+	c.filterFlags(cmd)
+	return cmd
+}
+
+// getOptions are the flags of the get command.
+type getOptions struct {
+	field  string
+	save   string
+	copy   bool
+	reveal bool
+}
+
+// getCommand returns the command printing an entry. Given a type and an entry, such as
+// "get login GitHub --field password", it prints the entry, or the raw value of one
+// field, without prompting. Files are written with --save, and fields copied to the
+// clipboard with --copy.
+func (c *Client) getCommand() *cobra.Command {
+	var opt getOptions
+	cmd := &cobra.Command{
+		Use:   "get [type] [title|id]",
+		Short: "Print an entry, one of its fields or its file",
+		Args:  cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return c.prompting(c.getData, "give the type and the title or id of the entry, such as 'get login GitHub'")
+			}
+			if len(args) == 1 {
+				return errors.New("give the title or id of the entry after its type")
+			}
+			t, err := recordType(args[0])
+			if err != nil {
+				return err
+			}
+			return c.getEntry(t, args[1], opt)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().StringVar(&opt.field, "field", "", "only print the value of the field, by name or label")
+	cmd.Flags().StringVar(&opt.save, "save", "", "write the file of a binary data entry to the path, - for the standard output")
+	cmd.Flags().BoolVar(&opt.copy, "copy", false, "copy the field, or the password or other secret, to the clipboard instead of printing it; "+
+		"it is cleared after -clipboardTimeout unless changed; with OSC 52 only if -clipboardForceClear is set")
+	cmd.Flags().BoolVar(&opt.reveal, "reveal", false, "with --copy, also print the other fields of the entry")
+	c.filterFlags(cmd)
+	return cmd
+}
+
+// addOptions are the flags of the add command.
+type addOptions struct {
+	title    string
+	set      []string // set holds name=value pairs.
+	fromFile string
+	folder   string
+	tags     []string
+}
+
+// addCommand returns the command adding an entry. Given a type, such as
+// "add text --title X --from-file notes.md", it takes the fields from the flags and
+// the secrets piped to the standard input, and prints the id of the new entry.
+// Otherwise the user chooses the type from a menu and is prompted for the fields.
+func (c *Client) addCommand() *cobra.Command {
+	var opt addOptions
+	cmd := &cobra.Command{
+		Use:   "add [type]",
+		Short: "Add an entry",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return c.prompting(c.addData, "give the type of the entry, such as 'add text --title X --from-file notes.md'")
+			}
+			t, err := recordType(args[0])
+			if err != nil {
+				return err
+			}
+			return c.addEntry(t, opt)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().StringVar(&opt.title, "title", "", "title of the entry (meta-information)")
+	cmd.Flags().StringArrayVar(&opt.set, "set", nil, "value of a field as name=value, may be repeated")
+	cmd.Flags().StringVar(&opt.fromFile, "from-file", "", "read the text, the file or the SSH private key of the entry from a file")
+	cmd.Flags().StringVar(&opt.folder, "folder", "", "folder of the entry")
+	cmd.Flags().StringArrayVar(&opt.tags, "tag", nil, "tag of the entry, may be repeated")
+	return cmd
+}
+
+// rmCommand returns the command deleting an entry. Given a type and an entry, such as
+// "rm card <id> --yes", it deletes the entry, asking for confirmation unless --yes is
+// given. Otherwise the user chooses the type and the entry from lists.
+func (c *Client) rmCommand() *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:     "rm [type] [title|id]",
+		Aliases: []string{"remove"},
+		Short:   "Delete an entry",
+		Args:    cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return c.prompting(c.DeleteData, "give the type and the title or id of the entry, such as 'rm card <id> --yes'")
+			}
+			if len(args) == 1 {
+				return errors.New("give the title or id of the entry after its type")
+			}
+			t, err := recordType(args[0])
+			if err != nil {
+				return err
+			}
+			return c.removeEntry(t, args[1], yes)
+		},
+		Annotations: structuredOutput,
+	}
+	cmd.Flags().BoolVar(&yes, "yes", false, "delete without asking for confirmation")
+	c.filterFlags(cmd)
+	return cmd
+}
+
+// filterFlags adds the flags filtering the entries listed by a command.
+func (c *Client) filterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&c.filter.Folder, "folder", "", "only entries in the folder")
+	cmd.Flags().StringVar(&c.filter.Tag, "tag", "", "only entries with the tag")
+}
+
+// prompting runs an interactive command if the user can be prompted.
+func (c *Client) prompting(run func(), hint string) error {
+	if err := c.canPrompt(hint); err != nil {
+		return err
+	}
+	run()
+	return nil
+}
+
+// canPrompt returns an error with the hint if the standard input is not a terminal to
+// prompt on or the output format is not for people.
+func (c *Client) canPrompt(hint string) error {
+	if !c.tty {
+		return fmt.Errorf("%w, %s", errTerminalRequired, hint)
+	}
+	if c.structured() {
+		return fmt.Errorf("%w, %s", errUnsupportedOutput, hint)
+	}
+	return nil
+}
+
+// recordType returns the record type with the key given on the command line.
+func recordType(key string) (*records.Type, error) {
+	t, ok := records.ByKey(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s, use one of %s", key, strings.Join(records.TypeKeys(), ", "))
+	}
+	return t, nil
+}
+
+// listAll prints the id and title of all entries of a type matching the filter, separated
+// by a tab, in the order given by the flags.
+func (c *Client) listAll(t *records.Type) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	opt := c.order
+	opt.Filter = c.filter
+	var entries []map[string]string
+	for {
+		page, err := c.service.List(c.ctx, t.Table, c.userID, opt)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, page.Entries...)
+		if page.Next == "" {
+			break
+		}
+		opt.Cursor = page.Next
+	}
+
+	doc := listOutput{Entries: []listItem{}}
+	for _, entry := range entries {
+		doc.Entries = append(doc.Entries, listItem{ID: entry["id"], Type: t.Key, Title: entry[records.TitleField],
+			UpdatedAt: entry["updated_at"], CreatedAt: entry["created_at"]})
+	}
+	return c.render(doc, func() {
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s%s\n", entry["id"], entry[records.TitleField], formatListTime(entry, opt.Sort))
+		}
+	})
+}
+
+// getEntry prints the entry of a type with the given title or id. With a field, only its
+// value is printed; with a save path, the file of a binary data entry is written; with
+// copy, the field is copied to the clipboard.
+func (c *Client) getEntry(t *records.Type, ref string, opt getOptions) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	id, err := c.findEntryID(t.Table, ref)
+	if err != nil {
+		return err
+	}
+	data, err := c.service.GetData(c.ctx, t.Table, c.userID, id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case opt.save != "":
+		if !t.Binary {
+			return fmt.Errorf("%s entries have no file, --save is for binary data", t.Key)
+		}
+		if opt.save == "-" && c.structured() {
+			return fmt.Errorf("%w, save the file to a path", errUnsupportedOutput)
+		}
+		return c.saveFile(data, opt.save)
+	case opt.copy:
+		return c.copyField(t, id, data, opt.field, opt.reveal)
+	case opt.reveal:
+		return errors.New("--reveal is only used with --copy, the fields are printed without it")
+	case opt.field != "":
+		value, err := c.fieldValue(t, id, data, opt.field)
+		if err != nil {
+			return err
+		}
+		return c.render(fieldOutput{ID: id, Field: opt.field, Value: value}, func() {
+			fmt.Println(value)
+		})
+	}
+	doc, err := c.entryDocument(t, id, data)
+	if err != nil {
+		return err
+	}
+	return c.render(doc, func() {
+		fmt.Printf("id: %s\n", id)
+		c.printData(t, data)
+		c.printTags(t.Table, id)
+	})
+}
+
+// entryDocument returns an entry in the json and yaml formats.
+func (c *Client) entryDocument(t *records.Type, id string, data map[string]string) (entryOutput, error) {
+	doc := entryOutput{ID: id, Type: t.Key, Title: data[records.TitleField], Fields: map[string]string{}}
+	for name, value := range data {
+		switch name {
+		case records.TitleField, records.CustomFieldsField, records.AttachmentsField, records.FolderField:
+		default:
+			doc.Fields[name] = value
+		}
+	}
+	if folderID := data[records.FolderField]; folderID != "" {
+		if folder, err := c.service.GetData(c.ctx, records.FoldersTable, c.userID, folderID); err == nil {
+			doc.Folder = folder[records.TitleField]
+		}
+	}
+	tags, err := c.service.EntryTags(c.ctx, t.Table, c.userID, id)
+	if err != nil {
+		return doc, err
+	}
+	doc.Tags = nonNil(tags)
+	custom, err := records.ParseCustomFields(data[records.CustomFieldsField])
+	if err != nil {
+		return doc, err
+	}
+	doc.CustomFields = nonNil(custom)
+	attachments, err := records.ParseAttachments(data[records.AttachmentsField])
+	if err != nil {
+		return doc, err
+	}
+	doc.Attachments = nonNil(attachments)
+	if t.Code != nil {
+		code, remaining, err := t.Code(data, time.Now())
+		if err != nil {
+			return doc, err
+		}
+		doc.Code = &codeOutput{Code: code, RemainingSeconds: int(remaining.Seconds())}
+	}
+	return doc, nil
+}
+
+// fieldValue returns the value of a field of an entry, given by name or label. The id,
+// custom fields by name and the current code of types generating codes can be asked for
+// as well. Linked entries are given by id.
+func (c *Client) fieldValue(t *records.Type, id string, data map[string]string, name string) (string, error) {
+	if strings.EqualFold(name, "id") {
+		return id, nil
+	}
+	if strings.EqualFold(name, "code") && t.Code != nil {
+		code, _, err := t.Code(data, time.Now())
+		return code, err
+	}
+	for _, f := range t.Fields {
+		if strings.EqualFold(name, f.Name) || strings.EqualFold(name, f.Label) {
+			return data[f.Name], nil
+		}
+	}
+	custom, err := records.ParseCustomFields(data[records.CustomFieldsField])
+	if err != nil {
+		return "", err
+	}
+	for _, f := range custom {
+		if strings.EqualFold(name, f.Name) {
+			return f.Value, nil
+		}
+	}
+	names := []string{"id"}
+	for _, f := range t.Fields {
+		names = append(names, f.Name)
+	}
+	for _, f := range custom {
+		names = append(names, f.Name)
+	}
+	return "", fmt.Errorf("the entry has no field %s, use one of %s", name, strings.Join(names, ", "))
+}
+
+// addEntry adds an entry of a type with the fields given by the options and prints its id.
+// Files are titled by their name unless a title is given.
+// Values missing from the options are read from the standard input when it is piped, and
+// prompted for when it is a terminal.
+func (c *Client) addEntry(t *records.Type, opt addOptions) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	if t.Binary && opt.title == "" && opt.fromFile != "" {
+		opt.title = filepath.Base(opt.fromFile)
+	}
+	var id string
+	var err error
+	switch {
+	case t.Binary:
+		id, err = c.addFileEntry(t, opt)
+	case t.Table == records.SSHKeysTable:
+		id, err = c.addSSHKeyEntry(t, opt)
+	default:
+		id, err = c.addFieldsEntry(t, opt)
+	}
+	if err != nil {
+		return err
+	}
+	for _, tag := range opt.tags {
+		if err := c.service.TagEntry(c.ctx, t.Table, c.userID, id, tag); err != nil {
+			return fmt.Errorf("the entry %s was added but tagging it failed: %w", id, err)
+		}
+	}
+	return c.render(addOutput{ID: id, Type: t.Key, Title: opt.title}, func() {
+		fmt.Println(id)
+	})
+}
+
+// addFieldsEntry adds an entry of a type whose fields are all entered by the user.
+func (c *Client) addFieldsEntry(t *records.Type, opt addOptions) (string, error) {
+	data, err := c.flagData(t, opt)
+	if err != nil {
+		return "", err
+	}
+	if opt.fromFile != "" {
+		if t.Table != records.TextTable {
+			return "", fmt.Errorf("%s entries cannot be read from a file", t.Key)
+		}
+		content, err := os.ReadFile(opt.fromFile)
+		if err != nil {
+			return "", err
+		}
+		data["data"] = string(content)
+	}
+
+	// A piped value fills the first missing secret, the text of text data, or all fields
+	// of an imported line such as an otpauth:// URI. It is only read if the flags left
+	// such a field empty, so that a command run with an open pipe does not wait for it.
+	if missingFromStdin(t, data) {
+		if value, ok, err := c.stdinValue(); err != nil {
+			return "", err
+		} else if ok {
+			if err := fillFromStdin(t, data, value); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var errs []error
+	for _, f := range t.InputFields() {
+		if data[f.Name] == "" && f.Default != "" {
+			data[f.Name] = f.Default
+		}
+		if f.Ref != "" {
+			continue
+		}
+		if err := f.Check(data[f.Name]); err != nil {
+			if c.tty && data[f.Name] == "" {
+				data[f.Name] = c.readField(f, f.AddPrompt(), "")
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return "", err
+	}
+	return c.service.Add(c.ctx, t.Table, c.userID, data)
+}
+
+// missingFromStdin reports whether a field of a new entry that the standard input may
+// fill is still empty: a sensitive or required field, or the text of text data.
+func missingFromStdin(t *records.Type, data map[string]string) bool {
+	if t.Table == records.TextTable && data["data"] == "" {
+		return true
+	}
+	for _, f := range t.InputFields() {
+		if f.Ref != "" || data[f.Name] != "" {
+			continue
+		}
+		if f.Sensitive || (f.Default == "" && f.Check("") != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// fillFromStdin sets the missing field of a record read from the standard input.
+func fillFromStdin(t *records.Type, data map[string]string, value string) error {
+	if t.Import != nil && strings.Contains(value, "://") {
+		imported, err := t.Import(value)
+		if err != nil {
+			return err
+		}
+		for name, v := range imported {
+			if data[name] == "" {
+				data[name] = v
+			}
+		}
+		return nil
+	}
+	for _, f := range t.InputFields() {
+		if f.Sensitive && data[f.Name] == "" {
+			data[f.Name] = value
+			return nil
+		}
+	}
+	if t.Table == records.TextTable && data["data"] == "" {
+		data["data"] = value
+		return nil
+	}
+	return errors.New("the standard input was given, but no field is missing a value")
+}
+
+// flagData returns the fields of a new entry given by the title, --set and --folder
+// flags. Links to other entries are given by their title or id.
+func (c *Client) flagData(t *records.Type, opt addOptions) (map[string]string, error) {
+	data := map[string]string{records.TitleField: opt.title}
+	for _, pair := range opt.set {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --set %s, use name=value", pair)
+		}
+		f, ok := inputField(t, name)
+		if !ok {
+			var names []string
+			for _, f := range t.InputFields() {
+				names = append(names, f.Name)
+			}
+			return nil, fmt.Errorf("%s entries have no field %s, use one of %s", t.Key, name, strings.Join(names, ", "))
+		}
+		if f.Ref != "" {
+			id, err := c.findEntryID(f.Ref, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Label, err)
+			}
+			value = id
+		}
+		data[f.Name] = value
+	}
+	if opt.folder != "" {
+		if _, ok := t.Field(records.FolderField); !ok {
+			return nil, fmt.Errorf("%s entries cannot be put in folders", t.Key)
+		}
+		id, err := c.service.FolderID(c.ctx, c.userID, opt.folder)
+		if err != nil {
+			return nil, err
+		}
+		data[records.FolderField] = id
+	}
+	return data, nil
+}
+
+// inputField returns the field entered by the user with the given name or label.
+func inputField(t *records.Type, name string) (records.Field, bool) {
+	for _, f := range t.InputFields() {
+		if strings.EqualFold(name, f.Name) || strings.EqualFold(name, f.Label) {
+			return f, true
+		}
+	}
+	return records.Field{}, false
+}
+
+// addFileEntry stores the file given by --from-file as a binary data entry.
+func (c *Client) addFileEntry(t *records.Type, opt addOptions) (string, error) {
+	if opt.fromFile == "" {
+		return "", errors.New("give the file to store with --from-file")
+	}
+	data, err := c.flagData(t, opt)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(opt.fromFile)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > int64(c.opt.MaxFileSize) {
+		return "", errors.New("the file is too large")
+	}
+	_, hash, err := c.enc.EncryptFile(opt.fromFile, c.opt.FileStoragePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt or write the file: %w", err)
+	}
+	data["path"] = fmt.Sprintf("%x", hash)
+	data["extension"] = filepath.Ext(opt.fromFile)
+	return c.service.Add(c.ctx, t.Table, c.userID, data)
+}
+
+// addSSHKeyEntry stores the private key given by --from-file, or a new ed25519 key, as
+// an SSH key entry. The comment of the key is given with --set comment=...
+func (c *Client) addSSHKeyEntry(t *records.Type, opt addOptions) (string, error) {
+	comment := ""
+	var set []string
+	for _, pair := range opt.set {
+		if name, value, _ := strings.Cut(pair, "="); strings.EqualFold(name, "comment") {
+			comment = value
+			continue
+		}
+		set = append(set, pair)
+	}
+	opt.set = set
+	data, err := c.flagData(t, opt)
+	if err != nil {
+		return "", err
+	}
+	key, err := c.loadSSHKey(opt.fromFile, comment)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the key: %w", err)
+	}
+	for name, value := range records.SSHKeyData(key) {
+		data[name] = value
+	}
+	if confirm, _ := t.Field("confirm"); data[confirm.Name] == "" {
+		data[confirm.Name] = confirm.Default
+	}
+	if err := t.Validate(data); err != nil {
+		return "", err
+	}
+	id, err := c.service.Add(c.ctx, t.Table, c.userID, data)
+	if err == nil {
+		fmt.Fprintln(os.Stderr, "Public key:", key.PublicKey)
+	}
+	return id, err
+}
+
+// removeEntry deletes the entry of a type with the given title or id, after confirmation
+// unless yes is set. Without a terminal to confirm on, yes is required.
+func (c *Client) removeEntry(t *records.Type, ref string, yes bool) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	id, err := c.findEntryID(t.Table, ref)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		if !c.tty {
+			return fmt.Errorf("%w, use --yes to delete without confirmation", errTerminalRequired)
+		}
+		data, err := c.service.GetData(c.ctx, t.Table, c.userID, id)
+		if err != nil {
+			return err
+		}
+		c.rl.SetPrompt(fmt.Sprintf("Delete %s %q (%s)? (yes/no): ", t.Key, data[records.TitleField], id))
+		line, _ := c.rl.Readline()
+		if strings.ToLower(strings.TrimSpace(line)) != "yes" {
+			if c.structured() {
+				return errors.New("deletion canceled")
+			}
+			fmt.Println("Deletion canceled.")
+			return nil
+		}
+	}
+	if err := c.service.DeleteData(c.ctx, t.Table, c.userID, id); err != nil {
+		return fmt.Errorf("failed to delete the entry: %w", err)
+	}
+	return c.render(deleteOutput{ID: id, Type: t.Key, Deleted: true}, func() {})
+}
+
+// stdinValue returns the value piped to the standard input, without its final line
+// break. It reports false if the standard input is a terminal or was already read.
+func (c *Client) stdinValue() (string, bool, error) {
+	if c.tty || c.stdinRead {
+		return "", false, nil
+	}
+	c.stdinRead = true
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", false, err
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	return value, value != "", nil
+}
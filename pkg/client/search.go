@@ -0,0 +1,68 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/search"
+)
+
+// search finds entries of all types by title, tags and the other non-sensitive fields,
+// and shows the entry chosen by the user. Sensitive fields are only searched with secrets.
+// In the json and yaml formats, the results are printed without prompting.
+func (c *Client) search(query string, secrets bool) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	results, err := c.service.Search(c.ctx, c.userID, query, search.Options{Secrets: secrets})
+	if err != nil {
+		return fmt.Errorf("failed to search: %w", err)
+	}
+	if c.structured() {
+		doc := searchOutput{Results: []searchItem{}}
+		for _, r := range results {
+			doc.Results = append(doc.Results, searchItem{ID: r.ID, Type: typeKey(r.Table), Title: r.Title, Matched: nonNil(r.Matched)})
+		}
+		return c.render(doc, nil)
+	}
+	if len(results) == 0 {
+		fmt.Println("No entries found.")
+		return nil
+	}
+
+	for i, r := range results {
+		name := r.Table
+		if t, ok := records.ByTable(r.Table); ok {
+			name = t.Name
+		}
+		fmt.Printf("#%d: [%s] %s (matched: %s)\n", i+1, name, r.Title, strings.Join(r.Matched, ", "))
+	}
+
+	for {
+		c.rl.SetPrompt("Enter the number of the entry to show (press Enter to skip): ")
+		line, err := c.rl.Readline()
+		if err != nil || strings.TrimSpace(line) == "" {
+			return nil
+		}
+		num, err := strconv.Atoi(strings.TrimSpace(line))
+		if err != nil || num < 1 || num > len(results) {
+			fmt.Println("Invalid input. Please enter a valid number.")
+			continue
+		}
+
+		r := results[num-1]
+		t, ok := records.ByTable(r.Table)
+		if !ok {
+			return nil
+		}
+		data, err := c.service.GetData(c.ctx, r.Table, c.userID, r.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get data: %w", err)
+		}
+		c.printData(t, data)
+		c.printTags(r.Table, r.ID)
+		return nil
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/sshkey"
+)
+
+// addSSHKey adds SSH keys read from a file or generated on the spot.
+// The private key is validated and stored unencrypted in OpenSSH format, together
+// with its public key and fingerprint.
+func (c *Client) addSSHKey(t *records.Type) {
+	for {
+		c.rl.SetPrompt("Choose a title (meta-information): ")
+		title, _ := c.rl.Readline()
+		c.rl.SetPrompt("Specify the private key file path (press Enter to generate a new ed25519 key): ")
+		path, _ := c.rl.Readline()
+		c.rl.SetPrompt("Enter a comment for the key (e.g. user@host): ")
+		comment, _ := c.rl.Readline()
+
+		key, err := c.loadSSHKey(strings.TrimSpace(path), comment)
+		if err != nil {
+			fmt.Printf("Failed to load the key: %s\n", err)
+			return
+		}
+
+		data := records.SSHKeyData(key)
+		data[records.TitleField] = title
+		confirm, _ := t.Field("confirm")
+		data[confirm.Name] = c.readField(confirm, confirm.AddPrompt(), "")
+		folder, _ := t.Field(records.FolderField)
+		data[folder.Name] = c.readRef(folder, "")
+
+		err = c.service.AddData(c.ctx, t.Table, c.userID, data)
+		if err != nil {
+			fmt.Printf("Failed to add data: %s\n", err)
+			return
+		}
+		fmt.Println(t.Summary(data))
+		fmt.Println("Public key:", key.PublicKey)
+		fmt.Println("Data added successfully!")
+
+		c.rl.SetPrompt("Do you want to continue adding SSH keys? (yes/no): ")
+		choice, _ := c.rl.Readline()
+		if strings.ToLower(choice) != "yes" && strings.ToLower(choice) != "y" {
+			break
+		}
+	}
+}
+
+// loadSSHKey reads a private key from a file, asking for its passphrase if needed and
+// the standard input is a terminal.
+// An empty path generates a new ed25519 key.
+func (c *Client) loadSSHKey(path, comment string) (*sshkey.Key, error) {
+	if path == "" {
+		return sshkey.Generate(comment)
+	}
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := sshkey.Parse(pemBytes, nil, comment)
+	// Without a terminal the passphrase cannot be asked for
+	if errors.Is(err, sshkey.ErrPassphraseRequired) && c.tty {
+		passphrase := c.readPassword("Enter the passphrase of the key: ")
+		key, err = sshkey.Parse(pemBytes, []byte(passphrase), comment)
+	}
+	return key, err
+}
+
+// sshAgent serves the SSH keys of the vault over the ssh-agent protocol on a Unix socket
+// until interrupted. Keys marked for confirmation, or all keys if confirmAll is set,
+// are only used after the user allows it.
+func (c *Client) sshAgent(socket string, confirmAll bool) {
+	if c.userID == 0 {
+		fmt.Println("Please log in or register.")
+		return
+	}
+
+	entries, err := c.service.GetAllData(c.ctx, records.SSHKeysTable, c.userID, "id", "meta_info", "private_key", "comment", "confirm")
+	if err != nil {
+		fmt.Printf("Failed to get SSH keys: %s\n", err)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("No entries found in the table:", records.SSHKeysTable)
+		return
+	}
+
+	keys := make([]*sshkey.Key, 0, len(entries))
+	titles := make(map[*sshkey.Key]string, len(entries))
+	needConfirm := make(map[*sshkey.Key]bool, len(entries))
+	for _, entry := range entries {
+		key, err := sshkey.Parse([]byte(entry["private_key"]), nil, entry["comment"])
+		if err != nil {
+			fmt.Printf("Skipping SSH key %q: %s\n", entry[records.TitleField], err)
+			continue
+		}
+		keys = append(keys, key)
+		titles[key] = entry[records.TitleField]
+		needConfirm[key] = confirmAll || strings.ToLower(entry["confirm"]) == "yes"
+	}
+
+	a, err := sshkey.NewAgent(keys, func(key *sshkey.Key) bool {
+		if !needConfirm[key] {
+			return true
+		}
+		c.rl.SetPrompt(fmt.Sprintf("Allow the use of SSH key %q (%s)? (yes/no): ", titles[key], key.Fingerprint))
+		choice, _ := c.rl.Readline()
+		return strings.ToLower(choice) == "yes" || strings.ToLower(choice) == "y"
+	})
+	if err != nil {
+		fmt.Printf("Failed to start the SSH agent: %s\n", err)
+		return
+	}
+
+	if socket == "" {
+		socket, err = defaultAgentSocket()
+		if err != nil {
+			fmt.Printf("Failed to start the SSH agent: %s\n", err)
+			return
+		}
+		defer os.Remove(filepath.Dir(socket))
+	}
+	ctx, stop := signal.NotifyContext(c.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", socket)
+	fmt.Printf("Serving %d SSH keys. Press Ctrl+C to stop.\n", len(keys))
+	if err := a.Serve(ctx, socket); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Printf("SSH agent error: %s\n", err)
+	}
+}
+
+// defaultAgentSocket returns the default path of the agent socket, in a new directory
+// only the user can access, created in the user's runtime directory if there is one.
+func defaultAgentSocket() (string, error) {
+	dir, err := os.MkdirTemp(os.Getenv("XDG_RUNTIME_DIR"), "gophkeeper-agent-")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "agent.sock"), nil
+}
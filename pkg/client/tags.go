@@ -0,0 +1,287 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// tagCommand returns the command group for tagging entries.
+func (c *Client) tagCommand() *cobra.Command {
+	tagCmd := &cobra.Command{
+		Use:         "tag",
+		Short:       "Tag entries of any type",
+		Annotations: structuredOutput,
+	}
+	tagCmd.AddCommand(&cobra.Command{
+		Use:   "add <entry> <tag>...",
+		Short: "Add tags to an entry",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.tagEntry(args[0], args[1:])
+		},
+	})
+	tagCmd.AddCommand(&cobra.Command{
+		Use:     "remove <entry> <tag>...",
+		Aliases: []string{"rm"},
+		Short:   "Remove tags from an entry",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.untagEntry(args[0], args[1:])
+		},
+	})
+	tagCmd.AddCommand(&cobra.Command{
+		Use:   "rename <old> <new>",
+		Short: "Rename a tag on all entries",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.renameTag(args[0], args[1])
+		},
+	})
+	tagCmd.AddCommand(&cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the tags in use",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.listTags()
+		},
+	})
+	return tagCmd
+}
+
+// folderCommand returns the command group for managing folders.
+func (c *Client) folderCommand() *cobra.Command {
+	folderCmd := &cobra.Command{
+		Use:   "folder",
+		Short: "Manage the folders holding entries",
+	}
+	folderCmd.AddCommand(&cobra.Command{
+		Use:   "add <name>",
+		Short: "Create a folder",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.addFolder(args[0])
+		},
+		Annotations: structuredOutput,
+	})
+	var removeYes bool
+	removeCmd := &cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a folder, keeping the entries in it",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.removeFolder(args[0], removeYes)
+		},
+		Annotations: structuredOutput,
+	}
+	removeCmd.Flags().BoolVar(&removeYes, "yes", false, "delete without asking for confirmation")
+	folderCmd.AddCommand(removeCmd)
+	folderCmd.AddCommand(&cobra.Command{
+		Use:   "rename <old> <new>",
+		Short: "Rename a folder",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.renameFolder(args[0], args[1])
+		},
+		Annotations: structuredOutput,
+	})
+	folderCmd.AddCommand(&cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the folders",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.listFolders()
+		},
+		Annotations: structuredOutput,
+	})
+	return folderCmd
+}
+
+// tagEntry adds tags to the entry with the given title or id.
+func (c *Client) tagEntry(ref string, tags []string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	t, id, err := c.findAnyEntry(ref)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := c.service.TagEntry(c.ctx, t.Table, c.userID, id, tag); err != nil {
+			return fmt.Errorf("failed to add tag %q: %w", tag, err)
+		}
+	}
+	return c.renderTags(t, id)
+}
+
+// untagEntry removes tags from the entry with the given title or id.
+func (c *Client) untagEntry(ref string, tags []string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	t, id, err := c.findAnyEntry(ref)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := c.service.UntagEntry(c.ctx, t.Table, c.userID, id, tag); err != nil {
+			return fmt.Errorf("failed to remove tag %q: %w", tag, err)
+		}
+	}
+	return c.renderTags(t, id)
+}
+
+// renderTags prints the tags of an entry after they changed.
+func (c *Client) renderTags(t *records.Type, id string) error {
+	if !c.structured() {
+		c.printTags(t.Table, id)
+		return nil
+	}
+	tags, err := c.service.EntryTags(c.ctx, t.Table, c.userID, id)
+	if err != nil {
+		return err
+	}
+	return c.render(entryTagsOutput{ID: id, Type: t.Key, Tags: nonNil(tags)}, nil)
+}
+
+// renameTag renames a tag on all entries having it.
+func (c *Client) renameTag(oldName, newName string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	if err := c.service.RenameTag(c.ctx, c.userID, oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return c.render(nameOutput{Name: newName}, func() {
+		fmt.Println("Tag renamed.")
+	})
+}
+
+// listTags prints the tags in use with the number of entries having them.
+func (c *Client) listTags() error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	counts, err := c.service.TagCounts(c.ctx, c.userID)
+	if err != nil {
+		return fmt.Errorf("failed to get tags: %w", err)
+	}
+	return c.render(tagsOutput{Tags: sortedCounts(counts)}, func() {
+		if len(counts) == 0 {
+			fmt.Println("No tags found. Use 'tag add <entry> <tag>' to tag an entry.")
+			return
+		}
+		printCounts(counts)
+	})
+}
+
+// addFolder creates a folder.
+func (c *Client) addFolder(name string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	if err := c.service.AddFolder(c.ctx, c.userID, name); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	return c.render(nameOutput{Name: strings.TrimSpace(name)}, func() {
+		fmt.Println("Folder created. Entries are moved into it when they are added or edited.")
+	})
+}
+
+// removeFolder deletes a folder, after confirmation unless yes is set. The entries in it
+// are kept.
+func (c *Client) removeFolder(name string, yes bool) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	if !yes {
+		if !c.tty {
+			return fmt.Errorf("%w, use --yes to delete without confirmation", errTerminalRequired)
+		}
+		c.rl.SetPrompt(fmt.Sprintf("Delete the folder %q? The entries in it are kept. (yes/no): ", name))
+		line, _ := c.rl.Readline()
+		if strings.ToLower(strings.TrimSpace(line)) != "yes" {
+			if c.structured() {
+				return errors.New("deletion canceled")
+			}
+			fmt.Println("Deletion canceled.")
+			return nil
+		}
+	}
+	moved, err := c.service.DeleteFolder(c.ctx, c.userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+	return c.render(folderDeleteOutput{Name: strings.TrimSpace(name), Deleted: true, MovedEntries: moved}, func() {
+		fmt.Printf("Folder deleted, %d entries moved out of it.\n", moved)
+	})
+}
+
+// renameFolder renames a folder.
+func (c *Client) renameFolder(oldName, newName string) error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	if err := c.service.RenameFolder(c.ctx, c.userID, oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename folder: %w", err)
+	}
+	return c.render(nameOutput{Name: strings.TrimSpace(newName)}, func() {
+		fmt.Println("Folder renamed.")
+	})
+}
+
+// listFolders prints the folders with the number of entries in them.
+func (c *Client) listFolders() error {
+	if c.userID == 0 {
+		return errNotLoggedIn
+	}
+	counts, err := c.service.FolderCounts(c.ctx, c.userID)
+	if err != nil {
+		return fmt.Errorf("failed to get folders: %w", err)
+	}
+	return c.render(foldersOutput{Folders: sortedCounts(counts)}, func() {
+		if len(counts) == 0 {
+			fmt.Println("No folders found. Use 'folder add <name>' to create one.")
+			return
+		}
+		printCounts(counts)
+	})
+}
+
+// printTags prints the tags of an entry, if it has any.
+func (c *Client) printTags(table, id string) {
+	tags, err := c.service.EntryTags(c.ctx, table, c.userID, id)
+	if err != nil {
+		fmt.Printf("tags: %s\n", err)
+		return
+	}
+	if len(tags) > 0 {
+		fmt.Printf("tags: %s\n", strings.Join(tags, ", "))
+	}
+}
+
+// printCounts prints names with their number of entries in alphabetical order.
+func printCounts(counts map[string]int) {
+	for _, n := range sortedCounts(counts) {
+		fmt.Printf("%s (%d)\n", n.Name, n.Entries)
+	}
+}
+
+// sortedCounts returns names with their number of entries in alphabetical order.
+func sortedCounts(counts map[string]int) []nameCount {
+	names := make([]nameCount, 0, len(counts))
+	for name, n := range counts {
+		names = append(names, nameCount{Name: name, Entries: n})
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i].Name) < strings.ToLower(names[j].Name)
+	})
+	return names
+}
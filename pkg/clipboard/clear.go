@@ -0,0 +1,180 @@
+package clipboard
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// clearerEnv is the environment variable starting the executable as the process clearing
+// the clipboard, set to the name of the clipboard and the delay.
+const clearerEnv = "GOPHKEEPER_CLIPBOARD_CLEAR"
+
+// Names of the OSC 52 clipboard in clearerEnv, written to the standard error of the
+// process clearing it.
+const (
+	clearerOSC52     = "osc52"
+	clearerOSC52Tmux = "osc52-tmux"
+)
+
+// ErrInvalidDigest is returned by ParseDigest for malformed digests.
+var ErrInvalidDigest = errors.New("invalid digest")
+
+// Digest identifies a copied value without revealing it: a SHA-256 hash of the value
+// with a random salt.
+type Digest struct {
+	Salt []byte
+	Sum  []byte
+}
+
+// NewDigest returns the digest of a value with a new salt.
+func NewDigest(value string) (Digest, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return Digest{}, err
+	}
+	return Digest{Salt: salt, Sum: digestSum(salt, value)}, nil
+}
+
+// Matches reports whether the digest is the one of the value.
+func (d Digest) Matches(value string) bool {
+	return hmac.Equal(d.Sum, digestSum(d.Salt, value))
+}
+
+// String formats the digest as the salt and hash in hexadecimal, separated by a colon.
+func (d Digest) String() string {
+	return hex.EncodeToString(d.Salt) + ":" + hex.EncodeToString(d.Sum)
+}
+
+// ParseDigest parses a digest formatted by Digest.String.
+func ParseDigest(s string) (Digest, error) {
+	salt, sum, ok := strings.Cut(s, ":")
+	if !ok {
+		return Digest{}, ErrInvalidDigest
+	}
+	var d Digest
+	var err1, err2 error
+	d.Salt, err1 = hex.DecodeString(salt)
+	d.Sum, err2 = hex.DecodeString(sum)
+	if err1 != nil || err2 != nil || len(d.Sum) != sha256.Size {
+		return Digest{}, ErrInvalidDigest
+	}
+	return d, nil
+}
+
+// digestSum hashes a value with a salt.
+func digestSum(salt []byte, value string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
+
+// ScheduleClear starts a background process clearing the clipboard after the delay. The
+// clipboard of a tool is only cleared if it still holds the value. The OSC 52 clipboard
+// cannot be read back and is cleared whatever it holds then, by writing to its terminal,
+// which must be a file such as os.Stderr; callers should only schedule that at the
+// explicit request of the user.
+//
+// The process is the running executable, which must call RunClearer first thing; it
+// outlives the caller and gets the digest of the value only, through a pipe.
+func ScheduleClear(cb Clipboard, value string, after time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	digest, err := NewDigest(value)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe)
+	switch cb := cb.(type) {
+	case *Tool:
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s %s", clearerEnv, cb.name, after))
+	case *OSC52:
+		term, ok := cb.W.(*os.File)
+		if !ok {
+			return errors.New("the terminal of the OSC 52 clipboard is not a file")
+		}
+		name := clearerOSC52
+		if cb.Tmux {
+			name = clearerOSC52Tmux
+		}
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s %s", clearerEnv, name, after))
+		cmd.Stderr = term
+	default:
+		return fmt.Errorf("the %s clipboard cannot be cleared in the background", cb.Name())
+	}
+	detach(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdin, digest.String())
+	if closeErr := stdin.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// RunClearer runs the process started by ScheduleClear, if the executable was started as
+// one: it waits for the delay and clears the clipboard if it was not changed, or if it
+// cannot be read back. It reports whether it ran, in which case the caller exits.
+func RunClearer() bool {
+	spec, ok := os.LookupEnv(clearerEnv)
+	if !ok {
+		return false
+	}
+	cb, after, digest, err := parseClearer(spec, os.Stdin, os.Stderr)
+	if err != nil {
+		return true
+	}
+	time.Sleep(after)
+	// Nobody is left to report errors to
+	if _, err := ClearIfUnchanged(cb, digest); errors.Is(err, ErrUnreadable) {
+		_ = cb.Clear()
+	}
+	return true
+}
+
+// parseClearer parses the environment variable and the standard input of the process
+// clearing the clipboard. The OSC 52 clipboard is the terminal term.
+func parseClearer(spec string, r io.Reader, term io.Writer) (Clipboard, time.Duration, Digest, error) {
+	name, delay, _ := strings.Cut(spec, " ")
+	var cb Clipboard
+	switch name {
+	case clearerOSC52, clearerOSC52Tmux:
+		cb = &OSC52{W: term, Tmux: name == clearerOSC52Tmux}
+	default:
+		t, ok := ToolByName(name)
+		if !ok {
+			return nil, 0, Digest{}, fmt.Errorf("unknown clipboard %s", name)
+		}
+		cb = t
+	}
+	after, err := time.ParseDuration(delay)
+	if err != nil {
+		return nil, 0, Digest{}, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, 1024))
+	if err != nil {
+		return nil, 0, Digest{}, err
+	}
+	digest, err := ParseDigest(strings.TrimSpace(string(data)))
+	return cb, after, digest, err
+}
@@ -0,0 +1,176 @@
+// Package clipboard copies values to the system clipboard with wl-copy, xclip or an
+// OSC 52 terminal escape, and clears them after a while unless the clipboard has been
+// changed in the meantime. The OSC 52 clipboard cannot be read back, it can only be
+// cleared whatever it holds.
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Errors returned by the clipboards.
+var (
+	ErrUnavailable = errors.New("no clipboard available: install wl-copy or xclip, or use a terminal supporting OSC 52")
+	// ErrUnreadable is returned by clipboards whose content cannot be read back, which
+	// can only be cleared unconditionally.
+	ErrUnreadable = errors.New("the clipboard cannot be read")
+)
+
+// Clipboard is a system clipboard.
+type Clipboard interface {
+	Name() string
+	Copy(value string) error
+	Read() (string, error)
+	Clear() error
+}
+
+// Tool is a clipboard set and read by command line tools.
+type Tool struct {
+	name  string
+	copy  []string // copy sets the clipboard to its standard input.
+	paste []string // paste prints the clipboard.
+	clear []string // clear empties the clipboard; without it, the empty value is copied.
+}
+
+// The tools, in order of preference. Each needs the display variable to be set.
+var (
+	WLCopy = &Tool{name: "wl-copy", copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"},
+		clear: []string{"wl-copy", "--clear"}}
+	XClip = &Tool{name: "xclip", copy: []string{"xclip", "-selection", "clipboard"},
+		paste: []string{"xclip", "-selection", "clipboard", "-o"}}
+)
+
+// displays are the environment variables the tools need, by tool.
+var displays = map[*Tool]string{WLCopy: "WAYLAND_DISPLAY", XClip: "DISPLAY"}
+
+// Detect returns the first tool that is installed and has a display to work with.
+// getenv and lookPath are os.Getenv and exec.LookPath outside of tests.
+func Detect(getenv func(string) string, lookPath func(string) (string, error)) (*Tool, error) {
+	for _, t := range []*Tool{WLCopy, XClip} {
+		if getenv(displays[t]) == "" {
+			continue
+		}
+		if _, err := lookPath(t.copy[0]); err == nil {
+			return t, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
+
+// ToolByName returns the tool with the given name.
+func ToolByName(name string) (*Tool, bool) {
+	for _, t := range []*Tool{WLCopy, XClip} {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Name returns the name of the tool.
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Copy sets the clipboard. The tools keep serving it in the background.
+func (t *Tool) Copy(value string) error {
+	cmd := exec.Command(t.copy[0], t.copy[1:]...)
+	cmd.Stdin = strings.NewReader(value)
+	return run(cmd)
+}
+
+// Read returns the content of the clipboard.
+func (t *Tool) Read() (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(t.paste[0], t.paste[1:]...)
+	cmd.Stdout = &out
+	if err := run(cmd); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Clear empties the clipboard.
+func (t *Tool) Clear() error {
+	if t.clear == nil {
+		return t.Copy("")
+	}
+	return run(exec.Command(t.clear[0], t.clear[1:]...))
+}
+
+// run runs a tool, with its error output in the error.
+func run(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// OSC52 is the clipboard of the terminal, set with the OSC 52 escape sequence written to
+// the terminal. It works over SSH, but cannot be read back.
+type OSC52 struct {
+	W    io.Writer // W is the terminal.
+	Tmux bool      // Tmux passes the sequence through tmux to the terminal.
+}
+
+// NewOSC52 returns the clipboard of the terminal w, passing through tmux if it runs in it.
+func NewOSC52(w io.Writer) *OSC52 {
+	return &OSC52{W: w, Tmux: os.Getenv("TMUX") != ""}
+}
+
+// Name returns the name of the clipboard.
+func (o *OSC52) Name() string {
+	return "OSC 52"
+}
+
+// Copy sets the clipboard.
+func (o *OSC52) Copy(value string) error {
+	_, err := io.WriteString(o.W, o.sequence(base64.StdEncoding.EncodeToString([]byte(value))))
+	return err
+}
+
+// Read returns ErrUnreadable: few terminals answer OSC 52 queries.
+func (o *OSC52) Read() (string, error) {
+	return "", ErrUnreadable
+}
+
+// Clear empties the clipboard.
+func (o *OSC52) Clear() error {
+	_, err := io.WriteString(o.W, o.sequence(""))
+	return err
+}
+
+// sequence returns the escape sequence setting the clipboard to the base64 payload.
+func (o *OSC52) sequence(payload string) string {
+	seq := "\x1b]52;c;" + payload + "\a"
+	if o.Tmux {
+		// tmux passes DCS sequences through, with their escapes doubled
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// ClearIfUnchanged clears the clipboard if it still holds the value with the given
+// digest, as returned by Digest. It reports whether the clipboard was cleared.
+func ClearIfUnchanged(cb Clipboard, digest Digest) (bool, error) {
+	current, err := cb.Read()
+	if err != nil {
+		return false, err
+	}
+	if !digest.Matches(current) {
+		return false, nil
+	}
+	return true, cb.Clear()
+}
@@ -0,0 +1,179 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	installed := func(tools ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, tool := range tools {
+				if tool == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+
+	tool, err := Detect(env(map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}), installed("wl-copy", "xclip"))
+	require.NoError(t, err)
+	assert.Equal(t, "wl-copy", tool.Name())
+
+	// Without a Wayland display, or without wl-copy, xclip is used
+	tool, err = Detect(env(map[string]string{"DISPLAY": ":0"}), installed("wl-copy", "xclip"))
+	require.NoError(t, err)
+	assert.Equal(t, "xclip", tool.Name())
+	tool, err = Detect(env(map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}), installed("xclip"))
+	require.NoError(t, err)
+	assert.Equal(t, "xclip", tool.Name())
+
+	_, err = Detect(env(nil), installed("wl-copy", "xclip"))
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = Detect(env(map[string]string{"DISPLAY": ":0"}), installed())
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestScheduleClear(t *testing.T) {
+	var buf bytes.Buffer
+	err := ScheduleClear(&OSC52{W: &buf}, "s3cret", time.Second)
+	assert.Error(t, err, "the terminal must be a file for the process clearing it")
+	err = ScheduleClear(&fakeClipboard{}, "s3cret", time.Second)
+	assert.Error(t, err)
+}
+
+func TestOSC52(t *testing.T) {
+	var buf bytes.Buffer
+	cb := &OSC52{W: &buf}
+	require.NoError(t, cb.Copy("s3cret"))
+	assert.Equal(t, "\x1b]52;c;czNjcmV0\a", buf.String())
+
+	buf.Reset()
+	require.NoError(t, cb.Clear())
+	assert.Equal(t, "\x1b]52;c;\a", buf.String())
+
+	buf.Reset()
+	cb.Tmux = true
+	require.NoError(t, cb.Copy("s3cret"))
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;czNjcmV0\a\x1b\\", buf.String())
+
+	_, err := cb.Read()
+	assert.ErrorIs(t, err, ErrUnreadable)
+}
+
+func TestDigest(t *testing.T) {
+	d, err := NewDigest("s3cret")
+	require.NoError(t, err)
+	assert.True(t, d.Matches("s3cret"))
+	assert.False(t, d.Matches("s3cret2"))
+	assert.NotContains(t, d.String(), "s3cret")
+
+	// Salts differ, so digests of the same value do too
+	other, err := NewDigest("s3cret")
+	require.NoError(t, err)
+	assert.NotEqual(t, d.String(), other.String())
+
+	parsed, err := ParseDigest(d.String())
+	require.NoError(t, err)
+	assert.True(t, parsed.Matches("s3cret"))
+
+	for _, s := range []string{"", "00", "zz:00", "00:" + strings.Repeat("0", 10)} {
+		_, err := ParseDigest(s)
+		assert.ErrorIs(t, err, ErrInvalidDigest, s)
+	}
+}
+
+// fakeClipboard is a clipboard in memory.
+type fakeClipboard struct {
+	value   string
+	readErr error
+}
+
+func (f *fakeClipboard) Name() string            { return "fake" }
+func (f *fakeClipboard) Copy(value string) error { f.value = value; return nil }
+func (f *fakeClipboard) Read() (string, error)   { return f.value, f.readErr }
+func (f *fakeClipboard) Clear() error            { f.value = ""; return nil }
+
+func TestClearIfUnchanged(t *testing.T) {
+	d, err := NewDigest("s3cret")
+	require.NoError(t, err)
+
+	cb := &fakeClipboard{value: "s3cret"}
+	cleared, err := ClearIfUnchanged(cb, d)
+	require.NoError(t, err)
+	assert.True(t, cleared)
+	assert.Empty(t, cb.value)
+
+	// A value copied since is kept
+	cb.value = "copied since"
+	cleared, err = ClearIfUnchanged(cb, d)
+	require.NoError(t, err)
+	assert.False(t, cleared)
+	assert.Equal(t, "copied since", cb.value)
+
+	cb = &fakeClipboard{value: "s3cret", readErr: errors.New("no display")}
+	cleared, err = ClearIfUnchanged(cb, d)
+	assert.Error(t, err)
+	assert.False(t, cleared)
+	assert.Equal(t, "s3cret", cb.value)
+}
+
+func TestParseClearer(t *testing.T) {
+	d, err := NewDigest("s3cret")
+	require.NoError(t, err)
+
+	var term bytes.Buffer
+	cb, after, parsed, err := parseClearer("xclip 45s", strings.NewReader(d.String()), &term)
+	require.NoError(t, err)
+	assert.Equal(t, XClip, cb)
+	assert.Equal(t, 45*time.Second, after)
+	assert.True(t, parsed.Matches("s3cret"))
+
+	// The OSC 52 clipboard is the terminal
+	cb, _, _, err = parseClearer("osc52-tmux 1m", strings.NewReader(d.String()), &term)
+	require.NoError(t, err)
+	assert.Equal(t, &OSC52{W: &term, Tmux: true}, cb)
+
+	_, _, _, err = parseClearer("pbcopy 45s", strings.NewReader(d.String()), &term)
+	assert.Error(t, err)
+	_, _, _, err = parseClearer("xclip soon", strings.NewReader(d.String()), &term)
+	assert.Error(t, err)
+	_, _, _, err = parseClearer("xclip 45s", strings.NewReader("s3cret"), &term)
+	assert.ErrorIs(t, err, ErrInvalidDigest)
+}
+
+func TestTool(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is required")
+	}
+	// The clipboard of the fake tool is a file
+	file := filepath.Join(t.TempDir(), "clipboard")
+	tool := &Tool{name: "fake", copy: []string{"sh", "-c", `cat > "$0"`, file}, paste: []string{"sh", "-c", `cat "$0"`, file}}
+
+	require.NoError(t, tool.Copy("s3cret"))
+	value, err := tool.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	require.NoError(t, tool.Clear())
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	failing := &Tool{name: "fake", copy: []string{"sh", "-c", "echo cannot open display >&2; exit 1"}}
+	err = failing.Copy("s3cret")
+	assert.ErrorContains(t, err, "cannot open display")
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package clipboard
+
+import "os/exec"
+
+// detach leaves the process as it is where sessions do not exist.
+func detach(cmd *exec.Cmd) {}
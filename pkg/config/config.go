@@ -14,16 +14,27 @@ import (
 
 // Options represents the configuration options for the application.
 type Options struct {
-	MaxFileSize     int           // MaxFileSize represents the maximum allowed size for files.
-	FileStoragePath string        // FileStoragePath represents the path where files are stored.
-	ServerURL       string        // ServerURL represents the URL of the server.
-	SyncWithServer  bool          // SyncWithServer determines whether to synchronize data with the server.
-	SessionDuration time.Duration // SessionDuration represents the duration of a session.
-	CertFilePath    string        // CertFilePath represents the path to the certificate file.
-	KeyFilePath     string        // KeyFilePath represents the path to the key file.
-	SysInfoPath     string        // SysInfoPath represents the path where synchronization data is stored.
-	SessionPath     string        // SessionPath represents the path where session data is stored..
-	enc             Encrypt       // enc is an instance implementing the Encrypt interface for encryption operations.
+	MaxFileSize         int           // MaxFileSize represents the maximum allowed size for files.
+	FileStoragePath     string        // FileStoragePath represents the path where files are stored.
+	ServerURL           string        // ServerURL represents the URL of the server.
+	SyncWithServer      bool          // SyncWithServer determines whether to synchronize data with the server.
+	AutoMigrate         bool          // AutoMigrate applies pending migrations of the local database before commands run.
+	SessionDuration     time.Duration // SessionDuration represents the duration of a session.
+	CertFilePath        string        // CertFilePath represents the path to the certificate file.
+	KeyFilePath         string        // KeyFilePath represents the path to the key file.
+	SysInfoPath         string        // SysInfoPath represents the path where synchronization data is stored.
+	SessionPath         string        // SessionPath represents the path where session data is stored..
+	HistoryRetention    int           // HistoryRetention is the number of previous versions kept per entry, 0 keeps all.
+	BackupDir           string        // BackupDir is the directory of the scheduled backups.
+	BackupInterval      time.Duration // BackupInterval is the time between scheduled backups, 0 disables them.
+	BackupKeep          int           // BackupKeep is the number of scheduled backups kept.
+	BackupPassphrase    string        // BackupPassphrase encrypts the scheduled backups; it is only read from the environment.
+	ExpiryWarning       time.Duration // ExpiryWarning is how long before entries expire they are reported at startup.
+	PasswordMaxAge      time.Duration // PasswordMaxAge is the age at which passwords are reported for a change, 0 disables it.
+	ClipboardTimeout    time.Duration // ClipboardTimeout is the time after which copied values are cleared from the clipboard, 0 keeps them.
+	ClipboardForceClear bool          // ClipboardForceClear clears clipboards that cannot be read back, such as OSC 52, whatever they hold then.
+	Args                []string      // Args are the command line arguments other than the options, for the commands.
+	enc                 Encrypt       // enc is an instance implementing the Encrypt interface for encryption operations.
 }
 
 // Encrypt is an interface for encryption operations.
@@ -37,12 +48,28 @@ func NewConfig(enc Encrypt) *Options {
 	fileStoragePath := flag.String("fileStoragePath", "", "file storage path")
 	serverURL := flag.String("serverURL", "http://localhost:8080", "server URL")
 	syncWithServer := flag.Bool("syncWithServer", true, "synchronize with server")
+	autoMigrate := flag.Bool("autoMigrate", true, "apply pending migrations of the local database before commands run")
 	certFilePath := flag.String("certFilePath", "server.crt", "certificate file path")
 	keyFilePath := flag.String("keyFilePath", "server.key", "key file path")
 	sysInfoPath := flag.String("sysInfoPath", "syncinfo.dat", "synchronization data file path")
 	sessionPath := flag.String("sessionPath", "session.dat", "session data file path")
+	historyRetention := flag.Int("historyRetention", 20, "number of previous versions kept per entry, 0 keeps all")
+	backupDir := flag.String("backupDir", "backups", "directory of the scheduled backups")
+	var backupInterval time.Duration
+	flag.Var((*durationValue)(&backupInterval), "backupInterval", "time between scheduled backups, such as 1d, 0 disables them")
+	backupKeep := flag.Int("backupKeep", 7, "number of scheduled backups kept")
+	expiryWarning := 30 * Day
+	flag.Var((*durationValue)(&expiryWarning), "expiryWarning", "how long before entries expire they are reported, such as 30d")
+	var passwordMaxAge time.Duration
+	flag.Var((*durationValue)(&passwordMaxAge), "passwordMaxAge", "age at which passwords are reported for a change, such as 365d, 0 disables it")
+	clipboardTimeout := 45 * time.Second
+	flag.Var((*durationValue)(&clipboardTimeout), "clipboardTimeout", "time after which copied values are cleared from the clipboard, such as 45s, 0 keeps them")
+	clipboardForceClear := flag.Bool("clipboardForceClear", false, "also clear clipboards that cannot be read back, such as OSC 52, including anything copied since")
 
-	flag.Parse()
+	// The options can be given anywhere on the command line, the other arguments are the
+	// command and its flags
+	own, args := splitArgs(flag.CommandLine, os.Args[1:])
+	_ = flag.CommandLine.Parse(own)
 
 	if *fileStoragePath == "" {
 		home, err := os.UserHomeDir()
@@ -81,18 +108,142 @@ func NewConfig(enc Encrypt) *Options {
 		}
 	}
 
+	if envAutoMigrate, exists := os.LookupEnv("AUTO_MIGRATE"); exists {
+		if value, err := strconv.ParseBool(envAutoMigrate); err == nil {
+			*autoMigrate = value
+		}
+	}
+
+	if envHistoryRetention, exists := os.LookupEnv("HISTORY_RETENTION"); exists {
+		if value, err := strconv.Atoi(envHistoryRetention); err == nil {
+			*historyRetention = value
+		}
+	}
+
+	if envBackupDir, exists := os.LookupEnv("BACKUP_DIR"); exists {
+		*backupDir = envBackupDir
+	}
+
+	if envBackupInterval, exists := os.LookupEnv("BACKUP_INTERVAL"); exists {
+		if value, err := ParseDuration(envBackupInterval); err == nil {
+			backupInterval = value
+		}
+	}
+
+	if envBackupKeep, exists := os.LookupEnv("BACKUP_KEEP"); exists {
+		if value, err := strconv.Atoi(envBackupKeep); err == nil {
+			*backupKeep = value
+		}
+	}
+
+	if envExpiryWarning, exists := os.LookupEnv("EXPIRY_WARNING"); exists {
+		if value, err := ParseDuration(envExpiryWarning); err == nil {
+			expiryWarning = value
+		}
+	}
+
+	if envPasswordMaxAge, exists := os.LookupEnv("PASSWORD_MAX_AGE"); exists {
+		if value, err := ParseDuration(envPasswordMaxAge); err == nil {
+			passwordMaxAge = value
+		}
+	}
+
+	if envClipboardTimeout, exists := os.LookupEnv("CLIPBOARD_TIMEOUT"); exists {
+		if value, err := ParseDuration(envClipboardTimeout); err == nil {
+			clipboardTimeout = value
+		}
+	}
+
+	if envClipboardForceClear, exists := os.LookupEnv("CLIPBOARD_FORCE_CLEAR"); exists {
+		if value, err := strconv.ParseBool(envClipboardForceClear); err == nil {
+			*clipboardForceClear = value
+		}
+	}
+
 	return &Options{
-		MaxFileSize:     *maxFileSize,
-		FileStoragePath: *fileStoragePath,
-		ServerURL:       *serverURL,
-		SyncWithServer:  *syncWithServer,
-		SessionDuration: time.Minute * 300,
-		CertFilePath:    *certFilePath,
-		KeyFilePath:     *keyFilePath,
-		SysInfoPath:     *sysInfoPath,
-		SessionPath:     *sessionPath,
-		enc:             enc,
+		MaxFileSize:         *maxFileSize,
+		FileStoragePath:     *fileStoragePath,
+		ServerURL:           *serverURL,
+		SyncWithServer:      *syncWithServer,
+		AutoMigrate:         *autoMigrate,
+		SessionDuration:     time.Minute * 300,
+		CertFilePath:        *certFilePath,
+		KeyFilePath:         *keyFilePath,
+		SysInfoPath:         *sysInfoPath,
+		SessionPath:         *sessionPath,
+		HistoryRetention:    *historyRetention,
+		BackupDir:           *backupDir,
+		BackupInterval:      backupInterval,
+		BackupKeep:          *backupKeep,
+		BackupPassphrase:    os.Getenv("BACKUP_PASSPHRASE"),
+		ExpiryWarning:       expiryWarning,
+		PasswordMaxAge:      passwordMaxAge,
+		ClipboardTimeout:    clipboardTimeout,
+		ClipboardForceClear: *clipboardForceClear,
+		Args:                args,
+		enc:                 enc,
+	}
+}
+
+// splitArgs separates the flags defined in fs, with their values, from the other arguments.
+// Flags are given with one or two dashes; everything after "--" is left to the commands.
+// The other arguments are never nil.
+func splitArgs(fs *flag.FlagSet, args []string) (own, rest []string) {
+	rest = []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"), "=")
+		f := fs.Lookup(name)
+		if !strings.HasPrefix(arg, "-") || name == "" || f == nil {
+			rest = append(rest, arg)
+			continue
+		}
+		own = append(own, arg)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); hasValue || (ok && b.IsBoolFlag()) {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			own = append(own, args[i])
+		}
+	}
+	return own, rest
+}
+
+// Day is the duration of a day, as used by ParseDuration.
+const Day = 24 * time.Hour
+
+// ParseDuration parses a duration such as "30d", "2w" or "36h". Days and weeks are
+// accepted as whole numbers in addition to the units of time.ParseDuration.
+func ParseDuration(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": Day, "w": 7 * Day} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(value)
+}
+
+// durationValue is a flag.Value parsed with ParseDuration.
+type durationValue time.Duration
+
+func (d *durationValue) String() string { return time.Duration(*d).String() }
+
+func (d *durationValue) Set(value string) error {
+	parsed, err := ParseDuration(value)
+	if err != nil {
+		return err
 	}
+	*d = durationValue(parsed)
+	return nil
 }
 
 // LoadSessionData loads session data from the session.dat file.
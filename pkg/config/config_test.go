@@ -1,8 +1,10 @@
 package config
 
 import (
+	"flag"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -60,7 +62,7 @@ func TestOptions_LoadSessionData(t *testing.T) {
 	// Создаем конфигурацию
 	mockEncrypt := &MockEncrypt{}
 	config := &Options{
-		SysInfoPath: file.Name(),
+		SessionPath: file.Name(),
 		enc:         mockEncrypt,
 	}
 
@@ -97,3 +99,44 @@ func getDefaultFileStoragePath() string {
 	}
 	return filepath.Join(home, "gkeeper")
 }
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d": 30 * Day,
+		"2w":  14 * Day,
+		"36h": 36 * time.Hour,
+		"0":   0,
+	}
+	for value, expected := range tests {
+		got, err := ParseDuration(value)
+		if err != nil || got != expected {
+			t.Errorf("ParseDuration(%q) = %v, %v, expected %v", value, got, err, expected)
+		}
+	}
+	for _, value := range []string{"d", "-1d", "1.5d", "soon"} {
+		if _, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded, expected an error", value)
+		}
+	}
+}
+
+// Тест проверяет отделение опций конфигурации от аргументов команд.
+func TestSplitArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("syncWithServer", true, "")
+	fs.String("serverURL", "", "")
+
+	own, rest := splitArgs(fs, []string{"-o", "json", "-syncWithServer=false", "ls", "--serverURL", "http://x", "logins", "-serverURL=y", "--", "-serverURL"})
+	if want := []string{"-syncWithServer=false", "--serverURL", "http://x", "-serverURL=y"}; !reflect.DeepEqual(own, want) {
+		t.Errorf("Expected options %q, got %q", want, own)
+	}
+	if want := []string{"-o", "json", "ls", "logins", "--", "-serverURL"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("Expected arguments %q, got %q", want, rest)
+	}
+
+	// Boolean flags take no separate value, and the arguments are never nil
+	own, rest = splitArgs(fs, []string{"-syncWithServer"})
+	if !reflect.DeepEqual(own, []string{"-syncWithServer"}) || rest == nil || len(rest) != 0 {
+		t.Errorf("Expected only the option, got %q and %q", own, rest)
+	}
+}
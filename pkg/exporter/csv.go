@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// CSVName is the name of the table of entries in a CSV export.
+const CSVName = "entries.csv"
+
+// WriteCSV writes records to a ZIP archive holding a plaintext table of the entries,
+// CSVName, and their files under files/. The table has a column for the type, title,
+// folder and tags, one for each field of the record types, and columns listing the
+// custom fields as "name: value" lines, the TOTP key as an otpauth:// URI and the
+// paths of the files in the archive.
+func WriteCSV(w io.Writer, recs []importer.Record, now time.Time) error {
+	columns := csvColumns()
+	zw := zip.NewWriter(w)
+	table, err := zw.CreateHeader(&zip.FileHeader{Name: CSVName, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(table)
+	header := []string{"type", "title", "folder", "tags"}
+	header = append(header, columns...)
+	header = append(header, "custom_fields", "totp", "files")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	type file struct {
+		name string
+		data []byte
+	}
+	var files []file
+	for i, rec := range recs {
+		t, ok := records.ByTable(rec.Table)
+		if !ok {
+			return fmt.Errorf("%s: unknown table %s", rec.Title(), rec.Table)
+		}
+		row := []string{t.Key, rec.Title(), rec.Folder, strings.Join(rec.Tags, ";")}
+		for _, name := range columns {
+			row = append(row, rec.Data[name])
+		}
+
+		custom, err := records.ParseCustomFields(rec.Data[records.CustomFieldsField])
+		if err != nil {
+			return fmt.Errorf("%s: %w", rec.Title(), err)
+		}
+		var lines []string
+		for _, f := range custom {
+			lines = append(lines, f.Name+": "+f.Value)
+		}
+		row = append(row, strings.Join(lines, "\n"))
+
+		otp := rec.TOTP
+		if t.Table == records.TOTPTable {
+			otp = rec.Data
+		}
+		uri := ""
+		if otp != nil {
+			key, err := records.TOTPKey(otp)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rec.Title(), err)
+			}
+			uri = key.URI()
+		}
+		row = append(row, uri)
+
+		attached := rec.Attachments
+		if rec.File != nil {
+			attached = append([]importer.Attachment{*rec.File}, attached...)
+		}
+		var paths []string
+		for _, a := range attached {
+			// Files are kept apart by the row of their entry
+			name := path.Join("files", fmt.Sprint(i+1), path.Base(strings.ReplaceAll(a.Name, "\\", "/")))
+			paths = append(paths, name)
+			files = append(files, file{name: name, data: a.Data})
+		}
+		row = append(row, strings.Join(paths, "\n"))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// csvColumns returns the names of the fields of all record types with a column of their
+// own, in menu order. The title, folder, custom fields, attachments and links to other
+// records have dedicated columns, and the files of binary data are in the archive.
+func csvColumns() []string {
+	var columns []string
+	seen := map[string]bool{
+		records.TitleField: true, records.FolderField: true,
+		records.CustomFieldsField: true, records.AttachmentsField: true,
+	}
+	for _, t := range records.All() {
+		for _, f := range t.Fields {
+			if seen[f.Name] || f.Ref != "" || (t.Binary && (f.Name == "path" || f.Name == "extension")) {
+				continue
+			}
+			seen[f.Name] = true
+			columns = append(columns, f.Name)
+		}
+	}
+	return columns
+}
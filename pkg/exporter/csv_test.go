@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+func TestWriteCSV(t *testing.T) {
+	custom := records.FormatCustomFields([]records.CustomField{{Name: "PIN", Type: records.CustomHidden, Value: "1234"}})
+	recs := []importer.Record{
+		{
+			Table: records.CredentialsTable, Folder: "Work", Tags: []string{"bank", "money"},
+			Data:        map[string]string{"meta_info": "Bank", "login": "alice", "password": "pw", records.CustomFieldsField: custom},
+			TOTP:        map[string]string{"meta_info": "Bank", "secret": "JBSWY3DPEHPK3PXP", "algorithm": "SHA1", "digits": "6", "period": "30"},
+			Attachments: []importer.Attachment{{Name: "codes.txt", Data: []byte("111 222")}},
+		},
+		{Table: records.FilesTable, Data: map[string]string{"meta_info": "Passport"},
+			File: &importer.Attachment{Name: "passport.pdf", Data: []byte("%PDF")}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, recs, time.Now()))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader([]byte(files[CSVName]))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	get := func(row int, column string) string {
+		for i, name := range rows[0] {
+			if name == column {
+				return rows[row][i]
+			}
+		}
+		t.Fatalf("no column %s", column)
+		return ""
+	}
+	assert.Equal(t, "login", get(1, "type"))
+	assert.Equal(t, "Bank", get(1, "title"))
+	assert.Equal(t, "Work", get(1, "folder"))
+	assert.Equal(t, "bank;money", get(1, "tags"))
+	assert.Equal(t, "pw", get(1, "password"))
+	assert.Equal(t, "PIN: 1234", get(1, "custom_fields"))
+	assert.Contains(t, get(1, "totp"), "otpauth://totp/")
+	assert.Equal(t, "files/1/codes.txt", get(1, "files"))
+	assert.Equal(t, "file", get(2, "type"))
+	assert.Equal(t, "files/2/passport.pdf", get(2, "files"))
+
+	assert.Equal(t, "111 222", files["files/1/codes.txt"])
+	assert.Equal(t, "%PDF", files["files/2/passport.pdf"])
+}
@@ -0,0 +1,17 @@
+// Package exporter writes GophKeeper records to files other applications can read.
+//
+// Records are given in the form read by the importer package, so that an export
+// imported again restores the same entries, folders, tags, TOTP authenticators and
+// attachments.
+package exporter
+
+// Supported formats.
+const (
+	JSON = "json" // GophKeeper archive, encrypted with a password or plaintext, read back by the importer.
+	KDBX = "kdbx" // KeePass database in the KDBX 4 format, as opened by KeePass 2 and KeePassXC.
+	CSV  = "csv"  // ZIP archive of a plaintext table of the entries and their files.
+	Pass = "pass" // Directory of a pass password store, encrypted with OpenPGP.
+)
+
+// Formats lists the supported formats.
+var Formats = []string{JSON, KDBX, Pass, CSV}
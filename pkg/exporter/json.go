@@ -0,0 +1,35 @@
+package exporter
+
+import (
+	"io"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/archive"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+)
+
+// WriteJSON writes records to an archive encrypted with the password.
+func WriteJSON(w io.Writer, recs []importer.Record, password string, now time.Time) error {
+	return archive.WriteEncrypted(w, Archive(recs, now), password)
+}
+
+// WritePlainJSON writes records to a plaintext archive.
+func WritePlainJSON(w io.Writer, recs []importer.Record, now time.Time) error {
+	return archive.Write(w, Archive(recs, now))
+}
+
+// Archive converts records to the content of an archive.
+func Archive(recs []importer.Record, now time.Time) *archive.Archive {
+	a := &archive.Archive{Exported: now.UTC(), Entries: make([]archive.Entry, 0, len(recs))}
+	for _, rec := range recs {
+		entry := archive.Entry{Type: rec.Table, Folder: rec.Folder, Tags: rec.Tags, Fields: rec.Data, TOTP: rec.TOTP}
+		if rec.File != nil {
+			entry.File = &archive.File{Name: rec.File.Name, Data: rec.File.Data}
+		}
+		for _, f := range rec.Attachments {
+			entry.Attachments = append(entry.Attachments, archive.File{Name: f.Name, Data: f.Data})
+		}
+		a.Entries = append(a.Entries, entry)
+	}
+	return a
+}
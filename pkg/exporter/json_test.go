@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/archive"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+func TestJSON_RoundTrip(t *testing.T) {
+	recs := []importer.Record{
+		{
+			Table: records.CredentialsTable, Folder: "Work", Tags: []string{"bank"},
+			Data:        map[string]string{"meta_info": "Bank", "login": "alice", "password": "pw"},
+			TOTP:        map[string]string{"meta_info": "Bank", "secret": "JBSWY3DPEHPK3PXP"},
+			Attachments: []importer.Attachment{{Name: "codes.txt", Data: []byte("111 222")}},
+		},
+		{Table: records.FilesTable, Data: map[string]string{"meta_info": "Passport"},
+			File: &importer.Attachment{Name: "passport.pdf", Data: []byte("%PDF")}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, recs, "secret", time.Now()))
+	assert.NotContains(t, buf.String(), "alice")
+
+	format, err := importer.Detect("vault.json", buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, importer.GophKeeper, format)
+
+	_, err = importer.Parse(format, buf.Bytes(), importer.Options{})
+	assert.ErrorIs(t, err, importer.ErrPasswordRequired)
+	_, err = importer.Parse(format, buf.Bytes(), importer.Options{
+		Password: func() (string, error) { return "wrong", nil },
+	})
+	assert.ErrorIs(t, err, archive.ErrDecrypt)
+
+	result, err := importer.Parse(format, buf.Bytes(), importer.Options{
+		Password: func() (string, error) { return "secret", nil },
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 2)
+	for i := range recs {
+		recs[i].Source = result.Records[i].Source
+	}
+	assert.Equal(t, recs, result.Records)
+}
+
+func TestWritePlainJSON(t *testing.T) {
+	recs := []importer.Record{{Table: records.TextTable, Data: map[string]string{"meta_info": "Wifi", "data": "password123"}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePlainJSON(&buf, recs, time.Now()))
+	assert.Contains(t, buf.String(), "password123")
+
+	result, err := importer.Parse(importer.GophKeeper, buf.Bytes(), importer.Options{
+		Password: func() (string, error) { return "", errors.New("no password expected") },
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 1)
+	assert.Equal(t, recs[0].Data, result.Records[0].Data)
+}
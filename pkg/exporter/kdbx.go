@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/kdbx"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// WriteKDBX writes records to a KeePass database encrypted with the given password.
+func WriteKDBX(w io.Writer, recs []importer.Record, password string, opt kdbx.Options) error {
+	db, err := KDBXDatabase(recs)
+	if err != nil {
+		return err
+	}
+	return kdbx.Write(w, db, password, opt)
+}
+
+// KDBXDatabase converts records to a KeePass database. Folders become groups, nested at
+// the slashes of their names. Every entry holds the table of its record in the
+// importer.KDBXTypeKey string, so that it is imported as the same type.
+func KDBXDatabase(recs []importer.Record) (*kdbx.Database, error) {
+	db := &kdbx.Database{Name: "GophKeeper", Root: kdbx.Group{Name: "GophKeeper"}}
+	for _, rec := range recs {
+		entry, err := kdbxEntry(rec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rec.Title(), err)
+		}
+		g := &db.Root
+		for _, name := range strings.Split(rec.Folder, "/") {
+			if name = strings.TrimSpace(name); name != "" {
+				g = subgroup(g, name)
+			}
+		}
+		g.Entries = append(g.Entries, entry)
+	}
+	return db, nil
+}
+
+// subgroup returns the subgroup of g with the given name, adding it if needed.
+func subgroup(g *kdbx.Group, name string) *kdbx.Group {
+	for i := range g.Groups {
+		if g.Groups[i].Name == name {
+			return &g.Groups[i]
+		}
+	}
+	g.Groups = append(g.Groups, kdbx.Group{Name: name})
+	return &g.Groups[len(g.Groups)-1]
+}
+
+// kdbxEntry converts a record to a KeePass entry. Sensitive fields and hidden custom
+// fields are protected, TOTP keys are stored as otpauth:// URIs and files as binaries.
+func kdbxEntry(rec importer.Record) (kdbx.Entry, error) {
+	t, ok := records.ByTable(rec.Table)
+	if !ok {
+		return kdbx.Entry{}, fmt.Errorf("unknown table %s", rec.Table)
+	}
+	entry := kdbx.Entry{Tags: rec.Tags}
+	keys := make(map[string]bool)
+	add := func(key, value string, protected bool) {
+		key = unique(keys, key)
+		entry.Strings = append(entry.Strings, kdbx.String{Key: key, Value: value, Protected: protected})
+	}
+
+	for _, f := range t.Fields {
+		if !importer.KDBXField(t, f) || (rec.Data[f.Name] == "" && f.Name != records.TitleField) {
+			continue
+		}
+		add(importer.KDBXKey(t, f), rec.Data[f.Name], f.Sensitive)
+	}
+	otp := rec.TOTP
+	if t.Table == records.TOTPTable {
+		otp = rec.Data
+	}
+	if otp != nil {
+		key, err := records.TOTPKey(otp)
+		if err != nil {
+			return entry, err
+		}
+		add(kdbx.OTP, key.URI(), true)
+	}
+	custom, err := records.ParseCustomFields(rec.Data[records.CustomFieldsField])
+	if err != nil {
+		return entry, err
+	}
+	for _, f := range custom {
+		add(f.Name, f.Value, f.Type == records.CustomHidden)
+	}
+	add(importer.KDBXTypeKey, t.Table, false)
+
+	// The entry expires at the end of its expiry date
+	if expires, ok := t.ExpiresAt(rec.Data, time.Local); ok {
+		entry.Times = kdbx.Times{Expires: true, Expiry: expires.Add(-time.Second)}
+	}
+
+	files := rec.Attachments
+	if rec.File != nil {
+		files = append([]importer.Attachment{*rec.File}, files...)
+	}
+	names := make(map[string]bool)
+	for _, f := range files {
+		entry.Binaries = append(entry.Binaries, kdbx.Binary{Name: unique(names, f.Name), Data: f.Data})
+	}
+	return entry, nil
+}
+
+// unique returns name, or "name 2" and so on if it is already taken, and marks it as taken.
+func unique(taken map[string]bool, name string) string {
+	key := name
+	for i := 2; taken[key]; i++ {
+		key = fmt.Sprintf("%s %d", name, i)
+	}
+	taken[key] = true
+	return key
+}
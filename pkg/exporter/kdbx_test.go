@@ -0,0 +1,71 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/kdbx"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// testOptions keep the key derivation fast in tests.
+var testOptions = kdbx.Options{Cipher: kdbx.ChaCha20, KDF: kdbx.Argon2d, Iterations: 1, Memory: 64 << 10, Parallelism: 1}
+
+func TestKDBX_RoundTrip(t *testing.T) {
+	custom := records.FormatCustomFields([]records.CustomField{
+		{Name: "URL", Type: records.CustomURL, Value: "https://bank.example.com"},
+		{Name: "PIN", Type: records.CustomHidden, Value: "1234"},
+	})
+	recs := []importer.Record{
+		{
+			Table: records.CredentialsTable, Folder: "Work/Finance", Tags: []string{"bank"},
+			Data: map[string]string{
+				"meta_info": "Bank", "login": "alice", "password": "pw",
+				records.ExpiresField: "2030-01-31", records.CustomFieldsField: custom,
+			},
+			TOTP:        map[string]string{"meta_info": "Bank", "secret": "JBSWY3DPEHPK3PXP", "algorithm": "SHA1", "digits": "6", "period": "30"},
+			Attachments: []importer.Attachment{{Name: "codes.txt", Data: []byte("111 222")}},
+		},
+		{Table: records.TextTable, Data: map[string]string{"meta_info": "Wifi", "data": "password123"}},
+		{Table: records.CardsTable, Data: map[string]string{"meta_info": "Visa", "card_number": "4111", "expiration_date": "01/30", "cvv": "123"}},
+		{Table: records.FilesTable, Data: map[string]string{"meta_info": "Passport"},
+			File: &importer.Attachment{Name: "passport.pdf", Data: []byte("%PDF")}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteKDBX(&buf, recs, "secret", testOptions))
+
+	db, err := kdbx.Read(bytes.NewReader(buf.Bytes()), "secret")
+	require.NoError(t, err)
+	require.Len(t, db.Root.Groups, 1)
+	bank := db.Root.Groups[0].Groups[0].Entries[0]
+	assert.Equal(t, "Bank", bank.Get(kdbx.Title))
+	assert.Equal(t, "alice", bank.Get(kdbx.UserName))
+	assert.Equal(t, "https://bank.example.com", bank.Get(kdbx.URL))
+	assert.Contains(t, bank.Get(kdbx.OTP), "otpauth://totp/")
+	assert.Equal(t, time.Date(2030, 1, 31, 23, 59, 59, 0, time.Local).UTC(), bank.Times.Expiry)
+
+	result, err := importer.Parse(importer.KDBX, buf.Bytes(), importer.Options{
+		Password: func() (string, error) { return "secret", nil },
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 4)
+
+	got := result.Records[3]
+	assert.Equal(t, "Work/Finance", got.Folder)
+	assert.Equal(t, []string{"bank"}, got.Tags)
+	assert.Equal(t, "pw", got.Data["password"])
+	assert.Equal(t, "2030-01-31", got.Data[records.ExpiresField])
+	assert.Equal(t, custom, got.Data[records.CustomFieldsField])
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", got.TOTP["secret"])
+	assert.Equal(t, recs[0].Attachments, got.Attachments)
+
+	assert.Equal(t, recs[1].Data, result.Records[0].Data)
+	assert.Equal(t, recs[2].Data, result.Records[1].Data)
+	assert.Equal(t, records.FilesTable, result.Records[2].Table)
+	assert.Equal(t, recs[3].File, result.Records[2].File)
+}
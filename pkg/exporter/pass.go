@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/passstore"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// WritePass writes records to a new pass password store in dir, encrypted to the
+// recipients. It returns the records, or the parts of them, that cannot be stored.
+func WritePass(dir string, recs []importer.Record, recipients openpgp.EntityList) ([]importer.Skipped, error) {
+	entries, skipped, err := PassEntries(recs)
+	if err != nil {
+		return nil, err
+	}
+	return skipped, passstore.WriteDir(dir, entries, recipients)
+}
+
+// PassEntries converts records to the entries of a pass store. Entries are named by the
+// folder and title of their record, numbered if several records have the same. Files
+// cannot be stored, so binary data records are skipped and attachments left out.
+func PassEntries(recs []importer.Record) ([]passstore.Entry, []importer.Skipped, error) {
+	var entries []passstore.Entry
+	var skipped []importer.Skipped
+	names := make(map[string]bool)
+	for _, rec := range recs {
+		t, ok := records.ByTable(rec.Table)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unknown table %s", rec.Title(), rec.Table)
+		}
+		if t.Binary {
+			skipped = append(skipped, importer.Skipped{Source: rec.Source, Title: rec.Title(), Reason: "files cannot be stored in a password store"})
+			continue
+		}
+		if len(rec.Attachments) > 0 {
+			skipped = append(skipped, importer.Skipped{Source: rec.Source, Title: rec.Title(),
+				Reason: fmt.Sprintf("%d attachments left out, files cannot be stored in a password store", len(rec.Attachments))})
+		}
+		content, err := passContent(t, rec)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", rec.Title(), err)
+		}
+
+		base := passName(rec.Folder, rec.Title())
+		name := base
+		for i := 2; names[strings.ToLower(name)]; i++ {
+			name = fmt.Sprintf("%s %d", base, i)
+		}
+		names[strings.ToLower(name)] = true
+		entries = append(entries, passstore.Entry{Name: name, Data: content.Bytes()})
+	}
+	return entries, skipped, nil
+}
+
+// passContent returns the content of the pass entry of a record. The type of records
+// other than logins is given by a field, and values on several lines are moved to the
+// free text, after the text of the record or its notes.
+func passContent(t *records.Type, rec importer.Record) (passstore.Content, error) {
+	c := passstore.Content{Password: rec.Data["password"]}
+	if t.Table != records.CredentialsTable {
+		c.Password = ""
+		c.Fields = append(c.Fields, passstore.Field{Key: importer.PassTypeKey, Value: t.Key})
+	}
+	var extra []string
+	add := func(key, value string) {
+		switch {
+		case value == "":
+		case strings.Contains(value, "\n"):
+			extra = append(extra, key+":\n"+value)
+		default:
+			c.Fields = append(c.Fields, passstore.Field{Key: key, Value: value})
+		}
+	}
+	for _, f := range t.Fields {
+		if importer.PassField(t, f) {
+			add(f.Name, rec.Data[f.Name])
+		}
+	}
+
+	otp := rec.TOTP
+	if t.Table == records.TOTPTable {
+		otp = rec.Data
+	}
+	if otp != nil {
+		key, err := records.TOTPKey(otp)
+		if err != nil {
+			return c, err
+		}
+		c.Fields = append(c.Fields, passstore.Field{Key: passstore.OTPKey, Value: key.URI()})
+	}
+
+	if body := importer.PassBodyField(t); body != "" {
+		c.Notes = rec.Data[body]
+	}
+	custom, err := records.ParseCustomFields(rec.Data[records.CustomFieldsField])
+	if err != nil {
+		return c, err
+	}
+	for _, f := range custom {
+		if f.Name == "Notes" && c.Notes == "" {
+			c.Notes = f.Value
+			continue
+		}
+		add(f.Name, f.Value)
+	}
+	if len(extra) > 0 {
+		c.Notes = strings.Join(append([]string{c.Notes}, extra...), "\n\n")
+		c.Notes = strings.TrimPrefix(c.Notes, "\n\n")
+	}
+	return c, nil
+}
+
+// passName returns the name of the entry of a record in its folder. Slashes in titles
+// and leading dots, which would hide entries, are replaced.
+func passName(folder, title string) string {
+	var parts []string
+	for _, part := range strings.Split(folder, "/") {
+		if part = passPart(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	name := passPart(strings.ReplaceAll(title, "/", "-"))
+	if name == "" {
+		name = "untitled"
+	}
+	return path.Join(append(parts, name)...)
+}
+
+// passPart returns a file name for a part of the name of an entry.
+func passPart(part string) string {
+	part = strings.TrimSpace(part)
+	if strings.HasPrefix(part, ".") {
+		part = "_" + part[1:]
+	}
+	return part
+}
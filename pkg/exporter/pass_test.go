@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+func TestPass_RoundTrip(t *testing.T) {
+	key, err := openpgp.NewEntity("Test", "", "alice@example.com", &packet.Config{RSABits: 1024})
+	require.NoError(t, err)
+	for _, id := range key.Identities {
+		id.SelfSignature.PreferredHash = []uint8{8}      // SHA-256
+		id.SelfSignature.PreferredSymmetric = []uint8{9} // AES-256
+	}
+
+	custom := records.FormatCustomFields([]records.CustomField{
+		{Name: "URL", Type: records.CustomURL, Value: "https://bank.example.com"},
+		{Name: "Notes", Type: records.CustomText, Value: "first\nsecond"},
+	})
+	recs := []importer.Record{
+		{
+			Table: records.CredentialsTable, Folder: "Work/Finance",
+			Data: map[string]string{"meta_info": "Bank", "login": "alice", "password": "pw", records.CustomFieldsField: custom},
+			TOTP: map[string]string{"meta_info": "Bank", "secret": "JBSWY3DPEHPK3PXP", "algorithm": "SHA1", "digits": "6", "period": "30"},
+		},
+		{Table: records.CredentialsTable, Folder: "Work/Finance", Data: map[string]string{"meta_info": "Bank", "login": "bob", "password": "pw2"}},
+		{Table: records.TextTable, Data: map[string]string{"meta_info": "Wifi", "data": "password: 123\nline"}},
+		{Table: records.CardsTable, Data: map[string]string{"meta_info": "Visa", "card_number": "4111", "expiration_date": "01/30", "cvv": "123"}},
+		{Table: records.FilesTable, Source: "7", Data: map[string]string{"meta_info": "Passport"},
+			File: &importer.Attachment{Name: "passport.pdf", Data: []byte("%PDF")}},
+	}
+
+	dir := filepath.Join(t.TempDir(), "store")
+	skipped, err := WritePass(dir, recs, openpgp.EntityList{key})
+	require.NoError(t, err)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "7", skipped[0].Source)
+
+	result, err := importer.ParsePass(dir, importer.Options{Keys: openpgp.EntityList{key}})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 4)
+
+	visa, wifi, bank, bank2 := result.Records[0], result.Records[1], result.Records[2], result.Records[3]
+	assert.Equal(t, "Work/Finance/Bank", bank.Source)
+	assert.Equal(t, "Work/Finance", bank.Folder)
+	assert.Equal(t, recs[0].Data, bank.Data)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", bank.TOTP["secret"])
+	assert.Equal(t, "Bank 2", bank2.Title())
+	assert.Equal(t, "bob", bank2.Data["login"])
+	assert.Equal(t, recs[2].Data, wifi.Data)
+	assert.Equal(t, records.CardsTable, visa.Table)
+	assert.Equal(t, recs[3].Data, visa.Data)
+
+	_, err = importer.ParsePass(dir, importer.Options{})
+	assert.ErrorIs(t, err, importer.ErrKeyRequired)
+}
@@ -24,19 +24,23 @@ var ErrNetworkUnavailable = errors.New("network unavailable")
 
 //---
 
+// Session defines model for Session.
+type Session struct {
+	Current    *bool      `json:"current,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	Device     *string    `json:"device,omitempty"`
+	LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+	SessionID  *string    `json:"sessionID,omitempty"`
+}
+
 // PostAddDataTableUserIDEntryIDJSONBody defines parameters for PostAddDataTableUserIDEntryID.
 type PostAddDataTableUserIDEntryIDJSONBody map[string]string
 
-// PostLoginJSONBody defines parameters for PostLogin.
-type PostLoginJSONBody struct {
-	Password string `json:"password,omitempty"`
-	Username string `json:"username,omitempty"`
-}
-
 // PostRegisterJSONBody defines parameters for PostRegister.
 type PostRegisterJSONBody struct {
-	Password string `json:"password,omitempty"`
+	Salt     string `json:"salt,omitempty"`
 	Username string `json:"username,omitempty"`
+	Verifier string `json:"verifier,omitempty"`
 }
 
 // PutUpdateDataTableUserIDEntryIDJSONBody defines parameters for PutUpdateDataTableUserIDEntryID.
@@ -45,15 +49,39 @@ type PutUpdateDataTableUserIDEntryIDJSONBody map[string]string
 // PostAddDataTableUserIDEntryIDJSONRequestBody defines body for PostAddDataTableUserIDEntryID for application/json ContentType.
 type PostAddDataTableUserIDEntryIDJSONRequestBody PostAddDataTableUserIDEntryIDJSONBody
 
-// PostLoginJSONRequestBody defines body for PostLogin for application/json ContentType.
-type PostLoginJSONRequestBody PostLoginJSONBody
-
 // PostRegisterJSONRequestBody defines body for PostRegister for application/json ContentType.
 type PostRegisterJSONRequestBody PostRegisterJSONBody
 
 // PutUpdateDataTableUserIDEntryIDJSONRequestBody defines body for PutUpdateDataTableUserIDEntryID for application/json ContentType.
 type PutUpdateDataTableUserIDEntryIDJSONRequestBody PutUpdateDataTableUserIDEntryIDJSONBody
 
+// PostLoginInitJSONBody defines parameters for PostLoginInit.
+type PostLoginInitJSONBody struct {
+	A        string `json:"A,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// PostLoginInitJSONRequestBody defines body for PostLoginInit for application/json ContentType.
+type PostLoginInitJSONRequestBody PostLoginInitJSONBody
+
+// PostLoginVerifyJSONBody defines parameters for PostLoginVerify.
+type PostLoginVerifyJSONBody struct {
+	M1        string `json:"M1,omitempty"`
+	SessionID string `json:"sessionID,omitempty"`
+}
+
+// PostLoginVerifyJSONRequestBody defines body for PostLoginVerify for application/json ContentType.
+type PostLoginVerifyJSONRequestBody PostLoginVerifyJSONBody
+
+// PutAccountPasswordJSONBody defines parameters for PutAccountPassword.
+type PutAccountPasswordJSONBody struct {
+	Salt     string `json:"salt,omitempty"`
+	Verifier string `json:"verifier,omitempty"`
+}
+
+// PutAccountPasswordJSONRequestBody defines body for PutAccountPassword for application/json ContentType.
+type PutAccountPasswordJSONRequestBody PutAccountPasswordJSONBody
+
 // RequestEditorFn  is the function signature for the RequestEditor callback function
 type RequestEditorFn func(ctx context.Context, req *http.Request) error
 
@@ -144,17 +172,9 @@ type ClientInterface interface {
 	// GetGetFileUserIDEntryID request
 	GetGetFileUserIDEntryID(ctx context.Context, userID int, entryID string, reqEditors ...RequestEditorFn) (*http.Response, error)
 
-	// GetGetPasswordUsername request
-	GetGetPasswordUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error)
-
 	// GetGetUserIDUsername request
 	GetGetUserIDUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error)
 
-	// PostLoginWithBody request with any body
-	PostLoginWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
-
-	PostLogin(ctx context.Context, body PostLoginJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
-
 	// PostRegisterWithBody request with any body
 	PostRegisterWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -167,6 +187,33 @@ type ClientInterface interface {
 	PutUpdateDataTableUserIDEntryIDWithBody(ctx context.Context, table string, userID int, entryID string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	PutUpdateDataTableUserIDEntryID(ctx context.Context, table string, userID int, entryID string, body PutUpdateDataTableUserIDEntryIDJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostLoginInitWithBody request with any body
+	PostLoginInitWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostLoginInit(ctx context.Context, body PostLoginInitJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostLoginVerifyWithBody request with any body
+	PostLoginVerifyWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostLoginVerify(ctx context.Context, body PostLoginVerifyJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PutAccountPasswordWithBody request with any body
+	PutAccountPasswordWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PutAccountPassword(ctx context.Context, body PutAccountPasswordJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DeleteAccount request
+	DeleteAccount(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetAccountSessions request
+	GetAccountSessions(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DeleteAccountSessions request
+	DeleteAccountSessions(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DeleteAccountSessionsSessionID request
+	DeleteAccountSessionsSessionID(ctx context.Context, sessionID string, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
 func (c *Client) PostAddDataTableUserIDEntryIDWithBody(ctx context.Context, table string, userID int, entryID string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -268,8 +315,8 @@ func (c *Client) GetGetFileUserIDEntryID(ctx context.Context, userID int, entryI
 	return c.Client.Do(req)
 }
 
-func (c *Client) GetGetPasswordUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewGetGetPasswordUsernameRequest(c.Server, username)
+func (c *Client) GetGetUserIDUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetGetUserIDUsernameRequest(c.Server, username)
 	if err != nil {
 		return nil, err
 	}
@@ -280,8 +327,8 @@ func (c *Client) GetGetPasswordUsername(ctx context.Context, username string, re
 	return c.Client.Do(req)
 }
 
-func (c *Client) GetGetUserIDUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewGetGetUserIDUsernameRequest(c.Server, username)
+func (c *Client) PostRegisterWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostRegisterRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -292,8 +339,8 @@ func (c *Client) GetGetUserIDUsername(ctx context.Context, username string, reqE
 	return c.Client.Do(req)
 }
 
-func (c *Client) PostLoginWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostLoginRequestWithBody(c.Server, contentType, body)
+func (c *Client) PostRegister(ctx context.Context, body PostRegisterJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostRegisterRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
@@ -304,32 +351,45 @@ func (c *Client) PostLoginWithBody(ctx context.Context, contentType string, body
 	return c.Client.Do(req)
 }
 
-func (c *Client) PostLogin(ctx context.Context, body PostLoginJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostLoginRequest(c.Server, body)
+func (c *Client) PostSendFileUserIDWithBody(ctx context.Context, userID int, fileName string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostSendFileUserIDRequestWithBody(c.Server, userID, fileName, contentType, body)
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
+	// +++
+	err = c.addTokenToHeader(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// ---
+
 	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
 		return nil, err
 	}
 	return c.Client.Do(req)
 }
 
-func (c *Client) PostRegisterWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostRegisterRequestWithBody(c.Server, contentType, body)
+func (c *Client) PutUpdateDataTableUserIDEntryIDWithBody(ctx context.Context, table string, userID int, entryID string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUpdateDataTableUserIDEntryIDRequestWithBody(c.Server, table, userID, entryID, contentType, body)
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
+	// +++
+	err = c.addTokenToHeader(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// ---
 	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
 		return nil, err
 	}
 	return c.Client.Do(req)
 }
 
-func (c *Client) PostRegister(ctx context.Context, body PostRegisterJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostRegisterRequest(c.Server, body)
+func (c *Client) PutUpdateDataTableUserIDEntryID(ctx context.Context, table string, userID int, entryID string, body PutUpdateDataTableUserIDEntryIDJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUpdateDataTableUserIDEntryIDRequest(c.Server, table, userID, entryID, body)
 	if err != nil {
 		return nil, err
 	}
@@ -340,8 +400,56 @@ func (c *Client) PostRegister(ctx context.Context, body PostRegisterJSONRequestB
 	return c.Client.Do(req)
 }
 
-func (c *Client) PostSendFileUserIDWithBody(ctx context.Context, userID int, fileName string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostSendFileUserIDRequestWithBody(c.Server, userID, fileName, contentType, body)
+func (c *Client) PostLoginInitWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostLoginInitRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostLoginInit(ctx context.Context, body PostLoginInitJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostLoginInitRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostLoginVerifyWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostLoginVerifyRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostLoginVerify(ctx context.Context, body PostLoginVerifyJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostLoginVerifyRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutAccountPasswordWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutAccountPasswordRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -352,15 +460,32 @@ func (c *Client) PostSendFileUserIDWithBody(ctx context.Context, userID int, fil
 		return nil, err
 	}
 	// ---
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
+func (c *Client) PutAccountPassword(ctx context.Context, body PutAccountPasswordJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutAccountPasswordRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	// +++
+	err = c.addTokenToHeader(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// ---
 	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
 		return nil, err
 	}
 	return c.Client.Do(req)
 }
 
-func (c *Client) PutUpdateDataTableUserIDEntryIDWithBody(ctx context.Context, table string, userID int, entryID string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPutUpdateDataTableUserIDEntryIDRequestWithBody(c.Server, table, userID, entryID, contentType, body)
+func (c *Client) DeleteAccount(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDeleteAccountRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -377,12 +502,54 @@ func (c *Client) PutUpdateDataTableUserIDEntryIDWithBody(ctx context.Context, ta
 	return c.Client.Do(req)
 }
 
-func (c *Client) PutUpdateDataTableUserIDEntryID(ctx context.Context, table string, userID int, entryID string, body PutUpdateDataTableUserIDEntryIDJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPutUpdateDataTableUserIDEntryIDRequest(c.Server, table, userID, entryID, body)
+func (c *Client) GetAccountSessions(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetAccountSessionsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	// +++
+	err = c.addTokenToHeader(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// ---
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DeleteAccountSessions(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDeleteAccountSessionsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	// +++
+	err = c.addTokenToHeader(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// ---
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DeleteAccountSessionsSessionID(ctx context.Context, sessionID string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDeleteAccountSessionsSessionIDRequest(c.Server, sessionID)
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
+	// +++
+	err = c.addTokenToHeader(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// ---
 	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
 		return nil, err
 	}
@@ -632,40 +799,6 @@ func NewGetGetFileUserIDEntryIDRequest(server string, userID int, entryID string
 	return req, nil
 }
 
-// NewGetGetPasswordUsernameRequest generates requests for GetGetPasswordUsername
-func NewGetGetPasswordUsernameRequest(server string, username string) (*http.Request, error) {
-	var err error
-
-	var pathParam0 string
-
-	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "username", runtime.ParamLocationPath, username)
-	if err != nil {
-		return nil, err
-	}
-
-	serverURL, err := url.Parse(server)
-	if err != nil {
-		return nil, err
-	}
-
-	operationPath := fmt.Sprintf("/getPassword/%s", pathParam0)
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
-	}
-
-	queryURL, err := serverURL.Parse(operationPath)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return req, nil
-}
-
 // NewGetGetUserIDUsernameRequest generates requests for GetGetUserIDUsername
 func NewGetGetUserIDUsernameRequest(server string, username string) (*http.Request, error) {
 	var err error
@@ -700,46 +833,6 @@ func NewGetGetUserIDUsernameRequest(server string, username string) (*http.Reque
 	return req, nil
 }
 
-// NewPostLoginRequest calls the generic PostLogin builder with application/json body
-func NewPostLoginRequest(server string, body PostLoginJSONRequestBody) (*http.Request, error) {
-	var bodyReader io.Reader
-	buf, err := json.Marshal(body)
-	if err != nil {
-		return nil, err
-	}
-	bodyReader = bytes.NewReader(buf)
-	return NewPostLoginRequestWithBody(server, "application/json", bodyReader)
-}
-
-// NewPostLoginRequestWithBody generates requests for PostLogin with any type of body
-func NewPostLoginRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
-	if err != nil {
-		return nil, err
-	}
-
-	operationPath := fmt.Sprintf("/login")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
-	}
-
-	queryURL, err := serverURL.Parse(operationPath)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest("POST", queryURL.String(), body)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("Content-Type", contentType)
-
-	return req, nil
-}
-
 // NewPostRegisterRequest calls the generic PostRegister builder with application/json body
 func NewPostRegisterRequest(server string, body PostRegisterJSONRequestBody) (*http.Request, error) {
 	var bodyReader io.Reader
@@ -883,39 +976,274 @@ func NewPutUpdateDataTableUserIDEntryIDRequestWithBody(server string, table stri
 	return req, nil
 }
 
-func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
-	for _, r := range c.RequestEditors {
-		if err := r(ctx, req); err != nil {
-			return err
-		}
-	}
-	for _, r := range additionalEditors {
-		if err := r(ctx, req); err != nil {
-			return err
-		}
+// NewPostLoginInitRequest calls the generic PostLoginInit builder with application/json body
+func NewPostLoginInitRequest(server string, body PostLoginInitJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	bodyReader = bytes.NewReader(buf)
+	return NewPostLoginInitRequestWithBody(server, "application/json", bodyReader)
 }
 
-// ClientWithResponses builds on ClientInterface to offer response payloads
-type ClientWithResponses struct {
-	ClientInterface
-}
+// NewPostLoginInitRequestWithBody generates requests for PostLoginInit with any type of body
+func NewPostLoginInitRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
 
-// NewClientWithResponses creates a new ClientWithResponses, which wraps
-// Client with return type handling
-func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
-	client, err := NewClient(server, opts...)
+	serverURL, err := url.Parse(server)
 	if err != nil {
 		return nil, err
 	}
-	return &ClientWithResponses{client}, nil
-}
 
-// WithBaseURL overrides the baseURL.
-func WithBaseURL(baseURL string) ClientOption {
-	return func(c *Client) error {
-		newBaseURL, err := url.Parse(baseURL)
+	operationPath := fmt.Sprintf("/login/init")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewPostLoginVerifyRequest calls the generic PostLoginVerify builder with application/json body
+func NewPostLoginVerifyRequest(server string, body PostLoginVerifyJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPostLoginVerifyRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewPostLoginVerifyRequestWithBody generates requests for PostLoginVerify with any type of body
+func NewPostLoginVerifyRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/login/verify")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewPutAccountPasswordRequest calls the generic PutAccountPassword builder with application/json body
+func NewPutAccountPasswordRequest(server string, body PutAccountPasswordJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPutAccountPasswordRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewPutAccountPasswordRequestWithBody generates requests for PutAccountPassword with any type of body
+func NewPutAccountPasswordRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/account/password")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewDeleteAccountRequest generates requests for DeleteAccount
+func NewDeleteAccountRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/account")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetAccountSessionsRequest generates requests for GetAccountSessions
+func NewGetAccountSessionsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/account/sessions")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewDeleteAccountSessionsRequest generates requests for DeleteAccountSessions
+func NewDeleteAccountSessionsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/account/sessions")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewDeleteAccountSessionsSessionIDRequest generates requests for DeleteAccountSessionsSessionID
+func NewDeleteAccountSessionsSessionIDRequest(server string, sessionID string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "sessionID", runtime.ParamLocationPath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/account/sessions/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
 		if err != nil {
 			return err
 		}
@@ -943,17 +1271,9 @@ type ClientWithResponsesInterface interface {
 	// GetGetFileUserIDEntryIDWithResponse request
 	GetGetFileUserIDEntryIDWithResponse(ctx context.Context, userID int, entryID string, reqEditors ...RequestEditorFn) (*GetGetFileUserIDEntryIDResponse, error)
 
-	// GetGetPasswordUsernameWithResponse request
-	GetGetPasswordUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*GetGetPasswordUsernameResponse, error)
-
 	// GetGetUserIDUsernameWithResponse request
 	GetGetUserIDUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*GetGetUserIDUsernameResponse, error)
 
-	// PostLoginWithBodyWithResponse request with any body
-	PostLoginWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostLoginResponse, error)
-
-	PostLoginWithResponse(ctx context.Context, body PostLoginJSONRequestBody, reqEditors ...RequestEditorFn) (*PostLoginResponse, error)
-
 	// PostRegisterWithBodyWithResponse request with any body
 	PostRegisterWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostRegisterResponse, error)
 
@@ -966,15 +1286,107 @@ type ClientWithResponsesInterface interface {
 	PutUpdateDataTableUserIDEntryIDWithBodyWithResponse(ctx context.Context, table string, userID int, entryID string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutUpdateDataTableUserIDEntryIDResponse, error)
 
 	PutUpdateDataTableUserIDEntryIDWithResponse(ctx context.Context, table string, userID int, entryID string, body PutUpdateDataTableUserIDEntryIDJSONRequestBody, reqEditors ...RequestEditorFn) (*PutUpdateDataTableUserIDEntryIDResponse, error)
+
+	// PostLoginInitWithBodyWithResponse request with any body
+	PostLoginInitWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostLoginInitResponse, error)
+
+	PostLoginInitWithResponse(ctx context.Context, body PostLoginInitJSONRequestBody, reqEditors ...RequestEditorFn) (*PostLoginInitResponse, error)
+
+	// PostLoginVerifyWithBodyWithResponse request with any body
+	PostLoginVerifyWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostLoginVerifyResponse, error)
+
+	PostLoginVerifyWithResponse(ctx context.Context, body PostLoginVerifyJSONRequestBody, reqEditors ...RequestEditorFn) (*PostLoginVerifyResponse, error)
+
+	// PutAccountPasswordWithBodyWithResponse request with any body
+	PutAccountPasswordWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutAccountPasswordResponse, error)
+
+	PutAccountPasswordWithResponse(ctx context.Context, body PutAccountPasswordJSONRequestBody, reqEditors ...RequestEditorFn) (*PutAccountPasswordResponse, error)
+
+	// DeleteAccountWithResponse request
+	DeleteAccountWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*DeleteAccountResponse, error)
+
+	// GetAccountSessionsWithResponse request
+	GetAccountSessionsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetAccountSessionsResponse, error)
+
+	// DeleteAccountSessionsWithResponse request
+	DeleteAccountSessionsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*DeleteAccountSessionsResponse, error)
+
+	// DeleteAccountSessionsSessionIDWithResponse request
+	DeleteAccountSessionsSessionIDWithResponse(ctx context.Context, sessionID string, reqEditors ...RequestEditorFn) (*DeleteAccountSessionsSessionIDResponse, error)
+}
+
+type PostAddDataTableUserIDEntryIDResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r PostAddDataTableUserIDEntryIDResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostAddDataTableUserIDEntryIDResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DeleteDeleteDataTableUserIDEntryIDResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DeleteDeleteDataTableUserIDEntryIDResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeleteDeleteDataTableUserIDEntryIDResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetGetAllDataTableUserIDResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]map[string]string
+}
+
+// Status returns HTTPResponse.Status
+func (r GetGetAllDataTableUserIDResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetGetAllDataTableUserIDResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
 }
 
-type PostAddDataTableUserIDEntryIDResponse struct {
+type GetGetDataTableUserIDEntryIDResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
+	JSON200      *map[string]string
 }
 
 // Status returns HTTPResponse.Status
-func (r PostAddDataTableUserIDEntryIDResponse) Status() string {
+func (r GetGetDataTableUserIDEntryIDResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -982,20 +1394,20 @@ func (r PostAddDataTableUserIDEntryIDResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r PostAddDataTableUserIDEntryIDResponse) StatusCode() int {
+func (r GetGetDataTableUserIDEntryIDResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type DeleteDeleteDataTableUserIDEntryIDResponse struct {
+type GetGetFileUserIDEntryIDResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 }
 
 // Status returns HTTPResponse.Status
-func (r DeleteDeleteDataTableUserIDEntryIDResponse) Status() string {
+func (r GetGetFileUserIDEntryIDResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1003,21 +1415,21 @@ func (r DeleteDeleteDataTableUserIDEntryIDResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r DeleteDeleteDataTableUserIDEntryIDResponse) StatusCode() int {
+func (r GetGetFileUserIDEntryIDResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetGetAllDataTableUserIDResponse struct {
+type GetGetUserIDUsernameResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON200      *[]map[string]string
+	JSON200      *int
 }
 
 // Status returns HTTPResponse.Status
-func (r GetGetAllDataTableUserIDResponse) Status() string {
+func (r GetGetUserIDUsernameResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1025,21 +1437,20 @@ func (r GetGetAllDataTableUserIDResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetGetAllDataTableUserIDResponse) StatusCode() int {
+func (r GetGetUserIDUsernameResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetGetDataTableUserIDEntryIDResponse struct {
+type PostRegisterResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON200      *map[string]string
 }
 
 // Status returns HTTPResponse.Status
-func (r GetGetDataTableUserIDEntryIDResponse) Status() string {
+func (r PostRegisterResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1047,20 +1458,20 @@ func (r GetGetDataTableUserIDEntryIDResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetGetDataTableUserIDEntryIDResponse) StatusCode() int {
+func (r PostRegisterResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetGetFileUserIDEntryIDResponse struct {
+type PostSendFileUserIDResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 }
 
 // Status returns HTTPResponse.Status
-func (r GetGetFileUserIDEntryIDResponse) Status() string {
+func (r PostSendFileUserIDResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1068,21 +1479,20 @@ func (r GetGetFileUserIDEntryIDResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetGetFileUserIDEntryIDResponse) StatusCode() int {
+func (r PostSendFileUserIDResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetGetPasswordUsernameResponse struct {
+type PutUpdateDataTableUserIDEntryIDResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON200      *string
 }
 
 // Status returns HTTPResponse.Status
-func (r GetGetPasswordUsernameResponse) Status() string {
+func (r PutUpdateDataTableUserIDEntryIDResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1090,21 +1500,25 @@ func (r GetGetPasswordUsernameResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetGetPasswordUsernameResponse) StatusCode() int {
+func (r PutUpdateDataTableUserIDEntryIDResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetGetUserIDUsernameResponse struct {
+type PostLoginInitResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON200      *int
+	JSON200      *struct {
+		B         *string `json:"B,omitempty"`
+		Salt      *string `json:"salt,omitempty"`
+		SessionID *string `json:"sessionID,omitempty"`
+	}
 }
 
 // Status returns HTTPResponse.Status
-func (r GetGetUserIDUsernameResponse) Status() string {
+func (r PostLoginInitResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1112,24 +1526,25 @@ func (r GetGetUserIDUsernameResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetGetUserIDUsernameResponse) StatusCode() int {
+func (r PostLoginInitResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type PostLoginResponse struct {
+type PostLoginVerifyResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 	JSON200      *struct {
+		M2     *string `json:"M2,omitempty"`
 		Token  *string `json:"token,omitempty"`
 		UserID *int    `json:"userID,omitempty"`
 	}
 }
 
 // Status returns HTTPResponse.Status
-func (r PostLoginResponse) Status() string {
+func (r PostLoginVerifyResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1137,20 +1552,20 @@ func (r PostLoginResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r PostLoginResponse) StatusCode() int {
+func (r PostLoginVerifyResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type PostRegisterResponse struct {
+type PutAccountPasswordResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 }
 
 // Status returns HTTPResponse.Status
-func (r PostRegisterResponse) Status() string {
+func (r PutAccountPasswordResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1158,20 +1573,20 @@ func (r PostRegisterResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r PostRegisterResponse) StatusCode() int {
+func (r PutAccountPasswordResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type PostSendFileUserIDResponse struct {
+type DeleteAccountResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 }
 
 // Status returns HTTPResponse.Status
-func (r PostSendFileUserIDResponse) Status() string {
+func (r DeleteAccountResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1179,20 +1594,21 @@ func (r PostSendFileUserIDResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r PostSendFileUserIDResponse) StatusCode() int {
+func (r DeleteAccountResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type PutUpdateDataTableUserIDEntryIDResponse struct {
+type GetAccountSessionsResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
+	JSON200      *[]Session
 }
 
 // Status returns HTTPResponse.Status
-func (r PutUpdateDataTableUserIDEntryIDResponse) Status() string {
+func (r GetAccountSessionsResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -1200,7 +1616,49 @@ func (r PutUpdateDataTableUserIDEntryIDResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r PutUpdateDataTableUserIDEntryIDResponse) StatusCode() int {
+func (r GetAccountSessionsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DeleteAccountSessionsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DeleteAccountSessionsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeleteAccountSessionsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DeleteAccountSessionsSessionIDResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DeleteAccountSessionsSessionIDResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeleteAccountSessionsSessionIDResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
@@ -1260,15 +1718,6 @@ func (c *ClientWithResponses) GetGetFileUserIDEntryIDWithResponse(ctx context.Co
 	return ParseGetGetFileUserIDEntryIDResponse(rsp)
 }
 
-// GetGetPasswordUsernameWithResponse request returning *GetGetPasswordUsernameResponse
-func (c *ClientWithResponses) GetGetPasswordUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*GetGetPasswordUsernameResponse, error) {
-	rsp, err := c.GetGetPasswordUsername(ctx, username, reqEditors...)
-	if err != nil {
-		return nil, err
-	}
-	return ParseGetGetPasswordUsernameResponse(rsp)
-}
-
 // GetGetUserIDUsernameWithResponse request returning *GetGetUserIDUsernameResponse
 func (c *ClientWithResponses) GetGetUserIDUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*GetGetUserIDUsernameResponse, error) {
 	rsp, err := c.GetGetUserIDUsername(ctx, username, reqEditors...)
@@ -1278,23 +1727,6 @@ func (c *ClientWithResponses) GetGetUserIDUsernameWithResponse(ctx context.Conte
 	return ParseGetGetUserIDUsernameResponse(rsp)
 }
 
-// PostLoginWithBodyWithResponse request with arbitrary body returning *PostLoginResponse
-func (c *ClientWithResponses) PostLoginWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostLoginResponse, error) {
-	rsp, err := c.PostLoginWithBody(ctx, contentType, body, reqEditors...)
-	if err != nil {
-		return nil, err
-	}
-	return ParsePostLoginResponse(rsp)
-}
-
-func (c *ClientWithResponses) PostLoginWithResponse(ctx context.Context, body PostLoginJSONRequestBody, reqEditors ...RequestEditorFn) (*PostLoginResponse, error) {
-	rsp, err := c.PostLogin(ctx, body, reqEditors...)
-	if err != nil {
-		return nil, err
-	}
-	return ParsePostLoginResponse(rsp)
-}
-
 // PostRegisterWithBodyWithResponse request with arbitrary body returning *PostRegisterResponse
 func (c *ClientWithResponses) PostRegisterWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostRegisterResponse, error) {
 	rsp, err := c.PostRegisterWithBody(ctx, contentType, body, reqEditors...)
@@ -1338,6 +1770,93 @@ func (c *ClientWithResponses) PutUpdateDataTableUserIDEntryIDWithResponse(ctx co
 	return ParsePutUpdateDataTableUserIDEntryIDResponse(rsp)
 }
 
+// PostLoginInitWithBodyWithResponse request with arbitrary body returning *PostLoginInitResponse
+func (c *ClientWithResponses) PostLoginInitWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostLoginInitResponse, error) {
+	rsp, err := c.PostLoginInitWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostLoginInitResponse(rsp)
+}
+
+func (c *ClientWithResponses) PostLoginInitWithResponse(ctx context.Context, body PostLoginInitJSONRequestBody, reqEditors ...RequestEditorFn) (*PostLoginInitResponse, error) {
+	rsp, err := c.PostLoginInit(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostLoginInitResponse(rsp)
+}
+
+// PostLoginVerifyWithBodyWithResponse request with arbitrary body returning *PostLoginVerifyResponse
+func (c *ClientWithResponses) PostLoginVerifyWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostLoginVerifyResponse, error) {
+	rsp, err := c.PostLoginVerifyWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostLoginVerifyResponse(rsp)
+}
+
+func (c *ClientWithResponses) PostLoginVerifyWithResponse(ctx context.Context, body PostLoginVerifyJSONRequestBody, reqEditors ...RequestEditorFn) (*PostLoginVerifyResponse, error) {
+	rsp, err := c.PostLoginVerify(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostLoginVerifyResponse(rsp)
+}
+
+// PutAccountPasswordWithBodyWithResponse request with arbitrary body returning *PutAccountPasswordResponse
+func (c *ClientWithResponses) PutAccountPasswordWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutAccountPasswordResponse, error) {
+	rsp, err := c.PutAccountPasswordWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutAccountPasswordResponse(rsp)
+}
+
+func (c *ClientWithResponses) PutAccountPasswordWithResponse(ctx context.Context, body PutAccountPasswordJSONRequestBody, reqEditors ...RequestEditorFn) (*PutAccountPasswordResponse, error) {
+	rsp, err := c.PutAccountPassword(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutAccountPasswordResponse(rsp)
+}
+
+// DeleteAccountWithResponse request returning *DeleteAccountResponse
+func (c *ClientWithResponses) DeleteAccountWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*DeleteAccountResponse, error) {
+	rsp, err := c.DeleteAccount(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeleteAccountResponse(rsp)
+}
+
+// GetAccountSessionsWithResponse request returning *GetAccountSessionsResponse
+func (c *ClientWithResponses) GetAccountSessionsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetAccountSessionsResponse, error) {
+	rsp, err := c.GetAccountSessions(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetAccountSessionsResponse(rsp)
+}
+
+// DeleteAccountSessionsWithResponse request returning *DeleteAccountSessionsResponse
+func (c *ClientWithResponses) DeleteAccountSessionsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*DeleteAccountSessionsResponse, error) {
+	rsp, err := c.DeleteAccountSessions(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeleteAccountSessionsResponse(rsp)
+}
+
+// DeleteAccountSessionsSessionIDWithResponse request returning *DeleteAccountSessionsSessionIDResponse
+func (c *ClientWithResponses) DeleteAccountSessionsSessionIDWithResponse(ctx context.Context, sessionID string, reqEditors ...RequestEditorFn) (*DeleteAccountSessionsSessionIDResponse, error) {
+	rsp, err := c.DeleteAccountSessionsSessionID(ctx, sessionID, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeleteAccountSessionsSessionIDResponse(rsp)
+}
+
 // ParsePostAddDataTableUserIDEntryIDResponse parses an HTTP response from a PostAddDataTableUserIDEntryIDWithResponse call
 func ParsePostAddDataTableUserIDEntryIDResponse(rsp *http.Response) (*PostAddDataTableUserIDEntryIDResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -1438,22 +1957,22 @@ func ParseGetGetFileUserIDEntryIDResponse(rsp *http.Response) (*GetGetFileUserID
 	return response, nil
 }
 
-// ParseGetGetPasswordUsernameResponse parses an HTTP response from a GetGetPasswordUsernameWithResponse call
-func ParseGetGetPasswordUsernameResponse(rsp *http.Response) (*GetGetPasswordUsernameResponse, error) {
+// ParseGetGetUserIDUsernameResponse parses an HTTP response from a GetGetUserIDUsernameWithResponse call
+func ParseGetGetUserIDUsernameResponse(rsp *http.Response) (*GetGetUserIDUsernameResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &GetGetPasswordUsernameResponse{
+	response := &GetGetUserIDUsernameResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest string
+		var dest int
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -1464,22 +1983,74 @@ func ParseGetGetPasswordUsernameResponse(rsp *http.Response) (*GetGetPasswordUse
 	return response, nil
 }
 
-// ParseGetGetUserIDUsernameResponse parses an HTTP response from a GetGetUserIDUsernameWithResponse call
-func ParseGetGetUserIDUsernameResponse(rsp *http.Response) (*GetGetUserIDUsernameResponse, error) {
+// ParsePostRegisterResponse parses an HTTP response from a PostRegisterWithResponse call
+func ParsePostRegisterResponse(rsp *http.Response) (*PostRegisterResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &GetGetUserIDUsernameResponse{
+	response := &PostRegisterResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParsePostSendFileUserIDResponse parses an HTTP response from a PostSendFileUserIDWithResponse call
+func ParsePostSendFileUserIDResponse(rsp *http.Response) (*PostSendFileUserIDResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostSendFileUserIDResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParsePutUpdateDataTableUserIDEntryIDResponse parses an HTTP response from a PutUpdateDataTableUserIDEntryIDWithResponse call
+func ParsePutUpdateDataTableUserIDEntryIDResponse(rsp *http.Response) (*PutUpdateDataTableUserIDEntryIDResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PutUpdateDataTableUserIDEntryIDResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParsePostLoginInitResponse parses an HTTP response from a PostLoginInitWithResponse call
+func ParsePostLoginInitResponse(rsp *http.Response) (*PostLoginInitResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostLoginInitResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest int
+		var dest struct {
+			B         *string `json:"B,omitempty"`
+			Salt      *string `json:"salt,omitempty"`
+			SessionID *string `json:"sessionID,omitempty"`
+		}
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -1490,15 +2061,15 @@ func ParseGetGetUserIDUsernameResponse(rsp *http.Response) (*GetGetUserIDUsernam
 	return response, nil
 }
 
-// ParsePostLoginResponse parses an HTTP response from a PostLoginWithResponse call
-func ParsePostLoginResponse(rsp *http.Response) (*PostLoginResponse, error) {
+// ParsePostLoginVerifyResponse parses an HTTP response from a PostLoginVerifyWithResponse call
+func ParsePostLoginVerifyResponse(rsp *http.Response) (*PostLoginVerifyResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &PostLoginResponse{
+	response := &PostLoginVerifyResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -1506,6 +2077,7 @@ func ParsePostLoginResponse(rsp *http.Response) (*PostLoginResponse, error) {
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
 		var dest struct {
+			M2     *string `json:"M2,omitempty"`
 			Token  *string `json:"token,omitempty"`
 			UserID *int    `json:"userID,omitempty"`
 		}
@@ -1519,15 +2091,15 @@ func ParsePostLoginResponse(rsp *http.Response) (*PostLoginResponse, error) {
 	return response, nil
 }
 
-// ParsePostRegisterResponse parses an HTTP response from a PostRegisterWithResponse call
-func ParsePostRegisterResponse(rsp *http.Response) (*PostRegisterResponse, error) {
+// ParsePutAccountPasswordResponse parses an HTTP response from a PutAccountPasswordWithResponse call
+func ParsePutAccountPasswordResponse(rsp *http.Response) (*PutAccountPasswordResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &PostRegisterResponse{
+	response := &PutAccountPasswordResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -1535,15 +2107,15 @@ func ParsePostRegisterResponse(rsp *http.Response) (*PostRegisterResponse, error
 	return response, nil
 }
 
-// ParsePostSendFileUserIDResponse parses an HTTP response from a PostSendFileUserIDWithResponse call
-func ParsePostSendFileUserIDResponse(rsp *http.Response) (*PostSendFileUserIDResponse, error) {
+// ParseDeleteAccountResponse parses an HTTP response from a DeleteAccountWithResponse call
+func ParseDeleteAccountResponse(rsp *http.Response) (*DeleteAccountResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &PostSendFileUserIDResponse{
+	response := &DeleteAccountResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -1551,15 +2123,57 @@ func ParsePostSendFileUserIDResponse(rsp *http.Response) (*PostSendFileUserIDRes
 	return response, nil
 }
 
-// ParsePutUpdateDataTableUserIDEntryIDResponse parses an HTTP response from a PutUpdateDataTableUserIDEntryIDWithResponse call
-func ParsePutUpdateDataTableUserIDEntryIDResponse(rsp *http.Response) (*PutUpdateDataTableUserIDEntryIDResponse, error) {
+// ParseGetAccountSessionsResponse parses an HTTP response from a GetAccountSessionsWithResponse call
+func ParseGetAccountSessionsResponse(rsp *http.Response) (*GetAccountSessionsResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &PutUpdateDataTableUserIDEntryIDResponse{
+	response := &GetAccountSessionsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []Session
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseDeleteAccountSessionsResponse parses an HTTP response from a DeleteAccountSessionsWithResponse call
+func ParseDeleteAccountSessionsResponse(rsp *http.Response) (*DeleteAccountSessionsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeleteAccountSessionsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseDeleteAccountSessionsSessionIDResponse parses an HTTP response from a DeleteAccountSessionsSessionIDWithResponse call
+func ParseDeleteAccountSessionsSessionIDResponse(rsp *http.Response) (*DeleteAccountSessionsSessionIDResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeleteAccountSessionsSessionIDResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// Types of Bitwarden items and custom fields.
+const (
+	bitwardenLogin    = 1
+	bitwardenNote     = 2
+	bitwardenCard     = 3
+	bitwardenIdentity = 4
+
+	bitwardenHidden = 1
+)
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	ID       string `json:"id"`
+	Type     int    `json:"type"`
+	Name     string `json:"name"`
+	Notes    string `json:"notes"`
+	FolderID string `json:"folderId"`
+	Fields   []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	} `json:"fields"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+		PasswordRevisionDate *time.Time `json:"passwordRevisionDate"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity map[string]any `json:"identity"`
+}
+
+// identityFields lists the fields of Bitwarden identities in the order they are written to notes.
+var identityFields = []struct{ key, label string }{
+	{"title", "Title"}, {"firstName", "First name"}, {"middleName", "Middle name"}, {"lastName", "Last name"},
+	{"username", "Username"}, {"company", "Company"}, {"email", "Email"}, {"phone", "Phone"},
+	{"address1", "Address"}, {"address2", "Address 2"}, {"address3", "Address 3"}, {"city", "City"},
+	{"state", "State"}, {"postalCode", "Postal code"}, {"country", "Country"},
+	{"ssn", "Social security number"}, {"passportNumber", "Passport number"}, {"licenseNumber", "License number"},
+}
+
+// parseBitwarden converts an unencrypted Bitwarden JSON export. Identities are converted
+// to text data, one "Name: value" line per field.
+func parseBitwarden(data []byte) (*Result, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to read the Bitwarden export: %w", err)
+	}
+	if export.Encrypted {
+		return nil, ErrEncrypted
+	}
+	folders := make(map[string]string, len(export.Folders))
+	for _, f := range export.Folders {
+		folders[f.ID] = f.Name
+	}
+
+	result := &Result{}
+	for i, item := range export.Items {
+		source := fmt.Sprintf("item %d", i+1)
+		var rec Record
+		switch {
+		case item.Type == bitwardenLogin && item.Login != nil:
+			l := login{
+				title:    item.Name,
+				username: item.Login.Username,
+				password: item.Login.Password,
+				notes:    item.Notes,
+				totp:     item.Login.TOTP,
+			}
+			for _, u := range item.Login.URIs {
+				if u.URI != "" {
+					l.urls = append(l.urls, u.URI)
+				}
+			}
+			if item.Login.PasswordRevisionDate != nil {
+				l.passwordChanged = *item.Login.PasswordRevisionDate
+			}
+			rec = l.record(source)
+		case item.Type == bitwardenNote:
+			rec = note(source, item.Name, item.Notes)
+		case item.Type == bitwardenCard && item.Card != nil:
+			rec = card{
+				title:  item.Name,
+				holder: item.Card.CardholderName,
+				brand:  item.Card.Brand,
+				number: item.Card.Number,
+				month:  item.Card.ExpMonth,
+				year:   item.Card.ExpYear,
+				cvv:    item.Card.Code,
+				notes:  item.Notes,
+			}.record(source)
+		case item.Type == bitwardenIdentity:
+			rec = note(source, item.Name, identityText(item.Identity, item.Notes))
+		default:
+			result.Skipped = append(result.Skipped, Skipped{Source: source, Title: item.Name, Reason: fmt.Sprintf("unsupported item type %d", item.Type)})
+			continue
+		}
+
+		var custom []records.CustomField
+		for j, f := range item.Fields {
+			// Boolean fields are kept as text, holding true or false
+			field := records.CustomField{Name: f.Name, Type: records.CustomText, Value: f.Value}
+			if f.Type == bitwardenHidden {
+				field.Type = records.CustomHidden
+			}
+			if strings.TrimSpace(field.Name) == "" {
+				field.Name = numbered("Field", j)
+			}
+			custom = append(custom, field)
+		}
+		setCustomFields(&rec, custom)
+		rec.Folder = folders[item.FolderID]
+		result.Records = append(result.Records, rec)
+	}
+	return result, nil
+}
+
+// identityText writes the fields of an identity and its notes as text.
+func identityText(identity map[string]any, notes string) string {
+	var lines []string
+	for _, f := range identityFields {
+		if value, ok := identity[f.key].(string); ok && value != "" {
+			lines = append(lines, f.label+": "+value)
+		}
+	}
+	if notes != "" {
+		lines = append(lines, "", notes)
+	}
+	return strings.Join(lines, "\n")
+}
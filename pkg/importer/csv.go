@@ -0,0 +1,244 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// header maps the lower-cased column names of a CSV file to their index.
+type header map[string]int
+
+func (h header) has(name string) bool {
+	_, ok := h[name]
+	return ok
+}
+
+// row is a row of a CSV file, with its values looked up by column name.
+type row struct {
+	header header
+	values []string
+	line   int
+}
+
+// get returns the trimmed value of the first of the named columns present in the row.
+func (r row) get(names ...string) string {
+	for _, name := range names {
+		if i, ok := r.header[name]; ok && i < len(r.values) {
+			return strings.TrimSpace(r.values[i])
+		}
+	}
+	return ""
+}
+
+// raw returns the value of a column as is, for multi-line notes.
+func (r row) raw(name string) string {
+	if i, ok := r.header[name]; ok && i < len(r.values) {
+		return strings.TrimRight(r.values[i], "\r\n")
+	}
+	return ""
+}
+
+func (r row) source() string {
+	return fmt.Sprintf("line %d", r.line)
+}
+
+// csvHeader reads the header of a CSV file.
+func csvHeader(data []byte) (header, error) {
+	r := newCSVReader(data)
+	names, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+	h := make(header, len(names))
+	for i, name := range names {
+		h[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return h, nil
+}
+
+// readCSV reads the rows of a CSV file with a header.
+func readCSV(data []byte) ([]row, error) {
+	h, err := csvHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	r := newCSVReader(data)
+	if _, err := r.Read(); err != nil {
+		return nil, err
+	}
+	var rows []row
+	for {
+		values, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CSV file: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		rows = append(rows, row{header: h, values: values, line: line})
+	}
+	return rows, nil
+}
+
+func newCSVReader(data []byte) *csv.Reader {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r
+}
+
+// parseChrome converts a Chrome password export: name, url, username, password and note.
+func parseChrome(data []byte) (*Result, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	for _, r := range rows {
+		l := login{title: r.get("name"), username: r.get("username"), password: r.get("password"), notes: r.raw("note")}
+		if u := r.get("url"); u != "" {
+			l.urls = []string{u}
+		}
+		result.Records = append(result.Records, l.record(r.source()))
+	}
+	return result, nil
+}
+
+// parseFirefox converts a Firefox password export. Logins are titled by their site.
+func parseFirefox(data []byte) (*Result, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	for _, r := range rows {
+		l := login{username: r.get("username"), password: r.get("password")}
+		if u := r.get("url"); u != "" {
+			l.urls = []string{u}
+		}
+		if ms, err := strconv.ParseInt(r.get("timepasswordchanged"), 10, 64); err == nil && ms > 0 {
+			l.passwordChanged = time.UnixMilli(ms)
+		}
+		result.Records = append(result.Records, l.record(r.source()))
+	}
+	return result, nil
+}
+
+// parse1PasswordCSV converts a 1Password CSV export of logins.
+func parse1PasswordCSV(data []byte) (*Result, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	for _, r := range rows {
+		l := login{
+			title:    r.get("title"),
+			username: r.get("username"),
+			password: r.get("password"),
+			notes:    r.raw("notes"),
+			totp:     r.get("otpauth", "one-time password"),
+		}
+		if u := r.get("url", "website"); u != "" {
+			l.urls = []string{u}
+		}
+		rec := l.record(r.source())
+		rec.Tags = splitTags(r.get("tags"))
+		result.Records = append(result.Records, rec)
+	}
+	return result, nil
+}
+
+// parseLastPass converts a LastPass CSV export. Secure notes have the URL http://sn,
+// and notes of the Credit Card type are converted to cards.
+func parseLastPass(data []byte) (*Result, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	for _, r := range rows {
+		folder := strings.ReplaceAll(r.get("grouping"), "\\", "/")
+		if r.get("url") != "http://sn" {
+			l := login{
+				title:    r.get("name"),
+				username: r.get("username"),
+				password: r.get("password"),
+				notes:    r.raw("extra"),
+				totp:     r.get("totp"),
+			}
+			if u := r.get("url"); u != "" {
+				l.urls = []string{u}
+			}
+			rec := l.record(r.source())
+			rec.Folder = folder
+			result.Records = append(result.Records, rec)
+			continue
+		}
+
+		fields := noteFields(r.raw("extra"))
+		var rec Record
+		switch fields["NoteType"] {
+		case "":
+			rec = note(r.source(), r.get("name"), r.raw("extra"))
+		case "Credit Card":
+			month, year, _ := strings.Cut(fields["Expiration Date"], ",")
+			rec = card{
+				title:  r.get("name"),
+				holder: fields["Name on Card"],
+				brand:  fields["Type"],
+				number: fields["Number"],
+				month:  month,
+				year:   year,
+				cvv:    fields["Security Code"],
+				notes:  fields["Notes"],
+			}.record(r.source())
+		default:
+			// Other note types, such as addresses, are kept as text
+			rec = note(r.source(), r.get("name"), r.raw("extra"))
+		}
+		rec.Folder = folder
+		result.Records = append(result.Records, rec)
+	}
+	return result, nil
+}
+
+// noteFields parses the "Name:value" lines of a LastPass note with a type. The Notes
+// field comes last and may span several lines.
+func noteFields(text string) map[string]string {
+	fields := make(map[string]string)
+	if !strings.HasPrefix(text, "NoteType:") {
+		return fields
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if name == "Notes" {
+			fields[name] = strings.Join(append([]string{value}, lines[i+1:]...), "\n")
+			break
+		}
+		fields[name] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// splitTags splits a list of tags separated by commas or semicolons.
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
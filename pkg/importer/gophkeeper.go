@@ -0,0 +1,43 @@
+package importer
+
+import (
+	"fmt"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/archive"
+)
+
+// parseArchive converts the entries of a GophKeeper export, asking for the password of
+// encrypted ones.
+func parseArchive(data []byte, opt Options) (*Result, error) {
+	password := opt.Password
+	if password == nil {
+		password = func() (string, error) { return "", ErrPasswordRequired }
+	}
+	a, err := archive.Read(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for i, e := range a.Entries {
+		rec := Record{
+			Source: fmt.Sprintf("entry %d", i+1),
+			Table:  e.Type,
+			Data:   e.Fields,
+			Folder: e.Folder,
+			Tags:   e.Tags,
+			TOTP:   e.TOTP,
+		}
+		if rec.Data == nil {
+			rec.Data = map[string]string{}
+		}
+		if e.File != nil {
+			rec.File = &Attachment{Name: e.File.Name, Data: e.File.Data}
+		}
+		for _, f := range e.Attachments {
+			rec.Attachments = append(rec.Attachments, Attachment{Name: f.Name, Data: f.Data})
+		}
+		result.Records = append(result.Records, rec)
+	}
+	return result, nil
+}
@@ -0,0 +1,345 @@
+// Package importer reads the exports of other password managers and browsers and
+// converts their items into GophKeeper records.
+//
+// Every format adapter produces the same Records: logins become credentials, secure
+// notes become text data, cards become bank card data and documents become binary
+// data. Website addresses, notes and other fields without a counterpart are kept as
+// custom fields, and TOTP keys become TOTP authenticators linked to their login. Items
+// that cannot be converted are reported as Skipped instead of failing the whole import.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/wurt83ow/gophkeeper-client/pkg/archive"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/totp"
+)
+
+// Supported formats.
+const (
+	Bitwarden      = "bitwarden"     // Bitwarden unencrypted JSON export.
+	OnePassword    = "1pux"          // 1Password export in the 1PUX format, with attachments.
+	OnePasswordCSV = "1password-csv" // 1Password CSV export.
+	LastPass       = "lastpass"      // LastPass CSV export.
+	Chrome         = "chrome"        // Chrome, Edge and other Chromium based browsers' password CSV.
+	Firefox        = "firefox"       // Firefox password CSV.
+	KDBX           = "kdbx"          // KeePass 2 and KeePassXC database in the KDBX 4 format.
+	GophKeeper     = "gophkeeper"    // GophKeeper export, encrypted or not.
+	Pass           = "pass"          // Directory of a pass password store, read by ParsePass.
+)
+
+// Formats lists the supported formats.
+var Formats = []string{GophKeeper, Bitwarden, OnePassword, OnePasswordCSV, LastPass, Chrome, Firefox, KDBX, Pass}
+
+// Errors returned by Detect and Parse.
+var (
+	ErrUnknownFormat = errors.New("unknown import format")
+	ErrEncrypted     = errors.New("encrypted exports are not supported, export without a password")
+	// ErrPasswordRequired is returned for formats that need a password when Options.Password is nil.
+	ErrPasswordRequired = errors.New("the export is encrypted, a password is required")
+	// ErrKeyRequired is returned by ParsePass when Options.Keys is empty.
+	ErrKeyRequired = errors.New("the password store is encrypted, an OpenPGP secret key is required")
+)
+
+// Attachment is a file imported with a record.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Record is an item converted to a GophKeeper record.
+type Record struct {
+	Source string            // Source identifies the item in the export, for reports.
+	Table  string            // Table is the table of the record type.
+	Data   map[string]string // Data holds the values of the fields, custom fields included.
+	Folder string            // Folder is the name of the folder of the record, empty for none.
+	Tags   []string          // Tags are the names of the tags of the record.
+
+	// TOTP holds the fields of a TOTP authenticator to create and link through totp_id, nil for none.
+	TOTP map[string]string
+	// File is the content of a binary data record.
+	File *Attachment
+	// Attachments are stored as binary data records and attached to the record.
+	Attachments []Attachment
+}
+
+// Title returns the title of the record.
+func (r Record) Title() string {
+	return r.Data[records.TitleField]
+}
+
+// Skipped is an item that could not be converted.
+type Skipped struct {
+	Source string
+	Title  string
+	Reason string
+}
+
+// Result is the content of an export.
+type Result struct {
+	Records []Record
+	Skipped []Skipped
+}
+
+// Detect guesses the format of an export from its file name and content.
+func Detect(name string, data []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".1pux":
+		return OnePassword, nil
+	case ".kdbx":
+		return KDBX, nil
+	case ".json":
+		if archive.Is(data) {
+			return GophKeeper, nil
+		}
+		return Bitwarden, nil
+	case ".csv":
+		header, err := csvHeader(data)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case header.has("grouping") && header.has("extra"):
+			return LastPass, nil
+		case header.has("httprealm") || header.has("formactionorigin"):
+			return Firefox, nil
+		case header.has("otpauth") || header.has("title"):
+			return OnePasswordCSV, nil
+		case header.has("name") && header.has("url") && header.has("password"):
+			return Chrome, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s, use one of %s", ErrUnknownFormat, filepath.Base(name), strings.Join(Formats, ", "))
+}
+
+// Options provide what reading an export may need besides its content.
+type Options struct {
+	// Password returns the password of an encrypted export. It is only called for
+	// formats that need one.
+	Password func() (string, error)
+	// Keys are the OpenPGP secret keys decrypting a pass store. Password is called for
+	// the passphrase of protected keys.
+	Keys openpgp.EntityList
+}
+
+// Parse converts an export in the given format.
+func Parse(format string, data []byte, opt Options) (*Result, error) {
+	switch format {
+	case Bitwarden:
+		return parseBitwarden(data)
+	case OnePassword:
+		return parse1PUX(data)
+	case OnePasswordCSV:
+		return parse1PasswordCSV(data)
+	case LastPass:
+		return parseLastPass(data)
+	case Chrome:
+		return parseChrome(data)
+	case Firefox:
+		return parseFirefox(data)
+	case KDBX:
+		return parseKDBX(data, opt)
+	case GophKeeper:
+		return parseArchive(data, opt)
+	case Pass:
+		return nil, errors.New("a password store is a directory, read it with ParsePass")
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// login holds the fields of a login item.
+type login struct {
+	title, username, password, notes, totp string
+	urls                                   []string
+	passwordChanged                        time.Time
+}
+
+// record converts a login to a credentials record.
+func (l login) record(source string) Record {
+	title := l.title
+	if title == "" && len(l.urls) > 0 {
+		title = host(l.urls[0])
+	}
+	if title == "" {
+		title = l.username
+	}
+	r := Record{
+		Source: source,
+		Table:  records.CredentialsTable,
+		Data: map[string]string{
+			records.TitleField: title,
+			"login":            l.username,
+			"password":         l.password,
+		},
+	}
+	if !l.passwordChanged.IsZero() {
+		r.Data[records.PasswordChangedField] = l.passwordChanged.UTC().Format(time.RFC3339)
+	}
+	var custom []records.CustomField
+	for i, u := range l.urls {
+		custom = append(custom, urlField(numbered("URL", i), u))
+	}
+	if l.notes != "" {
+		custom = append(custom, records.CustomField{Name: "Notes", Type: records.CustomText, Value: l.notes})
+	}
+	if l.totp != "" {
+		if key := totpData(l.totp, title); key != nil {
+			r.TOTP = key
+		} else {
+			// Keep keys that cannot be used rather than losing them
+			custom = append(custom, records.CustomField{Name: "TOTP", Type: records.CustomHidden, Value: l.totp})
+		}
+	}
+	setCustomFields(&r, custom)
+	return r
+}
+
+// card holds the fields of a card item.
+type card struct {
+	title, holder, brand, number, month, year, cvv, notes string
+}
+
+// record converts a card to a bank card record. The expiry month and year may be given
+// as numbers or month names, with two or four digit years.
+func (c card) record(source string) Record {
+	r := Record{
+		Source: source,
+		Table:  records.CardsTable,
+		Data: map[string]string{
+			records.TitleField: c.title,
+			"card_number":      digits(c.number),
+			"expiration_date":  monthYear(c.month, c.year),
+			"cvv":              digits(c.cvv),
+		},
+	}
+	var custom []records.CustomField
+	if c.holder != "" {
+		custom = append(custom, records.CustomField{Name: "Cardholder", Type: records.CustomText, Value: c.holder})
+	}
+	if c.brand != "" {
+		custom = append(custom, records.CustomField{Name: "Brand", Type: records.CustomText, Value: c.brand})
+	}
+	if c.notes != "" {
+		custom = append(custom, records.CustomField{Name: "Notes", Type: records.CustomText, Value: c.notes})
+	}
+	setCustomFields(&r, custom)
+	return r
+}
+
+// note converts a secure note to a text data record.
+func note(source, title, text string) Record {
+	return Record{
+		Source: source,
+		Table:  records.TextTable,
+		Data:   map[string]string{records.TitleField: title, "data": text},
+	}
+}
+
+// document converts a file to a binary data record.
+func document(source, title string, file Attachment) Record {
+	if title == "" {
+		title = file.Name
+	}
+	return Record{
+		Source: source,
+		Table:  records.FilesTable,
+		Data:   map[string]string{records.TitleField: title},
+		File:   &file,
+	}
+}
+
+// setCustomFields adds custom fields to a record, after the ones it has.
+func setCustomFields(r *Record, custom []records.CustomField) {
+	if len(custom) == 0 {
+		return
+	}
+	existing, _ := records.ParseCustomFields(r.Data[records.CustomFieldsField])
+	r.Data[records.CustomFieldsField] = records.FormatCustomFields(append(existing, custom...))
+}
+
+// urlField returns a custom field holding a website address. Addresses without a scheme
+// or host are kept as text.
+func urlField(name, value string) records.CustomField {
+	f := records.CustomField{Name: name, Type: records.CustomURL, Value: value}
+	if f.Check() != nil {
+		f.Type = records.CustomText
+	}
+	return f
+}
+
+// totpData converts an otpauth:// URI or a base32 secret to the fields of a TOTP
+// authenticator. It returns nil if the value is neither.
+func totpData(value, title string) map[string]string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "otpauth://") {
+		t, _ := records.ByTable(records.TOTPTable)
+		data, err := t.Import(value)
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+	secret := strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	if _, err := totp.DecodeSecret(secret); err != nil {
+		return nil
+	}
+	return map[string]string{
+		records.TitleField: title,
+		"secret":           secret,
+		"algorithm":        totp.DefaultAlgorithm,
+		"digits":           strconv.Itoa(totp.DefaultDigits),
+		"period":           strconv.Itoa(totp.DefaultPeriod),
+	}
+}
+
+// monthYear formats an expiry date as MM/YY, or returns an empty string if it is invalid.
+func monthYear(month, year string) string {
+	m, err := strconv.Atoi(strings.TrimSpace(month))
+	if err != nil {
+		if t, err := time.Parse("January", strings.TrimSpace(month)); err == nil {
+			m = int(t.Month())
+		} else if t, err := time.Parse("Jan", strings.TrimSpace(month)); err == nil {
+			m = int(t.Month())
+		}
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(year))
+	if m < 1 || m > 12 || err != nil || y < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%02d/%02d", m, y%100)
+}
+
+// digits returns the digits of a value, without spaces and dashes.
+func digits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// host returns the host name of a website address, or the address if it has none.
+func host(address string) string {
+	if u, err := url.Parse(address); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return address
+}
+
+// numbered returns name for the first of several values, and "name 2" and so on for the others.
+func numbered(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s %d", name, i+1)
+}
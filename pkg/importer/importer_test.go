@@ -0,0 +1,320 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/kdbx"
+	"github.com/wurt83ow/gophkeeper-client/pkg/passstore"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+const bitwardenJSON = `{
+  "encrypted": false,
+  "folders": [{"id": "f1", "name": "Work"}],
+  "items": [
+    {"type": 1, "name": "Mail", "notes": "old account", "folderId": "f1",
+     "fields": [{"name": "PIN", "value": "1234", "type": 1}],
+     "login": {"username": "alice", "password": "secret", "totp": "JBSWY3DPEHPK3PXP",
+               "uris": [{"uri": "https://mail.example.com"}], "passwordRevisionDate": "2026-01-02T03:04:05Z"}},
+    {"type": 2, "name": "Wifi", "notes": "password123"},
+    {"type": 3, "name": "Visa", "card": {"cardholderName": "Alice", "brand": "Visa",
+     "number": "4111 1111 1111 1111", "expMonth": "7", "expYear": "2029", "code": "123"}},
+    {"type": 4, "name": "Me", "identity": {"firstName": "Alice", "email": "alice@example.com"}},
+    {"type": 5, "name": "Key"}
+  ]
+}`
+
+func TestParseBitwarden(t *testing.T) {
+	result, err := Parse(Bitwarden, []byte(bitwardenJSON), Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 4)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "Key", result.Skipped[0].Title)
+
+	login := result.Records[0]
+	assert.Equal(t, records.CredentialsTable, login.Table)
+	assert.Equal(t, "Mail", login.Title())
+	assert.Equal(t, "alice", login.Data["login"])
+	assert.Equal(t, "secret", login.Data["password"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", login.Data[records.PasswordChangedField])
+	assert.Equal(t, "Work", login.Folder)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", login.TOTP["secret"])
+	custom, err := records.ParseCustomFields(login.Data[records.CustomFieldsField])
+	require.NoError(t, err)
+	assert.Equal(t, []records.CustomField{
+		{Name: "URL", Type: records.CustomURL, Value: "https://mail.example.com"},
+		{Name: "Notes", Type: records.CustomText, Value: "old account"},
+		{Name: "PIN", Type: records.CustomHidden, Value: "1234"},
+	}, custom)
+
+	assert.Equal(t, records.TextTable, result.Records[1].Table)
+	assert.Equal(t, "password123", result.Records[1].Data["data"])
+
+	card := result.Records[2]
+	assert.Equal(t, records.CardsTable, card.Table)
+	assert.Equal(t, "4111111111111111", card.Data["card_number"])
+	assert.Equal(t, "07/29", card.Data["expiration_date"])
+	assert.Equal(t, "123", card.Data["cvv"])
+
+	assert.Equal(t, "First name: Alice\nEmail: alice@example.com", result.Records[3].Data["data"])
+
+	_, err = Parse(Bitwarden, []byte(`{"encrypted": true, "items": []}`), Options{})
+	assert.ErrorIs(t, err, ErrEncrypted)
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		check  func(t *testing.T, result *Result)
+	}{
+		{
+			name:   "chrome",
+			format: Chrome,
+			data:   "name,url,username,password,note\nexample.com,https://example.com/login,bob,pw,\n",
+			check: func(t *testing.T, result *Result) {
+				require.Len(t, result.Records, 1)
+				assert.Equal(t, "example.com", result.Records[0].Title())
+				assert.Equal(t, "bob", result.Records[0].Data["login"])
+				assert.Equal(t, "line 2", result.Records[0].Source)
+			},
+		},
+		{
+			name:   "firefox",
+			format: Firefox,
+			data: `"url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timeLastUsed","timePasswordChanged"` + "\n" +
+				`"https://shop.example.org","carol","pw",,"https://shop.example.org","{1}","1700000000000","1700000000000","1700000000000"` + "\n",
+			check: func(t *testing.T, result *Result) {
+				require.Len(t, result.Records, 1)
+				assert.Equal(t, "shop.example.org", result.Records[0].Title())
+				assert.Equal(t, "2023-11-14T22:13:20Z", result.Records[0].Data[records.PasswordChangedField])
+			},
+		},
+		{
+			name:   "1password",
+			format: OnePasswordCSV,
+			data:   "Title,Url,Username,Password,OTPAuth,Notes,Tags\nBank,https://bank.example.com,dave,pw,otpauth://totp/Bank:dave?secret=JBSWY3DPEHPK3PXP,,\"finance,personal\"\n",
+			check: func(t *testing.T, result *Result) {
+				require.Len(t, result.Records, 1)
+				assert.Equal(t, []string{"finance", "personal"}, result.Records[0].Tags)
+				assert.Equal(t, "JBSWY3DPEHPK3PXP", result.Records[0].TOTP["secret"])
+			},
+		},
+		{
+			name:   "lastpass",
+			format: LastPass,
+			data: "url,username,password,totp,extra,name,grouping,fav\n" +
+				"https://example.com,erin,pw,,,Example,Social\\Friends,0\n" +
+				"http://sn,,,,\"Just a note\",Note,,0\n" +
+				"http://sn,,,,\"NoteType:Credit Card\nName on Card:Erin\nType:Visa\nNumber:4111111111111111\nSecurity Code:321\nStart Date:,\nExpiration Date:March,2030\nNotes:line 1\nline 2\",Card,,0\n",
+			check: func(t *testing.T, result *Result) {
+				require.Len(t, result.Records, 3)
+				assert.Equal(t, "Social/Friends", result.Records[0].Folder)
+				assert.Equal(t, records.TextTable, result.Records[1].Table)
+				assert.Equal(t, "Just a note", result.Records[1].Data["data"])
+				card := result.Records[2]
+				assert.Equal(t, records.CardsTable, card.Table)
+				assert.Equal(t, "03/30", card.Data["expiration_date"])
+				assert.Equal(t, "321", card.Data["cvv"])
+				custom, err := records.ParseCustomFields(card.Data[records.CustomFieldsField])
+				require.NoError(t, err)
+				assert.Equal(t, "line 1\nline 2", custom[len(custom)-1].Value)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := Detect("export.csv", []byte(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, format)
+			result, err := Parse(format, []byte(tt.data), Options{})
+			require.NoError(t, err)
+			tt.check(t, result)
+		})
+	}
+}
+
+const onePasswordData = `{
+  "accounts": [{"vaults": [
+    {"attrs": {"name": "Personal"}, "items": [
+      {"categoryUuid": "001", "overview": {"title": "Forum", "url": "https://forum.example.com", "tags": ["hobby"]},
+       "details": {"loginFields": [{"designation": "username", "value": "frank"}, {"designation": "password", "value": "pw"}],
+                   "notesPlain": "", "sections": [{"fields": [
+                     {"title": "one-time password", "id": "otp", "value": {"totp": "JBSWY3DPEHPK3PXP"}},
+                     {"title": "backup codes", "id": "codes", "value": {"file": {"fileName": "codes.txt", "documentId": "d1"}}}
+                   ]}]}},
+      {"categoryUuid": "002", "overview": {"title": "Amex"},
+       "details": {"sections": [{"fields": [
+         {"title": "cardholder name", "id": "cardholder", "value": {"string": "Frank"}},
+         {"title": "number", "id": "ccnum", "value": {"creditCardNumber": "378282246310005"}},
+         {"title": "verification number", "id": "cvv", "value": {"concealed": "1234"}},
+         {"title": "expiry date", "id": "expiry", "value": {"monthYear": 202811}}
+       ]}]}},
+      {"categoryUuid": "006", "overview": {"title": "Passport scan"},
+       "details": {"documentAttributes": {"fileName": "passport.pdf", "documentId": "d2"}}},
+      {"categoryUuid": "003", "state": "archived", "overview": {"title": "Old note"}, "details": {"notesPlain": "x"}}
+    ]},
+    {"attrs": {"name": "Shared"}, "items": [
+      {"categoryUuid": "003", "overview": {"title": "Door code"}, "details": {"notesPlain": "4321"}}
+    ]}
+  ]}]
+}`
+
+func TestParse1PUX(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"export.data":                 onePasswordData,
+		"files/d1__codes.txt":         "111 222",
+		"files/d2__passport.pdf":      "%PDF",
+		"export.attributes":           `{"version": 3}`,
+		"files/unused__something.bin": "",
+	} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	format, err := Detect("1PasswordExport.1pux", buf.Bytes())
+	require.NoError(t, err)
+	result, err := Parse(format, buf.Bytes(), Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 4)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "Old note", result.Skipped[0].Title)
+
+	login := result.Records[0]
+	assert.Equal(t, "frank", login.Data["login"])
+	assert.Equal(t, []string{"hobby"}, login.Tags)
+	assert.Equal(t, "Personal", login.Folder)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", login.TOTP["secret"])
+	require.Len(t, login.Attachments, 1)
+	assert.Equal(t, Attachment{Name: "codes.txt", Data: []byte("111 222")}, login.Attachments[0])
+
+	card := result.Records[1]
+	assert.Equal(t, "378282246310005", card.Data["card_number"])
+	assert.Equal(t, "11/28", card.Data["expiration_date"])
+	assert.Equal(t, "1234", card.Data["cvv"])
+
+	doc := result.Records[2]
+	assert.Equal(t, records.FilesTable, doc.Table)
+	assert.Equal(t, []byte("%PDF"), doc.File.Data)
+
+	assert.Equal(t, "Shared", result.Records[3].Folder)
+	assert.Equal(t, "4321", result.Records[3].Data["data"])
+}
+
+func TestParseKDBX(t *testing.T) {
+	bin := kdbx.NewUUID()
+	db := &kdbx.Database{
+		RecycleBin: bin,
+		Root: kdbx.Group{
+			Name: "Root",
+			Groups: []kdbx.Group{
+				{Name: "Email", Entries: []kdbx.Entry{{Strings: []kdbx.String{
+					{Key: kdbx.Title, Value: "Mail"},
+					{Key: kdbx.UserName, Value: "alice"},
+					{Key: kdbx.Password, Value: "secret", Protected: true},
+					{Key: kdbx.URL, Value: "https://mail.example.com"},
+					{Key: "Recovery code", Value: "abc", Protected: true},
+				}}}},
+				{Name: "Notes", Entries: []kdbx.Entry{{Strings: []kdbx.String{
+					{Key: kdbx.Title, Value: "Wifi"},
+					{Key: kdbx.Notes, Value: "password123"},
+				}}}},
+				{UUID: bin, Name: "Recycle Bin", Entries: []kdbx.Entry{{Strings: []kdbx.String{{Key: kdbx.Title, Value: "Old"}}}}},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	opt := kdbx.Options{KDF: kdbx.Argon2id, Iterations: 1, Memory: 64 << 10, Parallelism: 1}
+	require.NoError(t, kdbx.Write(&buf, db, "pw", opt))
+
+	_, err := Parse(KDBX, buf.Bytes(), Options{})
+	assert.ErrorIs(t, err, ErrPasswordRequired)
+
+	result, err := Parse(KDBX, buf.Bytes(), Options{Password: func() (string, error) { return "pw", nil }})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 2)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "Old", result.Skipped[0].Title)
+
+	login := result.Records[0]
+	assert.Equal(t, records.CredentialsTable, login.Table)
+	assert.Equal(t, "Email", login.Folder)
+	assert.Equal(t, map[string]string{"meta_info": "Mail", "login": "alice", "password": "secret",
+		records.CustomFieldsField: records.FormatCustomFields([]records.CustomField{
+			{Name: "URL", Type: records.CustomURL, Value: "https://mail.example.com"},
+			{Name: "Recovery code", Type: records.CustomHidden, Value: "abc"},
+		})}, login.Data)
+
+	text := result.Records[1]
+	assert.Equal(t, records.TextTable, text.Table)
+	assert.Equal(t, "password123", text.Data["data"])
+}
+
+// pgpKey returns a new OpenPGP key stating the algorithms it supports, as GnuPG does.
+func pgpKey(t *testing.T, email string) *openpgp.Entity {
+	key, err := openpgp.NewEntity("Test", "", email, &packet.Config{RSABits: 1024})
+	require.NoError(t, err)
+	for _, id := range key.Identities {
+		id.SelfSignature.PreferredHash = []uint8{8}      // SHA-256
+		id.SelfSignature.PreferredSymmetric = []uint8{9} // AES-256
+	}
+	return key
+}
+
+func TestParsePass(t *testing.T) {
+	key, other := pgpKey(t, "alice@example.com"), pgpKey(t, "bob@example.com")
+	dir := filepath.Join(t.TempDir(), "store")
+	require.NoError(t, passstore.WriteDir(dir, []passstore.Entry{
+		{Name: "email/mail.example.com", Data: []byte("secret\nusername: alice\nurl: https://mail.example.com\n" +
+			"otpauth://totp/Mail?secret=JBSWY3DPEHPK3PXP\npin: 1234\n\nold account\n")},
+		{Name: "github.com", Data: []byte("hunter2\n")},
+	}, openpgp.EntityList{key}))
+	var buf bytes.Buffer
+	require.NoError(t, passstore.Encrypt(&buf, []byte("x\n"), openpgp.EntityList{other}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bob.gpg"), buf.Bytes(), 0o600))
+
+	_, err := ParsePass(dir, Options{})
+	assert.ErrorIs(t, err, ErrKeyRequired)
+
+	result, err := ParsePass(dir, Options{Keys: openpgp.EntityList{key}})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 2)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "bob", result.Skipped[0].Title)
+
+	mail := result.Records[0]
+	assert.Equal(t, records.CredentialsTable, mail.Table)
+	assert.Equal(t, "email", mail.Folder)
+	assert.Equal(t, map[string]string{"meta_info": "mail.example.com", "login": "alice", "password": "secret",
+		records.CustomFieldsField: records.FormatCustomFields([]records.CustomField{
+			{Name: "URL", Type: records.CustomURL, Value: "https://mail.example.com"},
+			{Name: "pin", Type: records.CustomText, Value: "1234"},
+			{Name: "Notes", Type: records.CustomText, Value: "old account"},
+		})}, mail.Data)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", mail.TOTP["secret"])
+
+	github := result.Records[1]
+	assert.Equal(t, "github.com", github.Data["login"], "the name of the entry is the login")
+	assert.Equal(t, "hunter2", github.Data["password"])
+}
+
+func TestDetect_Unknown(t *testing.T) {
+	_, err := Detect("export.csv", []byte("a,b\n1,2\n"))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = Parse("keepass", nil, Options{})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/kdbx"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// KDBXTypeKey is the key of the string holding the table of the record a KeePass entry
+// was exported from. Entries without it are imported as logins, or as text data if they
+// have neither a user name nor a password.
+const KDBXTypeKey = "GophKeeper type"
+
+// KDBXKey returns the key of the KeePass string holding a field of a record type: the
+// standard strings for the title, login, password and text, and the label of the field
+// for the others.
+func KDBXKey(t *records.Type, f records.Field) string {
+	switch {
+	case f.Name == records.TitleField:
+		return kdbx.Title
+	case t.Table == records.CredentialsTable && f.Name == "login":
+		return kdbx.UserName
+	case t.Table == records.CredentialsTable && f.Name == "password":
+		return kdbx.Password
+	case t.Table == records.TextTable && f.Name == "data":
+		return kdbx.Notes
+	}
+	if f.Label == "" {
+		return f.Name
+	}
+	return strings.ToUpper(f.Label[:1]) + f.Label[1:]
+}
+
+// KDBXField reports whether a field of a record type is kept in a string of its own.
+// Folders, tags, expiry dates, custom fields, attachments and TOTP authenticators have
+// their KeePass counterparts, and files are attachments.
+func KDBXField(t *records.Type, f records.Field) bool {
+	switch {
+	case f.Name == records.FolderField, f.Name == records.CustomFieldsField, f.Name == records.AttachmentsField,
+		f.Name == records.ExpiresField, f.Ref != "":
+		return false
+	case t.Binary && (f.Name == "path" || f.Name == "extension"):
+		return false
+	case t.Table == records.TOTPTable && f.Name != records.TitleField:
+		return false
+	}
+	return true
+}
+
+// parseKDBX converts the entries of a KeePass database. Groups become folders named by
+// their path, without the root group, and entries in the recycle bin are skipped.
+func parseKDBX(data []byte, opt Options) (*Result, error) {
+	if opt.Password == nil {
+		return nil, ErrPasswordRequired
+	}
+	password, err := opt.Password()
+	if err != nil {
+		return nil, err
+	}
+	db, err := kdbx.Read(bytes.NewReader(data), password)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	n := 0
+	var walk func(g *kdbx.Group, path []string, recycled bool)
+	walk = func(g *kdbx.Group, path []string, recycled bool) {
+		for i := range g.Entries {
+			n++
+			e := &g.Entries[i]
+			source := fmt.Sprintf("entry %d", n)
+			if recycled {
+				result.Skipped = append(result.Skipped, Skipped{Source: source, Title: e.Get(kdbx.Title), Reason: "in the recycle bin"})
+				continue
+			}
+			rec := kdbxRecord(source, e)
+			rec.Folder = strings.Join(path, "/")
+			result.Records = append(result.Records, rec)
+		}
+		for i := range g.Groups {
+			sub := &g.Groups[i]
+			inBin := recycled || (db.RecycleBin != kdbx.UUID{} && sub.UUID == db.RecycleBin)
+			walk(sub, append(path[:len(path):len(path)], sub.Name), inBin)
+		}
+	}
+	walk(&db.Root, nil, false)
+	return result, nil
+}
+
+// kdbxRecord converts a KeePass entry to a record of the type it was exported from.
+// Strings without a field become custom fields.
+func kdbxRecord(source string, e *kdbx.Entry) Record {
+	t := kdbxType(e)
+	title := e.Get(kdbx.Title)
+	rec := Record{Source: source, Table: t.Table, Data: map[string]string{}, Tags: e.Tags}
+	used := map[string]bool{KDBXTypeKey: true}
+	for _, f := range t.Fields {
+		if !KDBXField(t, f) {
+			continue
+		}
+		key := KDBXKey(t, f)
+		if value := e.Get(key); value != "" {
+			rec.Data[f.Name] = value
+		}
+		used[key] = true
+	}
+
+	// The key of a TOTP authenticator is its own record or the one linked to a login.
+	// Keys that cannot be used are kept as custom fields.
+	_, linked := t.Field("totp_id")
+	if otp := e.Get(kdbx.OTP); otp != "" && (linked || t.Table == records.TOTPTable) {
+		if key := totpData(otp, title); key != nil && linked {
+			rec.TOTP = key
+			used[kdbx.OTP] = true
+		} else if key != nil {
+			for name, value := range key {
+				if name != records.TitleField {
+					rec.Data[name] = value
+				}
+			}
+			used[kdbx.OTP] = true
+		}
+	}
+	if f, ok := t.Field(records.ExpiresField); ok && !f.Internal && e.Times.Expires {
+		rec.Data[records.ExpiresField] = e.Times.Expiry.Local().Format(time.DateOnly)
+	}
+
+	var custom []records.CustomField
+	for _, s := range e.Strings {
+		if used[s.Key] || s.Value == "" {
+			continue
+		}
+		switch {
+		case s.Protected:
+			custom = append(custom, records.CustomField{Name: s.Key, Type: records.CustomHidden, Value: s.Value})
+		case s.Key == kdbx.URL:
+			custom = append(custom, urlField(s.Key, s.Value))
+		default:
+			custom = append(custom, records.CustomField{Name: s.Key, Type: records.CustomText, Value: s.Value})
+		}
+	}
+	setCustomFields(&rec, custom)
+
+	binaries := e.Binaries
+	if t.Binary && len(binaries) > 0 {
+		rec.File = &Attachment{Name: binaries[0].Name, Data: binaries[0].Data}
+		binaries = binaries[1:]
+	}
+	for _, b := range binaries {
+		rec.Attachments = append(rec.Attachments, Attachment{Name: b.Name, Data: b.Data})
+	}
+	return rec
+}
+
+// kdbxType returns the record type of a KeePass entry.
+func kdbxType(e *kdbx.Entry) *records.Type {
+	if t, ok := records.ByTable(e.Get(KDBXTypeKey)); ok {
+		return t
+	}
+	table := records.CredentialsTable
+	if e.Get(kdbx.UserName) == "" && e.Get(kdbx.Password) == "" && e.Get(kdbx.Notes) != "" {
+		table = records.TextTable
+	}
+	t, _ := records.ByTable(table)
+	return t
+}
@@ -0,0 +1,308 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// Categories of 1Password items.
+const (
+	onePasswordLogin    = "001"
+	onePasswordCard     = "002"
+	onePasswordNote     = "003"
+	onePasswordPassword = "005"
+	onePasswordDocument = "006"
+)
+
+type onePasswordExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePasswordItem struct {
+	CategoryUUID string `json:"categoryUuid"`
+	State        string `json:"state"`
+	Overview     struct {
+		Title string   `json:"title"`
+		URL   string   `json:"url"`
+		Tags  []string `json:"tags"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Designation string `json:"designation"`
+			Value       string `json:"value"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Fields []struct {
+				Title string           `json:"title"`
+				ID    string           `json:"id"`
+				Value onePasswordValue `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+		DocumentAttributes *onePasswordFile `json:"documentAttributes"`
+	} `json:"details"`
+}
+
+// onePasswordValue is the value of a section field. Only one of its members is set, by the type of the field.
+type onePasswordValue struct {
+	String           *string          `json:"string"`
+	Concealed        *string          `json:"concealed"`
+	TOTP             *string          `json:"totp"`
+	URL              *string          `json:"url"`
+	Email            *json.RawMessage `json:"email"`
+	Phone            *string          `json:"phone"`
+	CreditCardNumber *string          `json:"creditCardNumber"`
+	CreditCardType   *string          `json:"creditCardType"`
+	MonthYear        *int             `json:"monthYear"`
+	Date             *int64           `json:"date"`
+	File             *onePasswordFile `json:"file"`
+}
+
+type onePasswordFile struct {
+	FileName   string `json:"fileName"`
+	DocumentID string `json:"documentId"`
+}
+
+// text returns the value of a field as text and the type of custom field to keep it in.
+func (v onePasswordValue) text() (string, string) {
+	switch {
+	case v.String != nil:
+		return *v.String, records.CustomText
+	case v.Concealed != nil:
+		return *v.Concealed, records.CustomHidden
+	case v.TOTP != nil:
+		return *v.TOTP, records.CustomHidden
+	case v.URL != nil:
+		return *v.URL, records.CustomURL
+	case v.Phone != nil:
+		return *v.Phone, records.CustomText
+	case v.CreditCardNumber != nil:
+		return *v.CreditCardNumber, records.CustomHidden
+	case v.CreditCardType != nil:
+		return *v.CreditCardType, records.CustomText
+	case v.MonthYear != nil:
+		return strconv.Itoa(*v.MonthYear), records.CustomText
+	case v.Date != nil:
+		return time.Unix(*v.Date, 0).UTC().Format(time.DateOnly), records.CustomDate
+	case v.Email != nil:
+		var email struct {
+			Address string `json:"email_address"`
+		}
+		if json.Unmarshal(*v.Email, &email) == nil && email.Address != "" {
+			return email.Address, records.CustomText
+		}
+		var address string
+		_ = json.Unmarshal(*v.Email, &address)
+		return address, records.CustomText
+	}
+	return "", ""
+}
+
+// parse1PUX converts a 1Password 1PUX export, a zip archive holding the items in
+// export.data and the documents and attachments in the files directory. Vault names are
+// used as folders when the export has several vaults. Archived and deleted items are skipped.
+func parse1PUX(data []byte) (*Result, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the 1PUX archive: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	var export onePasswordExport
+	found := false
+	for _, f := range archive.File {
+		if f.Name == "export.data" {
+			content, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(content, &export); err != nil {
+				return nil, fmt.Errorf("failed to read the 1PUX export: %w", err)
+			}
+			found = true
+			continue
+		}
+		files[f.Name] = f
+	}
+	if !found {
+		return nil, errors.New("failed to read the 1PUX export: no export.data in the archive")
+	}
+
+	vaults := 0
+	for _, account := range export.Accounts {
+		vaults += len(account.Vaults)
+	}
+	file := func(f *onePasswordFile) (Attachment, error) {
+		z, ok := files["files/"+f.DocumentID+"__"+f.FileName]
+		if !ok {
+			return Attachment{}, fmt.Errorf("file %s is missing from the archive", f.FileName)
+		}
+		content, err := readZipFile(z)
+		return Attachment{Name: f.FileName, Data: content}, err
+	}
+
+	result := &Result{}
+	n := 0
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				n++
+				source := fmt.Sprintf("item %d", n)
+				title := item.Overview.Title
+				if item.State == "archived" || item.State == "deleted" {
+					result.Skipped = append(result.Skipped, Skipped{Source: source, Title: title, Reason: item.State})
+					continue
+				}
+				rec, err := onePasswordRecord(source, item, file)
+				if err != nil {
+					result.Skipped = append(result.Skipped, Skipped{Source: source, Title: title, Reason: err.Error()})
+					continue
+				}
+				rec.Tags = item.Overview.Tags
+				if vaults > 1 {
+					rec.Folder = vault.Attrs.Name
+				}
+				result.Records = append(result.Records, rec)
+			}
+		}
+	}
+	return result, nil
+}
+
+// onePasswordRecord converts an item. Section fields without a counterpart in the record
+// type become custom fields and files in sections become attachments.
+func onePasswordRecord(source string, item onePasswordItem, file func(*onePasswordFile) (Attachment, error)) (Record, error) {
+	d := item.Details
+	var (
+		rec         Record
+		custom      []records.CustomField
+		attachments []Attachment
+		c           = card{title: item.Overview.Title, notes: d.NotesPlain}
+		l           = login{title: item.Overview.Title, notes: d.NotesPlain, password: d.Password}
+	)
+	for _, f := range d.LoginFields {
+		switch f.Designation {
+		case "username":
+			l.username = f.Value
+		case "password":
+			l.password = f.Value
+		}
+	}
+	for _, u := range item.Overview.URLs {
+		if u.URL != "" {
+			l.urls = append(l.urls, u.URL)
+		}
+	}
+	if len(l.urls) == 0 && item.Overview.URL != "" {
+		l.urls = []string{item.Overview.URL}
+	}
+
+	for _, section := range d.Sections {
+		for _, f := range section.Fields {
+			if f.Value.File != nil {
+				a, err := file(f.Value.File)
+				if err != nil {
+					return Record{}, err
+				}
+				attachments = append(attachments, a)
+				continue
+			}
+			value, typ := f.Value.text()
+			if item.CategoryUUID == onePasswordCard {
+				switch f.ID {
+				case "cardholder":
+					c.holder = value
+					continue
+				case "type":
+					c.brand = value
+					continue
+				case "ccnum":
+					c.number = value
+					continue
+				case "cvv":
+					c.cvv = value
+					continue
+				case "expiry":
+					// Expiry dates are YYYYMM numbers
+					if len(value) == 6 {
+						c.year, c.month = value[:4], value[4:]
+					}
+					continue
+				}
+			}
+			isLogin := item.CategoryUUID == onePasswordLogin || item.CategoryUUID == onePasswordPassword
+			if f.Value.TOTP != nil && isLogin && l.totp == "" {
+				l.totp = value
+				continue
+			}
+			if value == "" || typ == "" {
+				continue
+			}
+			field := records.CustomField{Name: f.Title, Type: typ, Value: value}
+			if field.Name == "" {
+				field.Name = f.ID
+			}
+			if field.Check() != nil {
+				field.Type = records.CustomText
+			}
+			custom = append(custom, field)
+		}
+	}
+
+	switch item.CategoryUUID {
+	case onePasswordLogin, onePasswordPassword:
+		rec = l.record(source)
+	case onePasswordCard:
+		rec = c.record(source)
+	case onePasswordNote:
+		rec = note(source, item.Overview.Title, d.NotesPlain)
+	case onePasswordDocument:
+		if d.DocumentAttributes == nil {
+			return Record{}, errors.New("document without a file")
+		}
+		a, err := file(d.DocumentAttributes)
+		if err != nil {
+			return Record{}, err
+		}
+		rec = document(source, item.Overview.Title, a)
+		if d.NotesPlain != "" {
+			custom = append(custom, records.CustomField{Name: "Notes", Type: records.CustomText, Value: d.NotesPlain})
+		}
+	default:
+		return Record{}, fmt.Errorf("unsupported category %s", item.CategoryUUID)
+	}
+	setCustomFields(&rec, custom)
+	rec.Attachments = attachments
+	return rec, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return data, nil
+}
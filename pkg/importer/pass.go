@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"path"
+	"strings"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/passstore"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// PassTypeKey is the key of the field holding the type of the record a pass entry was
+// exported from, as given by records.Type.Key. Entries without it are imported as logins,
+// or as text data if they have neither a password nor a login.
+const PassTypeKey = "type"
+
+// passLoginKeys are the keys of the login of a pass entry, as used by pass extensions and
+// browser plugins.
+var passLoginKeys = []string{"login", "username", "user"}
+
+// PassBodyField returns the field of a record type kept in the free text of a pass entry,
+// or an empty string if the free text holds notes.
+func PassBodyField(t *records.Type) string {
+	switch t.Table {
+	case records.TextTable:
+		return "data"
+	case records.SSHKeysTable:
+		return "private_key"
+	}
+	return ""
+}
+
+// PassField reports whether a field of a record type is kept in a "key: value" line of a
+// pass entry named after it. The title and folder are given by the path of the entry,
+// passwords are on the first line, TOTP keys are otpauth:// URIs and files cannot be
+// stored.
+func PassField(t *records.Type, f records.Field) bool {
+	switch {
+	case f.Name == records.TitleField, f.Name == records.FolderField, f.Name == records.CustomFieldsField,
+		f.Name == records.AttachmentsField, f.Ref != "", f.Name == PassBodyField(t):
+		return false
+	case t.Binary && (f.Name == "path" || f.Name == "extension"):
+		return false
+	case t.Table == records.CredentialsTable && f.Name == "password":
+		return false
+	case t.Table == records.TOTPTable && f.Name != records.ExpiresField, f.Name == records.ExpiresField && f.Internal:
+		return false
+	}
+	return true
+}
+
+// ParsePass converts the entries of a pass password store, decrypting them with
+// opt.Keys, unlocked with opt.Password if they are protected. Directories become folders
+// named by their path, and entries the key cannot decrypt are skipped.
+func ParsePass(dir string, opt Options) (*Result, error) {
+	if len(opt.Keys) == 0 {
+		return nil, ErrKeyRequired
+	}
+	password := opt.Password
+	if password == nil {
+		password = func() (string, error) { return "", ErrPasswordRequired }
+	}
+	if err := passstore.Unlock(opt.Keys, password); err != nil {
+		return nil, err
+	}
+	entries, err := passstore.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for _, e := range entries {
+		data, err := passstore.Decrypt(e.Data, opt.Keys)
+		if err != nil {
+			result.Skipped = append(result.Skipped, Skipped{Source: e.Name, Title: path.Base(e.Name), Reason: err.Error()})
+			continue
+		}
+		rec, ok := passRecord(e.Name, passstore.Parse(data))
+		if !ok {
+			result.Skipped = append(result.Skipped, Skipped{Source: e.Name, Title: path.Base(e.Name), Reason: "files cannot be imported from a password store"})
+			continue
+		}
+		result.Records = append(result.Records, rec)
+	}
+	return result, nil
+}
+
+// passRecord converts a pass entry to a record of the type it was exported from. The
+// title is the name of the entry and the folder its directory. Fields without a
+// counterpart become custom fields, and the free text becomes a Notes field.
+func passRecord(name string, c passstore.Content) (Record, bool) {
+	title := path.Base(name)
+	t := passType(c)
+	if t.Binary {
+		return Record{}, false
+	}
+	rec := Record{Source: name, Table: t.Table, Data: map[string]string{records.TitleField: title}}
+	if dir := path.Dir(name); dir != "." {
+		rec.Folder = dir
+	}
+	used := make(map[string]bool)
+	if _, ok := records.ByKey(c.Get(PassTypeKey)); ok {
+		used[PassTypeKey] = true
+	}
+	var custom []records.CustomField
+
+	if t.Table == records.CredentialsTable {
+		rec.Data["password"] = c.Password
+		for _, key := range passLoginKeys {
+			if login := c.Get(key); login != "" && rec.Data["login"] == "" {
+				rec.Data["login"] = login
+			}
+			used[key] = true
+		}
+		// Without a login field, the name of the entry is the login, as for browserpass
+		if rec.Data["login"] == "" {
+			rec.Data["login"] = title
+		}
+	} else if c.Password != "" {
+		custom = append(custom, records.CustomField{Name: "Password", Type: records.CustomHidden, Value: c.Password})
+	}
+	for _, f := range t.Fields {
+		if !PassField(t, f) {
+			continue
+		}
+		if value := c.Get(f.Name); value != "" {
+			rec.Data[f.Name] = value
+		}
+		used[f.Name] = true
+	}
+
+	_, linked := t.Field("totp_id")
+	for _, f := range c.Fields {
+		if used[strings.ToLower(f.Key)] || f.Value == "" {
+			continue
+		}
+		switch {
+		case f.Key == passstore.OTPKey && (linked || t.Table == records.TOTPTable) && totpData(f.Value, title) != nil:
+			key := totpData(f.Value, title)
+			if linked {
+				rec.TOTP = key
+			} else {
+				for name, value := range key {
+					if name != records.TitleField {
+						rec.Data[name] = value
+					}
+				}
+			}
+		case f.Key == passstore.OTPKey:
+			custom = append(custom, records.CustomField{Name: "TOTP", Type: records.CustomHidden, Value: f.Value})
+		case strings.EqualFold(f.Key, "url") || strings.EqualFold(f.Key, "website"):
+			custom = append(custom, urlField("URL", f.Value))
+		default:
+			custom = append(custom, records.CustomField{Name: f.Key, Type: records.CustomText, Value: f.Value})
+		}
+	}
+
+	if body := PassBodyField(t); body != "" {
+		rec.Data[body] = c.Notes
+	} else if c.Notes != "" {
+		custom = append(custom, records.CustomField{Name: "Notes", Type: records.CustomText, Value: c.Notes})
+	}
+	setCustomFields(&rec, custom)
+	return rec, true
+}
+
+// passType returns the record type of a pass entry.
+func passType(c passstore.Content) *records.Type {
+	if t, ok := records.ByKey(c.Get(PassTypeKey)); ok {
+		return t
+	}
+	table := records.CredentialsTable
+	hasLogin := false
+	for _, key := range passLoginKeys {
+		hasLogin = hasLogin || c.Get(key) != ""
+	}
+	if c.Password == "" && !hasLogin {
+		table = records.TextTable
+	}
+	t, _ := records.ByTable(table)
+	return t
+}
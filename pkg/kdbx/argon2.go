@@ -0,0 +1,273 @@
+package kdbx
+
+// Argon2d is not exported by golang.org/x/crypto/argon2, which only offers Argon2i and
+// Argon2id, but it is the default key derivation function of KeePass. This is a plain
+// implementation of Argon2 version 1.3 (RFC 9106) following the structure of the x/crypto
+// package, used for Argon2d and for Argon2id with a secret key or associated data.
+
+import (
+	"encoding/binary"
+	"hash"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Argon2 variants, as used in the hash of the parameters.
+const (
+	argon2d  = 0
+	argon2id = 2
+)
+
+const (
+	argon2Version    = 0x13
+	argon2BlockWords = 128 // argon2BlockWords is the number of 64-bit words in a 1 KiB block.
+	argon2SyncPoints = 4
+)
+
+type argon2Block [argon2BlockWords]uint64
+
+// argon2Key derives a key of keyLen bytes. memory is in KiB.
+func argon2Key(mode int, password, salt, secret, data []byte, time, memory, threads, keyLen uint32) []byte {
+	h0 := argon2InitHash(mode, password, salt, secret, data, time, memory, threads, keyLen)
+
+	memory = memory / (argon2SyncPoints * threads) * (argon2SyncPoints * threads)
+	if memory < 2*argon2SyncPoints*threads {
+		memory = 2 * argon2SyncPoints * threads
+	}
+	B := argon2InitBlocks(&h0, memory, threads)
+	argon2ProcessBlocks(B, mode, time, memory, threads)
+	return argon2ExtractKey(B, memory, threads, keyLen)
+}
+
+func argon2InitHash(mode int, password, salt, secret, data []byte, time, memory, threads, keyLen uint32) [blake2b.Size + 8]byte {
+	var (
+		h0     [blake2b.Size + 8]byte
+		params [24]byte
+	)
+	b2, _ := blake2b.New512(nil)
+	binary.LittleEndian.PutUint32(params[0:4], threads)
+	binary.LittleEndian.PutUint32(params[4:8], keyLen)
+	binary.LittleEndian.PutUint32(params[8:12], memory)
+	binary.LittleEndian.PutUint32(params[12:16], time)
+	binary.LittleEndian.PutUint32(params[16:20], argon2Version)
+	binary.LittleEndian.PutUint32(params[20:24], uint32(mode))
+	b2.Write(params[:])
+	for _, value := range [][]byte{password, salt, secret, data} {
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(value)))
+		b2.Write(length[:])
+		b2.Write(value)
+	}
+	b2.Sum(h0[:0])
+	return h0
+}
+
+func argon2InitBlocks(h0 *[blake2b.Size + 8]byte, memory, threads uint32) []argon2Block {
+	var block0 [1024]byte
+	B := make([]argon2Block, memory)
+	for lane := uint32(0); lane < threads; lane++ {
+		j := lane * (memory / threads)
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+		for i := uint32(0); i < 2; i++ {
+			binary.LittleEndian.PutUint32(h0[blake2b.Size:], i)
+			blake2bLong(block0[:], h0[:])
+			for k := range B[j+i] {
+				B[j+i][k] = binary.LittleEndian.Uint64(block0[k*8:])
+			}
+		}
+	}
+	return B
+}
+
+func argon2ProcessBlocks(B []argon2Block, mode int, time, memory, threads uint32) {
+	lanes := memory / threads
+	segments := lanes / argon2SyncPoints
+
+	processSegment := func(n, slice, lane uint32, wg *sync.WaitGroup) {
+		defer wg.Done()
+		// Argon2id uses data-independent addressing in the first half of the first pass
+		independent := mode == argon2id && n == 0 && slice < argon2SyncPoints/2
+		var addresses, in, zero argon2Block
+		if independent {
+			in[0], in[1], in[2] = uint64(n), uint64(lane), uint64(slice)
+			in[3], in[4], in[5] = uint64(memory), uint64(time), uint64(mode)
+		}
+
+		index := uint32(0)
+		if n == 0 && slice == 0 {
+			index = 2 // the first two blocks of each lane are already set
+			if independent {
+				in[6]++
+				argon2G(&addresses, &in, &zero, false)
+				argon2G(&addresses, &addresses, &zero, false)
+			}
+		}
+
+		offset := lane*lanes + slice*segments + index
+		for index < segments {
+			prev := offset - 1
+			if index == 0 && slice == 0 {
+				prev += lanes // the last block of the lane
+			}
+			var random uint64
+			if independent {
+				if index%argon2BlockWords == 0 {
+					in[6]++
+					argon2G(&addresses, &in, &zero, false)
+					argon2G(&addresses, &addresses, &zero, false)
+				}
+				random = addresses[index%argon2BlockWords]
+			} else {
+				random = B[prev][0]
+			}
+			ref := argon2IndexAlpha(random, lanes, segments, threads, n, slice, lane, index)
+			argon2G(&B[offset], &B[prev], &B[ref], true)
+			index, offset = index+1, offset+1
+		}
+	}
+
+	for n := uint32(0); n < time; n++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			var wg sync.WaitGroup
+			for lane := uint32(0); lane < threads; lane++ {
+				wg.Add(1)
+				go processSegment(n, slice, lane, &wg)
+			}
+			wg.Wait()
+		}
+	}
+}
+
+func argon2ExtractKey(B []argon2Block, memory, threads, keyLen uint32) []byte {
+	lanes := memory / threads
+	for lane := uint32(0); lane < threads-1; lane++ {
+		for i, v := range B[lane*lanes+lanes-1] {
+			B[memory-1][i] ^= v
+		}
+	}
+	var block [1024]byte
+	for i, v := range B[memory-1] {
+		binary.LittleEndian.PutUint64(block[i*8:], v)
+	}
+	key := make([]byte, keyLen)
+	blake2bLong(key, block[:])
+	return key
+}
+
+// argon2IndexAlpha returns the index of the block referenced by the current one.
+func argon2IndexAlpha(random uint64, lanes, segments, threads, n, slice, lane, index uint32) uint32 {
+	refLane := uint32(random>>32) % threads
+	if n == 0 && slice == 0 {
+		refLane = lane
+	}
+	m, s := 3*segments, ((slice+1)%argon2SyncPoints)*segments
+	if lane == refLane {
+		m += index
+	}
+	if n == 0 {
+		m, s = slice*segments, 0
+		if slice == 0 || lane == refLane {
+			m += index
+		}
+	}
+	if index == 0 || lane == refLane {
+		m--
+	}
+	p := random & 0xFFFFFFFF
+	p = (p * p) >> 32
+	p = (p * uint64(m)) >> 32
+	return refLane*lanes + uint32((uint64(s)+uint64(m)-(p+1))%uint64(lanes))
+}
+
+// argon2G is the compression function: it sets out to G(in1, in2), or XORs it into out.
+func argon2G(out, in1, in2 *argon2Block, xor bool) {
+	var t argon2Block
+	for i := range t {
+		t[i] = in1[i] ^ in2[i]
+	}
+	// Rows of 16 words
+	for i := 0; i < argon2BlockWords; i += 16 {
+		blamka(&t, i, i+1, i+2, i+3, i+4, i+5, i+6, i+7, i+8, i+9, i+10, i+11, i+12, i+13, i+14, i+15)
+	}
+	// Columns of 2 words
+	for i := 0; i < argon2BlockWords/8; i += 2 {
+		blamka(&t, i, i+1, 16+i, 16+i+1, 32+i, 32+i+1, 48+i, 48+i+1,
+			64+i, 64+i+1, 80+i, 80+i+1, 96+i, 96+i+1, 112+i, 112+i+1)
+	}
+	for i := range t {
+		if xor {
+			out[i] ^= in1[i] ^ in2[i] ^ t[i]
+		} else {
+			out[i] = in1[i] ^ in2[i] ^ t[i]
+		}
+	}
+}
+
+// blamka is the BLAKE2b round with multiplications of Argon2, applied to 16 words of a block.
+func blamka(t *argon2Block, i ...int) {
+	mix := func(a, b, c, d int) {
+		va, vb, vc, vd := t[i[a]], t[i[b]], t[i[c]], t[i[d]]
+		va += vb + 2*uint64(uint32(va))*uint64(uint32(vb))
+		vd ^= va
+		vd = vd>>32 | vd<<32
+		vc += vd + 2*uint64(uint32(vc))*uint64(uint32(vd))
+		vb ^= vc
+		vb = vb>>24 | vb<<40
+		va += vb + 2*uint64(uint32(va))*uint64(uint32(vb))
+		vd ^= va
+		vd = vd>>16 | vd<<48
+		vc += vd + 2*uint64(uint32(vc))*uint64(uint32(vd))
+		vb ^= vc
+		vb = vb<<1 | vb>>63
+		t[i[a]], t[i[b]], t[i[c]], t[i[d]] = va, vb, vc, vd
+	}
+	mix(0, 4, 8, 12)
+	mix(1, 5, 9, 13)
+	mix(2, 6, 10, 14)
+	mix(3, 7, 11, 15)
+	mix(0, 5, 10, 15)
+	mix(1, 6, 11, 12)
+	mix(2, 7, 8, 13)
+	mix(3, 4, 9, 14)
+}
+
+// blake2bLong is the variable-length hash function H' of Argon2.
+func blake2bLong(out []byte, in []byte) {
+	var b2 hash.Hash
+	if n := len(out); n < blake2b.Size {
+		b2, _ = blake2b.New(n, nil)
+	} else {
+		b2, _ = blake2b.New512(nil)
+	}
+
+	var buffer [blake2b.Size]byte
+	binary.LittleEndian.PutUint32(buffer[:4], uint32(len(out)))
+	b2.Write(buffer[:4])
+	b2.Write(in)
+
+	if len(out) <= blake2b.Size {
+		b2.Sum(out[:0])
+		return
+	}
+
+	outLen := len(out)
+	b2.Sum(buffer[:0])
+	b2.Reset()
+	copy(out, buffer[:32])
+	out = out[32:]
+	for len(out) > blake2b.Size {
+		b2.Write(buffer[:])
+		b2.Sum(buffer[:0])
+		copy(out, buffer[:32])
+		out = out[32:]
+		b2.Reset()
+	}
+
+	if outLen%blake2b.Size > 0 {
+		r := ((outLen + 31) / 32) - 2
+		b2, _ = blake2b.New(outLen-32*r, nil)
+	}
+	b2.Write(buffer[:])
+	b2.Sum(out[:0])
+}
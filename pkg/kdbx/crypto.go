@@ -0,0 +1,399 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20"
+)
+
+// Identifiers of the ciphers and key derivation functions.
+var (
+	cipherAES256   = UUID{0x31, 0xc1, 0xf2, 0xe6, 0xbf, 0x71, 0x43, 0x50, 0xbe, 0x58, 0x05, 0x21, 0x6a, 0xfc, 0x5a, 0xff}
+	cipherChaCha20 = UUID{0xd6, 0x03, 0x8a, 0x2b, 0x8b, 0x6f, 0x4c, 0xb5, 0xa5, 0x24, 0x33, 0x9a, 0x31, 0xdb, 0xb5, 0x9a}
+
+	kdfAES      = UUID{0xc9, 0xd9, 0xf3, 0x9a, 0x62, 0x8a, 0x44, 0x60, 0xbf, 0x74, 0x0d, 0x08, 0xc1, 0x8a, 0x4f, 0xea}
+	kdfArgon2d  = UUID{0xef, 0x63, 0x6d, 0xdf, 0x8c, 0x29, 0x44, 0x4b, 0x91, 0xf7, 0xa9, 0xa4, 0x03, 0xe3, 0x0a, 0x0c}
+	kdfArgon2id = UUID{0x9e, 0x29, 0x8b, 0x19, 0x56, 0xdb, 0x47, 0x73, 0xb2, 0x3d, 0xfc, 0x3e, 0xc6, 0xf0, 0xa1, 0xe6}
+)
+
+// Inner streams protecting values in the XML document.
+const (
+	streamSalsa20  = 2
+	streamChaCha20 = 3
+)
+
+// Limits of the Argon2 parameters read from a database, far beyond the settings of
+// KeePass and KeePassXC. They keep a crafted file from exhausting the memory or keeping
+// the key derivation busy for hours.
+const (
+	maxArgon2Memory     = 4 << 30 // maxArgon2Memory is in bytes.
+	maxArgon2Iterations = 10_000
+)
+
+// compositeKey combines the credentials of a database. Only a password is supported.
+func compositeKey(password string) []byte {
+	h := sha256.Sum256([]byte(password))
+	key := sha256.Sum256(h[:])
+	return key[:]
+}
+
+// kdfParameters returns the key derivation parameters for the options, both as read
+// from a header and as written to it.
+func kdfParameters(opt Options) (map[string]any, []variant, error) {
+	var params []variant
+	switch opt.KDF {
+	case Argon2d, Argon2id:
+		id := kdfArgon2d
+		if opt.KDF == Argon2id {
+			id = kdfArgon2id
+		}
+		params = []variant{
+			{"$UUID", id[:]},
+			{"S", randomBytes(32)},
+			{"P", opt.Parallelism},
+			{"M", opt.Memory},
+			{"I", opt.Iterations},
+			{"V", uint32(argon2Version)},
+		}
+	case AESKDF:
+		params = []variant{
+			{"$UUID", kdfAES[:]},
+			{"S", randomBytes(32)},
+			{"R", opt.Iterations},
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: key derivation function %s", ErrUnsupported, opt.KDF)
+	}
+	kdf := make(map[string]any, len(params))
+	for _, p := range params {
+		kdf[p.name] = p.value
+	}
+	return kdf, params, nil
+}
+
+// transformKey derives the key of a database from its composite key.
+func transformKey(kdf map[string]any, key []byte) ([]byte, error) {
+	id, _ := kdf["$UUID"].([]byte)
+	salt, _ := kdf["S"].([]byte)
+	switch {
+	case bytes.Equal(id, kdfArgon2d[:]), bytes.Equal(id, kdfArgon2id[:]):
+		parallelism, ok1 := kdf["P"].(uint32)
+		memory, ok2 := kdf["M"].(uint64)
+		iterations, ok3 := kdf["I"].(uint64)
+		version, ok4 := kdf["V"].(uint32)
+		if !ok1 || !ok2 || !ok3 || !ok4 || len(salt) == 0 {
+			return nil, fmt.Errorf("%w: invalid Argon2 parameters", ErrInvalidFile)
+		}
+		if version != argon2Version || parallelism < 1 || parallelism > math.MaxUint8 ||
+			iterations < 1 || iterations > maxArgon2Iterations || memory > maxArgon2Memory {
+			return nil, fmt.Errorf("%w: Argon2 parameters", ErrUnsupported)
+		}
+		secret, _ := kdf["K"].([]byte)
+		data, _ := kdf["A"].([]byte)
+		mode := argon2d
+		if bytes.Equal(id, kdfArgon2id[:]) {
+			mode = argon2id
+		}
+		if mode == argon2id && len(secret) == 0 && len(data) == 0 {
+			return argon2.IDKey(key, salt, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+		}
+		return argon2Key(mode, key, salt, secret, data, uint32(iterations), uint32(memory/1024), parallelism, 32), nil
+	case bytes.Equal(id, kdfAES[:]):
+		rounds, ok := kdf["R"].(uint64)
+		if !ok || len(salt) != 32 {
+			return nil, fmt.Errorf("%w: invalid AES-KDF parameters", ErrInvalidFile)
+		}
+		block, err := aes.NewCipher(salt)
+		if err != nil {
+			return nil, err
+		}
+		out := append([]byte(nil), key...)
+		for i := uint64(0); i < rounds; i++ {
+			block.Encrypt(out[:16], out[:16])
+			block.Encrypt(out[16:], out[16:])
+		}
+		sum := sha256.Sum256(out)
+		return sum[:], nil
+	}
+	return nil, fmt.Errorf("%w: unknown key derivation function %x", ErrUnsupported, id)
+}
+
+// keys are the keys derived from the master seed and the transformed key.
+type keys struct {
+	cipher []byte // cipher encrypts the payload.
+	hmac   []byte // hmac is the base key of the HMACs of the header and the blocks.
+}
+
+func deriveKeys(masterSeed, transformed []byte) keys {
+	c := sha256.Sum256(append(append([]byte(nil), masterSeed...), transformed...))
+	h := sha512.Sum512(append(append(append([]byte(nil), masterSeed...), transformed...), 1))
+	return keys{cipher: c[:], hmac: h[:]}
+}
+
+// blockKey returns the HMAC key of a block. The header uses the index MaxUint64.
+func blockKey(base []byte, index uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], index)
+	key := sha512.Sum512(append(b[:], base...))
+	return key[:]
+}
+
+func headerHMAC(base, header []byte) []byte {
+	mac := hmac.New(sha256.New, blockKey(base, math.MaxUint64))
+	mac.Write(header)
+	return mac.Sum(nil)
+}
+
+func blockHMAC(base []byte, index uint64, data []byte) []byte {
+	mac := hmac.New(sha256.New, blockKey(base, index))
+	var prefix [12]byte
+	binary.LittleEndian.PutUint64(prefix[:8], index)
+	binary.LittleEndian.PutUint32(prefix[8:], uint32(len(data)))
+	mac.Write(prefix[:])
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// readBlocks verifies the HMAC blocks holding the encrypted payload and joins them.
+func readBlocks(data, base []byte) ([]byte, error) {
+	var payload bytes.Buffer
+	for index := uint64(0); ; index++ {
+		if len(data) < 36 {
+			return nil, fmt.Errorf("%w: truncated block %d", ErrCorrupted, index)
+		}
+		mac, size := data[:32], int(int32(binary.LittleEndian.Uint32(data[32:36])))
+		data = data[36:]
+		if size < 0 || len(data) < size {
+			return nil, fmt.Errorf("%w: truncated block %d", ErrCorrupted, index)
+		}
+		if !hmac.Equal(mac, blockHMAC(base, index, data[:size])) {
+			return nil, fmt.Errorf("%w: block %d does not match its HMAC", ErrCorrupted, index)
+		}
+		if size == 0 {
+			return payload.Bytes(), nil
+		}
+		payload.Write(data[:size])
+		data = data[size:]
+	}
+}
+
+func writeBlocks(w *bytes.Buffer, payload, base []byte) {
+	for index := uint64(0); ; index++ {
+		n := len(payload)
+		if n > blockSize {
+			n = blockSize
+		}
+		w.Write(blockHMAC(base, index, payload[:n]))
+		binary.Write(w, binary.LittleEndian, int32(n))
+		w.Write(payload[:n])
+		payload = payload[n:]
+		if n == 0 {
+			return
+		}
+	}
+}
+
+func decrypt(name string, key, iv, data []byte) ([]byte, error) {
+	switch name {
+	case AES256:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("%w: invalid AES payload", ErrCorrupted)
+		}
+		plain := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+		padding := int(plain[len(plain)-1])
+		if padding < 1 || padding > aes.BlockSize {
+			return nil, fmt.Errorf("%w: invalid padding", ErrCorrupted)
+		}
+		return plain[:len(plain)-padding], nil
+	case ChaCha20:
+		c, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCorrupted, err)
+		}
+		plain := make([]byte, len(data))
+		c.XORKeyStream(plain, data)
+		return plain, nil
+	}
+	return nil, fmt.Errorf("%w: cipher %s", ErrUnsupported, name)
+}
+
+func encrypt(name string, key, iv, data []byte) ([]byte, error) {
+	switch name {
+	case AES256:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		padding := aes.BlockSize - len(data)%aes.BlockSize
+		padded := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+		return padded, nil
+	case ChaCha20:
+		c, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, len(data))
+		c.XORKeyStream(out, data)
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: cipher %s", ErrUnsupported, name)
+}
+
+// innerStream is the key stream XORed with protected values, in document order.
+type innerStream struct {
+	chacha *chacha20.Cipher
+	// Salsa20 has no streaming API, so its key stream is generated ahead as needed
+	salsaKey    [32]byte
+	salsaNonce  []byte
+	salsaStream []byte
+	salsaUsed   int
+}
+
+func newInnerStream(id uint32, key []byte) (*innerStream, error) {
+	switch id {
+	case streamChaCha20:
+		h := sha512.Sum512(key)
+		c, err := chacha20.NewUnauthenticatedCipher(h[:32], h[32:44])
+		if err != nil {
+			return nil, err
+		}
+		return &innerStream{chacha: c}, nil
+	case streamSalsa20:
+		return &innerStream{
+			salsaKey:   sha256.Sum256(key),
+			salsaNonce: []byte{0xe8, 0x30, 0x09, 0x4b, 0x97, 0x20, 0x5d, 0x2a},
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: inner stream %d", ErrUnsupported, id)
+}
+
+// xor applies the next bytes of the key stream to data in place.
+func (s *innerStream) xor(data []byte) {
+	if s.chacha != nil {
+		s.chacha.XORKeyStream(data, data)
+		return
+	}
+	if need := s.salsaUsed + len(data); need > len(s.salsaStream) {
+		s.salsaStream = make([]byte, max(need, 2*len(s.salsaStream), 4096))
+		salsa20.XORKeyStream(s.salsaStream, s.salsaStream, s.salsaNonce, &s.salsaKey)
+	}
+	for i := range data {
+		data[i] ^= s.salsaStream[s.salsaUsed+i]
+	}
+	s.salsaUsed += len(data)
+}
+
+// variant is a named value of a variant dictionary, holding the key derivation parameters.
+type variant struct {
+	name  string
+	value any
+}
+
+// Types of variant dictionary values.
+const (
+	variantEnd    = 0x00
+	variantUint32 = 0x04
+	variantUint64 = 0x05
+	variantBool   = 0x08
+	variantInt32  = 0x0c
+	variantInt64  = 0x0d
+	variantString = 0x18
+	variantBytes  = 0x42
+)
+
+func readVariants(data []byte) (map[string]any, error) {
+	invalid := fmt.Errorf("%w: invalid key derivation parameters", ErrInvalidFile)
+	if len(data) < 2 || data[1] != 1 {
+		return nil, invalid
+	}
+	values := make(map[string]any)
+	n := 2
+	for {
+		if len(data) < n+1 {
+			return nil, invalid
+		}
+		typ := data[n]
+		n++
+		if typ == variantEnd {
+			return values, nil
+		}
+		if len(data) < n+4 {
+			return nil, invalid
+		}
+		nameLen := int(int32(binary.LittleEndian.Uint32(data[n:])))
+		n += 4
+		if nameLen < 0 || len(data) < n+nameLen+4 {
+			return nil, invalid
+		}
+		name := string(data[n : n+nameLen])
+		n += nameLen
+		valueLen := int(int32(binary.LittleEndian.Uint32(data[n:])))
+		n += 4
+		if valueLen < 0 || len(data) < n+valueLen {
+			return nil, invalid
+		}
+		value := data[n : n+valueLen]
+		n += valueLen
+
+		switch {
+		case typ == variantUint32 && valueLen == 4:
+			values[name] = binary.LittleEndian.Uint32(value)
+		case typ == variantUint64 && valueLen == 8:
+			values[name] = binary.LittleEndian.Uint64(value)
+		case typ == variantBool && valueLen == 1:
+			values[name] = value[0] != 0
+		case typ == variantInt32 && valueLen == 4:
+			values[name] = int32(binary.LittleEndian.Uint32(value))
+		case typ == variantInt64 && valueLen == 8:
+			values[name] = int64(binary.LittleEndian.Uint64(value))
+		case typ == variantString:
+			values[name] = string(value)
+		case typ == variantBytes:
+			values[name] = append([]byte(nil), value...)
+		default:
+			return nil, invalid
+		}
+	}
+}
+
+func writeVariants(values []variant) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0x00, 0x01})
+	for _, v := range values {
+		var typ byte
+		var value []byte
+		switch x := v.value.(type) {
+		case uint32:
+			typ, value = variantUint32, binary.LittleEndian.AppendUint32(nil, x)
+		case uint64:
+			typ, value = variantUint64, binary.LittleEndian.AppendUint64(nil, x)
+		case []byte:
+			typ, value = variantBytes, x
+		default:
+			panic(fmt.Sprintf("kdbx: unsupported variant %T", v.value))
+		}
+		b.WriteByte(typ)
+		binary.Write(&b, binary.LittleEndian, int32(len(v.name)))
+		b.WriteString(v.name)
+		binary.Write(&b, binary.LittleEndian, int32(len(value)))
+		b.Write(value)
+	}
+	b.WriteByte(variantEnd)
+	return b.Bytes()
+}
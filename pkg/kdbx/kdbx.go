@@ -0,0 +1,476 @@
+// Package kdbx reads and writes KeePass databases in the KDBX 4 format, as used by
+// KeePass 2 and KeePassXC.
+//
+// Databases are protected with a password. The payload can be encrypted with AES-256 or
+// ChaCha20, and the key derived with Argon2d, Argon2id or AES-KDF. Key files and the
+// KDBX 3 format of older databases are not supported.
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Errors returned by Read.
+var (
+	ErrInvalidFile     = errors.New("not a KeePass database")
+	ErrUnsupported     = errors.New("unsupported KeePass database")
+	ErrInvalidPassword = errors.New("invalid password or corrupted database header")
+	ErrCorrupted       = errors.New("the database is corrupted")
+)
+
+// UUID identifies groups and entries.
+type UUID [16]byte
+
+// NewUUID returns a random UUID.
+func NewUUID() UUID {
+	var u UUID
+	if _, err := rand.Read(u[:]); err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// Database is the content of a KeePass database.
+type Database struct {
+	Name string
+	Root Group
+	// RecycleBin is the UUID of the group holding deleted entries, zero if there is none.
+	RecycleBin UUID
+}
+
+// Group is a group of entries and subgroups.
+type Group struct {
+	UUID    UUID
+	Name    string
+	Notes   string
+	Times   Times
+	Entries []Entry
+	Groups  []Group
+}
+
+// Entry is an entry of a group. The standard fields, such as Title and Password, are
+// strings like the custom ones.
+type Entry struct {
+	UUID     UUID
+	Tags     []string
+	Times    Times
+	Strings  []String
+	Binaries []Binary
+}
+
+// Keys of the standard strings of an entry.
+const (
+	Title    = "Title"
+	UserName = "UserName"
+	Password = "Password"
+	URL      = "URL"
+	Notes    = "Notes"
+	OTP      = "otp" // OTP holds an otpauth:// URI, as written by KeePassXC.
+)
+
+// String is a named value of an entry. Protected values are encrypted in the XML of the database.
+type String struct {
+	Key       string
+	Value     string
+	Protected bool
+}
+
+// Binary is a file attached to an entry.
+type Binary struct {
+	Name string
+	Data []byte
+}
+
+// Times holds the times of a group or entry. Expiry is only used if Expires is set.
+type Times struct {
+	Created  time.Time
+	Modified time.Time
+	Expires  bool
+	Expiry   time.Time
+}
+
+// Get returns the value of a string of the entry.
+func (e *Entry) Get(key string) string {
+	for _, s := range e.Strings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// Ciphers encrypting the payload.
+const (
+	AES256   = "aes256"
+	ChaCha20 = "chacha20"
+)
+
+// Key derivation functions.
+const (
+	Argon2d  = "argon2d"
+	Argon2id = "argon2id"
+	AESKDF   = "aes-kdf"
+)
+
+// Options are the encryption settings of a written database.
+type Options struct {
+	Cipher string // Cipher is AES256 or ChaCha20.
+	KDF    string // KDF is Argon2d, Argon2id or AESKDF.
+	// Iterations is the number of Argon2 passes, or the number of AES-KDF rounds.
+	Iterations  uint64
+	Memory      uint64 // Memory is the Argon2 memory in bytes.
+	Parallelism uint32 // Parallelism is the number of Argon2 lanes.
+}
+
+// DefaultOptions are the settings recommended by RFC 9106 for Argon2id with 64 MiB of memory.
+var DefaultOptions = Options{
+	Cipher:      AES256,
+	KDF:         Argon2id,
+	Iterations:  3,
+	Memory:      64 << 20,
+	Parallelism: 4,
+}
+
+const (
+	signature1    = 0x9AA2D903
+	signature2    = 0xB54BFB67
+	majorVersion4 = 4
+
+	blockSize = 1 << 20 // blockSize is the size of the HMAC blocks written.
+)
+
+// Outer header fields.
+const (
+	headerEnd         = 0
+	headerCipherID    = 2
+	headerCompression = 3
+	headerMasterSeed  = 4
+	headerIV          = 7
+	headerKDF         = 11
+)
+
+// Inner header fields.
+const (
+	innerEnd       = 0
+	innerStreamID  = 1
+	innerStreamKey = 2
+	innerBinary    = 3
+)
+
+// header is the outer header of a database.
+type header struct {
+	cipher     string
+	compressed bool
+	masterSeed []byte
+	iv         []byte
+	kdf        map[string]any
+}
+
+// Read reads and decrypts a database.
+func Read(r io.Reader, password string) (*Database, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	h, n, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < n+64 {
+		return nil, ErrInvalidFile
+	}
+	if sum := sha256.Sum256(data[:n]); !bytes.Equal(sum[:], data[n:n+32]) {
+		return nil, fmt.Errorf("%w: header checksum mismatch", ErrCorrupted)
+	}
+
+	transformed, err := transformKey(h.kdf, compositeKey(password))
+	if err != nil {
+		return nil, err
+	}
+	keys := deriveKeys(h.masterSeed, transformed)
+	if !bytes.Equal(headerHMAC(keys.hmac, data[:n]), data[n+32:n+64]) {
+		return nil, ErrInvalidPassword
+	}
+
+	payload, err := readBlocks(data[n+64:], keys.hmac)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decrypt(h.cipher, keys.cipher, h.iv, payload)
+	if err != nil {
+		return nil, err
+	}
+	if h.compressed {
+		gz, err := gzip.NewReader(bytes.NewReader(plain))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCorrupted, err)
+		}
+		if plain, err = io.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCorrupted, err)
+		}
+	}
+
+	stream, binaries, content, err := readInnerHeader(plain)
+	if err != nil {
+		return nil, err
+	}
+	return parseXML(content, stream, binaries)
+}
+
+// Write encrypts a database with the given password and writes it. Zero options are
+// replaced by the DefaultOptions.
+func Write(w io.Writer, db *Database, password string, opt Options) error {
+	opt = opt.withDefaults()
+	h := header{cipher: opt.Cipher, compressed: true, masterSeed: randomBytes(32)}
+	switch opt.Cipher {
+	case AES256:
+		h.iv = randomBytes(16)
+	case ChaCha20:
+		h.iv = randomBytes(12)
+	default:
+		return fmt.Errorf("%w: cipher %s", ErrUnsupported, opt.Cipher)
+	}
+	kdf, params, err := kdfParameters(opt)
+	if err != nil {
+		return err
+	}
+	h.kdf = kdf
+
+	var out bytes.Buffer
+	writeHeader(&out, h, params)
+	headerBytes := out.Bytes()
+	sum := sha256.Sum256(headerBytes)
+
+	transformed, err := transformKey(h.kdf, compositeKey(password))
+	if err != nil {
+		return err
+	}
+	keys := deriveKeys(h.masterSeed, transformed)
+	mac := headerHMAC(keys.hmac, headerBytes)
+
+	streamKey := randomBytes(64)
+	stream, err := newInnerStream(streamChaCha20, streamKey)
+	if err != nil {
+		return err
+	}
+	content, binaries, err := buildXML(db, stream)
+	if err != nil {
+		return err
+	}
+	var plain bytes.Buffer
+	gz := gzip.NewWriter(&plain)
+	writeInnerHeader(gz, streamKey, binaries)
+	gz.Write(content)
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	payload, err := encrypt(h.cipher, keys.cipher, h.iv, plain.Bytes())
+	if err != nil {
+		return err
+	}
+
+	out.Write(sum[:])
+	out.Write(mac)
+	writeBlocks(&out, payload, keys.hmac)
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+func (o Options) withDefaults() Options {
+	if o.Cipher == "" {
+		o.Cipher = DefaultOptions.Cipher
+	}
+	if o.KDF == "" {
+		o.KDF = DefaultOptions.KDF
+	}
+	if o.Iterations == 0 {
+		o.Iterations = DefaultOptions.Iterations
+		if o.KDF == AESKDF {
+			o.Iterations = 1_000_000
+		}
+	}
+	if o.Memory == 0 {
+		o.Memory = DefaultOptions.Memory
+	}
+	if o.Parallelism == 0 {
+		o.Parallelism = DefaultOptions.Parallelism
+	}
+	return o
+}
+
+// readHeader parses the outer header and returns it with its length.
+func readHeader(data []byte) (header, int, error) {
+	var h header
+	if len(data) < 12 || binary.LittleEndian.Uint32(data[0:4]) != signature1 || binary.LittleEndian.Uint32(data[4:8]) != signature2 {
+		return h, 0, ErrInvalidFile
+	}
+	if major := binary.LittleEndian.Uint16(data[10:12]); major != majorVersion4 {
+		return h, 0, fmt.Errorf("%w: KDBX version %d, only version 4 is supported", ErrUnsupported, major)
+	}
+
+	n := 12
+	for {
+		if len(data) < n+5 {
+			return h, 0, ErrInvalidFile
+		}
+		id := data[n]
+		size := int(binary.LittleEndian.Uint32(data[n+1 : n+5]))
+		n += 5
+		if size < 0 || len(data) < n+size {
+			return h, 0, ErrInvalidFile
+		}
+		value := data[n : n+size]
+		n += size
+
+		switch id {
+		case headerEnd:
+			if h.cipher == "" || len(h.masterSeed) != 32 || h.kdf == nil {
+				return h, 0, fmt.Errorf("%w: incomplete header", ErrInvalidFile)
+			}
+			return h, n, nil
+		case headerCipherID:
+			switch {
+			case bytes.Equal(value, cipherAES256[:]):
+				h.cipher = AES256
+			case bytes.Equal(value, cipherChaCha20[:]):
+				h.cipher = ChaCha20
+			default:
+				return h, 0, fmt.Errorf("%w: unknown cipher %x", ErrUnsupported, value)
+			}
+		case headerCompression:
+			if len(value) != 4 || binary.LittleEndian.Uint32(value) > 1 {
+				return h, 0, fmt.Errorf("%w: unknown compression", ErrUnsupported)
+			}
+			h.compressed = binary.LittleEndian.Uint32(value) == 1
+		case headerMasterSeed:
+			h.masterSeed = value
+		case headerIV:
+			h.iv = value
+		case headerKDF:
+			kdf, err := readVariants(value)
+			if err != nil {
+				return h, 0, err
+			}
+			h.kdf = kdf
+		}
+	}
+}
+
+func writeHeader(w *bytes.Buffer, h header, kdf []variant) {
+	binary.Write(w, binary.LittleEndian, uint32(signature1))
+	binary.Write(w, binary.LittleEndian, uint32(signature2))
+	binary.Write(w, binary.LittleEndian, uint32(majorVersion4<<16))
+
+	field := func(id byte, value []byte) {
+		w.WriteByte(id)
+		binary.Write(w, binary.LittleEndian, uint32(len(value)))
+		w.Write(value)
+	}
+	cipherID := cipherAES256
+	if h.cipher == ChaCha20 {
+		cipherID = cipherChaCha20
+	}
+	field(headerCipherID, cipherID[:])
+	compression := []byte{0, 0, 0, 0}
+	if h.compressed {
+		compression[0] = 1
+	}
+	field(headerCompression, compression)
+	field(headerMasterSeed, h.masterSeed)
+	field(headerIV, h.iv)
+	field(headerKDF, writeVariants(kdf))
+	field(headerEnd, []byte("\r\n\r\n"))
+}
+
+// readInnerHeader parses the inner header at the start of the decrypted payload and
+// returns the stream protecting values, the binaries and the XML document.
+func readInnerHeader(data []byte) (*innerStream, [][]byte, []byte, error) {
+	var (
+		streamID  uint32
+		streamKey []byte
+		binaries  [][]byte
+	)
+	n := 0
+	for {
+		if len(data) < n+5 {
+			return nil, nil, nil, fmt.Errorf("%w: truncated inner header", ErrCorrupted)
+		}
+		id := data[n]
+		size := int(binary.LittleEndian.Uint32(data[n+1 : n+5]))
+		n += 5
+		if size < 0 || len(data) < n+size {
+			return nil, nil, nil, fmt.Errorf("%w: truncated inner header", ErrCorrupted)
+		}
+		value := data[n : n+size]
+		n += size
+
+		switch id {
+		case innerEnd:
+			stream, err := newInnerStream(streamID, streamKey)
+			return stream, binaries, data[n:], err
+		case innerStreamID:
+			if len(value) != 4 {
+				return nil, nil, nil, fmt.Errorf("%w: invalid inner stream", ErrCorrupted)
+			}
+			streamID = binary.LittleEndian.Uint32(value)
+		case innerStreamKey:
+			streamKey = value
+		case innerBinary:
+			if len(value) < 1 {
+				return nil, nil, nil, fmt.Errorf("%w: invalid binary", ErrCorrupted)
+			}
+			// The first byte holds flags, such as whether the binary is protected in memory
+			binaries = append(binaries, value[1:])
+		}
+	}
+}
+
+func writeInnerHeader(w io.Writer, streamKey []byte, binaries [][]byte) {
+	field := func(id byte, values ...[]byte) {
+		size := 0
+		for _, v := range values {
+			size += len(v)
+		}
+		w.Write([]byte{id})
+		binary.Write(w, binary.LittleEndian, uint32(size))
+		for _, v := range values {
+			w.Write(v)
+		}
+	}
+	id := make([]byte, 4)
+	binary.LittleEndian.PutUint32(id, streamChaCha20)
+	field(innerStreamID, id)
+	field(innerStreamKey, streamKey)
+	for _, b := range binaries {
+		field(innerBinary, []byte{0}, b)
+	}
+	field(innerEnd)
+}
+
+// splitTags splits the tags of an entry, separated by semicolons or commas.
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
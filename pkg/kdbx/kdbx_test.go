@@ -0,0 +1,131 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+func testDatabase() *Database {
+	expiry := time.Date(2030, 1, 2, 23, 59, 59, 0, time.UTC)
+	return &Database{
+		Name: "Vault",
+		Root: Group{
+			Name: "Root",
+			Entries: []Entry{{
+				Tags:  []string{"mail", "personal"},
+				Times: Times{Expires: true, Expiry: expiry},
+				Strings: []String{
+					{Key: Title, Value: "Mail"},
+					{Key: UserName, Value: "alice"},
+					{Key: Password, Value: "s3cr<et>&", Protected: true},
+					{Key: "PIN", Value: "1234", Protected: true},
+				},
+				Binaries: []Binary{{Name: "note.txt", Data: []byte("attached")}},
+			}},
+			Groups: []Group{{
+				Name:    "Work",
+				Entries: []Entry{{Strings: []String{{Key: Title, Value: "VPN"}, {Key: Password, Value: "", Protected: true}}}},
+			}},
+		},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	tests := []Options{
+		{Cipher: AES256, KDF: Argon2d, Iterations: 2, Memory: 64 << 10, Parallelism: 2},
+		{Cipher: ChaCha20, KDF: Argon2id, Iterations: 2, Memory: 64 << 10, Parallelism: 2},
+		{Cipher: AES256, KDF: AESKDF, Iterations: 1000},
+	}
+	for _, opt := range tests {
+		t.Run(opt.Cipher+"/"+opt.KDF, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, testDatabase(), "password", opt))
+
+			db, err := Read(bytes.NewReader(buf.Bytes()), "password")
+			require.NoError(t, err)
+			assert.Equal(t, "Vault", db.Name)
+			require.Len(t, db.Root.Entries, 1)
+			entry := db.Root.Entries[0]
+			assert.Equal(t, "Mail", entry.Get(Title))
+			assert.Equal(t, "alice", entry.Get(UserName))
+			assert.Equal(t, "s3cr<et>&", entry.Get(Password))
+			assert.Equal(t, "1234", entry.Get("PIN"))
+			assert.Equal(t, []string{"mail", "personal"}, entry.Tags)
+			assert.True(t, entry.Times.Expires)
+			assert.Equal(t, time.Date(2030, 1, 2, 23, 59, 59, 0, time.UTC), entry.Times.Expiry)
+			assert.Equal(t, []Binary{{Name: "note.txt", Data: []byte("attached")}}, entry.Binaries)
+
+			require.Len(t, db.Root.Groups, 1)
+			assert.Equal(t, "Work", db.Root.Groups[0].Name)
+			assert.Equal(t, "VPN", db.Root.Groups[0].Entries[0].Get(Title))
+		})
+	}
+}
+
+func TestRead_InvalidPassword(t *testing.T) {
+	var buf bytes.Buffer
+	opt := Options{KDF: Argon2id, Iterations: 1, Memory: 64 << 10, Parallelism: 1}
+	require.NoError(t, Write(&buf, testDatabase(), "password", opt))
+
+	_, err := Read(bytes.NewReader(buf.Bytes()), "wrong")
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+}
+
+func TestRead_Corrupted(t *testing.T) {
+	var buf bytes.Buffer
+	opt := Options{KDF: Argon2id, Iterations: 1, Memory: 64 << 10, Parallelism: 1}
+	require.NoError(t, Write(&buf, testDatabase(), "password", opt))
+
+	data := buf.Bytes()
+	data[len(data)-10] ^= 1
+	_, err := Read(bytes.NewReader(data), "password")
+	assert.ErrorIs(t, err, ErrCorrupted)
+
+	_, err = Read(bytes.NewReader([]byte("not a database")), "password")
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
+
+func TestArgon2Key(t *testing.T) {
+	// Test vectors of RFC 9106
+	password := bytes.Repeat([]byte{1}, 32)
+	salt := bytes.Repeat([]byte{2}, 16)
+	secret := bytes.Repeat([]byte{3}, 8)
+	data := bytes.Repeat([]byte{4}, 12)
+
+	key := argon2Key(argon2d, password, salt, secret, data, 3, 32, 4, 32)
+	assert.Equal(t, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb", hex.EncodeToString(key))
+	key = argon2Key(argon2id, password, salt, secret, data, 3, 32, 4, 32)
+	assert.Equal(t, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659", hex.EncodeToString(key))
+
+	// Without a secret or associated data, Argon2id matches golang.org/x/crypto/argon2
+	key = argon2Key(argon2id, password, salt, nil, nil, 2, 1024, 3, 32)
+	assert.Equal(t, argon2.IDKey(password, salt, 2, 1024, 3, 32), key)
+}
+
+func TestTransformKey_Argon2Limits(t *testing.T) {
+	params := func(memory, iterations uint64) map[string]any {
+		return map[string]any{
+			"$UUID": kdfArgon2d[:], "S": bytes.Repeat([]byte{1}, 32),
+			"P": uint32(1), "M": memory, "I": iterations, "V": uint32(argon2Version),
+		}
+	}
+	key := compositeKey("password")
+
+	_, err := transformKey(params(maxArgon2Memory+1024, 1), key)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = transformKey(params(64<<10, maxArgon2Iterations+1), key)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = transformKey(params(64<<10, 1), key)
+	assert.NoError(t, err)
+
+	// Writing is refused as well, before any memory is allocated
+	err = Write(io.Discard, testDatabase(), "password", Options{KDF: Argon2id, Memory: 8 << 30})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
@@ -0,0 +1,324 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The XML document of a database. Elements GophKeeper does not use, such as auto-type
+// settings and entry history, are ignored when reading and left out when writing.
+type xmlFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    xmlMeta  `xml:"Meta"`
+	Root    xmlRoot  `xml:"Root"`
+}
+
+type xmlMeta struct {
+	Generator        string `xml:"Generator"`
+	DatabaseName     string `xml:"DatabaseName"`
+	MemoryProtection struct {
+		ProtectTitle    string `xml:"ProtectTitle"`
+		ProtectUserName string `xml:"ProtectUserName"`
+		ProtectPassword string `xml:"ProtectPassword"`
+		ProtectURL      string `xml:"ProtectURL"`
+		ProtectNotes    string `xml:"ProtectNotes"`
+	} `xml:"MemoryProtection"`
+	RecycleBinEnabled string `xml:"RecycleBinEnabled"`
+	RecycleBinUUID    string `xml:"RecycleBinUUID"`
+}
+
+type xmlRoot struct {
+	Group          xmlGroup `xml:"Group"`
+	DeletedObjects string   `xml:"DeletedObjects"`
+}
+
+type xmlGroup struct {
+	UUID       string     `xml:"UUID"`
+	Name       string     `xml:"Name"`
+	Notes      string     `xml:"Notes"`
+	IconID     int        `xml:"IconID"`
+	Times      xmlTimes   `xml:"Times"`
+	IsExpanded string     `xml:"IsExpanded"`
+	Entries    []xmlEntry `xml:"Entry"`
+	Groups     []xmlGroup `xml:"Group"`
+}
+
+type xmlEntry struct {
+	UUID     string      `xml:"UUID"`
+	IconID   int         `xml:"IconID"`
+	Tags     string      `xml:"Tags"`
+	Times    xmlTimes    `xml:"Times"`
+	Strings  []xmlString `xml:"String"`
+	Binaries []xmlBinary `xml:"Binary"`
+}
+
+type xmlTimes struct {
+	LastModificationTime string `xml:"LastModificationTime"`
+	CreationTime         string `xml:"CreationTime"`
+	LastAccessTime       string `xml:"LastAccessTime"`
+	ExpiryTime           string `xml:"ExpiryTime"`
+	Expires              string `xml:"Expires"`
+	UsageCount           int    `xml:"UsageCount"`
+	LocationChanged      string `xml:"LocationChanged"`
+}
+
+type xmlString struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Protected string `xml:"Protected,attr,omitempty"`
+		Text      string `xml:",chardata"`
+	} `xml:"Value"`
+}
+
+type xmlBinary struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Ref string `xml:"Ref,attr"`
+	} `xml:"Value"`
+}
+
+// parseXML decodes the XML document of a database, given the stream protecting its
+// values and the binaries of the inner header.
+func parseXML(content []byte, stream *innerStream, binaries [][]byte) (*Database, error) {
+	content, err := transformProtected(content, func(value string) (string, error) {
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", err
+		}
+		stream.xor(data)
+		return string(data), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCorrupted, err)
+	}
+	var file xmlFile
+	if err := xml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCorrupted, err)
+	}
+
+	db := &Database{Name: file.Meta.DatabaseName}
+	if isTrue(file.Meta.RecycleBinEnabled) {
+		db.RecycleBin = parseUUID(file.Meta.RecycleBinUUID)
+	}
+	db.Root, err = file.Root.Group.group(binaries)
+	return db, err
+}
+
+func (g xmlGroup) group(binaries [][]byte) (Group, error) {
+	group := Group{UUID: parseUUID(g.UUID), Name: g.Name, Notes: g.Notes, Times: g.Times.times()}
+	for _, e := range g.Entries {
+		entry := Entry{UUID: parseUUID(e.UUID), Tags: splitTags(e.Tags), Times: e.Times.times()}
+		for _, s := range e.Strings {
+			entry.Strings = append(entry.Strings, String{Key: s.Key, Value: s.Value.Text, Protected: isTrue(s.Value.Protected)})
+		}
+		for _, b := range e.Binaries {
+			ref, err := strconv.Atoi(b.Value.Ref)
+			if err != nil || ref < 0 || ref >= len(binaries) {
+				return group, fmt.Errorf("%w: attachment %s of %s refers to a missing binary", ErrCorrupted, b.Key, entry.Get(Title))
+			}
+			entry.Binaries = append(entry.Binaries, Binary{Name: b.Key, Data: binaries[ref]})
+		}
+		group.Entries = append(group.Entries, entry)
+	}
+	for _, sub := range g.Groups {
+		child, err := sub.group(binaries)
+		if err != nil {
+			return group, err
+		}
+		group.Groups = append(group.Groups, child)
+	}
+	return group, nil
+}
+
+// buildXML encodes the XML document of a database, protecting values with the stream,
+// and returns it with the binaries for the inner header.
+func buildXML(db *Database, stream *innerStream) ([]byte, [][]byte, error) {
+	file := xmlFile{}
+	file.Meta.Generator = "GophKeeper"
+	file.Meta.DatabaseName = db.Name
+	file.Meta.MemoryProtection.ProtectTitle = "False"
+	file.Meta.MemoryProtection.ProtectUserName = "False"
+	file.Meta.MemoryProtection.ProtectPassword = "True"
+	file.Meta.MemoryProtection.ProtectURL = "False"
+	file.Meta.MemoryProtection.ProtectNotes = "False"
+	file.Meta.RecycleBinEnabled = "False"
+	if db.RecycleBin != (UUID{}) {
+		file.Meta.RecycleBinEnabled = "True"
+	}
+	file.Meta.RecycleBinUUID = formatUUID(db.RecycleBin)
+
+	var binaries [][]byte
+	file.Root.Group = xmlGroupOf(db.Root, &binaries)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "\t")
+	if err := enc.Encode(file); err != nil {
+		return nil, nil, err
+	}
+	content, err := transformProtected(buf.Bytes(), func(value string) (string, error) {
+		data := []byte(value)
+		stream.xor(data)
+		return base64.StdEncoding.EncodeToString(data), nil
+	})
+	return content, binaries, err
+}
+
+func xmlGroupOf(g Group, binaries *[][]byte) xmlGroup {
+	if g.UUID == (UUID{}) {
+		g.UUID = NewUUID()
+	}
+	group := xmlGroup{UUID: formatUUID(g.UUID), Name: g.Name, Notes: g.Notes, Times: xmlTimesOf(g.Times), IsExpanded: "True"}
+	if group.Name == "" {
+		group.Name = "Root"
+	}
+	for _, e := range g.Entries {
+		if e.UUID == (UUID{}) {
+			e.UUID = NewUUID()
+		}
+		entry := xmlEntry{UUID: formatUUID(e.UUID), Tags: strings.Join(e.Tags, ";"), Times: xmlTimesOf(e.Times)}
+		for _, s := range e.Strings {
+			var x xmlString
+			x.Key, x.Value.Text = s.Key, s.Value
+			if s.Protected {
+				x.Value.Protected = "True"
+			}
+			entry.Strings = append(entry.Strings, x)
+		}
+		for _, b := range e.Binaries {
+			var x xmlBinary
+			x.Key, x.Value.Ref = b.Name, strconv.Itoa(len(*binaries))
+			*binaries = append(*binaries, b.Data)
+			entry.Binaries = append(entry.Binaries, x)
+		}
+		group.Entries = append(group.Entries, entry)
+	}
+	for _, sub := range g.Groups {
+		group.Groups = append(group.Groups, xmlGroupOf(sub, binaries))
+	}
+	return group
+}
+
+// transformProtected replaces the text of the values marked Protected="True", in
+// document order, as protected values share a single key stream. The rest of the
+// document is copied unchanged.
+func transformProtected(content []byte, transform func(string) (string, error)) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	var buf bytes.Buffer
+	copied, protected := int64(0), false
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			protected = false
+			for _, a := range t.Attr {
+				if t.Name.Local == "Value" && a.Name.Local == "Protected" && isTrue(a.Value) {
+					protected = true
+				}
+			}
+		case xml.CharData:
+			if !protected {
+				break
+			}
+			value, err := transform(string(t))
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(content[copied:start])
+			if err := xml.EscapeText(&buf, []byte(value)); err != nil {
+				return nil, err
+			}
+			copied, protected = dec.InputOffset(), false
+		default:
+			protected = false
+		}
+	}
+	buf.Write(content[copied:])
+	return buf.Bytes(), nil
+}
+
+// timeOffset is the number of seconds between 0001-01-01 and the Unix epoch. KDBX 4 stores
+// times as base64 encoded little-endian seconds since 0001-01-01 UTC.
+const timeOffset = 62135596800
+
+func (t xmlTimes) times() Times {
+	return Times{
+		Created:  parseTime(t.CreationTime),
+		Modified: parseTime(t.LastModificationTime),
+		Expires:  isTrue(t.Expires),
+		Expiry:   parseTime(t.ExpiryTime),
+	}
+}
+
+func xmlTimesOf(t Times) xmlTimes {
+	now := time.Now()
+	if t.Created.IsZero() {
+		t.Created = now
+	}
+	if t.Modified.IsZero() {
+		t.Modified = t.Created
+	}
+	x := xmlTimes{
+		CreationTime:         formatTime(t.Created),
+		LastModificationTime: formatTime(t.Modified),
+		LastAccessTime:       formatTime(t.Modified),
+		ExpiryTime:           formatTime(t.Created),
+		Expires:              "False",
+		LocationChanged:      formatTime(t.Modified),
+	}
+	if t.Expires {
+		x.ExpiryTime, x.Expires = formatTime(t.Expiry), "True"
+	}
+	return x
+}
+
+// parseTime parses a KDBX 4 time, or an ISO 8601 time as written by KDBX 3.
+func parseTime(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(b) != 8 {
+		return time.Time{}
+	}
+	return time.Unix(int64(binary.LittleEndian.Uint64(b))-timeOffset, 0).UTC()
+}
+
+func formatTime(t time.Time) string {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(t.Unix()+timeOffset))
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+func parseUUID(value string) UUID {
+	var u UUID
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err == nil && len(b) == len(u) {
+		copy(u[:], b)
+	}
+	return u
+}
+
+func formatUUID(u UUID) string {
+	return base64.StdEncoding.EncodeToString(u[:])
+}
+
+func isTrue(value string) bool {
+	return strings.EqualFold(value, "true")
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // SyncQueue represents a model for synchronization queue.
 type SyncQueue struct {
 	ID        int    `db:"id"`
@@ -10,3 +12,10 @@ type SyncQueue struct {
 	Data      string `db:"data"`
 	Status    string `db:"status"`
 }
+
+// EntryVersion represents a previous version of an entry kept in the history.
+type EntryVersion struct {
+	Version   int               `db:"version"`
+	CreatedAt time.Time         `db:"created_at"` // CreatedAt is when the version was replaced.
+	Data      map[string]string `db:"data"`
+}
@@ -0,0 +1,96 @@
+package models
+
+// The record models mirror the record tables of the local database. Their values
+// are stored encrypted, so every field is a string.
+
+// Entry holds the columns shared by the records of every type.
+type Entry struct {
+	UpdatedAt    string `db:"updated_at"`
+	CreatedAt    string `db:"created_at"`
+	FolderID     string `db:"folder_id"`
+	CustomFields string `db:"custom_fields"`
+	Attachments  string `db:"attachments"`
+	ExpiresAt    string `db:"expires_at"`
+}
+
+// Credentials represents a login/password entry.
+type Credentials struct {
+	ID                string `db:"id"`
+	MetaInfo          string `db:"meta_info"`
+	Login             string `db:"login"`
+	Password          string `db:"password"`
+	TOTPID            string `db:"totp_id"`
+	PasswordChangedAt string `db:"password_changed_at"`
+	Entry
+}
+
+// TableName returns the table holding credentials.
+func (Credentials) TableName() string { return "UserCredentials" }
+
+// TextData represents an arbitrary text entry.
+type TextData struct {
+	ID       string `db:"id"`
+	MetaInfo string `db:"meta_info"`
+	Data     string `db:"data"`
+	Entry
+}
+
+// TableName returns the table holding text entries.
+func (TextData) TableName() string { return "TextData" }
+
+// FileData represents the metadata of an encrypted file.
+type FileData struct {
+	ID        string `db:"id"`
+	MetaInfo  string `db:"meta_info"`
+	Path      string `db:"path"`
+	Extension string `db:"extension"`
+	Entry
+}
+
+// TableName returns the table holding file metadata.
+func (FileData) TableName() string { return "FilesData" }
+
+// CreditCard represents a bank card entry.
+type CreditCard struct {
+	ID             string `db:"id"`
+	MetaInfo       string `db:"meta_info"`
+	CardNumber     string `db:"card_number"`
+	ExpirationDate string `db:"expiration_date"`
+	CVV            string `db:"cvv"`
+	Entry
+}
+
+// TableName returns the table holding bank cards.
+func (CreditCard) TableName() string { return "CreditCardData" }
+
+// TOTP represents a TOTP authenticator key.
+type TOTP struct {
+	ID          string `db:"id"`
+	MetaInfo    string `db:"meta_info"`
+	Secret      string `db:"secret"`
+	Issuer      string `db:"issuer"`
+	AccountName string `db:"account_name"`
+	Algorithm   string `db:"algorithm"`
+	Digits      string `db:"digits"`
+	Period      string `db:"period"`
+	Entry
+}
+
+// TableName returns the table holding TOTP keys.
+func (TOTP) TableName() string { return "TOTPData" }
+
+// SSHKey represents an SSH private key with its metadata.
+type SSHKey struct {
+	ID          string `db:"id"`
+	MetaInfo    string `db:"meta_info"`
+	PrivateKey  string `db:"private_key"`
+	KeyType     string `db:"key_type"`
+	PublicKey   string `db:"public_key"`
+	Fingerprint string `db:"fingerprint"`
+	Comment     string `db:"comment"`
+	Confirm     string `db:"confirm"`
+	Entry
+}
+
+// TableName returns the table holding SSH keys.
+func (SSHKey) TableName() string { return "SSHKeys" }
@@ -0,0 +1,80 @@
+package passstore
+
+import "strings"
+
+// OTPKey is the key of the field holding an otpauth:// URI, written by pass-otp as a
+// line of its own.
+const OTPKey = "otpauth"
+
+// Content is the decrypted content of an entry, following the conventions of pass and
+// its extensions: the password is the first line, fields follow as "key: value" lines
+// and the rest is free text, separated from the fields by an empty line.
+type Content struct {
+	Password string
+	Fields   []Field
+	Notes    string
+}
+
+// Field is a "key: value" line of an entry.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Get returns the value of the first field with the key, compared case-insensitively.
+func (c Content) Get(key string) string {
+	for _, f := range c.Fields {
+		if strings.EqualFold(f.Key, key) {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Parse parses the decrypted content of an entry. The fields end at the first line that
+// is neither a "key: value" line nor an otpauth:// URI.
+func Parse(data []byte) Content {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	c := Content{Password: lines[0]}
+	i := 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "otpauth://") {
+			c.Fields = append(c.Fields, Field{Key: OTPKey, Value: strings.TrimSpace(line)})
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" || (value != "" && value[0] != ' ' && value[0] != '\t') {
+			break
+		}
+		c.Fields = append(c.Fields, Field{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	if i < len(lines) && lines[i] == "" {
+		i++
+	}
+	if i < len(lines) {
+		c.Notes = strings.TrimRight(strings.Join(lines[i:], "\n"), "\n")
+	}
+	return c
+}
+
+// Bytes formats the content of an entry. Values of fields must be on a single line.
+func (c Content) Bytes() []byte {
+	var b strings.Builder
+	b.WriteString(c.Password)
+	b.WriteByte('\n')
+	for _, f := range c.Fields {
+		if f.Key == OTPKey {
+			b.WriteString(f.Value)
+		} else {
+			b.WriteString(f.Key + ": " + f.Value)
+		}
+		b.WriteByte('\n')
+	}
+	if c.Notes != "" {
+		b.WriteByte('\n')
+		b.WriteString(c.Notes)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,200 @@
+// Package passstore reads and writes password stores of pass, the standard unix password
+// manager: directories of files encrypted with OpenPGP, one for each entry, named by the
+// entry with the .gpg extension. The .gpg-id file at the root of the store lists the
+// recipients new entries are encrypted to.
+//
+// OpenPGP is implemented in pure Go by github.com/ProtonMail/go-crypto/openpgp, which
+// supports the ed25519 and cv25519 keys GnuPG creates by default as well as RSA, DSA,
+// ElGamal and NIST curve keys.
+package passstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// IDFile is the file listing the recipients of a store.
+const IDFile = ".gpg-id"
+
+// Extension is the extension of the files of the entries.
+const Extension = ".gpg"
+
+// Errors returned by the package.
+var (
+	ErrNoKey         = errors.New("no secret key can decrypt the entry")
+	ErrWrongPassword = errors.New("wrong passphrase of the secret key")
+	ErrNoRecipients  = errors.New("no recipients to encrypt to")
+)
+
+// Entry is an entry of a store.
+type Entry struct {
+	// Name is the path of the entry in the store, with slashes and without the extension.
+	Name string
+	Data []byte
+}
+
+// Is reports whether dir is a password store.
+func Is(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, IDFile))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ReadDir reads the encrypted entries of a store, in the order of their names. Hidden
+// files and directories, such as .git, are left out.
+func ReadDir(dir string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || filepath.Ext(path) != Extension {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{Name: filepath.ToSlash(strings.TrimSuffix(rel, Extension)), Data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// WriteDir writes a new store to dir, which must not exist, with the entries encrypted
+// to the recipients. The recipients are listed in the .gpg-id file by fingerprint.
+func WriteDir(dir string, entries []Entry, recipients openpgp.EntityList) error {
+	if len(recipients) == 0 {
+		return ErrNoRecipients
+	}
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return err
+	}
+	var ids strings.Builder
+	for _, e := range recipients {
+		fmt.Fprintf(&ids, "%X\n", e.PrimaryKey.Fingerprint)
+	}
+	if err := os.WriteFile(filepath.Join(dir, IDFile), []byte(ids.String()), 0o600); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, filepath.FromSlash(entry.Name)+Extension)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid entry name %s", entry.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := Encrypt(&buf, entry.Data, recipients); err != nil {
+			return fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadKeys reads OpenPGP keys, armored or binary, as exported by gpg --export or
+// gpg --export-secret-keys.
+func ReadKeys(data []byte) (openpgp.EntityList, error) {
+	if block, err := armor.Decode(bytes.NewReader(data)); err == nil {
+		data, err = io.ReadAll(block.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	keys, err := openpgp.ReadKeyRing(bytes.NewReader(data))
+	var unsupported pgperrors.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return nil, fmt.Errorf("unsupported OpenPGP key: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OpenPGP keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Unlock decrypts the secret keys protected with a passphrase. passphrase is only called
+// if there are any.
+func Unlock(keys openpgp.EntityList, passphrase func() (string, error)) error {
+	var locked []*packet.PrivateKey
+	for _, e := range keys {
+		if e.PrivateKey != nil && e.PrivateKey.Encrypted {
+			locked = append(locked, e.PrivateKey)
+		}
+		for _, sub := range e.Subkeys {
+			if sub.PrivateKey != nil && sub.PrivateKey.Encrypted {
+				locked = append(locked, sub.PrivateKey)
+			}
+		}
+	}
+	if len(locked) == 0 {
+		return nil
+	}
+	pw, err := passphrase()
+	if err != nil {
+		return err
+	}
+	for _, k := range locked {
+		if err := k.Decrypt([]byte(pw)); err != nil {
+			return ErrWrongPassword
+		}
+	}
+	return nil
+}
+
+// Decrypt decrypts an entry with the secret keys, which must be unlocked.
+func Decrypt(data []byte, keys openpgp.EntityList) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if block, err := armor.Decode(bytes.NewReader(data)); err == nil {
+		r = block.Body
+	}
+	prompt := func([]openpgp.Key, bool) ([]byte, error) { return nil, ErrNoKey }
+	md, err := openpgp.ReadMessage(r, keys, prompt, nil)
+	if errors.Is(err, ErrNoKey) || errors.Is(err, pgperrors.ErrKeyIncorrect) {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(md.UnverifiedBody)
+}
+
+// Encrypt encrypts an entry to the recipients.
+func Encrypt(w io.Writer, data []byte, recipients openpgp.EntityList) error {
+	pw, err := openpgp.Encrypt(w, recipients, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := pw.Write(data); err != nil {
+		return err
+	}
+	return pw.Close()
+}
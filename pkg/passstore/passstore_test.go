@@ -0,0 +1,132 @@
+package passstore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T, email string) *openpgp.Entity {
+	e, err := openpgp.NewEntity("Test", "", email, &packet.Config{RSABits: 1024})
+	require.NoError(t, err)
+	// Keys made by GnuPG state the algorithms they support, NewEntity does not
+	for _, id := range e.Identities {
+		id.SelfSignature.PreferredHash = []uint8{8}      // SHA-256
+		id.SelfSignature.PreferredSymmetric = []uint8{9} // AES-256
+	}
+	return e
+}
+
+func TestParse(t *testing.T) {
+	c := Parse([]byte("s3cret\nlogin: alice\nurl: https://example.com\notpauth://totp/x?secret=JBSWY3DPEHPK3PXP\n\nfirst line\nsecond: line\n"))
+	assert.Equal(t, "s3cret", c.Password)
+	assert.Equal(t, []Field{
+		{Key: "login", Value: "alice"},
+		{Key: "url", Value: "https://example.com"},
+		{Key: OTPKey, Value: "otpauth://totp/x?secret=JBSWY3DPEHPK3PXP"},
+	}, c.Fields)
+	assert.Equal(t, "first line\nsecond: line", c.Notes)
+	assert.Equal(t, "alice", c.Get("Login"))
+
+	c = Parse([]byte("pw\nhttps://example.com\nmore"))
+	assert.Empty(t, c.Fields, "an address is not a field")
+	assert.Equal(t, "https://example.com\nmore", c.Notes)
+
+	c = Parse([]byte("pw"))
+	assert.Equal(t, Content{Password: "pw"}, c)
+}
+
+func TestContent_Bytes(t *testing.T) {
+	c := Content{
+		Password: "pw",
+		Fields:   []Field{{Key: "login", Value: "alice"}, {Key: OTPKey, Value: "otpauth://totp/x?secret=JBSWY3DPEHPK3PXP"}},
+		Notes:    "some: notes\nmore",
+	}
+	assert.Equal(t, "pw\nlogin: alice\notpauth://totp/x?secret=JBSWY3DPEHPK3PXP\n\nsome: notes\nmore\n", string(c.Bytes()))
+	assert.Equal(t, c, Parse(c.Bytes()))
+}
+
+func TestWriteDir_ReadDir(t *testing.T) {
+	key := newKey(t, "alice@example.com")
+	other := newKey(t, "bob@example.com")
+	dir := filepath.Join(t.TempDir(), "store")
+
+	entries := []Entry{{Name: "Work/mail", Data: []byte("pw\n")}, {Name: "bank", Data: []byte("pw2\n")}}
+	require.NoError(t, WriteDir(dir, entries, openpgp.EntityList{key}))
+	assert.True(t, Is(dir))
+	ids, err := os.ReadFile(filepath.Join(dir, IDFile))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%X\n", key.PrimaryKey.Fingerprint), string(ids))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "x.gpg"), []byte("x"), 0o600))
+	read, err := ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, read, 2)
+	assert.Equal(t, "Work/mail", read[0].Name)
+
+	data, err := Decrypt(read[0].Data, openpgp.EntityList{key})
+	require.NoError(t, err)
+	assert.Equal(t, "pw\n", string(data))
+	_, err = Decrypt(read[0].Data, openpgp.EntityList{other})
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	assert.ErrorIs(t, WriteDir(filepath.Join(t.TempDir(), "empty"), entries, nil), ErrNoRecipients)
+}
+
+func TestReadKeys(t *testing.T) {
+	key := newKey(t, "alice@example.com")
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, key.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+
+	keys, err := ReadKeys(buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, key.PrimaryKey.Fingerprint, keys[0].PrimaryKey.Fingerprint)
+	require.NoError(t, Unlock(keys, func() (string, error) {
+		t.Error("no passphrase is needed for an unprotected key")
+		return "", nil
+	}))
+
+	_, err = ReadKeys([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+// The fixtures were made with GnuPG defaults: an ed25519 primary key with a cv25519
+// encryption subkey, protected by the passphrase "test", and an entry encrypted to it.
+func TestReadKeys_Curve25519(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "ed25519.asc"))
+	require.NoError(t, err)
+	keys, err := ReadKeys(data)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	assert.ErrorIs(t, Unlock(keys, func() (string, error) { return "wrong", nil }), ErrWrongPassword)
+	keys, err = ReadKeys(data)
+	require.NoError(t, err)
+	require.NoError(t, Unlock(keys, func() (string, error) { return "test", nil }))
+
+	entry, err := os.ReadFile(filepath.Join("testdata", "ed25519.gpg"))
+	require.NoError(t, err)
+	plain, err := Decrypt(entry, keys)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret\nlogin: alice\n", string(plain))
+
+	// Entries are encrypted to the cv25519 subkey as well
+	var buf bytes.Buffer
+	require.NoError(t, Encrypt(&buf, []byte("pw\n"), keys))
+	plain, err = Decrypt(buf.Bytes(), keys)
+	require.NoError(t, err)
+	assert.Equal(t, "pw\n", string(plain))
+}
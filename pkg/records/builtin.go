@@ -0,0 +1,114 @@
+package records
+
+import (
+	"errors"
+	"regexp"
+)
+
+// Tables of the built-in record types.
+const (
+	CredentialsTable = "UserCredentials"
+	TextTable        = "TextData"
+	FilesTable       = "FilesData"
+	CardsTable       = "CreditCardData"
+)
+
+var (
+	digitsOnly = regexp.MustCompile(`^\d+$`)
+	dateFormat = regexp.MustCompile(`^\d{2}/\d{2}$`)
+)
+
+// Digits accepts values consisting of digits only.
+func Digits(value string) error {
+	if !digitsOnly.MatchString(value) {
+		return errors.New("can only contain digits")
+	}
+	return nil
+}
+
+// MonthYear accepts dates in the MM/YY format with a month from 01 to 12.
+func MonthYear(value string) error {
+	if !dateFormat.MatchString(value) {
+		return errors.New("must be in the format MM/YY")
+	}
+	if month := value[:2]; month < "01" || month > "12" {
+		return errors.New("must have a month from 01 to 12")
+	}
+	return nil
+}
+
+// titleField is the title (meta-information) field shared by all built-in types.
+var titleField = Field{
+	Name:   TitleField,
+	Label:  "title",
+	Prompt: "Choose a title (meta-information)",
+}
+
+func init() {
+	Register(&Type{
+		Name:  "Login/Password",
+		Key:   "login",
+		Table: CredentialsTable,
+		Fields: []Field{
+			titleField,
+			{Name: "login", Label: "login", Required: true},
+			{Name: "password", Label: "password", Sensitive: true, Required: true},
+			{Name: "totp_id", Label: "TOTP authenticator", Ref: TOTPTable},
+			passwordChangedField,
+			expiresField,
+			folderField,
+			customFieldsField,
+			attachmentsField,
+		},
+	})
+
+	Register(&Type{
+		Name:  "Text data",
+		Key:   "text",
+		Table: TextTable,
+		Fields: []Field{
+			titleField,
+			{Name: "data", Label: "text data", Required: true},
+			expiresField,
+			folderField,
+			customFieldsField,
+			attachmentsField,
+		},
+	})
+
+	Register(&Type{
+		Name:   "Binary data",
+		Key:    "file",
+		Table:  FilesTable,
+		Binary: true,
+		Fields: []Field{
+			titleField,
+			{Name: "path", Label: "file hash", Internal: true, Required: true},
+			{Name: "extension", Label: "extension", Internal: true},
+			expiresField,
+			folderField,
+			customFieldsField,
+			attachmentsField,
+		},
+	})
+
+	Register(&Type{
+		Name:  "Bank card data",
+		Key:   "card",
+		Table: CardsTable,
+		Fields: []Field{
+			titleField,
+			{Name: "card_number", Label: "card number", Sensitive: true, Required: true, Validate: Digits},
+			{Name: "expiration_date", Label: "expiry date", Prompt: "Enter expiry date (MM/YY)", Required: true, Validate: MonthYear},
+			{Name: "cvv", Label: "CVV", Sensitive: true, Required: true, Validate: Digits},
+			derivedExpiresField,
+			folderField,
+			customFieldsField,
+			attachmentsField,
+		},
+		DeriveExpiry: CardExpiry,
+	})
+
+	Register(totpType)
+	Register(sshKeyType)
+}
@@ -0,0 +1,140 @@
+package records
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Names of the fields holding the custom fields and attachments of a record.
+// Both are stored as JSON in a single encrypted value.
+const (
+	CustomFieldsField = "custom_fields"
+	AttachmentsField  = "attachments"
+)
+
+// Types of custom fields.
+const (
+	CustomText   = "text"
+	CustomHidden = "hidden" // CustomHidden values are masked on input and never searched.
+	CustomURL    = "url"
+	CustomDate   = "date" // CustomDate values are in the format YYYY-MM-DD.
+)
+
+// CustomTypes lists the types of custom fields in menu order.
+var CustomTypes = []string{CustomText, CustomHidden, CustomURL, CustomDate}
+
+// CustomField is a named and typed value added to a record by the user.
+type CustomField struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// customFieldsField and attachmentsField are shared by all built-in types. They are
+// edited by dedicated flows rather than prompted for like the other fields.
+var (
+	customFieldsField = Field{
+		Name:     CustomFieldsField,
+		Label:    "custom fields",
+		Internal: true,
+		Validate: customFields,
+	}
+	attachmentsField = Field{
+		Name:     AttachmentsField,
+		Label:    "attachments",
+		Internal: true,
+		Validate: attachments,
+	}
+)
+
+// Check validates the name, type and value of a custom field.
+func (f CustomField) Check() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("name must not be empty")
+	}
+	switch f.Type {
+	case CustomText, CustomHidden:
+		return nil
+	case CustomURL:
+		u, err := url.Parse(f.Value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s must be an absolute URL such as https://example.com", f.Name)
+		}
+		return nil
+	case CustomDate:
+		if _, err := time.Parse(time.DateOnly, f.Value); err != nil {
+			return fmt.Errorf("%s must be a date in the format YYYY-MM-DD", f.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s has an unknown type %q", f.Name, f.Type)
+	}
+}
+
+// ParseCustomFields decodes the custom fields of a record. An empty value has none.
+func ParseCustomFields(value string) ([]CustomField, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var fields []CustomField
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return nil, fmt.Errorf("invalid custom fields: %w", err)
+	}
+	return fields, nil
+}
+
+// FormatCustomFields encodes custom fields for storage. No fields are stored as an empty value.
+func FormatCustomFields(fields []CustomField) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(fields)
+	return string(b)
+}
+
+// ParseAttachments decodes the ids of the FilesData entries attached to a record.
+func ParseAttachments(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(value), &ids); err != nil {
+		return nil, fmt.Errorf("invalid attachments: %w", err)
+	}
+	return ids, nil
+}
+
+// FormatAttachments encodes attachment ids for storage. No attachments are stored as an empty value.
+func FormatAttachments(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
+
+// customFields accepts a JSON list of valid custom fields.
+func customFields(value string) error {
+	fields, err := ParseCustomFields(value)
+	if err != nil {
+		return errors.New("must be a list of fields")
+	}
+	for _, f := range fields {
+		if err := f.Check(); err != nil {
+			return fmt.Errorf("are invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// attachments accepts a JSON list of entry ids.
+func attachments(value string) error {
+	if _, err := ParseAttachments(value); err != nil {
+		return errors.New("must be a list of entry ids")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Names of the fields tracking the expiry of records and the age of passwords.
+const (
+	ExpiresField         = "expires_at"          // ExpiresField holds the last day a record is valid, as YYYY-MM-DD.
+	PasswordChangedField = "password_changed_at" // PasswordChangedField holds when the password was last changed, in RFC 3339 format.
+)
+
+var (
+	// expiresField is the expiry date entered by the user, shared by the built-in types.
+	expiresField = Field{
+		Name:     ExpiresField,
+		Label:    "expires on",
+		Prompt:   "Enter the expiry date (YYYY-MM-DD), if any",
+		Validate: Date,
+	}
+	// derivedExpiresField is the expiry date of types deriving it from other fields.
+	derivedExpiresField = Field{
+		Name:     ExpiresField,
+		Label:    "expires on",
+		Internal: true,
+		Validate: Date,
+	}
+	passwordChangedField = Field{
+		Name:     PasswordChangedField,
+		Label:    "password changed",
+		Internal: true,
+	}
+)
+
+// Date accepts dates in the YYYY-MM-DD format.
+func Date(value string) error {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return errors.New("must be a date in the format YYYY-MM-DD")
+	}
+	return nil
+}
+
+// CardExpiry returns the last day a card with the given MM/YY expiry date is valid,
+// as YYYY-MM-DD, or an empty string if the date is invalid.
+func CardExpiry(data map[string]string) string {
+	var month, year int
+	value := data["expiration_date"]
+	if MonthYear(value) != nil {
+		return ""
+	}
+	if _, err := fmt.Sscanf(value, "%d/%d", &month, &year); err != nil {
+		return ""
+	}
+	// Day 0 of the next month is the last day of the month
+	return time.Date(2000+year, time.Month(month+1), 0, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+}
+
+// ExpiresAt returns when a record expires: at the end of its expiry date, in the given
+// location. The date is derived from the other fields if it is not stored and the type
+// derives it. It returns false if the record does not expire.
+func (t *Type) ExpiresAt(data map[string]string, loc *time.Location) (time.Time, bool) {
+	value := data[ExpiresField]
+	if value == "" && t.DeriveExpiry != nil {
+		value = t.DeriveExpiry(data)
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day.AddDate(0, 0, 1), true
+}
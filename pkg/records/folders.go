@@ -0,0 +1,25 @@
+package records
+
+// Tables organizing the records of every type. They are synchronized like record
+// tables, but are not record types themselves: their meta_info holds the name.
+const (
+	FoldersTable   = "Folders"
+	TagsTable      = "Tags"
+	EntryTagsTable = "EntryTags" // EntryTagsTable links a tag to an entry of any type.
+)
+
+// FolderField is the name of the field linking a record to its folder.
+const FolderField = "folder_id"
+
+// folderField is the folder field shared by all built-in types.
+var folderField = Field{
+	Name:  FolderField,
+	Label: "folder",
+	Ref:   FoldersTable,
+}
+
+// SyncTables returns the tables synchronized with the server: the tables of all
+// registered record types followed by the folders, tags and tag links.
+func SyncTables() []string {
+	return append(Tables(), FoldersTable, TagsTable, EntryTagsTable)
+}
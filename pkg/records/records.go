@@ -0,0 +1,255 @@
+// Package records provides the registry of record types stored in GophKeeper.
+//
+// Every record type declares its table, its fields, which of them are sensitive,
+// how they are validated and prompted for, and how a record is summarized.
+// The client menus, the add/edit/get flows and synchronization are all driven
+// by this registry, so adding a record type only requires registering it here
+// and adding a migration for its table.
+package records
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TitleField is the name of the field holding the title (meta-information) of a record.
+const TitleField = "meta_info"
+
+// Field describes a single field of a record type.
+type Field struct {
+	Name      string                   // Name is the column name of the field.
+	Label     string                   // Label is the human readable name of the field.
+	Prompt    string                   // Prompt is shown when the field is entered; derived from Label if empty.
+	Sensitive bool                     // Sensitive fields are masked on input and never shown as defaults.
+	Required  bool                     // Required fields must not be empty.
+	Internal  bool                     // Internal fields are set by the application, never prompted for.
+	Default   string                   // Default is used when no value is entered for a new record.
+	Ref       string                   // Ref is the table of the record whose id the field holds, if any.
+	Validate  func(value string) error // Validate checks a non-empty value; nil accepts anything.
+}
+
+// Type describes a record type stored in the vault.
+type Type struct {
+	Name    string                         // Name is the human readable name shown in menus.
+	Key     string                         // Key is the short name of the type on the command line, such as "login".
+	Table   string                         // Table is the database table holding the records.
+	Binary  bool                           // Binary types keep their payload in an encrypted file.
+	Fields  []Field                        // Fields lists the fields in display order.
+	Display func(map[string]string) string // Display summarizes a record; nil lists title and non-sensitive fields.
+
+	// ImportPrompt and Import allow creating a record from a single line of text,
+	// such as a URI. Import is nil for types that do not support it.
+	ImportPrompt string
+	Import       func(line string) (map[string]string, error)
+
+	// Code returns a short-lived code derived from a record and how long it stays valid.
+	// It is nil for types that do not generate codes.
+	Code func(data map[string]string, now time.Time) (string, time.Duration, error)
+
+	// DeriveExpiry returns the expiry date of a record derived from its other fields,
+	// or an empty string if they do not give one. It is nil for types whose expiry
+	// date is entered by the user.
+	DeriveExpiry func(data map[string]string) string
+}
+
+// registry holds the registered record types in menu order.
+var registry = struct {
+	sync.RWMutex
+	types []*Type
+}{}
+
+// Register adds a record type to the registry. It panics if the table is already registered,
+// as this is a programming error.
+func Register(t *Type) {
+	registry.Lock()
+	defer registry.Unlock()
+	for _, existing := range registry.types {
+		if existing.Table == t.Table {
+			panic(fmt.Sprintf("records: table %s registered twice", t.Table))
+		}
+	}
+	registry.types = append(registry.types, t)
+}
+
+// All returns all registered record types in menu order.
+func All() []*Type {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]*Type(nil), registry.types...)
+}
+
+// Tables returns the tables of all registered record types in menu order.
+func Tables() []string {
+	types := All()
+	tables := make([]string, 0, len(types))
+	for _, t := range types {
+		tables = append(tables, t.Table)
+	}
+	return tables
+}
+
+// ByTable returns the record type stored in the given table.
+func ByTable(table string) (*Type, bool) {
+	for _, t := range All() {
+		if t.Table == table {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// ByKey returns the record type with the given short name, also accepted in the plural
+// ("logins") and in any case.
+func ByKey(key string) (*Type, bool) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, t := range All() {
+		if t.Key != "" && (key == t.Key || key == t.Key+"s") {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// TypeKeys returns the short names of all registered record types in menu order.
+func TypeKeys() []string {
+	types := All()
+	keys := make([]string, 0, len(types))
+	for _, t := range types {
+		if t.Key != "" {
+			keys = append(keys, t.Key)
+		}
+	}
+	return keys
+}
+
+// ByChoice returns the record type for a 1-based menu choice.
+func ByChoice(choice string) (*Type, bool) {
+	types := All()
+	var n int
+	if _, err := fmt.Sscan(strings.TrimSpace(choice), &n); err != nil || n < 1 || n > len(types) {
+		return nil, false
+	}
+	return types[n-1], true
+}
+
+// Field returns the field with the given name.
+func (t *Type) Field(name string) (Field, bool) {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// InputFields returns the fields the user is prompted for.
+func (t *Type) InputFields() []Field {
+	fields := make([]Field, 0, len(t.Fields))
+	for _, f := range t.Fields {
+		if !f.Internal {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Validate checks all fields of a record.
+func (t *Type) Validate(data map[string]string) error {
+	var errs []error
+	for _, f := range t.Fields {
+		if err := f.Check(data[f.Name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Summary returns a one-line description of a record. Empty optional fields are left out.
+func (t *Type) Summary(data map[string]string) string {
+	if t.Display != nil {
+		return t.Display(data)
+	}
+	parts := make([]string, 0, len(t.Fields))
+	for _, f := range t.Fields {
+		if f.Sensitive || f.Internal || f.Ref != "" || (!f.Required && data[f.Name] == "") {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", capitalize(f.Label), data[f.Name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Keys returns the keys of a record in display order: registered fields first,
+// then any other keys sorted by name.
+func (t *Type) Keys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	known := make(map[string]bool, len(t.Fields))
+	for _, f := range t.Fields {
+		known[f.Name] = true
+		if _, ok := data[f.Name]; ok {
+			keys = append(keys, f.Name)
+		}
+	}
+	var rest []string
+	for k := range data {
+		if !known[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// Check validates a single value of the field.
+func (f Field) Check(value string) error {
+	if value == "" {
+		if f.Required {
+			return fmt.Errorf("%s must not be empty", capitalize(f.Label))
+		}
+		return nil
+	}
+	if f.Validate != nil {
+		if err := f.Validate(value); err != nil {
+			return fmt.Errorf("%s %w", capitalize(f.Label), err)
+		}
+	}
+	return nil
+}
+
+// AddPrompt returns the prompt shown when a new value is entered.
+func (f Field) AddPrompt() string {
+	label := f.Prompt
+	if label == "" {
+		label = "Enter " + f.Label
+	}
+	if f.Default != "" {
+		return fmt.Sprintf("%s [%s]: ", label, f.Default)
+	}
+	return label + ": "
+}
+
+// EditPrompt returns the prompt shown when a value is edited. Sensitive values are never shown.
+func (f Field) EditPrompt(old string) string {
+	label := f.Prompt
+	if label == "" {
+		label = "Enter " + f.Label
+	}
+	label = strings.Replace(label, "Enter ", "Enter new ", 1)
+	label = strings.Replace(label, "Choose a ", "Choose a new ", 1)
+	if f.Sensitive {
+		return label + ": "
+	}
+	return fmt.Sprintf("%s [%s]: ", label, old)
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
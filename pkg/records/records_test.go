@@ -0,0 +1,210 @@
+package records
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wurt83ow/gophkeeper-client/pkg/sshkey"
+)
+
+func TestBuiltinTypes(t *testing.T) {
+	assert.Equal(t, []string{CredentialsTable, TextTable, FilesTable, CardsTable}, Tables()[:4])
+
+	typ, ok := ByChoice("4")
+	assert.True(t, ok)
+	assert.Equal(t, CardsTable, typ.Table)
+
+	_, ok = ByChoice("0")
+	assert.False(t, ok)
+	_, ok = ByChoice("abc")
+	assert.False(t, ok)
+
+	typ, ok = ByTable(FilesTable)
+	assert.True(t, ok)
+	assert.True(t, typ.Binary)
+	assert.Len(t, typ.InputFields(), 3)
+}
+
+func TestByKey(t *testing.T) {
+	assert.Equal(t, []string{"login", "text", "file", "card", "totp", "ssh"}, TypeKeys())
+
+	typ, ok := ByKey("Logins")
+	assert.True(t, ok)
+	assert.Equal(t, CredentialsTable, typ.Table)
+	typ, ok = ByKey("card")
+	assert.True(t, ok)
+	assert.Equal(t, CardsTable, typ.Table)
+	_, ok = ByKey("note")
+	assert.False(t, ok)
+}
+
+func TestRegister_DuplicateTablePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		Register(&Type{Name: "Duplicate", Table: CredentialsTable})
+	})
+}
+
+func TestType_Validate(t *testing.T) {
+	cards, _ := ByTable(CardsTable)
+
+	err := cards.Validate(map[string]string{
+		"card_number":     "4111111111111111",
+		"expiration_date": "12/30",
+		"cvv":             "123",
+	})
+	assert.NoError(t, err)
+
+	err = cards.Validate(map[string]string{
+		"card_number":     "4111-1111",
+		"expiration_date": "2030-12",
+		"cvv":             "",
+	})
+	assert.ErrorContains(t, err, "Card number can only contain digits")
+	assert.ErrorContains(t, err, "Expiry date must be in the format MM/YY")
+	assert.ErrorContains(t, err, "CVV must not be empty")
+
+	for _, date := range []string{"13/99", "00/25"} {
+		err = cards.Validate(map[string]string{"card_number": "4111111111111111", "expiration_date": date, "cvv": "123"})
+		assert.ErrorContains(t, err, "Expiry date must have a month from 01 to 12", date)
+	}
+}
+
+func TestType_SummaryHidesSensitiveFields(t *testing.T) {
+	creds, _ := ByTable(CredentialsTable)
+	summary := creds.Summary(map[string]string{"meta_info": "mail", "login": "bob", "password": "secret"})
+	assert.Equal(t, "Title: mail, Login: bob", summary)
+
+	cards, _ := ByTable(CardsTable)
+	summary = cards.Summary(map[string]string{"meta_info": "visa", "card_number": "4111111111111111", "expiration_date": "12/30", "cvv": "123"})
+	assert.NotContains(t, summary, "4111")
+}
+
+func TestType_Keys(t *testing.T) {
+	creds, _ := ByTable(CredentialsTable)
+	keys := creds.Keys(map[string]string{"password": "p", "zeta": "z", "login": "l", "alpha": "a", "meta_info": "m"})
+	assert.Equal(t, []string{"meta_info", "login", "password", "alpha", "zeta"}, keys)
+}
+
+func TestField_Prompts(t *testing.T) {
+	title := Field{Name: TitleField, Label: "title", Prompt: "Choose a title (meta-information)"}
+	assert.Equal(t, "Choose a title (meta-information): ", title.AddPrompt())
+	assert.Equal(t, "Choose a new title (meta-information) [old]: ", title.EditPrompt("old"))
+
+	password := Field{Name: "password", Label: "password", Sensitive: true}
+	assert.Equal(t, "Enter password: ", password.AddPrompt())
+	assert.Equal(t, "Enter new password: ", password.EditPrompt("secret"))
+}
+
+func TestTOTPType(t *testing.T) {
+	typ, ok := ByTable(TOTPTable)
+	assert.True(t, ok)
+
+	data, err := typ.Import("otpauth://totp/ACME:john?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME&digits=8")
+	assert.NoError(t, err)
+	assert.Equal(t, "ACME (john)", data[TitleField])
+	assert.NoError(t, typ.Validate(data))
+
+	code, remaining, err := typ.Code(data, time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+	assert.Equal(t, time.Second, remaining)
+
+	data["digits"] = "9"
+	assert.ErrorContains(t, typ.Validate(data), "Number of digits must be 6, 7 or 8")
+}
+
+func TestField_DefaultPrompt(t *testing.T) {
+	f := Field{Name: "period", Label: "period in seconds", Default: "30"}
+	assert.Equal(t, "Enter period in seconds [30]: ", f.AddPrompt())
+}
+
+func TestSSHKeyType(t *testing.T) {
+	typ, ok := ByTable(SSHKeysTable)
+	assert.True(t, ok)
+	assert.Len(t, typ.InputFields(), 4)
+
+	key, err := sshkey.Generate("deploy")
+	assert.NoError(t, err)
+	data := SSHKeyData(key)
+	data[TitleField] = "deploy key"
+	data["confirm"] = "no"
+	assert.NoError(t, typ.Validate(data))
+	assert.Equal(t, "Title: deploy key, Key: ssh-ed25519 "+key.Fingerprint, typ.Summary(data))
+
+	data["private_key"] = "garbage"
+	data["confirm"] = "maybe"
+	err = typ.Validate(data)
+	assert.ErrorContains(t, err, "Private key must be an unencrypted OpenSSH or PEM private key")
+	assert.ErrorContains(t, err, "Confirmation must be yes or no")
+}
+
+func TestFolderField(t *testing.T) {
+	for _, typ := range All() {
+		f, ok := typ.Field(FolderField)
+		assert.True(t, ok, typ.Name)
+		assert.Equal(t, FoldersTable, f.Ref, typ.Name)
+	}
+	assert.Equal(t, append(Tables(), FoldersTable, TagsTable, EntryTagsTable), SyncTables())
+}
+
+func TestCustomFields(t *testing.T) {
+	fields := []CustomField{
+		{Name: "Security question", Type: CustomText, Value: "Blue"},
+		{Name: "PIN", Type: CustomHidden, Value: "1234"},
+		{Name: "Portal", Type: CustomURL, Value: "https://bank.example.com"},
+		{Name: "Renewal", Type: CustomDate, Value: "2030-01-31"},
+	}
+	value := FormatCustomFields(fields)
+	parsed, err := ParseCustomFields(value)
+	assert.NoError(t, err)
+	assert.Equal(t, fields, parsed)
+	assert.Equal(t, "", FormatCustomFields(nil))
+
+	cards, _ := ByTable(CardsTable)
+	f, ok := cards.Field(CustomFieldsField)
+	assert.True(t, ok)
+	assert.NoError(t, f.Check(value))
+	assert.NotContains(t, cards.Summary(map[string]string{CustomFieldsField: value}), "1234")
+
+	invalid := FormatCustomFields([]CustomField{{Name: "Portal", Type: CustomURL, Value: "bank"}})
+	assert.ErrorContains(t, f.Check(invalid), "Portal must be an absolute URL")
+	invalid = FormatCustomFields([]CustomField{{Name: "Renewal", Type: CustomDate, Value: "31.01.2030"}})
+	assert.ErrorContains(t, f.Check(invalid), "Renewal must be a date in the format YYYY-MM-DD")
+	assert.ErrorContains(t, f.Check("{"), "Custom fields must be a list of fields")
+}
+
+func TestAttachments(t *testing.T) {
+	value := FormatAttachments([]string{"a", "b"})
+	ids, err := ParseAttachments(value)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	ids, err = ParseAttachments("")
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+	_, err = ParseAttachments("a,b")
+	assert.Error(t, err)
+}
+
+func TestExpiry(t *testing.T) {
+	assert.Equal(t, "2027-02-28", CardExpiry(map[string]string{"expiration_date": "02/27"}))
+	assert.Equal(t, "2026-12-31", CardExpiry(map[string]string{"expiration_date": "12/26"}))
+	assert.Equal(t, "", CardExpiry(map[string]string{"expiration_date": "13/26"}))
+	assert.Equal(t, "", CardExpiry(map[string]string{}))
+
+	cards, _ := ByTable(CardsTable)
+	expires, ok := cards.ExpiresAt(map[string]string{"expiration_date": "10/26"}, time.UTC)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), expires)
+
+	// A stored expiry date takes precedence over the derived one
+	expires, ok = cards.ExpiresAt(map[string]string{"expiration_date": "10/26", ExpiresField: "2026-10-15"}, time.UTC)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), expires)
+
+	texts, _ := ByTable(TextTable)
+	_, ok = texts.ExpiresAt(map[string]string{"data": "x"}, time.UTC)
+	assert.False(t, ok)
+	assert.Error(t, texts.Validate(map[string]string{"data": "x", ExpiresField: "31.12.2026"}))
+}
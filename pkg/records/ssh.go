@@ -0,0 +1,63 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/sshkey"
+)
+
+// SSHKeysTable is the table of the SSH key record type.
+const SSHKeysTable = "SSHKeys"
+
+// sshKeyType describes SSH private keys. The private key is entered from a file or
+// generated, so only the title and the confirmation setting are prompted for.
+var sshKeyType = &Type{
+	Name:  "SSH key",
+	Key:   "ssh",
+	Table: SSHKeysTable,
+	Fields: []Field{
+		titleField,
+		{Name: "private_key", Label: "private key", Sensitive: true, Internal: true, Required: true, Validate: privateKey},
+		{Name: "key_type", Label: "key type", Internal: true},
+		{Name: "public_key", Label: "public key", Internal: true},
+		{Name: "fingerprint", Label: "fingerprint", Internal: true},
+		{Name: "comment", Label: "comment", Internal: true},
+		{Name: "confirm", Label: "confirmation", Prompt: "Ask for confirmation before each use of the key (yes/no)", Default: "no", Required: true, Validate: YesNo},
+		expiresField,
+		folderField,
+		customFieldsField,
+		attachmentsField,
+	},
+	Display: func(data map[string]string) string {
+		return fmt.Sprintf("Title: %s, Key: %s %s", data[TitleField], data["key_type"], data["fingerprint"])
+	},
+}
+
+// SSHKeyData returns the fields of an SSH key record describing the given key.
+func SSHKeyData(key *sshkey.Key) map[string]string {
+	return map[string]string{
+		"private_key": string(key.PEM),
+		"key_type":    key.Type,
+		"public_key":  key.PublicKey,
+		"fingerprint": key.Fingerprint,
+		"comment":     key.Comment,
+	}
+}
+
+// YesNo accepts "yes" and "no".
+func YesNo(value string) error {
+	switch strings.ToLower(value) {
+	case "yes", "no":
+		return nil
+	}
+	return errors.New("must be yes or no")
+}
+
+func privateKey(value string) error {
+	if _, err := sshkey.Parse([]byte(value), nil, ""); err != nil {
+		return errors.New("must be an unencrypted OpenSSH or PEM private key")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/totp"
+)
+
+// TOTPTable is the table of the TOTP authenticator record type.
+const TOTPTable = "TOTPData"
+
+// totpType describes TOTP authenticator keys. Credentials link to them through totp_id.
+var totpType = &Type{
+	Name:  "TOTP authenticator",
+	Key:   "totp",
+	Table: TOTPTable,
+	Fields: []Field{
+		titleField,
+		{Name: "secret", Label: "secret key (base32)", Sensitive: true, Required: true, Validate: base32Secret},
+		{Name: "issuer", Label: "issuer"},
+		{Name: "account_name", Label: "account name"},
+		{Name: "algorithm", Label: "algorithm (SHA1, SHA256 or SHA512)", Default: totp.DefaultAlgorithm, Required: true, Validate: algorithm},
+		{Name: "digits", Label: "number of digits", Default: strconv.Itoa(totp.DefaultDigits), Required: true, Validate: codeDigits},
+		{Name: "period", Label: "period in seconds", Default: strconv.Itoa(totp.DefaultPeriod), Required: true, Validate: period},
+		expiresField,
+		folderField,
+		customFieldsField,
+		attachmentsField,
+	},
+	ImportPrompt: "Paste an otpauth:// URI (press Enter to enter the key manually): ",
+	Import:       importTOTP,
+	Code:         totpCode,
+}
+
+// TOTPKey builds a TOTP key from a stored record.
+func TOTPKey(data map[string]string) (*totp.Key, error) {
+	key := totp.NewKey(data["secret"])
+	key.Issuer = data["issuer"]
+	key.Account = data["account_name"]
+	if data["algorithm"] != "" {
+		key.Algorithm = data["algorithm"]
+	}
+	var err error
+	if data["digits"] != "" {
+		if key.Digits, err = strconv.Atoi(data["digits"]); err != nil {
+			return nil, fmt.Errorf("invalid number of digits %q", data["digits"])
+		}
+	}
+	if data["period"] != "" {
+		if key.Period, err = strconv.Atoi(data["period"]); err != nil {
+			return nil, fmt.Errorf("invalid period %q", data["period"])
+		}
+	}
+	return key, nil
+}
+
+// importTOTP creates a TOTP record from an otpauth:// URI.
+func importTOTP(line string) (map[string]string, error) {
+	key, err := totp.ParseURI(line)
+	if err != nil {
+		return nil, err
+	}
+	title := key.Account
+	if key.Issuer != "" {
+		title = key.Issuer
+		if key.Account != "" {
+			title += " (" + key.Account + ")"
+		}
+	}
+	return map[string]string{
+		TitleField:     title,
+		"secret":       key.Secret,
+		"issuer":       key.Issuer,
+		"account_name": key.Account,
+		"algorithm":    key.Algorithm,
+		"digits":       strconv.Itoa(key.Digits),
+		"period":       strconv.Itoa(key.Period),
+	}, nil
+}
+
+// totpCode generates the current code of a TOTP record.
+func totpCode(data map[string]string, now time.Time) (string, time.Duration, error) {
+	key, err := TOTPKey(data)
+	if err != nil {
+		return "", 0, err
+	}
+	return key.Code(now)
+}
+
+func base32Secret(value string) error {
+	if _, err := totp.DecodeSecret(value); err != nil {
+		return errors.New("must be a valid base32 string")
+	}
+	return nil
+}
+
+func algorithm(value string) error {
+	if !totp.ValidAlgorithm(value) {
+		return errors.New("must be SHA1, SHA256 or SHA512")
+	}
+	return nil
+}
+
+func codeDigits(value string) error {
+	if value != "6" && value != "7" && value != "8" {
+		return errors.New("must be 6, 7 or 8")
+	}
+	return nil
+}
+
+func period(value string) error {
+	if n, err := strconv.Atoi(strings.TrimSpace(value)); err != nil || n <= 0 {
+		return errors.New("must be a positive number")
+	}
+	return nil
+}
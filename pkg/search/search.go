@@ -0,0 +1,267 @@
+// Package search implements an in-memory index over decrypted vault entries.
+//
+// Queries are split into terms and every term must match a field of an entry,
+// either as a case-insensitive substring or, for longer terms, as a word with a
+// small number of typos. Secret fields are only matched when asked for.
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Field is a searchable value of an entry.
+type Field struct {
+	Name   string // Name identifies the field in the results, e.g. its label.
+	Value  string // Value is the decrypted value.
+	Secret bool   // Secret fields are only matched with Options.Secrets.
+}
+
+// Document is an entry as stored in the index.
+type Document struct {
+	Table  string
+	ID     string
+	Title  string
+	Fields []Field
+	Tags   []string
+}
+
+// Options control a search.
+type Options struct {
+	Secrets bool // Secrets includes secret fields in matching.
+	Limit   int  // Limit caps the number of results; 0 returns all.
+}
+
+// Result is an entry matching a query.
+type Result struct {
+	Table   string
+	ID      string
+	Title   string
+	Score   int      // Score ranks the results, higher is better.
+	Matched []string // Matched lists the names of the matching fields.
+}
+
+// Names of the title and tags in the results.
+const (
+	TitleName = "title"
+	TagsName  = "tags"
+)
+
+// Scores of the kinds of matches. The title weighs more than other fields.
+const (
+	scoreExact     = 6
+	scoreWordStart = 5
+	scoreSubstring = 4
+	scoreTypo      = 2
+	scorePrefix    = 1
+	titleBonus     = 2
+)
+
+type key struct {
+	table string
+	id    string
+}
+
+// Index is an in-memory search index. It is safe for concurrent use.
+type Index struct {
+	mu   sync.RWMutex
+	docs map[key]*Document
+}
+
+// New returns an empty index.
+func New() *Index {
+	return &Index{docs: make(map[key]*Document)}
+}
+
+// Add adds an entry to the index, replacing the previous version of the entry.
+// The tags of a replaced entry are kept if doc has none.
+func (ix *Index) Add(doc Document) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	k := key{doc.Table, doc.ID}
+	if old, ok := ix.docs[k]; ok && doc.Tags == nil {
+		doc.Tags = old.Tags
+	}
+	ix.docs[k] = &doc
+}
+
+// Remove removes an entry from the index.
+func (ix *Index) Remove(table, id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	delete(ix.docs, key{table, id})
+}
+
+// SetTags replaces the tags of all entries. tags maps a table to the tags of its entries by id.
+func (ix *Index) SetTags(tags map[string]map[string][]string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for k, doc := range ix.docs {
+		doc.Tags = tags[k.table][k.id]
+	}
+}
+
+// Len returns the number of entries in the index.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Search returns the entries matching all terms of the query, best matches first.
+func (ix *Index) Search(query string, opt Options) []Result {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	ix.mu.RLock()
+	var results []Result
+	for _, doc := range ix.docs {
+		if r, ok := match(doc, terms, opt); ok {
+			results = append(results, r)
+		}
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return strings.ToLower(results[i].Title) < strings.ToLower(results[j].Title)
+	})
+	if opt.Limit > 0 && len(results) > opt.Limit {
+		results = results[:opt.Limit]
+	}
+	return results
+}
+
+// match scores a document against the terms. Every term must match a field.
+func match(doc *Document, terms []string, opt Options) (Result, bool) {
+	fields := make([]Field, 0, len(doc.Fields)+2)
+	fields = append(fields, Field{Name: TitleName, Value: doc.Title})
+	fields = append(fields, doc.Fields...)
+	if len(doc.Tags) > 0 {
+		fields = append(fields, Field{Name: TagsName, Value: strings.Join(doc.Tags, " ")})
+	}
+
+	r := Result{Table: doc.Table, ID: doc.ID, Title: doc.Title}
+	matched := make(map[string]bool)
+	for _, term := range terms {
+		best, bestField := 0, ""
+		for _, f := range fields {
+			if f.Secret && !opt.Secrets {
+				continue
+			}
+			score := scoreValue(term, strings.ToLower(f.Value))
+			if score > 0 && f.Name == TitleName {
+				score += titleBonus
+			}
+			if score > best {
+				best, bestField = score, f.Name
+			}
+		}
+		if best == 0 {
+			return Result{}, false
+		}
+		r.Score += best
+		if !matched[bestField] {
+			matched[bestField] = true
+			r.Matched = append(r.Matched, bestField)
+		}
+	}
+	return r, true
+}
+
+// scoreValue scores how well a lower-case term matches a lower-case value; 0 means no match.
+func scoreValue(term, value string) int {
+	if value == "" {
+		return 0
+	}
+	if value == term {
+		return scoreExact
+	}
+	if i := strings.Index(value, term); i >= 0 {
+		for ; i >= 0; i = nextIndex(value, term, i) {
+			if i == 0 || !isWordRune(lastRune(value[:i])) {
+				return scoreWordStart
+			}
+		}
+		return scoreSubstring
+	}
+
+	typos := maxTypos(term)
+	if typos == 0 {
+		return 0
+	}
+	score := 0
+	for _, word := range strings.FieldsFunc(value, func(r rune) bool { return !isWordRune(r) }) {
+		if distance(term, word) <= typos {
+			return scoreTypo
+		}
+		// Words being typed are matched by their beginning
+		if w := []rune(word); len(w) > len([]rune(term)) && distance(term, string(w[:len([]rune(term))])) <= typos {
+			score = scorePrefix
+		}
+	}
+	return score
+}
+
+// nextIndex returns the next occurrence of term in value after position i, or -1.
+func nextIndex(value, term string, i int) int {
+	j := strings.Index(value[i+1:], term)
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+// maxTypos returns the number of typos tolerated in a term.
+func maxTypos(term string) int {
+	n := len([]rune(term))
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance returns the Damerau-Levenshtein (optimal string alignment) distance of two strings.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	return r[len(r)-1]
+}
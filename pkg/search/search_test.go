@@ -0,0 +1,99 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testIndex() *Index {
+	ix := New()
+	ix.Add(Document{Table: "UserCredentials", ID: "1", Title: "Mail", Fields: []Field{
+		{Name: "login", Value: "bob@example.com"},
+		{Name: "password", Value: "hunter2-secret", Secret: true},
+	}})
+	ix.Add(Document{Table: "UserCredentials", ID: "2", Title: "https://github.com/login", Fields: []Field{
+		{Name: "login", Value: "octocat"},
+	}})
+	ix.Add(Document{Table: "TextData", ID: "3", Title: "Notes", Fields: []Field{
+		{Name: "text data", Value: "The wifi password of the summer house"},
+	}})
+	return ix
+}
+
+func ids(results []Result) []string {
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestSearch_Substring(t *testing.T) {
+	ix := testIndex()
+	assert.Equal(t, []string{"2"}, ids(ix.Search("GitHub", Options{})))
+	assert.Equal(t, []string{"1"}, ids(ix.Search("example.com", Options{})))
+
+	results := ix.Search("summer wifi", Options{})
+	require.Len(t, results, 1)
+	assert.Equal(t, "3", results[0].ID)
+	assert.Equal(t, []string{"text data"}, results[0].Matched)
+
+	// Every term must match
+	assert.Empty(t, ix.Search("summer github", Options{}))
+	assert.Empty(t, ix.Search("   ", Options{}))
+}
+
+func TestSearch_Ranking(t *testing.T) {
+	ix := testIndex()
+	// The exact title beats a match inside another title
+	ix.Add(Document{Table: "TextData", ID: "4", Title: "Login"})
+	assert.Equal(t, []string{"4", "2"}, ids(ix.Search("login", Options{})))
+	assert.Equal(t, []string{"4"}, ids(ix.Search("login", Options{Limit: 1})))
+}
+
+func TestSearch_Typos(t *testing.T) {
+	ix := testIndex()
+	assert.Equal(t, []string{"2"}, ids(ix.Search("githbu", Options{})))
+	assert.Equal(t, []string{"2"}, ids(ix.Search("octcat", Options{})))
+	assert.Equal(t, []string{"3"}, ids(ix.Search("sumer", Options{})))
+	// Words being typed match their beginning
+	assert.Equal(t, []string{"2"}, ids(ix.Search("octoc", Options{})))
+	// Short terms must match exactly
+	assert.Empty(t, ix.Search("nxt", Options{}))
+}
+
+func TestSearch_Secrets(t *testing.T) {
+	ix := testIndex()
+	assert.Empty(t, ix.Search("hunter2", Options{}))
+
+	results := ix.Search("hunter2", Options{Secrets: true})
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{"password"}, results[0].Matched)
+}
+
+func TestIndex_Updates(t *testing.T) {
+	ix := testIndex()
+	ix.SetTags(map[string]map[string][]string{"TextData": {"3": {"home", "network"}}})
+	results := ix.Search("home", Options{})
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{TagsName}, results[0].Matched)
+
+	// Replacing an entry keeps its tags
+	ix.Add(Document{Table: "TextData", ID: "3", Title: "Router"})
+	assert.Equal(t, []string{"3"}, ids(ix.Search("network router", Options{})))
+	assert.Empty(t, ix.Search("summer", Options{}))
+
+	ix.Remove("TextData", "3")
+	assert.Empty(t, ix.Search("router", Options{}))
+	assert.Equal(t, 2, ix.Len())
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("abc", "abc"))
+	assert.Equal(t, 1, distance("abc", "acb"))
+	assert.Equal(t, 1, distance("kitten", "kittn"))
+	assert.Equal(t, 3, distance("kitten", "sitting"))
+	assert.Equal(t, 1, distance("ключ", "клюк"))
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/backup"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// Layout of a backup, which is also the layout of a restored profile: the database
+// and, in a directory next to it, the encrypted files of the file entries.
+const (
+	BackupDatabase = "data.db"
+	BackupFilesDir = "files"
+)
+
+// ErrProfileNotEmpty is returned when a backup is restored into a directory that is not empty.
+var ErrProfileNotEmpty = errors.New("the profile directory is not empty")
+
+// CreateBackup writes an encrypted backup of the whole vault to path: a consistent
+// copy of the database and the encrypted files of all file entries. Files that are
+// missing locally are listed in the manifest of the backup.
+func (s *Service) CreateBackup(ctx context.Context, path string, passphrase string) (*backup.Manifest, error) {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s already exists", path)
+	}
+	tmp, err := os.MkdirTemp("", "gophkeeper-backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	db := filepath.Join(tmp, BackupDatabase)
+	if err := s.keeper.Snapshot(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to copy the database: %w", err)
+	}
+	entries := []backup.Entry{{Name: BackupDatabase, Path: db}}
+
+	blobs, err := s.fileBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, blob := range blobs {
+		name := BackupFilesDir + "/" + blob
+		blobPath := filepath.Join(s.opt.FileStoragePath, blob)
+		if _, err := os.Stat(blobPath); errors.Is(err, os.ErrNotExist) {
+			missing = append(missing, name)
+			continue
+		}
+		entries = append(entries, backup.Entry{Name: name, Path: blobPath})
+	}
+
+	// Write next to the target and rename, so that an interrupted backup leaves no file behind.
+	out, err := os.CreateTemp(filepath.Dir(path), ".gophkeeper-backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(out.Name())
+	manifest, err := backup.Create(out, passphrase, entries, missing, time.Now())
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// VerifyBackup decrypts a backup into a temporary directory, checks all files against
+// the manifest and checks the integrity of the database.
+func (s *Service) VerifyBackup(ctx context.Context, path string, passphrase string) (*backup.Manifest, error) {
+	tmp, err := os.MkdirTemp("", "gophkeeper-verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	return extractBackup(ctx, path, passphrase, tmp)
+}
+
+// RestoreBackup restores a backup into dir, which must not exist or be empty. The
+// backup is verified before anything is written to dir. The database is migrated
+// to the current schema.
+func (s *Service) RestoreBackup(ctx context.Context, path string, passphrase string, dir string) (*backup.Manifest, error) {
+	entries, err := os.ReadDir(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	case len(entries) > 0:
+		return nil, ErrProfileNotEmpty
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".gophkeeper-restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	manifest, err := extractBackup(ctx, path, passphrase, tmp)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(tmp, BackupFilesDir), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// extractBackup extracts a backup into dir and checks the integrity of its database.
+func extractBackup(ctx context.Context, path string, passphrase string, dir string) (*backup.Manifest, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	manifest, err := backup.Extract(in, passphrase, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keeper, err := bdkeeper.Open(filepath.Join(dir, BackupDatabase))
+	if err != nil {
+		return nil, fmt.Errorf("the database of the backup cannot be opened: %w", err)
+	}
+	defer keeper.Close()
+	problems, err := keeper.IntegrityCheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("the database of the backup is damaged: %s", problems[0])
+	}
+	return manifest, nil
+}
+
+// ScheduledBackup writes a backup to the backup directory with the backup passphrase
+// of the configuration and deletes the oldest backups beyond the configured number.
+// It returns the path of the backup.
+func (s *Service) ScheduledBackup(ctx context.Context, now time.Time) (string, error) {
+	if s.opt.BackupPassphrase == "" {
+		return "", errors.New("BACKUP_PASSPHRASE is not set")
+	}
+	if err := os.MkdirAll(s.opt.BackupDir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.opt.BackupDir, backup.FileName(now))
+	if _, err := s.CreateBackup(ctx, path, s.opt.BackupPassphrase); err != nil {
+		return "", err
+	}
+	if s.opt.BackupKeep > 0 {
+		if _, err := backup.Rotate(s.opt.BackupDir, s.opt.BackupKeep); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// RunScheduledBackups makes a backup every BackupInterval until ctx is done.
+func (s *Service) RunScheduledBackups(ctx context.Context) {
+	ticker := time.NewTicker(s.opt.BackupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			path, err := s.ScheduledBackup(ctx, now)
+			if err != nil {
+				s.logger.Printf("Error making a scheduled backup: %v", err)
+				continue
+			}
+			s.logger.Printf("Scheduled backup written to %s", path)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fileBlobs returns the names of the encrypted files of all file entries, sorted.
+func (s *Service) fileBlobs(ctx context.Context) ([]string, error) {
+	owners, err := s.keeper.OwnerIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, id := range owners {
+		rows, err := s.keeper.GetAllData(ctx, records.FilesTable, id, "path")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			// Paths that cannot be decrypted are reported by Doctor.
+			if blob, err := s.decryptChecked(row["path"]); err == nil && blobName.MatchString(blob) {
+				seen[blob] = true
+			}
+		}
+	}
+	blobs := make([]string, 0, len(seen))
+	for blob := range seen {
+		blobs = append(blobs, blob)
+	}
+	sort.Strings(blobs)
+	return blobs, nil
+}
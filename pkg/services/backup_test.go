@@ -0,0 +1,77 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/backup"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/config"
+	"github.com/wurt83ow/gophkeeper-client/pkg/encription"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+	"github.com/wurt83ow/gophkeeper-client/pkg/syncinfo"
+)
+
+func TestService_Backup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keeper, err := bdkeeper.Open(filepath.Join(dir, "data.db"))
+	require.NoError(t, err)
+	defer keeper.Close()
+	opt := &config.Options{FileStoragePath: filepath.Join(dir, "files"), BackupDir: filepath.Join(dir, "backups"), BackupKeep: 1, BackupPassphrase: "scheduled"}
+	require.NoError(t, os.Mkdir(opt.FileStoragePath, 0o700))
+	s := services.NewServices(keeper, nil, syncinfo.NewSyncManager(filepath.Join(dir, "sync.json")),
+		encription.NewEnc("0123456789abcdef0123456789abcdef"), opt, false, log.New(io.Discard, "", 0))
+
+	blob, gone := strings.Repeat("ab", 32), strings.Repeat("cd", 32)
+	require.NoError(t, os.WriteFile(filepath.Join(opt.FileStoragePath, blob), []byte("encrypted"), 0o600))
+	require.NoError(t, s.AddData(ctx, "FilesData", 1, map[string]string{"path": blob, "meta_info": "kept"}))
+	require.NoError(t, s.AddData(ctx, "FilesData", 1, map[string]string{"path": gone, "meta_info": "lost"}))
+	require.NoError(t, s.AddData(ctx, "TextData", 1, map[string]string{"data": "note"}))
+
+	archive := filepath.Join(dir, "vault.gkbackup")
+	manifest, err := s.CreateBackup(ctx, archive, "secret")
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 2)
+	assert.Equal(t, []string{"files/" + gone}, manifest.Missing)
+	_, err = s.CreateBackup(ctx, archive, "secret")
+	assert.Error(t, err, "an existing backup must not be overwritten")
+
+	_, err = s.VerifyBackup(ctx, archive, "secret")
+	require.NoError(t, err)
+	_, err = s.VerifyBackup(ctx, archive, "wrong")
+	assert.ErrorIs(t, err, backup.ErrDecrypt)
+
+	profile := filepath.Join(dir, "restored")
+	_, err = s.RestoreBackup(ctx, archive, "secret", profile)
+	require.NoError(t, err)
+	_, err = s.RestoreBackup(ctx, archive, "secret", profile)
+	assert.ErrorIs(t, err, services.ErrProfileNotEmpty)
+
+	restored, err := bdkeeper.Open(filepath.Join(profile, services.BackupDatabase))
+	require.NoError(t, err)
+	defer restored.Close()
+	rows, err := restored.GetAllData(ctx, "TextData", 1, "id")
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.FileExists(t, filepath.Join(profile, services.BackupFilesDir, blob))
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	_, err = s.ScheduledBackup(ctx, start)
+	require.NoError(t, err)
+	latest, err := s.ScheduledBackup(ctx, start.Add(time.Hour))
+	require.NoError(t, err)
+	backups, err := filepath.Glob(filepath.Join(opt.BackupDir, "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{latest}, backups)
+	_, err = s.VerifyBackup(ctx, latest, "scheduled")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// Checks run by Doctor.
+const (
+	CheckIntegrity   = "integrity"    // the SQLite database is intact
+	CheckDecryption  = "decryption"   // every stored value decrypts with the current key
+	CheckMissingBlob = "missing-blob" // every file entry has its encrypted file
+	CheckOrphanBlob  = "orphan-blob"  // every encrypted file belongs to a file entry
+	CheckSyncEntry   = "sync-entry"   // every queued create or update refers to an existing entry
+	CheckStuckSync   = "stuck-sync"   // no queued change is left in progress by an interrupted synchronization
+	CheckOrphanUser  = "orphan-user"  // every stored row belongs to a local account
+)
+
+// Finding is a problem found by Doctor.
+type Finding struct {
+	Check   string `json:"check"`   // Check is the check that found the problem.
+	Problem string `json:"problem"` // Problem describes the problem.
+	Fix     string `json:"fix"`     // Fix describes the automatic fix or, if there is none, what to do about it.
+	Auto    bool   `json:"auto"`    // Auto is set if Doctor can fix the problem itself.
+	Fixed   bool   `json:"fixed"`   // Fixed is set if the problem was fixed.
+	Error   string `json:"error,omitempty"`
+
+	repair func(ctx context.Context) error
+}
+
+// blobName matches the names of the encrypted files, the hex SHA-256 of their content.
+var blobName = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Doctor checks the integrity of the local vault and returns the problems found.
+// If fix is set, the problems that can be fixed automatically are fixed.
+// user_id is the logged in user, whose data is never considered orphaned.
+func (s *Service) Doctor(ctx context.Context, user_id int, fix bool) ([]Finding, error) {
+	var findings []Finding
+
+	problems, err := s.keeper.IntegrityCheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range problems {
+		findings = append(findings, Finding{
+			Check:   CheckIntegrity,
+			Problem: problem,
+			Fix:     "Restore the database from a backup, or delete it and synchronize again with the server.",
+		})
+	}
+
+	users, err := s.keeper.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := s.keeper.OwnerIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := make(map[string]bool)
+	blobsKnown := true
+	for _, id := range owners {
+		userFindings, userBlobs, ok, err := s.checkEntries(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, userFindings...)
+		for blob := range userBlobs {
+			blobs[blob] = true
+		}
+		blobsKnown = blobsKnown && ok
+	}
+
+	orphans, err := s.checkBlobs(blobs, blobsKnown)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, orphans...)
+
+	queued, err := s.checkSyncQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, queued...)
+
+	// With synchronization the entries belong to the server ids of the users,
+	// which are unrelated to the ids of the local accounts.
+	if !s.syncWithServer {
+		for _, id := range owners {
+			if _, ok := users[id]; ok || id == user_id {
+				continue
+			}
+			id := id
+			findings = append(findings, Finding{
+				Check:   CheckOrphanUser,
+				Problem: fmt.Sprintf("user %d has no local account but owns data in the vault", id),
+				Fix:     "Delete the data of the user.",
+				Auto:    true,
+				repair: func(ctx context.Context) error {
+					return s.clearUser(ctx, id)
+				},
+			})
+		}
+	}
+
+	if fix {
+		for i := range findings {
+			if !findings[i].Auto {
+				continue
+			}
+			if err := findings[i].repair(ctx); err != nil {
+				findings[i].Error = err.Error()
+				continue
+			}
+			findings[i].Fixed = true
+		}
+	}
+	return findings, nil
+}
+
+// checkEntries checks that all entries of the user, and their history, decrypt with
+// the current key and that the files of the file entries exist. It returns the names
+// of the files referenced and whether all of them could be determined.
+func (s *Service) checkEntries(ctx context.Context, user_id int) ([]Finding, map[string]bool, bool, error) {
+	var findings []Finding
+	blobs := make(map[string]bool)
+	blobsKnown := true
+
+	for _, table := range records.SyncTables() {
+		t, err := s.keeper.Schema().Table(table)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		columns := encryptedColumns(t)
+		rows, err := s.keeper.GetAllData(ctx, table, user_id, append([]string{"id"}, columns...)...)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		for _, row := range rows {
+			entry := fmt.Sprintf("%s entry %s of user %d", table, row["id"], user_id)
+			if bad := s.undecryptable(row, columns); len(bad) > 0 {
+				findings = append(findings, Finding{
+					Check:   CheckDecryption,
+					Problem: fmt.Sprintf("%s: %s cannot be decrypted with the current key", entry, strings.Join(bad, ", ")),
+					Fix:     "Restore the entry from a backup or synchronize it again from the server, or delete it.",
+				})
+			}
+
+			versions, err := s.keeper.GetVersions(ctx, table, user_id, row["id"])
+			if err != nil {
+				return nil, nil, false, err
+			}
+			for _, v := range versions {
+				if bad := s.undecryptable(v.Data, sortedKeys(v.Data)); len(bad) > 0 {
+					findings = append(findings, Finding{
+						Check:   CheckDecryption,
+						Problem: fmt.Sprintf("%s: %s of version %d cannot be decrypted with the current key", entry, strings.Join(bad, ", "), v.Version),
+						Fix:     "The version can no longer be restored; it is pruned as the entry changes.",
+					})
+				}
+			}
+
+			if table != records.FilesTable {
+				continue
+			}
+			blob, err := s.decryptChecked(row["path"])
+			if err != nil || !blobName.MatchString(blob) {
+				blobsKnown = false
+				continue
+			}
+			blobs[blob] = true
+			if finding, ok := s.checkBlob(user_id, entry, blob); !ok {
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings, blobs, blobsKnown, nil
+}
+
+// checkBlob checks that the encrypted file of a file entry exists.
+func (s *Service) checkBlob(user_id int, entry, blob string) (Finding, bool) {
+	path := filepath.Join(s.opt.FileStoragePath, blob)
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return Finding{}, true
+	}
+	finding := Finding{
+		Check:   CheckMissingBlob,
+		Problem: fmt.Sprintf("%s: the encrypted file %s is missing", entry, blob),
+		Fix:     "Delete the entry; the file cannot be recovered without the server.",
+	}
+	if s.syncWithServer {
+		finding.Fix = "Download the file from the server."
+		finding.Auto = true
+		finding.repair = func(ctx context.Context) error {
+			s.RetrieveFile(ctx, user_id, blob, path)
+			if _, err := os.Stat(path); err != nil {
+				return errors.New("the file could not be downloaded")
+			}
+			return nil
+		}
+	}
+	return finding, false
+}
+
+// checkBlobs finds the encrypted files that belong to no file entry. They are only
+// deleted automatically if the files of all entries are known.
+func (s *Service) checkBlobs(blobs map[string]bool, blobsKnown bool) ([]Finding, error) {
+	files, err := os.ReadDir(s.opt.FileStoragePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, file := range files {
+		if !file.Type().IsRegular() || !blobName.MatchString(file.Name()) || blobs[file.Name()] {
+			continue
+		}
+		path := filepath.Join(s.opt.FileStoragePath, file.Name())
+		finding := Finding{
+			Check:   CheckOrphanBlob,
+			Problem: fmt.Sprintf("the encrypted file %s belongs to no entry", file.Name()),
+			Fix:     "Delete the file once the entries that cannot be decrypted are resolved.",
+		}
+		if blobsKnown {
+			finding.Fix = "Delete the file."
+			finding.Auto = true
+			finding.repair = func(ctx context.Context) error {
+				return os.Remove(path)
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// checkSyncQueue finds queued changes of entries that no longer exist and changes
+// left in progress by an interrupted synchronization.
+func (s *Service) checkSyncQueue(ctx context.Context) ([]Finding, error) {
+	var findings []Finding
+	for _, status := range []string{"Pending", "Progress", "Error"} {
+		entries, err := s.keeper.GetSyncEntriesByStatus(ctx, status)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			entry := entry
+			if entry.Operation != "Delete" && !s.entryExists(ctx, entry.TableName, entry.UserID, entry.EntryID) {
+				findings = append(findings, Finding{
+					Check:   CheckSyncEntry,
+					Problem: fmt.Sprintf("queued %s of %s entry %s refers to a missing entry", strings.ToLower(entry.Operation), entry.TableName, entry.EntryID),
+					Fix:     "Remove the change from the queue.",
+					Auto:    true,
+					repair: func(ctx context.Context) error {
+						return s.keeper.DeleteSyncEntry(ctx, entry.ID)
+					},
+				})
+				continue
+			}
+			if status == "Progress" && s.syncing.Load() == 0 {
+				findings = append(findings, Finding{
+					Check:   CheckStuckSync,
+					Problem: fmt.Sprintf("queued %s of %s entry %s was left in progress", strings.ToLower(entry.Operation), entry.TableName, entry.EntryID),
+					Fix:     "Queue the change again.",
+					Auto:    true,
+					repair: func(ctx context.Context) error {
+						return s.keeper.UpdateSyncEntryStatus(ctx, entry.ID, "Pending")
+					},
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// entryExists reports whether an entry exists in a table that is synchronized.
+func (s *Service) entryExists(ctx context.Context, table string, user_id int, entry_id string) bool {
+	for _, t := range records.SyncTables() {
+		if t != table {
+			continue
+		}
+		_, err := s.keeper.GetData(ctx, table, user_id, entry_id)
+		return err == nil
+	}
+	return false
+}
+
+// clearUser deletes all data of a user from the vault.
+func (s *Service) clearUser(ctx context.Context, user_id int) error {
+	return s.keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		for _, table := range records.SyncTables() {
+			if err := tx.ClearData(ctx, table, user_id); err != nil {
+				return err
+			}
+		}
+		if err := tx.ClearSyncEntries(ctx, user_id); err != nil {
+			return err
+		}
+		return tx.ClearVersions(ctx, user_id)
+	})
+}
+
+// decryptChecked decrypts a stored value and checks that the result is text. The
+// values are encrypted without authentication, so a value encrypted with another key
+// still decrypts, but almost never to valid UTF-8.
+func (s *Service) decryptChecked(value string) (string, error) {
+	decrypted, err := s.decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.ValidString(decrypted) {
+		return "", errors.New("the value is not encrypted with the current key")
+	}
+	return decrypted, nil
+}
+
+// undecryptable returns the columns of a row whose values cannot be decrypted.
+func (s *Service) undecryptable(row map[string]string, columns []string) []string {
+	var bad []string
+	for _, column := range columns {
+		if _, err := s.decryptChecked(row[column]); err != nil {
+			bad = append(bad, column)
+		}
+	}
+	return bad
+}
+
+// encryptedColumns returns the columns of a table holding encrypted values.
+func encryptedColumns(t *bdkeeper.Table) []string {
+	var columns []string
+	for _, column := range t.Columns {
+		switch column {
+		case "id", "user_id", "updated_at", "created_at", "deleted":
+		default:
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
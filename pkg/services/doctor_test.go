@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/encription"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// checks returns the checks of the findings.
+func checks(findings []services.Finding) []string {
+	var names []string
+	for _, f := range findings {
+		names = append(names, f.Check)
+	}
+	return names
+}
+
+func TestService_Doctor(t *testing.T) {
+	ctx := context.Background()
+	s, store, opt := newService(t)
+	storage := opt.FileStoragePath
+
+	findings, err := s.Doctor(ctx, 1, false)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	blob := strings.Repeat("ab", 32)
+	orphan := strings.Repeat("cd", 32)
+	require.NoError(t, os.WriteFile(filepath.Join(storage, orphan), []byte("x"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(storage, "notes.txt"), []byte("x"), 0o600))
+	require.NoError(t, s.AddData(ctx, "FilesData", 1, map[string]string{"path": blob, "meta_info": "file"}))
+
+	other := encription.NewEnc("another key")
+	foreign, err := other.Encrypt("a secret written with another key")
+	require.NoError(t, err)
+	require.NoError(t, store.AddData(ctx, "TextData", 1, "foreign", map[string]string{"data": foreign}))
+	require.NoError(t, store.AddData(ctx, "TextData", 7, "left", map[string]string{"data": ""}))
+
+	require.NoError(t, store.CreateSyncEntry(ctx, "Update", "TextData", 1, "gone", map[string]string{}))
+	require.NoError(t, store.CreateSyncEntry(ctx, "Delete", "TextData", 1, "gone", map[string]string{}))
+	require.NoError(t, store.CreateSyncEntry(ctx, "Update", "TextData", 1, "foreign", map[string]string{}))
+	entries, err := store.GetSyncEntriesByStatus(ctx, "Pending")
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateSyncEntryStatus(ctx, entries[2].ID, "Progress"))
+
+	findings, err = s.Doctor(ctx, 1, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		services.CheckDecryption, services.CheckMissingBlob, services.CheckOrphanBlob,
+		services.CheckSyncEntry, services.CheckStuckSync, services.CheckOrphanUser,
+	}, checks(findings))
+
+	findings, err = s.Doctor(ctx, 1, true)
+	require.NoError(t, err)
+	for _, f := range findings {
+		assert.Equal(t, f.Auto, f.Fixed, f.Check)
+	}
+
+	findings, err = s.Doctor(ctx, 1, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{services.CheckDecryption, services.CheckMissingBlob}, checks(findings))
+
+	assert.NoFileExists(t, filepath.Join(storage, orphan))
+	assert.FileExists(t, filepath.Join(storage, "notes.txt"))
+	entries, err = store.GetSyncEntriesByStatus(ctx, "Pending")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "foreign", entries[1].EntryID)
+	owners, err := store.OwnerIDs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, owners)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// Reasons for reporting an entry as expiring.
+const (
+	ExpiryEntry    = "expires"  // ExpiryEntry entries expire themselves, like cards.
+	ExpiryPassword = "password" // ExpiryPassword entries have a password due for a change.
+)
+
+// ExpiringEntry is an entry that has expired or expires soon, or whose password is due
+// for a change.
+type ExpiringEntry struct {
+	Table  string
+	ID     string
+	Title  string
+	Reason string
+	// At is when the entry expires, or when its password reaches the maximum age.
+	At time.Time
+}
+
+// Expired reports whether the entry has expired at the given time.
+func (e ExpiringEntry) Expired(now time.Time) bool {
+	return !e.At.After(now)
+}
+
+// Expiring returns the entries of the user that have expired or expire within the given
+// time, and the credentials whose password reaches the configured maximum age within it,
+// soonest first. Cards expire at the end of the month of their expiry date.
+func (s *Service) Expiring(ctx context.Context, user_id int, within time.Duration, now time.Time) ([]ExpiringEntry, error) {
+	deadline := now.Add(within)
+	var found []ExpiringEntry
+	for _, t := range records.All() {
+		rows, err := s.expiryRows(ctx, t, user_id)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			entry := ExpiringEntry{Table: t.Table, ID: row["id"], Title: row[records.TitleField]}
+			if at, ok := t.ExpiresAt(row, now.Location()); ok && !at.After(deadline) {
+				entry.Reason, entry.At = ExpiryEntry, at
+				found = append(found, entry)
+			}
+			if _, ok := t.Field(records.PasswordChangedField); ok && s.opt.PasswordMaxAge > 0 {
+				changed, ok := parseTime(row[records.PasswordChangedField])
+				if !ok {
+					// Passwords changed before their age was kept are at least as old as the last update
+					changed = s.entryTime(row["updated_at"])
+				}
+				if due := changed.Add(s.opt.PasswordMaxAge); !changed.IsZero() && !due.After(deadline) {
+					entry.Reason, entry.At = ExpiryPassword, due
+					found = append(found, entry)
+				}
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].At.Before(found[j].At)
+	})
+	return found, nil
+}
+
+// expiryRows returns the decrypted non-sensitive fields of all records of a type,
+// with their id and update time.
+func (s *Service) expiryRows(ctx context.Context, t *records.Type, user_id int) ([]map[string]string, error) {
+	columns := []string{"id", "updated_at"}
+	for _, f := range t.Fields {
+		if !f.Sensitive {
+			columns = append(columns, f.Name)
+		}
+	}
+	repo, err := s.rows(s.keeper, t.Table)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := repo.ListRows(ctx, user_id, columns...)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for key, value := range row {
+			if key == "id" || key == "updated_at" {
+				continue
+			}
+			if row[key], err = s.decrypt(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rows, nil
+}
+
+// derive returns the data of a record with the fields the service maintains: the expiry
+// date of types deriving it, and the time the password was last changed. old holds the
+// current values of an updated record, nil for a new one. Data of other tables is returned as is.
+func (s *Service) derive(table string, data, old map[string]string, now time.Time) map[string]string {
+	t, ok := records.ByTable(table)
+	if !ok {
+		return data
+	}
+	derived := make(map[string]string, len(data)+2)
+	for key, value := range data {
+		derived[key] = value
+	}
+	if t.DeriveExpiry != nil {
+		if expires := t.DeriveExpiry(data); expires != "" {
+			derived[records.ExpiresField] = expires
+		}
+	}
+	if _, ok := t.Field(records.PasswordChangedField); ok {
+		password, set := data["password"]
+		_, explicit := data[records.PasswordChangedField]
+		if set && !explicit && (old == nil || old["password"] != password) {
+			derived[records.PasswordChangedField] = now.UTC().Format(time.RFC3339)
+		}
+	}
+	return derived
+}
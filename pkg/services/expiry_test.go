@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/config"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+func TestService_Expiring(t *testing.T) {
+	ctx := context.Background()
+	s, _, opt := newService(t)
+	opt.PasswordMaxAge = 90 * config.Day
+	now := time.Now()
+
+	lastMonth := now.AddDate(0, -1, 0).Format("01/06")
+	require.NoError(t, s.AddData(ctx, records.CardsTable, 1, map[string]string{
+		"meta_info": "Corporate card", "card_number": "4111", "expiration_date": lastMonth, "cvv": "123"}))
+	require.NoError(t, s.AddData(ctx, records.CardsTable, 1, map[string]string{
+		"meta_info": "New card", "card_number": "4222", "expiration_date": now.AddDate(3, 0, 0).Format("01/06"), "cvv": "123"}))
+	require.NoError(t, s.AddData(ctx, records.TextTable, 1, map[string]string{
+		"meta_info": "License", "data": "key", records.ExpiresField: now.AddDate(0, 0, 10).Format(time.DateOnly)}))
+	require.NoError(t, s.AddData(ctx, records.CredentialsTable, 1, map[string]string{
+		"meta_info": "Mail", "login": "alice", "password": "old"}))
+
+	// The expiry date of cards is derived from their MM/YY date
+	card := onlyTitle(t, s, records.CardsTable, "Corporate card")
+	data, err := s.GetData(ctx, records.CardsTable, 1, card)
+	require.NoError(t, err)
+	assert.Equal(t, records.CardExpiry(map[string]string{"expiration_date": lastMonth}), data[records.ExpiresField])
+
+	found, err := s.Expiring(ctx, 1, 30*config.Day, now)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "Corporate card", found[0].Title)
+	assert.True(t, found[0].Expired(now))
+	assert.Equal(t, "License", found[1].Title)
+	assert.Equal(t, services.ExpiryEntry, found[1].Reason)
+	assert.False(t, found[1].Expired(now))
+
+	// Passwords are reported when they reach the maximum age, counted from their last change
+	found, err = s.Expiring(ctx, 1, 100*config.Day, now)
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, services.ExpiryPassword, found[2].Reason)
+
+	mail := onlyTitle(t, s, records.CredentialsTable, "Mail")
+	before, err := s.GetData(ctx, records.CredentialsTable, 1, mail)
+	require.NoError(t, err)
+	require.NotEmpty(t, before[records.PasswordChangedField])
+	require.NoError(t, s.UpdateData(ctx, records.CredentialsTable, 1, mail, map[string]string{"login": "alice2", "password": "old"}))
+	after, err := s.GetData(ctx, records.CredentialsTable, 1, mail)
+	require.NoError(t, err)
+	assert.Equal(t, before[records.PasswordChangedField], after[records.PasswordChangedField])
+
+	found, err = s.Expiring(ctx, 1, 30*config.Day, now.Add(100*config.Day))
+	require.NoError(t, err)
+	assert.Equal(t, services.ExpiryPassword, found[len(found)-1].Reason)
+	assert.True(t, found[len(found)-1].Expired(now.Add(100*config.Day)))
+}
+
+// onlyTitle returns the id of the entry of a table with the given title.
+func onlyTitle(t *testing.T, s *services.Service, table, title string) string {
+	rows, err := s.GetAllData(context.Background(), table, 1, "id", "meta_info")
+	require.NoError(t, err)
+	for _, row := range rows {
+		if row["meta_info"] == title {
+			return row["id"]
+		}
+	}
+	t.Fatalf("no entry %s in %s", title, table)
+	return ""
+}
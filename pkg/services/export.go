@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// ExportRecords returns the decrypted records of the user for an export, in the form
+// read by Import. Folders and tags are given by name, linked TOTP authenticators are
+// set on their login and attachments are read from the file storage. Authenticators and
+// files linked to an exported record are only exported with it.
+//
+// The records are limited to the tables given, all of them if there are none, and to the
+// filter, which returns ErrFolderNotFound or ErrTagNotFound for unknown names.
+func (s *Service) ExportRecords(ctx context.Context, user_id int, tables []string, filter EntryFilter) ([]importer.Record, error) {
+	folders, err := s.names(ctx, records.FoldersTable, user_id)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.entryTagNames(ctx, user_id)
+	if err != nil {
+		return nil, err
+	}
+	match, err := s.exportFilter(ctx, user_id, filter, folders, tags)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		selected[table] = true
+	}
+
+	rows := make(map[string][]map[string]string)
+	byID := make(map[string]map[string]map[string]string)
+	for _, t := range records.All() {
+		columns := []string{"id"}
+		for _, f := range t.Fields {
+			columns = append(columns, f.Name)
+		}
+		entries, err := s.GetAllData(ctx, t.Table, user_id, columns...)
+		if err != nil {
+			return nil, err
+		}
+		byID[t.Table] = make(map[string]map[string]string, len(entries))
+		for _, entry := range entries {
+			byID[t.Table][entry["id"]] = entry
+			if (len(selected) == 0 || selected[t.Table]) && match(t.Table, entry) {
+				rows[t.Table] = append(rows[t.Table], entry)
+			}
+		}
+	}
+
+	// Records linked from an exported one are exported with it
+	linked := make(map[string]bool)
+	for _, entries := range rows {
+		for _, entry := range entries {
+			if id := entry["totp_id"]; id != "" && byID[records.TOTPTable][id] != nil {
+				linked[id] = true
+			}
+			ids, _ := records.ParseAttachments(entry[records.AttachmentsField])
+			for _, id := range ids {
+				if byID[records.FilesTable][id] != nil {
+					linked[id] = true
+				}
+			}
+		}
+	}
+
+	var recs []importer.Record
+	for _, t := range records.All() {
+		for _, entry := range rows[t.Table] {
+			if linked[entry["id"]] {
+				continue
+			}
+			rec := importer.Record{
+				Source: entry["id"],
+				Table:  t.Table,
+				Data:   exportedData(t, entry),
+				Folder: folders[entry[records.FolderField]],
+				Tags:   tags[t.Table][entry["id"]],
+			}
+			if totp := byID[records.TOTPTable][entry["totp_id"]]; totp != nil {
+				totpType, _ := records.ByTable(records.TOTPTable)
+				rec.TOTP = exportedData(totpType, totp)
+			}
+			if t.Binary {
+				file, err := s.exportFile(ctx, user_id, entry)
+				if err != nil {
+					return nil, err
+				}
+				rec.File = &file
+			}
+			ids, _ := records.ParseAttachments(entry[records.AttachmentsField])
+			for _, id := range ids {
+				attached := byID[records.FilesTable][id]
+				if attached == nil {
+					continue
+				}
+				file, err := s.exportFile(ctx, user_id, attached)
+				if err != nil {
+					return nil, err
+				}
+				rec.Attachments = append(rec.Attachments, file)
+			}
+			recs = append(recs, rec)
+		}
+	}
+	return recs, nil
+}
+
+// exportFilter returns a function reporting whether an entry of a table matches the filter.
+func (s *Service) exportFilter(ctx context.Context, user_id int, filter EntryFilter,
+	folders map[string]string, tags map[string]map[string][]string) (func(string, map[string]string) bool, error) {
+	folder, tag := filter.Folder, filter.Tag
+	if folder != "" {
+		found, err := s.findByName(ctx, records.FoldersTable, user_id, folder)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return nil, fmt.Errorf("%w: %s", ErrFolderNotFound, folder)
+		}
+		folder = found[records.TitleField]
+	}
+	if tag != "" {
+		found, err := s.findByName(ctx, records.TagsTable, user_id, tag)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return nil, fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+		}
+		tag = found[records.TitleField]
+	}
+	title := strings.ToLower(filter.Title)
+
+	return func(table string, entry map[string]string) bool {
+		if folder != "" && folders[entry[records.FolderField]] != folder {
+			return false
+		}
+		if tag != "" && !slices.Contains(tags[table][entry["id"]], tag) {
+			return false
+		}
+		return strings.Contains(strings.ToLower(entry[records.TitleField]), title)
+	}, nil
+}
+
+// exportedData returns the non-empty values of the fields of a record, without the
+// folder, attachments and links to other records, and without the file of binary data.
+func exportedData(t *records.Type, entry map[string]string) map[string]string {
+	data := make(map[string]string, len(t.Fields))
+	for _, f := range t.Fields {
+		switch {
+		case f.Name == records.FolderField || f.Name == records.AttachmentsField || f.Ref != "":
+		case t.Binary && (f.Name == "path" || f.Name == "extension"):
+		case entry[f.Name] != "":
+			data[f.Name] = entry[f.Name]
+		}
+	}
+	return data
+}
+
+// exportFile reads the content of a binary data record, downloading its encrypted file
+// from the server if it is not stored locally. The file is named by the title of the
+// record, with its extension.
+func (s *Service) exportFile(ctx context.Context, user_id int, entry map[string]string) (importer.Attachment, error) {
+	name := entry[records.TitleField]
+	if ext := strings.TrimPrefix(entry["extension"], "."); ext != "" && filepath.Ext(name) == "" {
+		name += "." + ext
+	}
+	file := importer.Attachment{Name: name}
+
+	inputPath := filepath.Join(s.opt.FileStoragePath, entry["path"])
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) && s.syncWithServer {
+		s.RetrieveFile(ctx, user_id, entry["path"], inputPath)
+	}
+	tmp, err := os.CreateTemp("", "gophkeeper-export-*")
+	if err != nil {
+		return file, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := s.enc.DecryptFile(inputPath, tmp.Name()); err != nil {
+		return file, fmt.Errorf("failed to read the file of %s: %w", entry[records.TitleField], err)
+	}
+	file.Data, err = os.ReadFile(tmp.Name())
+	return file, err
+}
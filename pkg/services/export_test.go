@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+func TestService_ExportRecords(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newService(t)
+	_, err := s.Import(ctx, 1, []importer.Record{
+		{
+			Table: records.CredentialsTable, Folder: "Work", Tags: []string{"finance"},
+			Data:        map[string]string{"meta_info": "Bank", "login": "alice", "password": "pw"},
+			TOTP:        map[string]string{"meta_info": "Bank", "secret": "JBSWY3DPEHPK3PXP", "algorithm": "SHA1", "digits": "6", "period": "30"},
+			Attachments: []importer.Attachment{{Name: "codes.txt", Data: []byte("111 222")}},
+		},
+		{Table: records.FilesTable, Data: map[string]string{"meta_info": "passport"},
+			File: &importer.Attachment{Name: "passport.pdf", Data: []byte("%PDF")}},
+	})
+	require.NoError(t, err)
+
+	recs, err := s.ExportRecords(ctx, 1, nil, services.EntryFilter{})
+	require.NoError(t, err)
+	require.Len(t, recs, 2, "the linked TOTP authenticator and attachment are exported with their login")
+
+	bank := recs[0]
+	assert.Equal(t, records.CredentialsTable, bank.Table)
+	assert.Equal(t, "alice", bank.Data["login"])
+	assert.Equal(t, "pw", bank.Data["password"])
+	assert.NotContains(t, bank.Data, "totp_id")
+	assert.Equal(t, "Work", bank.Folder)
+	assert.Equal(t, []string{"finance"}, bank.Tags)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", bank.TOTP["secret"])
+	assert.Equal(t, []importer.Attachment{{Name: "codes.txt", Data: []byte("111 222")}}, bank.Attachments)
+
+	passport := recs[1]
+	assert.Equal(t, records.FilesTable, passport.Table)
+	assert.NotContains(t, passport.Data, "path")
+	require.NotNil(t, passport.File)
+	assert.Equal(t, importer.Attachment{Name: "passport.pdf", Data: []byte("%PDF")}, *passport.File)
+}
+
+func TestService_ExportRecords_Filter(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newService(t)
+	_, err := s.Import(ctx, 1, []importer.Record{
+		{Table: records.CredentialsTable, Folder: "Work", Tags: []string{"finance"},
+			Data: map[string]string{"meta_info": "Bank", "login": "alice", "password": "pw"},
+			TOTP: map[string]string{"meta_info": "Bank", "secret": "JBSWY3DPEHPK3PXP", "algorithm": "SHA1", "digits": "6", "period": "30"}},
+		{Table: records.CredentialsTable, Data: map[string]string{"meta_info": "Mail", "login": "bob", "password": "pw"}},
+		{Table: records.TextTable, Folder: "Work", Data: map[string]string{"meta_info": "Notes", "data": "text"}},
+	})
+	require.NoError(t, err)
+
+	titles := func(recs []importer.Record) []string {
+		var titles []string
+		for _, rec := range recs {
+			titles = append(titles, rec.Title())
+		}
+		return titles
+	}
+
+	recs, err := s.ExportRecords(ctx, 1, []string{records.CredentialsTable}, services.EntryFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bank", "Mail"}, titles(recs))
+
+	recs, err = s.ExportRecords(ctx, 1, nil, services.EntryFilter{Folder: "work"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bank", "Notes"}, titles(recs))
+
+	recs, err = s.ExportRecords(ctx, 1, nil, services.EntryFilter{Tag: "finance"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bank"}, titles(recs))
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", recs[0].TOTP["secret"])
+
+	recs, err = s.ExportRecords(ctx, 1, []string{records.TOTPTable}, services.EntryFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bank"}, titles(recs), "a TOTP authenticator is exported alone when its login is not")
+	assert.Equal(t, records.TOTPTable, recs[0].Table)
+
+	_, err = s.ExportRecords(ctx, 1, nil, services.EntryFilter{Folder: "Home"})
+	assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	_, err = s.ExportRecords(ctx, 1, nil, services.EntryFilter{Tag: "home"})
+	assert.ErrorIs(t, err, services.ErrTagNotFound)
+}
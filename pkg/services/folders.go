@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// Errors returned by the folder and tag operations.
+var (
+	ErrFolderNotFound = errors.New("folder not found")
+	ErrFolderExists   = errors.New("folder already exists")
+	ErrTagNotFound    = errors.New("tag not found")
+	ErrTagExists      = errors.New("tag already exists")
+	ErrInvalidName    = errors.New("name must not be empty")
+)
+
+// AddFolder creates a folder with the given name.
+func (s *Service) AddFolder(ctx context.Context, user_id int, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrInvalidName
+	}
+	existing, err := s.findByName(ctx, records.FoldersTable, user_id, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: %s", ErrFolderExists, name)
+	}
+	return s.AddData(ctx, records.FoldersTable, user_id, map[string]string{records.TitleField: name})
+}
+
+// FolderID returns the id of the folder with the given name.
+func (s *Service) FolderID(ctx context.Context, user_id int, name string) (string, error) {
+	folder, err := s.findByName(ctx, records.FoldersTable, user_id, name)
+	if err != nil {
+		return "", err
+	}
+	if folder == nil {
+		return "", fmt.Errorf("%w: %s", ErrFolderNotFound, name)
+	}
+	return folder["id"], nil
+}
+
+// RenameFolder renames a folder. The entries in it are not changed.
+func (s *Service) RenameFolder(ctx context.Context, user_id int, oldName, newName string) error {
+	return s.rename(ctx, records.FoldersTable, user_id, oldName, newName, ErrFolderNotFound, ErrFolderExists)
+}
+
+// DeleteFolder deletes a folder and returns the number of entries moved out of it.
+// The entries themselves are kept.
+func (s *Service) DeleteFolder(ctx context.Context, user_id int, name string) (int, error) {
+	folder, err := s.findByName(ctx, records.FoldersTable, user_id, name)
+	if err != nil {
+		return 0, err
+	}
+	if folder == nil {
+		return 0, fmt.Errorf("%w: %s", ErrFolderNotFound, name)
+	}
+
+	// Move the entries out first, so that a failure never leaves them in a missing folder
+	moved := 0
+	for _, table := range records.Tables() {
+		entries, err := s.GetAllData(ctx, table, user_id, "id", records.FolderField)
+		if err != nil {
+			return moved, err
+		}
+		for _, entry := range entries {
+			if entry[records.FolderField] != folder["id"] {
+				continue
+			}
+			err := s.UpdateData(ctx, table, user_id, entry["id"], map[string]string{
+				records.FolderField: "",
+				"updated_at":        s.sm.GetTimeWithoutTimeZone().Format(time.RFC3339),
+			})
+			if err != nil {
+				return moved, fmt.Errorf("failed to move entry %s out of the folder: %w", entry["id"], err)
+			}
+			moved++
+		}
+	}
+	return moved, s.DeleteData(ctx, records.FoldersTable, user_id, folder["id"])
+}
+
+// TagEntry adds a tag to an entry, creating the tag if it does not exist yet.
+// Adding a tag the entry already has does nothing.
+func (s *Service) TagEntry(ctx context.Context, table string, user_id int, entry_id string, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrInvalidName
+	}
+	tag, err := s.findByName(ctx, records.TagsTable, user_id, name)
+	if err != nil {
+		return err
+	}
+	if tag == nil {
+		if err := s.AddData(ctx, records.TagsTable, user_id, map[string]string{records.TitleField: name}); err != nil {
+			return err
+		}
+		if tag, err = s.findByName(ctx, records.TagsTable, user_id, name); err != nil {
+			return err
+		}
+	}
+
+	links, err := s.tagLinks(ctx, s.keeper, user_id)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if link["tag_id"] == tag["id"] && link["table_name"] == table && link["entry_id"] == entry_id {
+			return nil
+		}
+	}
+	return s.AddData(ctx, records.EntryTagsTable, user_id, map[string]string{
+		"tag_id":     tag["id"],
+		"table_name": table,
+		"entry_id":   entry_id,
+	})
+}
+
+// UntagEntry removes a tag from an entry. The tag is kept for the other entries.
+func (s *Service) UntagEntry(ctx context.Context, table string, user_id int, entry_id string, name string) error {
+	tag, err := s.findByName(ctx, records.TagsTable, user_id, name)
+	if err != nil {
+		return err
+	}
+	if tag != nil {
+		links, err := s.tagLinks(ctx, s.keeper, user_id)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			if link["tag_id"] == tag["id"] && link["table_name"] == table && link["entry_id"] == entry_id {
+				return s.DeleteData(ctx, records.EntryTagsTable, user_id, link["id"])
+			}
+		}
+	}
+	return fmt.Errorf("%w: the entry has no tag %s", ErrTagNotFound, name)
+}
+
+// RenameTag renames a tag on all entries that have it.
+func (s *Service) RenameTag(ctx context.Context, user_id int, oldName, newName string) error {
+	return s.rename(ctx, records.TagsTable, user_id, oldName, newName, ErrTagNotFound, ErrTagExists)
+}
+
+// EntryTags returns the sorted names of the tags of an entry.
+func (s *Service) EntryTags(ctx context.Context, table string, user_id int, entry_id string) ([]string, error) {
+	names, err := s.names(ctx, records.TagsTable, user_id)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.tagLinks(ctx, s.keeper, user_id)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, link := range links {
+		if name, ok := names[link["tag_id"]]; ok && link["table_name"] == table && link["entry_id"] == entry_id {
+			tags = append(tags, name)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// TagCounts returns the names of the tags in use with the number of entries having them.
+func (s *Service) TagCounts(ctx context.Context, user_id int) (map[string]int, error) {
+	names, err := s.names(ctx, records.TagsTable, user_id)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.tagLinks(ctx, s.keeper, user_id)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, link := range links {
+		if name, ok := names[link["tag_id"]]; ok {
+			counts[name]++
+		}
+	}
+	return counts, nil
+}
+
+// FolderCounts returns the names of all folders with the number of entries in them.
+func (s *Service) FolderCounts(ctx context.Context, user_id int) (map[string]int, error) {
+	names, err := s.names(ctx, records.FoldersTable, user_id)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(names))
+	for _, name := range names {
+		counts[name] = 0
+	}
+	for _, table := range records.Tables() {
+		entries, err := s.GetAllData(ctx, table, user_id, "id", records.FolderField)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if name, ok := names[entry[records.FolderField]]; ok {
+				counts[name]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// rename changes the name of a folder or tag, keeping names unique.
+func (s *Service) rename(ctx context.Context, table string, user_id int, oldName, newName string, errNotFound, errExists error) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return ErrInvalidName
+	}
+	entry, err := s.findByName(ctx, table, user_id, oldName)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("%w: %s", errNotFound, oldName)
+	}
+	existing, err := s.findByName(ctx, table, user_id, newName)
+	if err != nil {
+		return err
+	}
+	// Changing only the case of the name is allowed
+	if existing != nil && existing["id"] != entry["id"] {
+		return fmt.Errorf("%w: %s", errExists, newName)
+	}
+	return s.UpdateData(ctx, table, user_id, entry["id"], map[string]string{
+		records.TitleField: newName,
+		"updated_at":       s.sm.GetTimeWithoutTimeZone().Format(time.RFC3339),
+	})
+}
+
+// findByName returns the folder or tag with the given name, compared case-insensitively,
+// or nil if there is none.
+func (s *Service) findByName(ctx context.Context, table string, user_id int, name string) (map[string]string, error) {
+	entries, err := s.GetAllData(ctx, table, user_id, "id", records.TitleField)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry[records.TitleField], strings.TrimSpace(name)) {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// names maps the ids of the folders or tags to their names.
+func (s *Service) names(ctx context.Context, table string, user_id int) (map[string]string, error) {
+	entries, err := s.GetAllData(ctx, table, user_id, "id", records.TitleField)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		names[entry["id"]] = entry[records.TitleField]
+	}
+	return names, nil
+}
+
+// tagLinks returns the decrypted links between tags and entries.
+func (s *Service) tagLinks(ctx context.Context, k bdkeeper.Storage, user_id int) ([]map[string]string, error) {
+	links, err := k.GetAllData(ctx, records.EntryTagsTable, user_id, "id", "tag_id", "table_name", "entry_id")
+	if err != nil {
+		return nil, err
+	}
+	return links, s.decryptAll(links)
+}
+
+// unlinkTags deletes the links of an entry to its tags, queueing the deletions for synchronization.
+func (s *Service) unlinkTags(ctx context.Context, tx bdkeeper.Storage, table string, user_id int, entry_id string) error {
+	links, err := s.tagLinks(ctx, tx, user_id)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if link["table_name"] != table || link["entry_id"] != entry_id {
+			continue
+		}
+		if err := tx.DeleteData(ctx, records.EntryTagsTable, user_id, link["id"]); err != nil {
+			return err
+		}
+		if s.syncWithServer {
+			data := map[string]string{"id": link["id"]}
+			if err := tx.CreateSyncEntry(ctx, "Delete", records.EntryTagsTable, user_id, link["id"], data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+)
+
+// archive keeps the current values of an entry in its history before they are replaced,
+// and prunes the history to the configured retention. If the new plaintext values are
+// given and equal the current ones, nothing is kept.
+func (s *Service) archive(ctx context.Context, tx bdkeeper.Storage, table string, userID int, entryID string, data map[string]string) error {
+	repo, err := s.rows(tx, table)
+	if err != nil {
+		return err
+	}
+	current, err := repo.GetRow(ctx, userID, entryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if data != nil {
+		changed := false
+		for key, value := range data {
+			if key == "updated_at" {
+				continue
+			}
+			old, err := s.decrypt(current[key])
+			if err != nil || old != value {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return nil
+		}
+	}
+
+	if _, err := tx.AddVersion(ctx, table, userID, entryID, current); err != nil {
+		return err
+	}
+	return tx.PruneVersions(ctx, table, userID, entryID, s.opt.HistoryRetention)
+}
+
+// GetHistory returns the previous versions of an entry, oldest first, with their values decrypted.
+func (s *Service) GetHistory(ctx context.Context, table string, user_id int, entry_id string) ([]models.EntryVersion, error) {
+	versions, err := s.keeper.GetVersions(ctx, table, user_id, entry_id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		for key, value := range versions[i].Data {
+			decryptedValue, err := s.decrypt(value)
+			if err != nil {
+				return nil, err
+			}
+			versions[i].Data[key] = decryptedValue
+		}
+	}
+	return versions, nil
+}
+
+// Rollback restores a previous version of an entry. The restore is a regular update:
+// the replaced values are kept in the history and the change is synchronized.
+func (s *Service) Rollback(ctx context.Context, table string, user_id int, entry_id string, version int) error {
+	v, err := s.keeper.GetVersion(ctx, table, user_id, entry_id, version)
+	if err != nil {
+		return err
+	}
+	data := make(map[string]string, len(v.Data)+1)
+	for key, value := range v.Data {
+		decryptedValue, err := s.decrypt(value)
+		if err != nil {
+			return err
+		}
+		data[key] = decryptedValue
+	}
+	data["updated_at"] = s.sm.GetTimeWithoutTimeZone().Format(time.RFC3339)
+	return s.UpdateData(ctx, table, user_id, entry_id, data)
+}
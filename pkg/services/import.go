@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// ImportPlan is the result of checking an export against the vault, before anything is stored.
+type ImportPlan struct {
+	Records []importer.Record // Records are new to the vault.
+	// Duplicates match an entry of the vault or an earlier record of the export.
+	Duplicates []importer.Record
+	Skipped    []importer.Skipped
+}
+
+// PlanImport validates the records of an export and sorts out the ones already in the
+// vault. Logins are duplicates when they have the same title and login, cards the same
+// number, notes the same title and text, and files the same title.
+func (s *Service) PlanImport(ctx context.Context, user_id int, result *importer.Result) (*ImportPlan, error) {
+	plan := &ImportPlan{Skipped: append([]importer.Skipped(nil), result.Skipped...)}
+	seen := make(map[string]bool)
+	loaded := make(map[string]bool)
+	for _, rec := range result.Records {
+		if err := s.checkImport(rec); err != nil {
+			plan.Skipped = append(plan.Skipped, importer.Skipped{Source: rec.Source, Title: rec.Title(), Reason: err.Error()})
+			continue
+		}
+		key := duplicateKey(rec.Table, rec.Data)
+		if key == "" {
+			plan.Records = append(plan.Records, rec)
+			continue
+		}
+		if !loaded[rec.Table] {
+			rows, err := s.GetAllData(ctx, rec.Table, user_id, duplicateFields[rec.Table]...)
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				seen[duplicateKey(rec.Table, row)] = true
+			}
+			loaded[rec.Table] = true
+		}
+		if seen[key] {
+			plan.Duplicates = append(plan.Duplicates, rec)
+			continue
+		}
+		seen[key] = true
+		plan.Records = append(plan.Records, rec)
+	}
+	return plan, nil
+}
+
+// checkImport validates an imported record, its TOTP key and its files.
+func (s *Service) checkImport(rec importer.Record) error {
+	t, ok := records.ByTable(rec.Table)
+	if !ok {
+		return fmt.Errorf("unknown table %s", rec.Table)
+	}
+	var errs []error
+	for _, f := range t.Fields {
+		// The hash and extension of a file are only known once it is stored
+		if t.Binary && (f.Name == "path" || f.Name == "extension") {
+			continue
+		}
+		if err := f.Check(rec.Data[f.Name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if t.Binary && rec.File == nil {
+		errs = append(errs, errors.New("File content is missing"))
+	}
+	if rec.TOTP != nil {
+		totp, _ := records.ByTable(records.TOTPTable)
+		if err := totp.Validate(rec.TOTP); err != nil {
+			errs = append(errs, fmt.Errorf("TOTP: %w", err))
+		}
+	}
+	files := rec.Attachments
+	if rec.File != nil {
+		files = append([]importer.Attachment{*rec.File}, files...)
+	}
+	for _, f := range files {
+		if s.opt.MaxFileSize > 0 && len(f.Data) > s.opt.MaxFileSize {
+			errs = append(errs, fmt.Errorf("File %s is too large", f.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// duplicateFields lists the fields compared when looking for duplicates, by table.
+// Records of other tables are never considered duplicates.
+var duplicateFields = map[string][]string{
+	records.CredentialsTable: {records.TitleField, "login"},
+	records.CardsTable:       {"card_number"},
+	records.TextTable:        {records.TitleField, "data"},
+	records.FilesTable:       {records.TitleField},
+}
+
+// duplicateKey returns the values identifying a record when looking for duplicates, or
+// an empty string for tables without duplicates. Titles are compared case-insensitively.
+func duplicateKey(table string, data map[string]string) string {
+	fields, ok := duplicateFields[table]
+	if !ok {
+		return ""
+	}
+	key := table
+	for _, name := range fields {
+		value := data[name]
+		if name == records.TitleField {
+			value = strings.ToLower(strings.TrimSpace(value))
+		}
+		key += "\x00" + value
+	}
+	return key
+}
+
+// storedFile is an imported file stored in the file storage.
+type storedFile struct {
+	hash, extension string
+}
+
+// Import stores the given records, creating the folders and tags they refer to, their TOTP
+// authenticators and their attachments. The entries and their sync queue entries are
+// stored in a single transaction, so a failure imports nothing. It returns the number of
+// records imported.
+func (s *Service) Import(ctx context.Context, user_id int, recs []importer.Record) (int, error) {
+	// Files are stored first, and removed again if the import fails
+	var created []string
+	removeCreated := func() {
+		for _, path := range created {
+			os.Remove(path)
+		}
+	}
+	store := func(a importer.Attachment) (storedFile, error) {
+		f, isNew, err := s.storeImportedFile(a)
+		if err != nil {
+			return f, fmt.Errorf("failed to store file %s: %w", a.Name, err)
+		}
+		if isNew {
+			created = append(created, filepath.Join(s.opt.FileStoragePath, f.hash))
+		}
+		return f, nil
+	}
+	files := make([]storedFile, len(recs))
+	attachments := make([][]storedFile, len(recs))
+	for i, rec := range recs {
+		if rec.File != nil {
+			f, err := store(*rec.File)
+			if err != nil {
+				removeCreated()
+				return 0, err
+			}
+			files[i] = f
+		}
+		for _, a := range rec.Attachments {
+			f, err := store(a)
+			if err != nil {
+				removeCreated()
+				return 0, err
+			}
+			attachments[i] = append(attachments[i], f)
+		}
+	}
+
+	folders, err := s.idsByName(ctx, records.FoldersTable, user_id)
+	if err != nil {
+		removeCreated()
+		return 0, err
+	}
+	tags, err := s.idsByName(ctx, records.TagsTable, user_id)
+	if err != nil {
+		removeCreated()
+		return 0, err
+	}
+
+	now := time.Now()
+	err = s.keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		// named returns the id of the folder or tag with the given name, creating it if needed
+		named := func(table string, ids map[string]string, name string) (string, error) {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if id, ok := ids[key]; ok {
+				return id, nil
+			}
+			id, err := s.GenerateUUID(ctx)
+			if err != nil {
+				return "", err
+			}
+			if err := s.addEntry(ctx, tx, table, user_id, id, map[string]string{records.TitleField: strings.TrimSpace(name)}); err != nil {
+				return "", err
+			}
+			ids[key] = id
+			return id, nil
+		}
+		add := func(table string, data map[string]string) (string, error) {
+			id, err := s.GenerateUUID(ctx)
+			if err != nil {
+				return "", err
+			}
+			return id, s.addEntry(ctx, tx, table, user_id, id, s.derive(table, data, nil, now))
+		}
+
+		for i, rec := range recs {
+			data := make(map[string]string, len(rec.Data)+4)
+			for key, value := range rec.Data {
+				data[key] = value
+			}
+			if strings.TrimSpace(rec.Folder) != "" {
+				id, err := named(records.FoldersTable, folders, rec.Folder)
+				if err != nil {
+					return fmt.Errorf("failed to create folder %s: %w", rec.Folder, err)
+				}
+				data[records.FolderField] = id
+			}
+			if rec.TOTP != nil {
+				id, err := add(records.TOTPTable, rec.TOTP)
+				if err != nil {
+					return fmt.Errorf("failed to add the TOTP authenticator of %s: %w", rec.Title(), err)
+				}
+				data["totp_id"] = id
+			}
+			if rec.File != nil {
+				data["path"], data["extension"] = files[i].hash, files[i].extension
+			}
+			var ids []string
+			for j, a := range rec.Attachments {
+				id, err := add(records.FilesTable, map[string]string{
+					records.TitleField: a.Name,
+					"path":             attachments[i][j].hash,
+					"extension":        attachments[i][j].extension,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to add attachment %s: %w", a.Name, err)
+				}
+				ids = append(ids, id)
+			}
+			if len(ids) > 0 {
+				data[records.AttachmentsField] = records.FormatAttachments(ids)
+			}
+
+			entry_id, err := add(rec.Table, data)
+			if err != nil {
+				return fmt.Errorf("failed to add %s: %w", rec.Title(), err)
+			}
+			for _, tag := range rec.Tags {
+				if strings.TrimSpace(tag) == "" {
+					continue
+				}
+				tag_id, err := named(records.TagsTable, tags, tag)
+				if err != nil {
+					return fmt.Errorf("failed to create tag %s: %w", tag, err)
+				}
+				_, err = add(records.EntryTagsTable, map[string]string{"tag_id": tag_id, "table_name": rec.Table, "entry_id": entry_id})
+				if err != nil {
+					return fmt.Errorf("failed to tag %s: %w", rec.Title(), err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		removeCreated()
+		return 0, err
+	}
+	s.dropIndex()
+	if s.syncWithServer {
+		go s.SyncAllWithServer(ctx)
+	}
+	return len(recs), nil
+}
+
+// storeImportedFile encrypts an imported file into the file storage and reports whether
+// it is new. Files are stored by the hash of their content, so an identical file stored
+// before is reused and must not be removed if the import fails.
+func (s *Service) storeImportedFile(a importer.Attachment) (storedFile, bool, error) {
+	f := storedFile{hash: fmt.Sprintf("%x", sha256.Sum256(a.Data)), extension: filepath.Ext(a.Name)}
+	if _, err := os.Stat(filepath.Join(s.opt.FileStoragePath, f.hash)); err == nil {
+		return f, false, nil
+	}
+
+	tmp, err := os.CreateTemp("", "gophkeeper-import-*"+f.extension)
+	if err != nil {
+		return f, false, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(a.Data); err != nil {
+		tmp.Close()
+		return f, false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return f, false, err
+	}
+	if _, _, err := s.enc.EncryptFile(tmp.Name(), s.opt.FileStoragePath); err != nil {
+		return f, false, err
+	}
+	return f, true, nil
+}
+
+// idsByName maps the lower-cased names of the folders or tags to their ids.
+func (s *Service) idsByName(ctx context.Context, table string, user_id int) (map[string]string, error) {
+	names, err := s.names(ctx, table, user_id)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string, len(names))
+	for id, name := range names {
+		ids[strings.ToLower(strings.TrimSpace(name))] = id
+	}
+	return ids, nil
+}
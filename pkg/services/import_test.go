@@ -0,0 +1,100 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/importer"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+func TestService_Import(t *testing.T) {
+	ctx := context.Background()
+	s, _, opt := newService(t)
+	require.NoError(t, s.AddData(ctx, records.CredentialsTable, 1, map[string]string{"meta_info": "Mail", "login": "alice", "password": "pw"}))
+	require.NoError(t, s.AddFolder(ctx, 1, "Work"))
+
+	result := &importer.Result{
+		Records: []importer.Record{
+			{Source: "item 1", Table: records.CredentialsTable, Data: map[string]string{"meta_info": "mail", "login": "alice", "password": "new"}},
+			{
+				Source: "item 2", Table: records.CredentialsTable, Folder: "work", Tags: []string{"finance"},
+				Data:        map[string]string{"meta_info": "Bank", "login": "alice", "password": "pw"},
+				TOTP:        map[string]string{"meta_info": "Bank", "secret": "JBSWY3DPEHPK3PXP", "algorithm": "SHA1", "digits": "6", "period": "30"},
+				Attachments: []importer.Attachment{{Name: "codes.txt", Data: []byte("111 222")}},
+			},
+			{Source: "item 3", Table: records.CredentialsTable, Data: map[string]string{"meta_info": "Bank", "login": "alice", "password": "pw"}},
+			{Source: "item 4", Table: records.CardsTable, Data: map[string]string{"meta_info": "Visa", "card_number": "4111", "expiration_date": "1/30", "cvv": "1"}},
+			{Source: "item 5", Table: records.FilesTable, Folder: "Scans", Data: map[string]string{"meta_info": "Passport"},
+				File: &importer.Attachment{Name: "passport.pdf", Data: []byte("%PDF")}},
+		},
+		Skipped: []importer.Skipped{{Source: "item 6", Title: "Identity", Reason: "unsupported item type 4"}},
+	}
+
+	plan, err := s.PlanImport(ctx, 1, result)
+	require.NoError(t, err)
+	require.Len(t, plan.Records, 2)
+	assert.Equal(t, "item 2", plan.Records[0].Source)
+	assert.Equal(t, "item 5", plan.Records[1].Source)
+	require.Len(t, plan.Duplicates, 2)
+	assert.Equal(t, "item 1", plan.Duplicates[0].Source, "titles are compared case-insensitively")
+	assert.Equal(t, "item 3", plan.Duplicates[1].Source, "records repeated in the export are duplicates")
+	require.Len(t, plan.Skipped, 2)
+	assert.Equal(t, "item 4", plan.Skipped[1].Source)
+
+	n, err := s.Import(ctx, 1, plan.Records)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	bank := onlyTitle(t, s, records.CredentialsTable, "Bank")
+	data, err := s.GetData(ctx, records.CredentialsTable, 1, bank)
+	require.NoError(t, err)
+	assert.NotEmpty(t, data["totp_id"])
+	assert.NotEmpty(t, data[records.PasswordChangedField])
+	totp, err := s.GetData(ctx, records.TOTPTable, 1, data["totp_id"])
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", totp["secret"])
+
+	ids, err := records.ParseAttachments(data[records.AttachmentsField])
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	attachment, err := s.GetData(ctx, records.FilesTable, 1, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, ".txt", attachment["extension"])
+	assert.FileExists(t, filepath.Join(opt.FileStoragePath, attachment["path"]))
+
+	// Existing folders are reused and missing folders and tags created
+	counts, err := s.FolderCounts(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Work": 1, "Scans": 1}, counts)
+	tags, err := s.EntryTags(ctx, records.CredentialsTable, 1, bank)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"finance"}, tags)
+
+	// Importing again finds only duplicates
+	plan, err = s.PlanImport(ctx, 1, result)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Records)
+	assert.Len(t, plan.Duplicates, 4)
+}
+
+func TestService_Import_RemovesFilesOnFailure(t *testing.T) {
+	ctx := context.Background()
+	s, _, opt := newService(t)
+
+	_, err := s.Import(ctx, 1, []importer.Record{
+		{Table: records.FilesTable, Data: map[string]string{"meta_info": "Notes"}, File: &importer.Attachment{Name: "notes.txt", Data: []byte("notes")}},
+		{Table: "Unknown", Data: map[string]string{"meta_info": "x"}},
+	})
+	require.Error(t, err)
+	files, err := os.ReadDir(opt.FileStoragePath)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	rows, err := s.GetAllData(ctx, records.FilesTable, 1, "id")
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
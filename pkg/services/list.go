@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+)
+
+// Sort orders of listed entries.
+const (
+	SortTitle   = "title"
+	SortUpdated = "updated"
+	SortCreated = "created"
+)
+
+// Errors returned by List.
+var (
+	ErrInvalidSort   = errors.New("invalid sort order")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// sortTimeLayout formats times as sort keys, which compare as strings.
+const sortTimeLayout = "20060102150405.000000000"
+
+// EntryFilter limits the listed entries to a folder and a tag, given by name, and to
+// titles containing a text, compared case-insensitively. Empty fields do not filter.
+type EntryFilter struct {
+	Folder string
+	Tag    string
+	Title  string
+}
+
+// ListOptions selects, orders and pages the listed entries.
+type ListOptions struct {
+	Filter EntryFilter
+	// Sort is SortTitle, the default, SortUpdated or SortCreated. Entries with the same
+	// title or time are ordered by id.
+	Sort    string
+	Reverse bool
+	// Limit is the maximum number of entries of a page, 0 for all of them.
+	Limit int
+	// Cursor continues the listing after the last entry of a previous page, see ListPage.Next.
+	// It must be used with the same sort order.
+	Cursor string
+}
+
+// ListPage is a page of listed entries.
+type ListPage struct {
+	// Entries have their id and title, and their update and creation times in RFC 3339
+	// format, empty if unknown.
+	Entries []map[string]string
+	// Next is the cursor of the next page, empty on the last one.
+	Next string
+	// Total is the number of entries matching the filter, on all pages.
+	Total int
+}
+
+// listed is an entry being listed, with its sort key.
+type listed struct {
+	row map[string]string
+	key string
+}
+
+// List returns a page of the entries of a table matching the filter, in the given order.
+// Only the columns needed to filter and sort are read, and titles are decrypted only if
+// they are filtered or sorted on, or are on the page.
+func (s *Service) List(ctx context.Context, table string, user_id int, opt ListOptions) (*ListPage, error) {
+	if opt.Sort == "" {
+		opt.Sort = SortTitle
+	}
+	if opt.Sort != SortTitle && opt.Sort != SortUpdated && opt.Sort != SortCreated {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSort, opt.Sort)
+	}
+	t, err := s.keeper.Schema().Table(table)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{"id", records.TitleField, "updated_at"}
+	if t.HasColumn("created_at") {
+		columns = append(columns, "created_at")
+	}
+	if opt.Filter.Folder != "" {
+		columns = append(columns, records.FolderField)
+	}
+	repo, err := s.rows(s.keeper, table)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := repo.ListRows(ctx, user_id, columns...)
+	if err != nil {
+		return nil, err
+	}
+	// Titles are decrypted at most once; decrypted marks the rows whose title is.
+	decrypted := make(map[string]bool)
+	decryptTitle := func(row map[string]string) error {
+		if decrypted[row["id"]] {
+			return nil
+		}
+		title, err := s.decrypt(row[records.TitleField])
+		if err != nil {
+			return err
+		}
+		row[records.TitleField] = title
+		decrypted[row["id"]] = true
+		return nil
+	}
+
+	if rows, err = s.filterRows(ctx, table, user_id, opt.Filter, rows, decryptTitle); err != nil {
+		return nil, err
+	}
+
+	entries := make([]listed, len(rows))
+	for i, row := range rows {
+		entries[i].row = row
+		switch opt.Sort {
+		case SortTitle:
+			if err := decryptTitle(row); err != nil {
+				return nil, err
+			}
+			entries[i].key = strings.ToLower(row[records.TitleField])
+		case SortUpdated:
+			entries[i].key = sortTime(s.entryTime(row["updated_at"]))
+		case SortCreated:
+			entries[i].key = sortTime(s.createdTime(row))
+		}
+	}
+	before := func(a, b listed) bool {
+		if a.key != b.key {
+			return (a.key < b.key) != opt.Reverse
+		}
+		if a.row["id"] == b.row["id"] {
+			return false
+		}
+		return (a.row["id"] < b.row["id"]) != opt.Reverse
+	}
+	sort.Slice(entries, func(i, j int) bool { return before(entries[i], entries[j]) })
+
+	start := 0
+	if opt.Cursor != "" {
+		last, err := decodeCursor(opt.Cursor, opt.Sort)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(entries), func(i int) bool { return before(last, entries[i]) })
+	}
+	end := len(entries)
+	if opt.Limit > 0 && start+opt.Limit < end {
+		end = start + opt.Limit
+	}
+
+	page := &ListPage{Entries: make([]map[string]string, 0, end-start), Total: len(entries)}
+	for _, entry := range entries[start:end] {
+		if err := decryptTitle(entry.row); err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, map[string]string{
+			"id":               entry.row["id"],
+			records.TitleField: entry.row[records.TitleField],
+			"updated_at":       formatTime(s.entryTime(entry.row["updated_at"])),
+			"created_at":       formatTime(s.createdTime(entry.row)),
+		})
+	}
+	if end < len(entries) {
+		page.Next = encodeCursor(entries[end-1], opt.Sort)
+	}
+	return page, nil
+}
+
+// ListEntries returns the id and title of all entries of a table matching the filter,
+// ordered by title.
+func (s *Service) ListEntries(ctx context.Context, table string, user_id int, filter EntryFilter) ([]map[string]string, error) {
+	page, err := s.List(ctx, table, user_id, ListOptions{Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+	return page.Entries, nil
+}
+
+// filterRows returns the rows matching the filter. Folders are compared by the decrypted
+// folder_id column, which must have been read.
+func (s *Service) filterRows(ctx context.Context, table string, user_id int, filter EntryFilter,
+	rows []map[string]string, decryptTitle func(map[string]string) error) ([]map[string]string, error) {
+	if filter.Folder != "" {
+		folder, err := s.findByName(ctx, records.FoldersTable, user_id, filter.Folder)
+		if err != nil {
+			return nil, err
+		}
+		if folder == nil {
+			return nil, fmt.Errorf("%w: %s", ErrFolderNotFound, filter.Folder)
+		}
+		var kept []map[string]string
+		for _, row := range rows {
+			folderID, err := s.decrypt(row[records.FolderField])
+			if err != nil {
+				return nil, err
+			}
+			if folderID == folder["id"] {
+				kept = append(kept, row)
+			}
+		}
+		rows = kept
+	}
+
+	if filter.Tag != "" {
+		tag, err := s.findByName(ctx, records.TagsTable, user_id, filter.Tag)
+		if err != nil {
+			return nil, err
+		}
+		if tag == nil {
+			return nil, fmt.Errorf("%w: %s", ErrTagNotFound, filter.Tag)
+		}
+		links, err := s.tagLinks(ctx, s.keeper, user_id)
+		if err != nil {
+			return nil, err
+		}
+		tagged := make(map[string]bool)
+		for _, link := range links {
+			if link["tag_id"] == tag["id"] && link["table_name"] == table {
+				tagged[link["entry_id"]] = true
+			}
+		}
+		rows = filterEntries(rows, func(row map[string]string) bool {
+			return tagged[row["id"]]
+		})
+	}
+
+	if filter.Title != "" {
+		text := strings.ToLower(filter.Title)
+		var kept []map[string]string
+		for _, row := range rows {
+			if err := decryptTitle(row); err != nil {
+				return nil, err
+			}
+			if strings.Contains(strings.ToLower(row[records.TitleField]), text) {
+				kept = append(kept, row)
+			}
+		}
+		rows = kept
+	}
+	return rows, nil
+}
+
+// filterEntries returns the entries for which keep returns true.
+func filterEntries(entries []map[string]string, keep func(map[string]string) bool) []map[string]string {
+	var kept []map[string]string
+	for _, entry := range entries {
+		if keep(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// entryTime parses a stored update time. Times set by the database are stored in plain
+// text and times set by the client are encrypted. It returns the zero time for values
+// that are neither.
+func (s *Service) entryTime(value string) time.Time {
+	if t, ok := parseTime(value); ok {
+		return t
+	}
+	if plain, err := s.decrypt(value); err == nil {
+		if t, ok := parseTime(plain); ok {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// createdTime returns the creation time of an entry, its update time for entries made
+// before creation times were kept.
+func (s *Service) createdTime(row map[string]string) time.Time {
+	if t, ok := parseTime(row["created_at"]); ok {
+		return t
+	}
+	return s.entryTime(row["updated_at"])
+}
+
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, time.DateTime} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func sortTime(t time.Time) string {
+	return t.UTC().Format(sortTimeLayout)
+}
+
+// encodeCursor returns the cursor continuing a listing after the entry. It holds the sort
+// order, the sort key and the id of the entry.
+func encodeCursor(entry listed, order string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(order + "\x00" + entry.key + "\x00" + entry.row["id"]))
+}
+
+// decodeCursor returns the entry a cursor continues after, checking its sort order.
+func decodeCursor(cursor string, order string) (listed, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return listed{}, ErrInvalidCursor
+	}
+	// The key is a title, which may contain anything
+	first, last := strings.IndexByte(string(data), 0), strings.LastIndexByte(string(data), 0)
+	if first < 0 || first == last || last == len(data)-1 {
+		return listed{}, ErrInvalidCursor
+	}
+	if cursorOrder := string(data[:first]); cursorOrder != order {
+		return listed{}, fmt.Errorf("%w: the cursor is for the %s order", ErrInvalidCursor, cursorOrder)
+	}
+	return listed{row: map[string]string{"id": string(data[last+1:])}, key: string(data[first+1 : last])}, nil
+}
@@ -0,0 +1,74 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+)
+
+// titles returns the titles of the entries.
+func titles(entries []map[string]string) []string {
+	var titles []string
+	for _, entry := range entries {
+		titles = append(titles, entry["meta_info"])
+	}
+	return titles
+}
+
+// listAll pages through a listing and returns the titles of all pages.
+func listAll(t *testing.T, s *services.Service, opt services.ListOptions) []string {
+	var all []string
+	for {
+		page, err := s.List(context.Background(), "TextData", 1, opt)
+		require.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+		all = append(all, titles(page.Entries)...)
+		if page.Next == "" {
+			return all
+		}
+		require.Len(t, page.Entries, opt.Limit)
+		opt.Cursor = page.Next
+	}
+}
+
+func TestService_List(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newService(t)
+
+	for _, title := range []string{"delta", "Alpha", "echo", "charlie", "bravo"} {
+		require.NoError(t, s.AddData(ctx, "TextData", 1, map[string]string{"data": "x", "meta_info": title}))
+	}
+
+	sorted := []string{"Alpha", "bravo", "charlie", "delta", "echo"}
+	assert.Equal(t, sorted, listAll(t, s, services.ListOptions{Limit: 2}))
+	assert.Equal(t, []string{"echo", "delta", "charlie", "bravo", "Alpha"}, listAll(t, s, services.ListOptions{Limit: 3, Reverse: true}))
+
+	// The entry updated last comes last, and first in reverse order
+	entries, err := s.ListEntries(ctx, "TextData", 1, services.EntryFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, sorted, titles(entries))
+	require.NoError(t, s.UpdateData(ctx, "TextData", 1, entries[2]["id"], map[string]string{"updated_at": "2099-01-01T00:00:00Z"}))
+	page, err := s.List(ctx, "TextData", 1, services.ListOptions{Sort: services.SortUpdated, Reverse: true, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"charlie"}, titles(page.Entries))
+	assert.Equal(t, "2099-01-01T00:00:00Z", page.Entries[0]["updated_at"])
+	assert.NotEmpty(t, page.Entries[0]["created_at"])
+	assert.Len(t, listAll(t, s, services.ListOptions{Sort: services.SortCreated, Limit: 2}), 5)
+
+	page, err = s.List(ctx, "TextData", 1, services.ListOptions{Filter: services.EntryFilter{Title: "A"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alpha", "bravo", "charlie", "delta"}, titles(page.Entries))
+	assert.Equal(t, 4, page.Total)
+
+	_, err = s.List(ctx, "TextData", 1, services.ListOptions{Sort: "size"})
+	assert.ErrorIs(t, err, services.ErrInvalidSort)
+	page, err = s.List(ctx, "TextData", 1, services.ListOptions{Limit: 1})
+	require.NoError(t, err)
+	_, err = s.List(ctx, "TextData", 1, services.ListOptions{Sort: services.SortUpdated, Cursor: page.Next})
+	assert.ErrorIs(t, err, services.ErrInvalidCursor)
+	_, err = s.List(ctx, "TextData", 1, services.ListOptions{Cursor: "!"})
+	assert.ErrorIs(t, err, services.ErrInvalidCursor)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/search"
+)
+
+// Search searches the entries of all types. The index is built from the decrypted
+// entries on the first search after the vault is unlocked and is then kept up to
+// date as entries change locally or are synchronized.
+func (s *Service) Search(ctx context.Context, user_id int, query string, opt search.Options) ([]search.Result, error) {
+	ix, err := s.searchIndex(ctx, user_id)
+	if err != nil {
+		return nil, err
+	}
+	return ix.Search(query, opt), nil
+}
+
+// searchIndex returns the search index of the user, building it if needed.
+func (s *Service) searchIndex(ctx context.Context, user_id int) (*search.Index, error) {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	if s.index != nil && s.indexUser == user_id {
+		return s.index, nil
+	}
+
+	ix := search.New()
+	for _, t := range records.All() {
+		columns := []string{"id"}
+		for _, f := range t.Fields {
+			columns = append(columns, f.Name)
+		}
+		entries, err := s.GetAllData(ctx, t.Table, user_id, columns...)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			ix.Add(document(t, entry["id"], entry))
+		}
+	}
+	tags, err := s.entryTagNames(ctx, user_id)
+	if err != nil {
+		return nil, err
+	}
+	ix.SetTags(tags)
+
+	s.index, s.indexUser = ix, user_id
+	return ix, nil
+}
+
+// builtIndex returns the search index if it is built for the user, nil otherwise.
+func (s *Service) builtIndex(user_id int) *search.Index {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	if s.indexUser != user_id {
+		return nil
+	}
+	return s.index
+}
+
+// dropIndex discards the search index, so that it is rebuilt on the next search.
+func (s *Service) dropIndex() {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	s.index = nil
+}
+
+// reindex updates the search index, if it is built, after an entry of a table changed.
+// A change of any tag or tag link refreshes the tags of all entries.
+func (s *Service) reindex(ctx context.Context, table string, user_id int, entry_id string) {
+	ix := s.builtIndex(user_id)
+	if ix == nil {
+		return
+	}
+	if table == records.TagsTable || table == records.EntryTagsTable {
+		tags, err := s.entryTagNames(ctx, user_id)
+		if err != nil {
+			s.logger.Printf("Error refreshing the tags of the search index: %v", err)
+			return
+		}
+		ix.SetTags(tags)
+		return
+	}
+
+	t, ok := records.ByTable(table)
+	if !ok {
+		return
+	}
+	data, err := s.GetData(ctx, table, user_id, entry_id)
+	if errors.Is(err, sql.ErrNoRows) {
+		ix.Remove(table, entry_id)
+		return
+	}
+	if err != nil {
+		s.logger.Printf("Error updating entry %s in the search index: %v", entry_id, err)
+		return
+	}
+	ix.Add(document(t, entry_id, data))
+}
+
+// reindexRows updates the search index after rows of a table were synchronized.
+func (s *Service) reindexRows(ctx context.Context, table string, user_id int, rows []map[string]string) {
+	if table == records.TagsTable || table == records.EntryTagsTable {
+		if len(rows) > 0 {
+			s.reindex(ctx, table, user_id, "")
+		}
+		return
+	}
+	for _, row := range rows {
+		s.reindex(ctx, table, user_id, row["id"])
+	}
+}
+
+// document converts a decrypted entry into a search document. The title is matched
+// separately, sensitive fields and hidden custom fields are marked secret, and other
+// internal fields and links are not searchable.
+func document(t *records.Type, id string, data map[string]string) search.Document {
+	doc := search.Document{Table: t.Table, ID: id, Title: data[records.TitleField]}
+	for _, f := range t.Fields {
+		if f.Name == records.TitleField || f.Internal || f.Ref != "" {
+			continue
+		}
+		doc.Fields = append(doc.Fields, search.Field{Name: f.Label, Value: data[f.Name], Secret: f.Sensitive})
+	}
+	// Custom fields are searched by their own names; hidden ones are secret
+	custom, _ := records.ParseCustomFields(data[records.CustomFieldsField])
+	for _, f := range custom {
+		doc.Fields = append(doc.Fields, search.Field{Name: f.Name, Value: f.Value, Secret: f.Type == records.CustomHidden})
+	}
+	return doc
+}
+
+// entryTagNames returns the sorted tag names of all tagged entries by table and id.
+func (s *Service) entryTagNames(ctx context.Context, user_id int) (map[string]map[string][]string, error) {
+	names, err := s.names(ctx, records.TagsTable, user_id)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.tagLinks(ctx, s.keeper, user_id)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]map[string][]string)
+	for _, link := range links {
+		name, ok := names[link["tag_id"]]
+		if !ok {
+			continue
+		}
+		if tags[link["table_name"]] == nil {
+			tags[link["table_name"]] = make(map[string][]string)
+		}
+		tags[link["table_name"]][link["entry_id"]] = append(tags[link["table_name"]][link["entry_id"]], name)
+	}
+	for _, entries := range tags {
+		for _, names := range entries {
+			sort.Strings(names)
+		}
+	}
+	return tags, nil
+}
@@ -11,26 +11,38 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/wurt83ow/gophkeeper-client/pkg/appcontext"
 	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
 	"github.com/wurt83ow/gophkeeper-client/pkg/config"
 	"github.com/wurt83ow/gophkeeper-client/pkg/encription"
 	"github.com/wurt83ow/gophkeeper-client/pkg/gksync"
 	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/search"
+	"github.com/wurt83ow/gophkeeper-client/pkg/srp"
 	"github.com/wurt83ow/gophkeeper-client/pkg/syncinfo"
 )
 
 // Service provides methods for user registration, login, and data synchronization.
 type Service struct {
-	keeper         *bdkeeper.Keeper
+	keeper         bdkeeper.Storage
 	sync           *gksync.ClientWithResponses
 	sm             *syncinfo.SyncManager
 	enc            *encription.Enc
 	opt            *config.Options
 	syncWithServer bool
 	logger         Logger
+
+	syncing atomic.Int32 // syncing counts the running SyncAllWithServer calls
+
+	searchMu  sync.Mutex
+	index     *search.Index // index is the search index of indexUser, nil until the first search
+	indexUser int
 }
 
 // Logger is an interface for logging messages.
@@ -39,7 +51,7 @@ type Logger interface {
 }
 
 // NewServices creates a new instance of the Service.
-func NewServices(keeper *bdkeeper.Keeper, sync *gksync.ClientWithResponses, sm *syncinfo.SyncManager, enc *encription.Enc,
+func NewServices(keeper bdkeeper.Storage, sync *gksync.ClientWithResponses, sm *syncinfo.SyncManager, enc *encription.Enc,
 	opt *config.Options, syncWithServer bool, logger Logger) *Service {
 	return &Service{
 		keeper:         keeper,
@@ -52,6 +64,35 @@ func NewServices(keeper *bdkeeper.Keeper, sync *gksync.ClientWithResponses, sm *
 	}
 }
 
+// repositories returns the typed repositories of the built-in record types by table.
+var repositories = map[string]func(bdkeeper.Storage) (bdkeeper.Rows, error){
+	records.CredentialsTable: repository[models.Credentials],
+	records.TextTable:        repository[models.TextData],
+	records.FilesTable:       repository[models.FileData],
+	records.CardsTable:       repository[models.CreditCard],
+	records.TOTPTable:        repository[models.TOTP],
+	records.SSHKeysTable:     repository[models.SSHKey],
+}
+
+// repository returns the typed repository of the model T in the storage.
+func repository[T bdkeeper.Model](st bdkeeper.Storage) (bdkeeper.Rows, error) {
+	r, err := bdkeeper.NewRepository[T](st)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// rows returns the access to the records of a table in the storage st, which may be a
+// transaction: the typed repository of a built-in record type, or the table of the
+// storage for folders, tags and other registered types.
+func (s *Service) rows(st bdkeeper.Storage, table string) (bdkeeper.Rows, error) {
+	if repo, ok := repositories[table]; ok {
+		return repo(st)
+	}
+	return bdkeeper.TableRows(st, table), nil
+}
+
 // Register registers a new user with the provided username and password.
 func (s *Service) Register(ctx context.Context, username string, password string) error {
 	// Check if the user exists in the database
@@ -74,22 +115,32 @@ func (s *Service) Register(ctx context.Context, username string, password string
 		}
 	}
 
-	// Hash the password
+	// Hash the password for offline logins. The hash never leaves this machine
+	// and is not accepted by the server as a credential.
 	hashedPassword, err := s.enc.HashPassword(password)
 	if err != nil {
 		return err
 	}
 
-	// Save the new user on the server
+	// Save the new user on the server. The server only receives the SRP salt
+	// and verifier, from which the password cannot be used to log in.
 	if s.syncWithServer {
+		salt, verifier, err := srp.NewVerifier(username, password)
+		if err != nil {
+			return err
+		}
 		body := gksync.PostRegisterJSONRequestBody{
 			Username: username,
-			Password: hashedPassword,
+			Salt:     srp.Encode(salt),
+			Verifier: srp.Encode(verifier),
 		}
-		_, err = s.sync.PostRegister(ctx, body)
+		resp, err := s.sync.PostRegisterWithResponse(ctx, body)
 		if err != nil {
 			return err
 		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("registration rejected by server: %s", resp.Status())
+		}
 	}
 
 	// Save the new user in the database
@@ -102,50 +153,246 @@ func (s *Service) Register(ctx context.Context, username string, password string
 }
 
 // Login authenticates the user with the provided username and password and returns the user ID and token.
+// When synchronization is enabled the password is proven to the server with SRP-6a,
+// so neither the password nor anything derived from it can be replayed.
 func (s *Service) Login(ctx context.Context, username string, password string) (int, string, error) {
-	var userID int
-	var token string
-	var err error
-
-	// Attempt to get the hashed password of the user from the local database
+	// Check the password against the local hash, if the user is known locally
 	hashedPassword, err := s.keeper.GetPassword(ctx, username)
+	localUser := err == nil
+	if localUser && !s.enc.CompareHashAndPassword(hashedPassword, password) {
+		return 0, "", errors.New("Invalid password")
+	}
+
+	if !s.syncWithServer {
+		// If syncWithServer=false, only get the userID from keeper
+		userID, err := s.keeper.GetUserID(ctx, username)
+		if err != nil {
+			return 0, "", errors.New("Invalid userId")
+		}
+		return userID, "", nil
+	}
+
+	userID, token, err := s.srpLogin(ctx, username, password)
 	if err != nil {
-		// If an error occurs, use the original password
-		hashedPassword = password
-	} else {
-		// Compare the hashed password with the hash of the entered password
-		if !s.enc.CompareHashAndPassword(hashedPassword, password) {
-			return 0, "", errors.New("Invalid password")
+		return 0, "", err
+	}
+
+	// Remember the user locally so that offline logins work on this machine
+	if !localUser {
+		hashedPassword, err = s.enc.HashPassword(password)
+		if err == nil {
+			err = s.keeper.AddUser(ctx, username, hashedPassword)
+		}
+		if err != nil {
+			s.logger.Printf("Error saving user locally: %v", err)
 		}
 	}
 
+	// Return the user ID and token
+	return userID, token, nil
+}
+
+// srpLogin performs the SRP-6a exchange with the server and returns the user ID and token.
+func (s *Service) srpLogin(ctx context.Context, username string, password string) (int, string, error) {
+	client, err := srp.NewClient(username, password)
+	if err != nil {
+		return 0, "", err
+	}
+
+	initResp, err := s.sync.PostLoginInitWithResponse(ctx, gksync.PostLoginInitJSONRequestBody{
+		Username: username,
+		A:        srp.Encode(client.PublicKey()),
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	init := initResp.JSON200
+	if init == nil || init.SessionID == nil || init.Salt == nil || init.B == nil {
+		return 0, "", fmt.Errorf("Unauthorized")
+	}
+
+	salt, err := srp.Decode(*init.Salt)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid salt from server: %w", err)
+	}
+	serverKey, err := srp.Decode(*init.B)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid public key from server: %w", err)
+	}
+	proof, err := client.ComputeProof(salt, serverKey)
+	if err != nil {
+		return 0, "", err
+	}
+
+	verifyResp, err := s.sync.PostLoginVerifyWithResponse(ctx, gksync.PostLoginVerifyJSONRequestBody{
+		SessionID: *init.SessionID,
+		M1:        srp.Encode(proof),
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	verify := verifyResp.JSON200
+	if verify == nil || verify.M2 == nil || verify.Token == nil || verify.UserID == nil {
+		return 0, "", fmt.Errorf("Unauthorized")
+	}
+
+	// Make sure we are talking to a server that knows our verifier
+	serverProof, err := srp.Decode(*verify.M2)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid server proof: %w", err)
+	}
+	if err := client.VerifyServer(serverProof); err != nil {
+		return 0, "", err
+	}
+
+	return *verify.UserID, *verify.Token, nil
+}
+
+// ErrSyncDisabled is returned by operations that require the server when synchronization is disabled.
+var ErrSyncDisabled = errors.New("synchronization with the server is disabled")
+
+// ChangePassword changes the login password of the current user both on the server and in the local database.
+// The current password is verified by logging in again before anything is changed.
+func (s *Service) ChangePassword(ctx context.Context, userID int, username string, oldPassword string, newPassword string) error {
+	token, err := s.reauthenticate(ctx, userID, username, oldPassword)
+	if err != nil {
+		return err
+	}
+
+	// Replace the SRP verifier on the server
 	if s.syncWithServer {
-		// If syncWithServer=true, get the userID and jwtToken from the server
-		body := gksync.PostLoginJSONRequestBody{
-			Username: username,
-			Password: hashedPassword,
+		salt, verifier, err := srp.NewVerifier(username, newPassword)
+		if err != nil {
+			return err
 		}
-		resp, err := s.sync.PostLoginWithResponse(ctx, body)
+		body := gksync.PutAccountPasswordJSONRequestBody{
+			Salt:     srp.Encode(salt),
+			Verifier: srp.Encode(verifier),
+		}
+		resp, err := s.sync.PutAccountPasswordWithResponse(appcontext.WithJWTToken(ctx, token), body)
 		if err != nil {
-			return 0, "", err
+			return err
 		}
-		// Check if resp is nil
-		if resp.JSON200 == nil {
-			return 0, "", fmt.Errorf("Unauthorized")
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("password change rejected by server: %s", resp.Status())
 		}
+	}
 
-		userID = *resp.JSON200.UserID
-		token = string(*resp.JSON200.Token)
-	} else {
-		// If syncWithServer=false, only get the userID from keeper
-		userID, err = s.keeper.GetUserID(ctx, username)
+	// Replace the local hash
+	hashedPassword, err := s.enc.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.keeper.UpdatePassword(ctx, username, hashedPassword)
+}
+
+// DeleteAccount deletes the account of the current user on the server and wipes the local vault.
+// The password is verified by logging in again before anything is deleted.
+func (s *Service) DeleteAccount(ctx context.Context, userID int, username string, password string) error {
+	token, err := s.reauthenticate(ctx, userID, username, password)
+	if err != nil {
+		return err
+	}
+
+	// Remove the account on the server
+	if s.syncWithServer {
+		resp, err := s.sync.DeleteAccountWithResponse(appcontext.WithJWTToken(ctx, token))
 		if err != nil {
-			return 0, "", errors.New("Invalid userId")
+			return err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("account deletion rejected by server: %s", resp.Status())
 		}
 	}
 
-	// Return the user ID and token
-	return userID, token, nil
+	return s.WipeLocalVault(ctx, userID, username)
+}
+
+// WipeLocalVault removes all local data, pending synchronization entries and files of the user,
+// and the user itself, from this machine.
+func (s *Service) WipeLocalVault(ctx context.Context, userID int, username string) error {
+	err := s.keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		for _, table := range records.SyncTables() {
+			if err := tx.ClearData(ctx, table, userID); err != nil {
+				return fmt.Errorf("failed to clear table %s: %w", table, err)
+			}
+		}
+		if err := tx.ClearSyncEntries(ctx, userID); err != nil {
+			return fmt.Errorf("failed to clear sync queue: %w", err)
+		}
+		if err := tx.ClearVersions(ctx, userID); err != nil {
+			return fmt.Errorf("failed to clear history: %w", err)
+		}
+		if err := tx.DeleteUser(ctx, username); err != nil {
+			return fmt.Errorf("failed to delete local user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.dropIndex()
+	if err := s.DeleteAllLocalFiles(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete local files: %w", err)
+	}
+	return nil
+}
+
+// GetSessions returns the active server sessions of the current user.
+func (s *Service) GetSessions(ctx context.Context) ([]gksync.Session, error) {
+	if !s.syncWithServer {
+		return nil, ErrSyncDisabled
+	}
+	resp, err := s.sync.GetAccountSessionsWithResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.JSON200 == nil {
+		return nil, fmt.Errorf("failed to get sessions: %s", resp.Status())
+	}
+	return *resp.JSON200, nil
+}
+
+// RevokeSession revokes a single server session of the current user.
+func (s *Service) RevokeSession(ctx context.Context, sessionID string) error {
+	if !s.syncWithServer {
+		return ErrSyncDisabled
+	}
+	resp, err := s.sync.DeleteAccountSessionsSessionIDWithResponse(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("failed to revoke session: %s", resp.Status())
+	}
+	return nil
+}
+
+// RevokeOtherSessions signs the current user out everywhere except in the current session.
+func (s *Service) RevokeOtherSessions(ctx context.Context) error {
+	if !s.syncWithServer {
+		return ErrSyncDisabled
+	}
+	resp, err := s.sync.DeleteAccountSessionsWithResponse(ctx)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("failed to revoke sessions: %s", resp.Status())
+	}
+	return nil
+}
+
+// reauthenticate verifies the credentials of the current user and returns a fresh token.
+func (s *Service) reauthenticate(ctx context.Context, userID int, username string, password string) (string, error) {
+	loggedUserID, token, err := s.Login(ctx, username, password)
+	if err != nil {
+		return "", err
+	}
+	if loggedUserID != userID {
+		return "", errors.New("credentials do not belong to the current user")
+	}
+	return token, nil
 }
 
 // SyncFile synchronizes a file with the server.
@@ -175,9 +422,6 @@ func (s *Service) SyncAllData(ctx context.Context, userID int, update bool) erro
 		return nil
 	}
 
-	// List of all data tables
-	tables := []string{"UserCredentials", "CreditCardData", "TextData", "FilesData"}
-
 	var lastSync time.Time
 	if update {
 
@@ -185,74 +429,43 @@ func (s *Service) SyncAllData(ctx context.Context, userID int, update bool) erro
 		var err error
 		lastSync, err = s.sm.LoadAndUpdateLastSyncFromFile()
 		if err != nil {
-			s.logger.Printf("lastSync", err)
-			fmt.Printf("Error loading and updating lastSync: %v\n", err)
+			s.logger.Printf("Error loading and updating lastSync: %v", err)
 		}
 	}
 
 	// Iterate over each table
-	for _, table := range tables {
+	var failed []error
+	for _, table := range records.SyncTables() {
 		// Get all data from the table on the server
 		resp, err := s.sync.GetGetAllDataTableUserIDWithResponse(ctx, table, userID, lastSync)
-
+		if err == nil && (resp.StatusCode() != http.StatusOK || resp.JSON200 == nil) {
+			err = fmt.Errorf("server returned status %d", resp.StatusCode())
+		}
 		if err != nil {
 			s.logger.Printf("Error getting data from table %s: %v", table, err)
+			failed = append(failed, fmt.Errorf("%s: %w", table, err))
+			continue
 		}
 
-		if resp == nil || resp.JSON200 == nil {
+		// Apply the page all-or-nothing, so that a failure leaves the table as it was
+		err = s.keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+			return s.applyServerRows(ctx, tx, table, userID, *resp.JSON200, update)
+		})
+		if err != nil {
+			s.logger.Printf("Error applying data from table %s: %v", table, err)
+			failed = append(failed, fmt.Errorf("%s: %w", table, err))
 			continue
 		}
-
-		if !update {
-			// Clear the corresponding table in the local database
-			err = s.keeper.ClearData(ctx, table, userID)
-			if err != nil {
-				s.logger.Printf("Error clearing table %s: %v", table, err)
-			}
+		if update {
+			s.reindexRows(ctx, table, userID, *resp.JSON200)
+		} else {
+			s.dropIndex()
 		}
+	}
 
-		// Add all retrieved data to the local database
-		for _, row := range *resp.JSON200 {
-			if update {
-				// Check if the row was deleted
-				deleted, _ := strconv.ParseBool(row["deleted"])
-				updatedAt, _ := time.Parse(time.RFC3339, row["updated_at"])
-
-				// Get data from the local database
-				localData, err := s.keeper.GetData(ctx, table, userID, row["id"])
-				if err != nil {
-					// If data does not exist, add a new row
-					err = s.keeper.AddData(ctx, table, userID, row["id"], row)
-					if err != nil {
-						s.logger.Printf("Error adding data to table %s: %v", table, err)
-					}
-				} else {
-					localUpdatedAt, _ := time.Parse(time.RFC3339, localData["updated_at"])
-					// If data exists and differs, and updatedAt in row is greater than in localData, update it
-					if !mapsEqual(localData, row) && !updatedAt.Before(localUpdatedAt) {
-						if deleted {
-							// If the row was deleted
-							err = s.keeper.DeleteData(ctx, table, userID, row["id"])
-							if err != nil {
-								s.logger.Printf("Error deleting data from table %s: %v", table, err)
-							}
-						} else {
-							err = s.keeper.UpdateData(ctx, table, userID, row["id"], row)
-							if err != nil {
-								s.logger.Printf("Error updating data in table %s: %v", table, err)
-							}
-						}
-					} else {
-						s.logger.Printf("Data in table %s was not updated because the update time is less than or equal to the last synchronization time", table)
-					}
-				}
-			} else {
-				err = s.keeper.AddData(ctx, table, userID, row["id"], row)
-				if err != nil {
-					s.logger.Printf("Error adding data to table %s: %v", table, err)
-				}
-			}
-		}
+	// Keep the last synchronization time if a table failed, so it is pulled again
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to synchronize, the changes are pulled again next time: %w", errors.Join(failed...))
 	}
 
 	// Create new synchronization information
@@ -263,20 +476,98 @@ func (s *Service) SyncAllData(ctx context.Context, userID int, update bool) erro
 	// Update and save synchronization information
 	err := s.sm.UpdateAndSaveSyncInfo(info)
 	if err != nil {
-		// Handle the error
-		fmt.Println("Error updating and saving synchronization information:", err)
+		s.logger.Printf("Error updating and saving synchronization information: %v", err)
 	}
 
 	return nil
 }
 
-// mapsEqual checks if two cards are equal
-func mapsEqual(a, b map[string]string) bool {
-	if len(a) != len(b) {
-		return false
+// applyServerRows stores the rows pulled from the server for a table. Without update the
+// local table is replaced, otherwise rows are added, updated or deleted depending on their
+// update time.
+func (s *Service) applyServerRows(ctx context.Context, tx bdkeeper.Storage, table string, userID int, rows []map[string]string, update bool) error {
+	repo, err := s.rows(tx, table)
+	if err != nil {
+		return err
+	}
+	if !update {
+		// Clear the corresponding table in the local database
+		if err := tx.ClearData(ctx, table, userID); err != nil {
+			return fmt.Errorf("failed to clear table: %w", err)
+		}
 	}
-	for k, v := range a {
-		if b[k] != v {
+
+	// Add all retrieved data to the local database
+	for _, row := range rows {
+		if !update {
+			if err := repo.AddRow(ctx, userID, row["id"], row); err != nil {
+				return fmt.Errorf("failed to add entry %s: %w", row["id"], err)
+			}
+			continue
+		}
+
+		// Check if the row was deleted
+		deleted, _ := strconv.ParseBool(row["deleted"])
+		updatedAt, _ := time.Parse(time.RFC3339, row["updated_at"])
+
+		// Get data from the local database
+		localData, err := repo.GetRow(ctx, userID, row["id"])
+		if err != nil {
+			// If data does not exist, add a new row
+			if err := repo.AddRow(ctx, userID, row["id"], row); err != nil {
+				return fmt.Errorf("failed to add entry %s: %w", row["id"], err)
+			}
+			continue
+		}
+
+		localUpdatedAt, _ := time.Parse(time.RFC3339, localData["updated_at"])
+		// If data exists and differs, and updatedAt in row is greater than in localData, update it
+		if updatedAt.Before(localUpdatedAt) {
+			s.logger.Printf("Data in table %s was not updated because the update time is less than or equal to the last synchronization time", table)
+			continue
+		}
+		if !deleted && s.sameValues(localData, row) {
+			// Pulling an entry again must not push its real versions out of the history
+			continue
+		}
+		if deleted {
+			// If the row was deleted
+			if err := repo.Delete(ctx, userID, row["id"]); err != nil {
+				return fmt.Errorf("failed to delete entry %s: %w", row["id"], err)
+			}
+			if err := tx.DeleteVersions(ctx, table, userID, row["id"]); err != nil {
+				return fmt.Errorf("failed to delete the history of entry %s: %w", row["id"], err)
+			}
+			continue
+		}
+		if err := s.archive(ctx, tx, table, userID, row["id"], nil); err != nil {
+			return fmt.Errorf("failed to keep the previous version of entry %s: %w", row["id"], err)
+		}
+		if err := repo.UpdateRow(ctx, userID, row["id"], row); err != nil {
+			return fmt.Errorf("failed to update entry %s: %w", row["id"], err)
+		}
+	}
+	return nil
+}
+
+// sameValues reports whether a row pulled from the server holds the values of the stored
+// row. Values are compared decrypted, as encrypting the same value twice gives different
+// ciphertexts; the id, owner and timestamps are ignored.
+func (s *Service) sameValues(stored, row map[string]string) bool {
+	for key, value := range row {
+		switch key {
+		case "id", "user_id", "deleted", "created_at", "updated_at":
+			continue
+		}
+		if stored[key] == value {
+			continue
+		}
+		old, err := s.decrypt(stored[key])
+		if err != nil {
+			return false
+		}
+		value, err = s.decrypt(value)
+		if err != nil || old != value {
 			return false
 		}
 	}
@@ -290,6 +581,9 @@ func (s *Service) GetSyncEntriesByStatus(ctx context.Context, status string) ([]
 
 // SyncAllWithServer synchronizes all pending data entries with the server.
 func (s *Service) SyncAllWithServer(ctx context.Context) {
+	s.syncing.Add(1)
+	defer s.syncing.Add(-1)
+
 	// Get all entries from the sync table with status "Pending"
 	entries, err := s.GetSyncEntriesByStatus(ctx, "Pending")
 	if err != nil {
@@ -363,7 +657,7 @@ func (s *Service) sendData(ctx context.Context, entry models.SyncQueue) error {
 	bodyReader := bytes.NewReader([]byte(entry.Data))
 	switch entry.Operation {
 	case "Create":
-		if entry.TableName == "FilesData" {
+		if t, ok := records.ByTable(entry.TableName); ok && t.Binary {
 			var data map[string]string
 			if err := json.Unmarshal([]byte(entry.Data), &data); err != nil {
 				return err
@@ -399,12 +693,36 @@ func (s *Service) sendData(ctx context.Context, entry models.SyncQueue) error {
 
 // AddData adds data to the specified table for the user and initiates synchronization if enabled.
 func (s *Service) AddData(ctx context.Context, table string, user_id int, data map[string]string) error {
+	_, err := s.Add(ctx, table, user_id, data)
+	return err
+}
+
+// Add adds data to the specified table for the user like AddData and returns the id of
+// the new entry.
+func (s *Service) Add(ctx context.Context, table string, user_id int, data map[string]string) (string, error) {
 	entry_id, err := s.GenerateUUID(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
+	data = s.derive(table, data, nil, time.Now())
 
-	// Encrypt each value in the data before saving it
+	// Store the entry and its sync queue entry atomically
+	err = s.keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		return s.addEntry(ctx, tx, table, user_id, entry_id, data)
+	})
+	if err != nil {
+		return "", err
+	}
+	s.reindex(ctx, table, user_id, entry_id)
+	if s.syncWithServer {
+		go s.SyncAllWithServer(ctx)
+	}
+	return entry_id, nil
+}
+
+// addEntry encrypts each value of the data and stores it as a new entry, with its sync
+// queue entry if synchronization is enabled.
+func (s *Service) addEntry(ctx context.Context, tx bdkeeper.Storage, table string, user_id int, entry_id string, data map[string]string) error {
 	encryptedData := make(map[string]string)
 	for key, value := range data {
 		encryptedValue, err := s.enc.Encrypt(value)
@@ -413,27 +731,33 @@ func (s *Service) AddData(ctx context.Context, table string, user_id int, data m
 		}
 		encryptedData[key] = encryptedValue
 	}
-
-	err = s.keeper.AddData(ctx, table, user_id, entry_id, encryptedData)
-	if s.syncWithServer && err == nil {
-		err = s.keeper.CreateSyncEntry(ctx, "Create", table, user_id, entry_id, encryptedData)
-		if err == nil {
-			go s.SyncAllWithServer(ctx)
-		}
+	repo, err := s.rows(tx, table)
+	if err != nil {
+		return err
 	}
-	return err
+	if err := repo.AddRow(ctx, user_id, entry_id, encryptedData); err != nil {
+		return err
+	}
+	if s.syncWithServer {
+		return tx.CreateSyncEntry(ctx, "Create", table, user_id, entry_id, encryptedData)
+	}
+	return nil
 }
 
 // GetData retrieves data from the specified table for the user.
 func (s *Service) GetData(ctx context.Context, table string, user_id int, entry_id string) (map[string]string, error) {
-	data, err := s.keeper.GetData(ctx, table, user_id, entry_id)
+	repo, err := s.rows(s.keeper, table)
+	if err != nil {
+		return nil, err
+	}
+	data, err := repo.GetRow(ctx, user_id, entry_id)
 	if err != nil {
 		return nil, err
 	}
 
 	// Decrypt the data before returning it
 	for key, value := range data {
-		decryptedValue, err := s.enc.Decrypt(value)
+		decryptedValue, err := s.decrypt(value)
 		if err != nil {
 			return nil, err
 		}
@@ -443,8 +767,24 @@ func (s *Service) GetData(ctx context.Context, table string, user_id int, entry_
 	return data, nil
 }
 
+// decrypt decrypts a stored value. Empty values are left as is: they come from
+// columns added by later migrations to already existing records.
+func (s *Service) decrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return s.enc.Decrypt(value)
+}
+
 // UpdateData updates data in the specified table for the user and initiates synchronization if enabled.
 func (s *Service) UpdateData(ctx context.Context, table string, user_id int, entry_id string, data map[string]string) error {
+	var old map[string]string
+	if _, ok := data["password"]; ok {
+		// The time the password was changed is kept only if it changes
+		old, _ = s.GetData(ctx, table, user_id, entry_id)
+	}
+	data = s.derive(table, data, old, time.Now())
+
 	encryptedData := make(map[string]string)
 	for key, value := range data {
 		encryptedValue, err := s.enc.Encrypt(value)
@@ -454,49 +794,98 @@ func (s *Service) UpdateData(ctx context.Context, table string, user_id int, ent
 		encryptedData[key] = encryptedValue
 	}
 
-	err := s.keeper.UpdateData(ctx, table, user_id, entry_id, encryptedData)
-	if s.syncWithServer && err == nil {
-		err = s.keeper.CreateSyncEntry(ctx, "Update", table, user_id, entry_id, encryptedData)
-		if err == nil {
-			go s.SyncAllWithServer(ctx)
+	// Keep the previous version, then store the change and its sync queue entry atomically
+	err := s.keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		if err := s.archive(ctx, tx, table, user_id, entry_id, data); err != nil {
+			return fmt.Errorf("failed to keep the previous version: %w", err)
+		}
+		repo, err := s.rows(tx, table)
+		if err != nil {
+			return err
+		}
+		if err := repo.UpdateRow(ctx, user_id, entry_id, encryptedData); err != nil {
+			return err
+		}
+		if s.syncWithServer {
+			return tx.CreateSyncEntry(ctx, "Update", table, user_id, entry_id, encryptedData)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	return err
+	s.reindex(ctx, table, user_id, entry_id)
+	if s.syncWithServer {
+		go s.SyncAllWithServer(ctx)
+	}
+	return nil
 }
 
 // DeleteData deletes data from the specified table for the user and initiates synchronization if enabled.
 func (s *Service) DeleteData(ctx context.Context, table string, user_id int, entry_id string) error {
-	err := s.keeper.DeleteData(ctx, table, user_id, entry_id)
-	if s.syncWithServer && err == nil {
-		data := map[string]string{"id": entry_id}
-		err = s.keeper.CreateSyncEntry(ctx, "Delete", table, user_id, entry_id, data)
-		if err == nil {
-			go s.SyncAllWithServer(ctx)
+	// Delete the entry and store its sync queue entry atomically
+	err := s.keeper.WithTx(ctx, func(tx bdkeeper.Storage) error {
+		repo, err := s.rows(tx, table)
+		if err != nil {
+			return err
+		}
+		if err := repo.Delete(ctx, user_id, entry_id); err != nil {
+			return err
+		}
+		if err := tx.DeleteVersions(ctx, table, user_id, entry_id); err != nil {
+			return err
+		}
+		if _, ok := records.ByTable(table); ok {
+			if err := s.unlinkTags(ctx, tx, table, user_id, entry_id); err != nil {
+				return err
+			}
 		}
+		if s.syncWithServer {
+			data := map[string]string{"id": entry_id}
+			return tx.CreateSyncEntry(ctx, "Delete", table, user_id, entry_id, data)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	return err
+	s.reindex(ctx, table, user_id, entry_id)
+	if s.syncWithServer {
+		go s.SyncAllWithServer(ctx)
+	}
+	return nil
 }
 
 // GetAllData retrieves all data from the specified table for the user and decrypts it before returning.
 func (s *Service) GetAllData(ctx context.Context, table string, user_id int, columns ...string) ([]map[string]string, error) {
-	data, err := s.keeper.GetAllData(ctx, table, user_id, columns...)
+	repo, err := s.rows(s.keeper, table)
 	if err != nil {
 		return nil, err
 	}
+	data, err := repo.ListRows(ctx, user_id, columns...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decryptAll(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
 
+// decryptAll decrypts the values of the given rows in place, except their ids.
+func (s *Service) decryptAll(data []map[string]string) error {
 	for i, item := range data {
 		for key, value := range item {
 			if key != "id" {
-				decryptedValue, err := s.enc.Decrypt(value)
+				decryptedValue, err := s.decrypt(value)
 				if err != nil {
-					return nil, err
+					return err
 				}
 				data[i][key] = decryptedValue
 			}
 		}
 	}
-
-	return data, nil
+	return nil
 }
 
 // RetrieveFile retrieves a file from the server and saves it locally.
@@ -534,6 +923,21 @@ func (s *Service) ClearLocalData(ctx context.Context, table string, user_id int)
 	return s.keeper.ClearData(ctx, table, user_id)
 }
 
+// MigrationStatus returns the state of the migrations of the local database.
+func (s *Service) MigrationStatus(ctx context.Context) ([]bdkeeper.MigrationStatus, error) {
+	return s.keeper.MigrationStatus(ctx)
+}
+
+// MigrateUp applies all pending migrations of the local database.
+func (s *Service) MigrateUp(ctx context.Context) ([]*bdkeeper.Migration, error) {
+	return s.keeper.MigrateUp(ctx)
+}
+
+// MigrateDown rolls back the given number of migrations of the local database.
+func (s *Service) MigrateDown(ctx context.Context, steps int) ([]*bdkeeper.Migration, error) {
+	return s.keeper.MigrateDown(ctx, steps)
+}
+
 // DeleteAllLocalFiles deletes all files stored locally.
 func (s *Service) DeleteAllLocalFiles() error {
 	files, err := os.ReadDir(s.opt.FileStoragePath)
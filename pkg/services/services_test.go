@@ -0,0 +1,221 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wurt83ow/gophkeeper-client/pkg/appcontext"
+	"github.com/wurt83ow/gophkeeper-client/pkg/bdkeeper"
+	"github.com/wurt83ow/gophkeeper-client/pkg/config"
+	"github.com/wurt83ow/gophkeeper-client/pkg/encription"
+	"github.com/wurt83ow/gophkeeper-client/pkg/gksync"
+	"github.com/wurt83ow/gophkeeper-client/pkg/models"
+	"github.com/wurt83ow/gophkeeper-client/pkg/records"
+	"github.com/wurt83ow/gophkeeper-client/pkg/search"
+	"github.com/wurt83ow/gophkeeper-client/pkg/services"
+	"github.com/wurt83ow/gophkeeper-client/pkg/syncinfo"
+)
+
+// newService returns a Service backed by an in-memory storage without server synchronization.
+func newService(t *testing.T) (*services.Service, bdkeeper.Storage, *config.Options) {
+	store := bdkeeper.NewMemoryStorage()
+	sm := syncinfo.NewSyncManager(filepath.Join(t.TempDir(), "sync.json"))
+	enc := encription.NewEnc("0123456789abcdef0123456789abcdef")
+	opt := &config.Options{HistoryRetention: 5, FileStoragePath: t.TempDir()}
+	return services.NewServices(store, nil, sm, enc, opt, false, log.New(io.Discard, "", 0)), store, opt
+}
+
+// onlyID returns the id of the single entry of a table.
+func onlyID(t *testing.T, s *services.Service, table string) string {
+	rows, err := s.GetAllData(context.Background(), table, 1, "id")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	return rows[0]["id"]
+}
+
+func TestService_Data(t *testing.T) {
+	ctx := context.Background()
+	s, store, _ := newService(t)
+
+	require.NoError(t, s.AddData(ctx, "TextData", 1, map[string]string{"data": "secret", "meta_info": "note"}))
+	id := onlyID(t, s, "TextData")
+
+	stored, err := store.GetData(ctx, "TextData", 1, id)
+	require.NoError(t, err)
+	assert.NotEqual(t, "secret", stored["data"], "values must be stored encrypted")
+
+	data, err := s.GetData(ctx, "TextData", 1, id)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", data["data"])
+
+	require.NoError(t, s.UpdateData(ctx, "TextData", 1, id, map[string]string{"data": "changed"}))
+	history, err := s.GetHistory(ctx, "TextData", 1, id)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "secret", history[0].Data["data"])
+
+	require.NoError(t, s.Rollback(ctx, "TextData", 1, id, history[0].Version))
+	data, err = s.GetData(ctx, "TextData", 1, id)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", data["data"])
+
+	require.NoError(t, s.DeleteData(ctx, "TextData", 1, id))
+	rows, err := s.GetAllData(ctx, "TextData", 1, "id")
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+
+	id, err = s.Add(ctx, "TextData", 1, map[string]string{"data": "new", "meta_info": "note"})
+	require.NoError(t, err)
+	assert.Equal(t, id, onlyID(t, s, "TextData"))
+}
+
+func TestService_FoldersAndTags(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newService(t)
+
+	require.NoError(t, s.AddFolder(ctx, 1, "Work"))
+	assert.ErrorIs(t, s.AddFolder(ctx, 1, "work"), services.ErrFolderExists)
+	folders, err := s.GetAllData(ctx, "Folders", 1, "id")
+	require.NoError(t, err)
+	require.Len(t, folders, 1)
+	folderID, err := s.FolderID(ctx, 1, "WORK")
+	require.NoError(t, err)
+	assert.Equal(t, folders[0]["id"], folderID)
+	_, err = s.FolderID(ctx, 1, "Home")
+	assert.ErrorIs(t, err, services.ErrFolderNotFound)
+
+	require.NoError(t, s.AddData(ctx, "TextData", 1, map[string]string{"data": "a", "folder_id": folders[0]["id"]}))
+	id := onlyID(t, s, "TextData")
+	require.NoError(t, s.TagEntry(ctx, "TextData", 1, id, "urgent"))
+
+	rows, err := s.ListEntries(ctx, "TextData", 1, services.EntryFilter{Folder: "Work", Tag: "urgent"})
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+
+	tags, err := s.EntryTags(ctx, "TextData", 1, id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"urgent"}, tags)
+
+	moved, err := s.DeleteFolder(ctx, 1, "Work")
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	_, err = s.ListEntries(ctx, "TextData", 1, services.EntryFilter{Folder: "Work"})
+	assert.ErrorIs(t, err, services.ErrFolderNotFound)
+
+	require.NoError(t, s.UntagEntry(ctx, "TextData", 1, id, "urgent"))
+	counts, err := s.TagCounts(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, counts["urgent"])
+}
+
+func TestService_Search(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newService(t)
+
+	require.NoError(t, s.AddData(ctx, "UserCredentials", 1, map[string]string{"login": "alice", "password": "hunter2", "meta_info": "Mail account"}))
+	results, err := s.Search(ctx, 1, "mail", search.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Mail account", results[0].Title)
+
+	results, err = s.Search(ctx, 1, "hunter2", search.Options{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	require.NoError(t, s.AddData(ctx, "TextData", 1, map[string]string{"data": "mailbox settings"}))
+	results, err = s.Search(ctx, 1, "mail", search.Options{})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestService_SyncAllDataFailure(t *testing.T) {
+	ctx := appcontext.WithJWTToken(context.Background(), "token")
+	failing := records.CredentialsTable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/"+failing+"/") {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+	sync, err := gksync.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	syncFile := filepath.Join(t.TempDir(), "sync.json")
+	sm := syncinfo.NewSyncManager(syncFile)
+	enc := encription.NewEnc("0123456789abcdef0123456789abcdef")
+	opt := &config.Options{HistoryRetention: 5, FileStoragePath: t.TempDir()}
+	s := services.NewServices(bdkeeper.NewMemoryStorage(), sync, sm, enc, opt, true, log.New(io.Discard, "", 0))
+
+	// A table the server fails on keeps the last synchronization time, to be pulled again
+	err = s.SyncAllData(ctx, 1, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), failing)
+	data, err := os.ReadFile(syncFile)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	failing = "none"
+	require.NoError(t, s.SyncAllData(ctx, 1, true))
+	data, err = os.ReadFile(syncFile)
+	require.NoError(t, err)
+	assert.NotEmpty(t, data)
+}
+
+func TestService_SyncAllData_KeepsHistoryOfRepeatedPulls(t *testing.T) {
+	ctx := appcontext.WithJWTToken(context.Background(), "token")
+	enc := encription.NewEnc("0123456789abcdef0123456789abcdef")
+	text, updatedAt := "hello", "2026-01-02T10:00:00Z"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(r.URL.Path, "/"+records.TextTable+"/") {
+			_, _ = w.Write([]byte("[]"))
+			return
+		}
+		// Values are encrypted anew on every pull, as they would be after a round trip
+		data, err := enc.Encrypt(text)
+		require.NoError(t, err)
+		meta, err := enc.Encrypt("note")
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode([]map[string]string{{
+			"id": "n1", "user_id": "1", "meta_info": meta, "data": data, "updated_at": updatedAt,
+		}}))
+	}))
+	defer srv.Close()
+	sync, err := gksync.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	sm := syncinfo.NewSyncManager(filepath.Join(t.TempDir(), "sync.json"))
+	opt := &config.Options{HistoryRetention: 5, FileStoragePath: t.TempDir()}
+	s := services.NewServices(bdkeeper.NewMemoryStorage(), sync, sm, enc, opt, true, log.New(io.Discard, "", 0))
+	history := func() []models.EntryVersion {
+		versions, err := s.GetHistory(ctx, records.TextTable, 1, "n1")
+		require.NoError(t, err)
+		return versions
+	}
+
+	require.NoError(t, s.SyncAllData(ctx, 1, true))
+	require.NoError(t, s.SyncAllData(ctx, 1, true))
+	assert.Empty(t, history(), "pulling an unchanged entry must not add versions")
+
+	text, updatedAt = "changed", "2026-01-03T10:00:00Z"
+	require.NoError(t, s.SyncAllData(ctx, 1, true))
+	versions := history()
+	require.Len(t, versions, 1)
+	assert.Equal(t, "hello", versions[0].Data["data"])
+	data, err := s.GetData(ctx, records.TextTable, 1, "n1")
+	require.NoError(t, err)
+	assert.Equal(t, "changed", data["data"])
+}
@@ -0,0 +1,298 @@
+// Package srp implements the SRP-6a augmented password-authenticated key exchange
+// (RFC 2945, RFC 5054) used by GophKeeper to log in without ever sending the
+// password or a password-equivalent value to the server.
+//
+// The server stores only a salt and a verifier v = g^x mod N, where x is derived
+// from the password with Argon2id so that a stolen verifier is slow to brute-force
+// offline. During login the
+// client and the server exchange the ephemeral values A and B, derive the same
+// session key and prove to each other that they know it. Nothing transmitted
+// during the exchange can be replayed to log in again.
+package srp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// SaltSize is the size of a freshly generated salt in bytes.
+const SaltSize = 32
+
+// Argon2id parameters of the private key. They cannot change without computing new
+// verifiers, since the server stores only the salt.
+const (
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024 // KiB
+	kdfThreads = 4
+)
+
+// ErrInvalidPublicKey is returned when the peer's ephemeral value is zero modulo N.
+var ErrInvalidPublicKey = errors.New("srp: invalid public ephemeral value")
+
+// ErrInvalidProof is returned when a peer's key confirmation does not match.
+var ErrInvalidProof = errors.New("srp: invalid proof")
+
+// Group represents the SRP group parameters.
+type Group struct {
+	N *big.Int // N is a large safe prime.
+	G *big.Int // G is a generator modulo N.
+}
+
+// rfc5054N2048 is the 2048-bit group prime from RFC 5054, appendix A.
+const rfc5054N2048 = "AC6BDB41324A9A9BF166DE5E1389582FAF72B6651987EE07FC3192943DB56050" +
+	"A37329CBB4A099ED8193E0757767A13DD52312AB4B03310DCD7F48A9DA04FD50" +
+	"E8083969EDB767B0CF6095179A163AB3661A05FBD5FAAAE82918A9962F0B93B8" +
+	"55F97993EC975EEAA80D740ADBF4FF747359D041D5C33EA71D281E446B14773B" +
+	"CA97B43A23FB801676BD207A436C6481F1D2B9078717461A5B9D32E688F87748" +
+	"544523B524B0D57D5EA77A2775D2ECFA032CFBDBF52FB3786160279004E57AE6" +
+	"AF874E7303CE53299CCC041C7BC308D82A5698F3A8D0C38271AE35F8E9DBFBB6" +
+	"94B5C803D89F7AE435DE236D525F54759B65E372FCD68EF20FA7111F9E4AFF73"
+
+// DefaultGroup is the 2048-bit RFC 5054 group with generator 2.
+var DefaultGroup = newGroup(rfc5054N2048, 2)
+
+func newGroup(n string, g int64) *Group {
+	N, ok := new(big.Int).SetString(n, 16)
+	if !ok {
+		panic("srp: invalid group prime")
+	}
+	return &Group{N: N, G: big.NewInt(g)}
+}
+
+// pad left-pads b with zeros to the byte length of N.
+func (g *Group) pad(b []byte) []byte {
+	size := (g.N.BitLen() + 7) / 8
+	if len(b) >= size {
+		return b
+	}
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}
+
+// k computes the multiplier parameter k = H(N | PAD(g)).
+func (g *Group) k() *big.Int {
+	return hashInt(g.N.Bytes(), g.pad(g.G.Bytes()))
+}
+
+// hash returns SHA-256 over the concatenation of the given byte slices.
+func hash(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// hashInt returns the hash of the given byte slices as an integer.
+func hashInt(parts ...[]byte) *big.Int {
+	return new(big.Int).SetBytes(hash(parts...))
+}
+
+// privateKey computes x = H(salt | Argon2id(username ":" password, salt)).
+func privateKey(username, password string, salt []byte) *big.Int {
+	return hashInt(salt, argon2.IDKey([]byte(username+":"+password), salt, kdfTime, kdfMemory, kdfThreads, 32))
+}
+
+// randomExponent returns a random 256-bit exponent.
+func randomExponent() (*big.Int, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// NewVerifier generates a random salt and computes the verifier for the given credentials.
+// Only the salt and the verifier are ever sent to the server.
+func NewVerifier(username, password string) (salt []byte, verifier []byte, err error) {
+	salt = make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	return salt, ComputeVerifier(username, password, salt), nil
+}
+
+// ComputeVerifier computes v = g^x mod N for the given credentials and salt.
+func ComputeVerifier(username, password string, salt []byte) []byte {
+	g := DefaultGroup
+	x := privateKey(username, password, salt)
+	return new(big.Int).Exp(g.G, x, g.N).Bytes()
+}
+
+// clientProof computes M1 = H(H(N) xor H(g) | H(I) | s | A | B | K).
+func clientProof(g *Group, username string, salt, A, B, K []byte) []byte {
+	hN := hash(g.N.Bytes())
+	hG := hash(g.pad(g.G.Bytes()))
+	for i := range hN {
+		hN[i] ^= hG[i]
+	}
+	return hash(hN, hash([]byte(username)), salt, A, B, K)
+}
+
+// serverProof computes M2 = H(A | M1 | K).
+func serverProof(A, M1, K []byte) []byte {
+	return hash(A, M1, K)
+}
+
+// Client holds the client side state of a single SRP exchange.
+type Client struct {
+	group    *Group
+	username string
+	password string
+	a        *big.Int
+	A        *big.Int
+	m1       []byte
+	m2       []byte
+	key      []byte
+}
+
+// NewClient starts a new SRP exchange for the given credentials.
+func NewClient(username, password string) (*Client, error) {
+	g := DefaultGroup
+	a, err := randomExponent()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		group:    g,
+		username: username,
+		password: password,
+		a:        a,
+		A:        new(big.Int).Exp(g.G, a, g.N),
+	}, nil
+}
+
+// PublicKey returns the client's public ephemeral value A.
+func (c *Client) PublicKey() []byte {
+	return c.group.pad(c.A.Bytes())
+}
+
+// ComputeProof processes the salt and the server's public ephemeral value B and
+// returns the client's key confirmation M1.
+func (c *Client) ComputeProof(salt, serverKey []byte) ([]byte, error) {
+	g := c.group
+	B := new(big.Int).SetBytes(serverKey)
+	if new(big.Int).Mod(B, g.N).Sign() == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	A := c.PublicKey()
+	Bp := g.pad(B.Bytes())
+	u := hashInt(A, Bp)
+	if u.Sign() == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	x := privateKey(c.username, c.password, salt)
+
+	// S = (B - k * g^x) ^ (a + u * x) mod N
+	gx := new(big.Int).Exp(g.G, x, g.N)
+	kgx := new(big.Int).Mul(g.k(), gx)
+	base := new(big.Int).Sub(B, kgx)
+	base.Mod(base, g.N)
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, c.a)
+	S := new(big.Int).Exp(base, exp, g.N)
+
+	c.key = hash(g.pad(S.Bytes()))
+	c.m1 = clientProof(g, c.username, salt, A, Bp, c.key)
+	c.m2 = serverProof(A, c.m1, c.key)
+	return c.m1, nil
+}
+
+// VerifyServer checks the server's key confirmation M2.
+func (c *Client) VerifyServer(proof []byte) error {
+	if c.m2 == nil || subtle.ConstantTimeCompare(c.m2, proof) != 1 {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// SessionKey returns the shared session key K after a successful exchange.
+func (c *Client) SessionKey() []byte {
+	return c.key
+}
+
+// Server holds the server side state of a single SRP exchange.
+// The client does not use it directly; it documents the protocol the GophKeeper
+// server implements and is used in tests.
+type Server struct {
+	group    *Group
+	username string
+	salt     []byte
+	v        *big.Int
+	b        *big.Int
+	B        *big.Int
+	key      []byte
+}
+
+// NewServer starts the server side of an SRP exchange for a stored salt and verifier.
+func NewServer(username string, salt, verifier []byte) (*Server, error) {
+	g := DefaultGroup
+	b, err := randomExponent()
+	if err != nil {
+		return nil, err
+	}
+	v := new(big.Int).SetBytes(verifier)
+
+	// B = k*v + g^b mod N
+	B := new(big.Int).Mul(g.k(), v)
+	B.Add(B, new(big.Int).Exp(g.G, b, g.N))
+	B.Mod(B, g.N)
+
+	return &Server{group: g, username: username, salt: salt, v: v, b: b, B: B}, nil
+}
+
+// PublicKey returns the server's public ephemeral value B.
+func (s *Server) PublicKey() []byte {
+	return s.group.pad(s.B.Bytes())
+}
+
+// VerifyClient checks the client's proof M1 for the given public value A and
+// returns the server's proof M2.
+func (s *Server) VerifyClient(clientKey, proof []byte) ([]byte, error) {
+	g := s.group
+	A := new(big.Int).SetBytes(clientKey)
+	if new(big.Int).Mod(A, g.N).Sign() == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	Ap := g.pad(A.Bytes())
+	Bp := s.PublicKey()
+	u := hashInt(Ap, Bp)
+
+	// S = (A * v^u) ^ b mod N
+	base := new(big.Int).Mul(A, new(big.Int).Exp(s.v, u, g.N))
+	base.Mod(base, g.N)
+	S := new(big.Int).Exp(base, s.b, g.N)
+
+	key := hash(g.pad(S.Bytes()))
+	expected := clientProof(g, s.username, s.salt, Ap, Bp, key)
+	if subtle.ConstantTimeCompare(expected, proof) != 1 {
+		return nil, ErrInvalidProof
+	}
+	s.key = key
+	return serverProof(Ap, proof, key), nil
+}
+
+// SessionKey returns the shared session key K after a successful exchange.
+func (s *Server) SessionKey() []byte {
+	return s.key
+}
+
+// Encode encodes a protocol value for transport.
+func Encode(b []byte) string {
+	return hex.EncodeToString(b)
+}
+
+// Decode decodes a protocol value received from the peer.
+func Decode(s string) ([]byte, error) {
+	return hex.DecodeString(s)
+}
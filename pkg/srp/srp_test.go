@@ -0,0 +1,86 @@
+package srp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestExchange_Success(t *testing.T) {
+	salt, verifier, err := NewVerifier("alice", "secret")
+	require.NoError(t, err)
+
+	client, err := NewClient("alice", "secret")
+	require.NoError(t, err)
+
+	server, err := NewServer("alice", salt, verifier)
+	require.NoError(t, err)
+
+	m1, err := client.ComputeProof(salt, server.PublicKey())
+	require.NoError(t, err)
+
+	m2, err := server.VerifyClient(client.PublicKey(), m1)
+	require.NoError(t, err)
+
+	assert.NoError(t, client.VerifyServer(m2))
+	assert.True(t, bytes.Equal(client.SessionKey(), server.SessionKey()))
+}
+
+func TestExchange_WrongPassword(t *testing.T) {
+	salt, verifier, err := NewVerifier("alice", "secret")
+	require.NoError(t, err)
+
+	client, err := NewClient("alice", "wrong")
+	require.NoError(t, err)
+
+	server, err := NewServer("alice", salt, verifier)
+	require.NoError(t, err)
+
+	m1, err := client.ComputeProof(salt, server.PublicKey())
+	require.NoError(t, err)
+
+	_, err = server.VerifyClient(client.PublicKey(), m1)
+	assert.ErrorIs(t, err, ErrInvalidProof)
+}
+
+func TestExchange_ProofIsNotReplayable(t *testing.T) {
+	salt, verifier, err := NewVerifier("alice", "secret")
+	require.NoError(t, err)
+
+	client, err := NewClient("alice", "secret")
+	require.NoError(t, err)
+	first, err := NewServer("alice", salt, verifier)
+	require.NoError(t, err)
+	m1, err := client.ComputeProof(salt, first.PublicKey())
+	require.NoError(t, err)
+
+	// A second session uses a fresh server ephemeral, so the captured proof is useless.
+	second, err := NewServer("alice", salt, verifier)
+	require.NoError(t, err)
+	_, err = second.VerifyClient(client.PublicKey(), m1)
+	assert.ErrorIs(t, err, ErrInvalidProof)
+}
+
+func TestClient_RejectsZeroServerKey(t *testing.T) {
+	client, err := NewClient("alice", "secret")
+	require.NoError(t, err)
+
+	_, err = client.ComputeProof([]byte("salt"), DefaultGroup.N.Bytes())
+	assert.ErrorIs(t, err, ErrInvalidPublicKey)
+}
+
+func TestComputeVerifier_Deterministic(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	assert.Equal(t, ComputeVerifier("bob", "pw", salt), ComputeVerifier("bob", "pw", salt))
+	assert.NotEqual(t, ComputeVerifier("bob", "pw", salt), ComputeVerifier("bob", "pw2", salt))
+}
+
+func TestPrivateKey_UsesArgon2id(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, SaltSize)
+	inner := argon2.IDKey([]byte("alice:secret"), salt, kdfTime, kdfMemory, kdfThreads, 32)
+	assert.Equal(t, hashInt(salt, inner), privateKey("alice", "secret", salt))
+	assert.NotEqual(t, hashInt(salt, hash([]byte("alice:secret"))), privateKey("alice", "secret", salt))
+}
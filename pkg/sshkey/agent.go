@@ -0,0 +1,158 @@
+package sshkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrReadOnly is returned for agent requests that would modify the set of keys,
+// which is managed in the vault.
+var ErrReadOnly = errors.New("keys are managed by GophKeeper and cannot be added or removed through the agent")
+
+// ErrDenied is returned when the use of a key was not confirmed.
+var ErrDenied = errors.New("use of the key was denied")
+
+// Agent is a read-only SSH agent serving keys from the vault.
+// It supports locking and an optional confirmation before each use of a key.
+type Agent struct {
+	keyring agent.ExtendedAgent
+	keys    map[string]*Key // keys by marshaled public key
+
+	mu      sync.Mutex // serializes confirmations
+	confirm func(key *Key) bool
+}
+
+// NewAgent creates an agent serving the given keys. If confirm is not nil, it is
+// called before every signature and the request fails unless it returns true.
+func NewAgent(keys []*Key, confirm func(key *Key) bool) (*Agent, error) {
+	a := &Agent{
+		keyring: agent.NewKeyring().(agent.ExtendedAgent),
+		keys:    make(map[string]*Key, len(keys)),
+		confirm: confirm,
+	}
+	for _, k := range keys {
+		if err := a.keyring.Add(agent.AddedKey{PrivateKey: k.raw, Comment: k.Comment}); err != nil {
+			return nil, fmt.Errorf("failed to add key %s: %w", k.Fingerprint, err)
+		}
+		a.keys[string(k.signer.PublicKey().Marshal())] = k
+	}
+	return a, nil
+}
+
+// List returns the identities known to the agent.
+func (a *Agent) List() ([]*agent.Key, error) {
+	return a.keyring.List()
+}
+
+// Sign signs data with the given key after confirmation.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags signs data with the given key and signature flags after confirmation.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if err := a.confirmUse(key); err != nil {
+		return nil, err
+	}
+	return a.keyring.SignWithFlags(key, data, flags)
+}
+
+// confirmUse asks for confirmation of the use of a key, one request at a time.
+func (a *Agent) confirmUse(key ssh.PublicKey) error {
+	if a.confirm == nil {
+		return nil
+	}
+	k, ok := a.keys[string(key.Marshal())]
+	if !ok {
+		// Unknown keys are rejected by the keyring
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.confirm(k) {
+		return ErrDenied
+	}
+	return nil
+}
+
+// Add is not supported, keys are added to the vault instead.
+func (a *Agent) Add(key agent.AddedKey) error {
+	return ErrReadOnly
+}
+
+// Remove is not supported, keys are removed from the vault instead.
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	return ErrReadOnly
+}
+
+// RemoveAll is not supported, keys are removed from the vault instead.
+func (a *Agent) RemoveAll() error {
+	return ErrReadOnly
+}
+
+// Lock locks the agent until it is unlocked with the same passphrase.
+func (a *Agent) Lock(passphrase []byte) error {
+	return a.keyring.Lock(passphrase)
+}
+
+// Unlock unlocks the agent.
+func (a *Agent) Unlock(passphrase []byte) error {
+	return a.keyring.Unlock(passphrase)
+}
+
+// Signers returns signers for all the known keys.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	return a.keyring.Signers()
+}
+
+// Extension reports that no extensions are supported.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// Serve serves the agent on a Unix socket at path until the context is canceled.
+// The socket is only accessible by the current user and is removed on return. An
+// existing file at path is only replaced if it is a socket.
+func (a *Agent) Serve(ctx context.Context, path string) error {
+	// Remove a stale socket left over by a previous run
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != os.ModeSocket {
+			return fmt.Errorf("%s already exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	l, err := listenPrivate(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			agent.ServeAgent(a, conn)
+		}()
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package sshkey
+
+import (
+	"net"
+	"os"
+)
+
+// listenPrivate listens on a Unix socket only the current user can connect to.
+func listenPrivate(path string) (net.Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package sshkey
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenPrivate listens on a Unix socket only the current user can connect to. The
+// umask is restricted while the socket is created, so it is never accessible by others.
+func listenPrivate(path string) (net.Listener, error) {
+	old := syscall.Umask(0o177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,96 @@
+// Package sshkey parses, validates and generates SSH private keys and serves
+// them to SSH clients over the ssh-agent protocol.
+package sshkey
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrPassphraseRequired is returned by Parse when the key is protected by a passphrase
+// and none was given.
+var ErrPassphraseRequired = errors.New("the private key is protected by a passphrase")
+
+// Key is a parsed SSH private key together with its metadata.
+type Key struct {
+	Type        string // Type is the key algorithm, for example ssh-ed25519.
+	PublicKey   string // PublicKey is the public key in authorized_keys format.
+	Fingerprint string // Fingerprint is the SHA256 fingerprint of the public key.
+	Comment     string // Comment is the key comment, usually user@host.
+	PEM         []byte // PEM is the unencrypted private key in OpenSSH format.
+
+	raw    crypto.PrivateKey
+	signer ssh.Signer
+}
+
+// Parse parses a private key in OpenSSH or PEM (PKCS#1, PKCS#8, SEC 1) format.
+// Keys protected by a passphrase are decrypted with the given passphrase; the
+// returned key is always stored unencrypted in OpenSSH format, as the vault
+// encrypts it anyway.
+func Parse(data []byte, passphrase []byte, comment string) (*Key, error) {
+	var (
+		raw any
+		err error
+	)
+	if len(passphrase) > 0 {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase(data, passphrase)
+	} else {
+		raw, err = ssh.ParseRawPrivateKey(data)
+	}
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, ErrPassphraseRequired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return newKey(raw, comment)
+}
+
+// Generate creates a new ed25519 key.
+func Generate(comment string) (*Key, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newKey(priv, comment)
+}
+
+// newKey fills in the metadata of a raw private key.
+func newKey(raw crypto.PrivateKey, comment string) (*Key, error) {
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(raw, comment)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key: %w", err)
+	}
+
+	pub := signer.PublicKey()
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if comment != "" {
+		authorized += " " + comment
+	}
+	return &Key{
+		Type:        pub.Type(),
+		PublicKey:   authorized,
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		Comment:     comment,
+		PEM:         pem.EncodeToMemory(block),
+		raw:         raw,
+		signer:      signer,
+	}, nil
+}
+
+// Signer returns the signer of the key.
+func (k *Key) Signer() ssh.Signer {
+	return k.signer
+}
@@ -0,0 +1,130 @@
+package sshkey
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestGenerateAndParse(t *testing.T) {
+	key, err := Generate("alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, ssh.KeyAlgoED25519, key.Type)
+	assert.True(t, strings.HasPrefix(key.Fingerprint, "SHA256:"))
+	assert.True(t, strings.HasSuffix(key.PublicKey, " alice@example.com"))
+
+	parsed, err := Parse(key.PEM, nil, key.Comment)
+	require.NoError(t, err)
+	assert.Equal(t, key.Fingerprint, parsed.Fingerprint)
+	assert.Equal(t, key.PublicKey, parsed.PublicKey)
+}
+
+func TestParse_PEM(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	key, err := Parse(data, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "ecdsa-sha2-nistp256", key.Type)
+	assert.Contains(t, string(key.PEM), "OPENSSH PRIVATE KEY")
+}
+
+func TestParse_Passphrase(t *testing.T) {
+	key, err := Generate("")
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(key.raw, "", []byte("secret"))
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(block)
+
+	_, err = Parse(data, nil, "")
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+
+	_, err = Parse(data, []byte("wrong"), "")
+	assert.Error(t, err)
+
+	parsed, err := Parse(data, []byte("secret"), "")
+	require.NoError(t, err)
+	assert.Equal(t, key.Fingerprint, parsed.Fingerprint)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("not a key"), nil, "")
+	assert.Error(t, err)
+}
+
+func TestAgent_Serve(t *testing.T) {
+	key, err := Generate("deploy")
+	require.NoError(t, err)
+
+	var allow atomic.Bool
+	allow.Store(true)
+	a, err := NewAgent([]*Key{key}, func(k *Key) bool {
+		return allow.Load()
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, path) }()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		conn, err = net.Dial("unix", path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer conn.Close()
+	client := agent.NewClient(conn)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "deploy", keys[0].Comment)
+
+	data := []byte("challenge")
+	sig, err := client.Sign(key.Signer().PublicKey(), data)
+	require.NoError(t, err)
+	assert.NoError(t, key.Signer().PublicKey().Verify(data, sig))
+
+	allow.Store(false)
+	_, err = client.Sign(key.Signer().PublicKey(), data)
+	assert.Error(t, err)
+
+	assert.Error(t, client.RemoveAll())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestAgent_ServeKeepsOtherFiles(t *testing.T) {
+	a, err := NewAgent(nil, nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	assert.ErrorContains(t, a.Serve(context.Background(), path), "is not a socket")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+}
@@ -0,0 +1,196 @@
+// Package totp implements time-based one-time passwords (RFC 6238) and parsing
+// of otpauth:// key URIs as used by authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default key parameters, as assumed by authenticator apps when a URI omits them.
+const (
+	DefaultAlgorithm = "SHA1"
+	DefaultDigits    = 6
+	DefaultPeriod    = 30
+)
+
+// Key represents a TOTP key together with its parameters.
+type Key struct {
+	Secret    string // Secret is the base32-encoded shared secret.
+	Issuer    string // Issuer is the provider of the account.
+	Account   string // Account is the account name, usually a login or an e-mail.
+	Algorithm string // Algorithm is SHA1, SHA256 or SHA512.
+	Digits    int    // Digits is the number of digits in a code.
+	Period    int    // Period is the validity of a code in seconds.
+}
+
+// NewKey returns a key with the default parameters.
+func NewKey(secret string) *Key {
+	return &Key{
+		Secret:    secret,
+		Algorithm: DefaultAlgorithm,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+}
+
+// ParseURI parses an otpauth://totp/ URI.
+func ParseURI(uri string) (*Key, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, fmt.Errorf("invalid otpauth URI: %w", err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, errors.New("invalid otpauth URI: scheme must be otpauth")
+	}
+	if u.Host != "totp" {
+		return nil, fmt.Errorf("unsupported OTP type %q, only totp is supported", u.Host)
+	}
+
+	q := u.Query()
+	key := NewKey(strings.ToUpper(strings.ReplaceAll(q.Get("secret"), " ", "")))
+	if _, err := DecodeSecret(key.Secret); err != nil {
+		return nil, err
+	}
+
+	// The label is "issuer:account" or just "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, found := strings.Cut(label, ":"); found {
+		key.Issuer = strings.TrimSpace(issuer)
+		key.Account = strings.TrimSpace(account)
+	} else {
+		key.Account = strings.TrimSpace(label)
+	}
+	if issuer := q.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	if algorithm := q.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+		if _, err := hashFunc(key.Algorithm); err != nil {
+			return nil, err
+		}
+	}
+	if digits := q.Get("digits"); digits != "" {
+		key.Digits, err = strconv.Atoi(digits)
+		if err != nil || key.Digits < 6 || key.Digits > 8 {
+			return nil, fmt.Errorf("invalid number of digits %q", digits)
+		}
+	}
+	if period := q.Get("period"); period != "" {
+		key.Period, err = strconv.Atoi(period)
+		if err != nil || key.Period <= 0 {
+			return nil, fmt.Errorf("invalid period %q", period)
+		}
+	}
+	return key, nil
+}
+
+// URI returns the otpauth:// URI of the key.
+func (k *Key) URI() string {
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+	q := url.Values{}
+	q.Set("secret", k.Secret)
+	if k.Issuer != "" {
+		q.Set("issuer", k.Issuer)
+	}
+	q.Set("algorithm", k.Algorithm)
+	q.Set("digits", strconv.Itoa(k.Digits))
+	q.Set("period", strconv.Itoa(k.Period))
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// Code returns the code valid at the given time and how long it stays valid.
+func (k *Key) Code(t time.Time) (string, time.Duration, error) {
+	secret, err := DecodeSecret(k.Secret)
+	if err != nil {
+		return "", 0, err
+	}
+	if k.Period <= 0 {
+		return "", 0, fmt.Errorf("invalid period %d", k.Period)
+	}
+	code, err := Generate(secret, t, k.Period, k.Digits, k.Algorithm)
+	if err != nil {
+		return "", 0, err
+	}
+	period := int64(k.Period)
+	remaining := time.Duration(period-t.Unix()%period) * time.Second
+	return code, remaining, nil
+}
+
+// Generate computes the TOTP code for the given time as defined in RFC 6238.
+func Generate(secret []byte, t time.Time, period int, digits int, algorithm string) (string, error) {
+	if digits < 6 || digits > 8 {
+		return "", fmt.Errorf("invalid number of digits %d", digits)
+	}
+	h, err := hashFunc(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(period)))
+
+	mac := hmac.New(h, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod), nil
+}
+
+// DecodeSecret decodes a base32 secret, ignoring case, spaces and missing padding.
+func DecodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, errors.New("secret must not be empty")
+	}
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("secret is not valid base32: %w", err)
+	}
+	return b, nil
+}
+
+// hashFunc returns the hash constructor for an algorithm name.
+func hashFunc(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToUpper(algorithm) {
+	case "", "SHA1":
+		return sha1.New, nil
+	case "SHA256":
+		return sha256.New, nil
+	case "SHA512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+}
+
+// ValidAlgorithm reports whether the algorithm is supported.
+func ValidAlgorithm(algorithm string) bool {
+	_, err := hashFunc(algorithm)
+	return err == nil && algorithm != ""
+}
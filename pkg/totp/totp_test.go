@@ -0,0 +1,89 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from RFC 6238, appendix B.
+func TestGenerate_RFC6238(t *testing.T) {
+	seeds := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	tests := []struct {
+		unix      int64
+		algorithm string
+		want      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1234567890, "SHA1", "89005924"},
+		{2000000000, "SHA256", "90698825"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for _, tt := range tests {
+		got, err := Generate(seeds[tt.algorithm], time.Unix(tt.unix, 0), 30, 8, tt.algorithm)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s at %d", tt.algorithm, tt.unix)
+	}
+}
+
+func TestKey_Code(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	key := NewKey(secret)
+	key.Digits = 8
+
+	code, remaining, err := key.Code(time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+	assert.Equal(t, time.Second, remaining)
+}
+
+func TestParseURI(t *testing.T) {
+	key, err := ParseURI("otpauth://totp/ACME%20Co:john@example.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60")
+	require.NoError(t, err)
+	assert.Equal(t, "HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ", key.Secret)
+	assert.Equal(t, "ACME Co", key.Issuer)
+	assert.Equal(t, "john@example.com", key.Account)
+	assert.Equal(t, "SHA256", key.Algorithm)
+	assert.Equal(t, 8, key.Digits)
+	assert.Equal(t, 60, key.Period)
+
+	parsed, err := ParseURI(key.URI())
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+}
+
+func TestParseURI_Defaults(t *testing.T) {
+	key, err := ParseURI("otpauth://totp/alice?secret=jbsw y3dp ehpk 3pxp")
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", key.Secret)
+	assert.Equal(t, "alice", key.Account)
+	assert.Equal(t, DefaultAlgorithm, key.Algorithm)
+	assert.Equal(t, DefaultDigits, key.Digits)
+	assert.Equal(t, DefaultPeriod, key.Period)
+}
+
+func TestParseURI_Invalid(t *testing.T) {
+	for _, uri := range []string{
+		"https://example.com",
+		"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://totp/alice",
+		"otpauth://totp/alice?secret=not-base32!",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=12",
+	} {
+		_, err := ParseURI(uri)
+		assert.Error(t, err, uri)
+	}
+}